@@ -1,12 +1,21 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
 
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/config"
 	"github.com/conall-obrien/mcp-ssh-wingman/internal/server"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
 )
 
 var (
@@ -15,17 +24,90 @@ var (
 	commit  = "none"
 	date    = "unknown"
 
-	sessionName  = flag.String("session", "mcp-wingman", "tmux session name to attach to")
-	versionFlag  = flag.Bool("version", false, "print version and exit")
+	sessionName   = flag.String("session", "mcp-wingman", "tmux session name to attach to")
+	serverName    = flag.String("server-name", server.ServerName, "server name reported to MCP clients during initialize")
+	serverVersion = flag.String("server-version", "", "server version reported to MCP clients during initialize (default: build version)")
+	allowWrites   = flag.Bool("allow-writes", false, "allow tools that modify the tmux session (e.g. rename_window); the server is read-only by default")
+	allowUnlock   = flag.Bool("allow-runtime-unlock", false, "allow the unlock_writes and lock_writes tools to turn write tools on and off while the server runs")
+	unlockSecret  = flag.String("unlock-secret", "", "secret unlock_writes must be given (with -allow-runtime-unlock); prefer WINGMAN_UNLOCK_SECRET, since arguments are visible to other users")
+	promptRegex   = flag.String("prompt-regex", tmux.DefaultPromptPattern, "regular expression matching the shell prompt, used by run_command to detect that a command has finished")
+	promptStrat   = flag.String("prompt-strategy", tmux.PromptStrategyOSCMark, "how run_command recognises the prompt: \"regex\" matches -prompt-regex, \"oscmark\" reads the OSC 133 markers of shells with terminal integration and falls back to -prompt-regex")
+	exitSentinel  = flag.String("exit-sentinel", tmux.DefaultExitSentinel, "marker run_command echoes before a command's exit status (letters, digits and underscores)")
+	pollInterval  = flag.Duration("poll-interval", tmux.DefaultPollInterval, "default delay between captures for tools that poll the pane; lower is more responsive but spawns more tmux processes")
+	metricsOn     = flag.Bool("metrics", false, "collect per-tool call counts, errors, returned bytes and latency, reported by the server_status tool")
+	metricsAddr   = flag.String("metrics-addr", "", "serve the metrics for Prometheus at http://ADDR/metrics, e.g. \"127.0.0.1:9464\"; implies -metrics")
+	singleShot    = flag.Bool("single-shot", false, "answer one request and exit, even if more input is pending; the initialize handshake is not required")
+	idleTimeout   = flag.Duration("idle-timeout", 0, "exit after this long without a request from the client (e.g. 30m); 0 disables")
+	initTimeout   = flag.Duration("init-timeout", 0, "exit if the client hasn't sent initialize this long after starting (e.g. 30s); 0 disables")
+	keepWarm      = flag.Duration("keep-warm", 0, "after this long without a request, press Enter at the session's idle shell prompt, and repeat, so a shell with TMOUT set stays logged in (e.g. 5m; needs -allow-writes or -allow-runtime-unlock); 0 disables")
+	captureArgs   = flag.String("capture-args", "", "extra capture-pane flags added to every capture, e.g. \"-N\"; only -a -C -e -J -N -P -q -T are accepted")
+	normalizeNL   = flag.Bool("normalize-newlines", true, "convert \\r\\n to \\n in captures and replay carriage returns, so a redrawn progress bar shows only its final state")
+	maxLineWidth  = flag.Int("max-line-width", 0, "truncate lines longer than this many characters in read output, marking the cut with …; 0 disables")
+	saveDir       = flag.String("save-dir", "", "directory save_capture may write captures to (with -allow-writes); save_capture is disabled without it")
+	allowAbs      = flag.Bool("allow-abs-paths", false, "let read_file read absolute paths and paths outside the pane's working directory")
+	recordPath    = flag.String("record", "", "write every tmux invocation and its output, with timestamps, to this file for later -replay")
+	replayPath    = flag.String("replay", "", "answer tmux invocations from a -record file instead of a live tmux server, for demos and tests")
+	tracePath     = flag.String("trace-file", "", "append every JSON-RPC message read from or written to the client, verbatim and with timestamps, to this file as JSON Lines")
+	outputTTL     = flag.Duration("output-ttl", server.DefaultOutputTTL, "how long run_command_to_resource keeps each command's output readable as a terminal://command-output resource")
+	maxRequest    = flag.Int64("max-request-bytes", server.DefaultMaxRequestBytes, "largest JSON-RPC message accepted from the client; a larger one is answered with an error and skipped. 0 disables the limit")
+	pretty        = flag.Bool("pretty", false, "indent JSON-RPC output for reading by eye; each message then spans several lines, which clients expecting one message per line can't parse")
+	configPath    = flag.String("config", "", "YAML file with settings for any of the other flags; flags and WINGMAN_ variables override it")
+	startupCmds   = stringListFlag("startup-command", "command to run in the session when the server creates it, e.g. \"cd ~/project\"; repeat to run several in order. Never runs in an existing session")
+	allowSessions = stringListFlag("allow-session", "session bind_session may switch to, as a shell pattern such as \"build-*\"; repeat for several. bind_session is disabled without it")
+	jobsCmds      = stringListFlag("jobs-command", "command jobs and wait_job type to list a shell's background jobs, as SHELL=COMMAND, e.g. \"nu=job list\"; repeat for several shells")
+	versionFlag   = flag.Bool("version", false, "print version and exit")
 )
 
+// stringList is a flag that collects every value it is given, for flags
+// that may be repeated
+type stringList []string
+
+// stringListFlag defines a repeatable string flag
+func stringListFlag(name, usage string) *stringList {
+	l := &stringList{}
+	flag.Var(l, name, usage)
+	return l
+}
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// envPrefix starts the names of the environment variables that stand in
+// for flags, for hosts that can set a server's environment but not its
+// arguments
+const envPrefix = "WINGMAN_"
+
 func main() {
+	flag.Usage = usage
 	flag.Parse()
+	if err := applyEnv(flag.CommandLine); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *configPath != "" {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if err := applyConfig(flag.CommandLine, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
 
 	if *versionFlag {
 		fmt.Printf("mcp-ssh-wingman %s\n", version)
-		fmt.Printf("  commit: %s\n", commit)
-		fmt.Printf("  built:  %s\n", date)
+		fmt.Printf("  commit:  %s\n", commit)
+		fmt.Printf("  built:   %s\n", date)
+		fmt.Printf("  backend: tmux\n")
+		fmt.Printf("  tmux:    %s\n", tmuxStatus())
 		os.Exit(0)
 	}
 
@@ -35,10 +117,240 @@ func main() {
 	// Log to stderr so it doesn't interfere with JSON-RPC on stdout
 	log.SetOutput(os.Stderr)
 
+	prompt, err := regexp.Compile(*promptRegex)
+	if err != nil {
+		log.Fatalf("Invalid --prompt-regex: %v", err)
+	}
+	detector, err := tmux.NewPromptDetector(*promptStrat, prompt)
+	if err != nil {
+		log.Fatalf("Invalid --prompt-strategy: %v", err)
+	}
+	if err := tmux.ValidateExitSentinel(*exitSentinel); err != nil {
+		log.Fatalf("Invalid --exit-sentinel: %v", err)
+	}
+	if err := tmux.ValidatePollInterval(*pollInterval); err != nil {
+		log.Fatalf("Invalid --poll-interval: %v", err)
+	}
+	extraCaptureArgs, err := tmux.ParseCaptureArgs(*captureArgs)
+	if err != nil {
+		log.Fatalf("Invalid --capture-args: %v", err)
+	}
+
+	if *maxLineWidth < 0 {
+		log.Fatalf("Invalid --max-line-width: must not be negative")
+	}
+	if *keepWarm < 0 {
+		log.Fatalf("Invalid --keep-warm: must not be negative")
+	}
+	if *keepWarm > 0 && !*allowWrites && !*allowUnlock {
+		log.Fatalf("Invalid --keep-warm: it sends input to the session, so it needs --allow-writes or --allow-runtime-unlock")
+	}
+	if *outputTTL <= 0 {
+		log.Fatalf("Invalid --output-ttl: must be positive")
+	}
+	if *maxRequest < 0 {
+		log.Fatalf("Invalid --max-request-bytes: must not be negative")
+	}
+	if *saveDir != "" {
+		if info, err := os.Stat(*saveDir); err != nil || !info.IsDir() {
+			log.Fatalf("Invalid --save-dir: %s is not a directory", *saveDir)
+		}
+	}
+	for _, command := range *startupCmds {
+		if err := tmux.ValidateCommandLine(command); err != nil {
+			log.Fatalf("Invalid --startup-command %q: %v", command, err)
+		}
+	}
+
+	for _, pattern := range *allowSessions {
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Fatalf("Invalid --allow-session %q: %v", pattern, err)
+		}
+	}
+
+	jobsCommands := map[string]string{}
+	for _, value := range *jobsCmds {
+		shell, command, err := server.ParseJobsCommand(value)
+		if err != nil {
+			log.Fatalf("Invalid --jobs-command: %v", err)
+		}
+		jobsCommands[shell] = command
+	}
+
+	opts := []server.Option{
+		server.WithServerName(*serverName),
+		server.WithServerVersion(*serverVersion),
+		server.WithWritesEnabled(*allowWrites),
+		server.WithRuntimeUnlock(*allowUnlock, *unlockSecret),
+		server.WithPromptRegex(prompt),
+		server.WithPromptDetector(detector),
+		server.WithExitSentinel(*exitSentinel),
+		server.WithPollInterval(*pollInterval),
+		server.WithIdleTimeout(*idleTimeout),
+		server.WithInitTimeout(*initTimeout),
+		server.WithKeepWarm(*keepWarm),
+		server.WithSingleShot(*singleShot),
+		server.WithMetrics(*metricsOn || *metricsAddr != ""),
+		server.WithCaptureArgs(extraCaptureArgs),
+		server.WithNormalizeNewlines(*normalizeNL),
+		server.WithMaxLineWidth(*maxLineWidth),
+		server.WithStartupCommands(*startupCmds),
+		server.WithPrettyJSON(*pretty),
+		server.WithMaxRequestBytes(*maxRequest),
+		server.WithOutputTTL(*outputTTL),
+		server.WithSaveDir(*saveDir),
+		server.WithAbsPaths(*allowAbs),
+		server.WithBindableSessions(*allowSessions),
+		server.WithJobsCommands(jobsCommands),
+	}
+	if *replayPath != "" {
+		f, err := os.Open(*replayPath)
+		if err != nil {
+			log.Fatalf("Invalid --replay: %v", err)
+		}
+		replay, err := tmux.NewReplayRunner(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Invalid --replay: %v", err)
+		}
+		log.Printf("Replaying tmux output from %s", *replayPath)
+		opts = append(opts, server.WithCommandRunner(replay))
+	}
+	if *recordPath != "" {
+		f, err := os.Create(*recordPath)
+		if err != nil {
+			log.Fatalf("Invalid --record: %v", err)
+		}
+		defer f.Close()
+		log.Printf("Recording tmux invocations to %s", *recordPath)
+		opts = append(opts, server.WithRecording(f))
+	}
+
+	if *tracePath != "" {
+		f, err := os.OpenFile(*tracePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			log.Fatalf("Invalid --trace-file: %v", err)
+		}
+		defer f.Close()
+		log.Printf("Tracing JSON-RPC messages to %s", *tracePath)
+		opts = append(opts, server.WithTrace(f))
+	}
+
 	log.Printf("Starting MCP server for tmux session: %s", *sessionName)
+	if *allowWrites {
+		log.Printf("Write tools enabled: the server may modify the tmux session")
+	}
+	if *allowUnlock {
+		log.Printf("Runtime unlock enabled: unlock_writes and lock_writes may change write access")
+	}
 
-	srv := server.NewServer(*sessionName, os.Stdin, os.Stdout)
+	srv := server.NewServer(*sessionName, os.Stdin, os.Stdout, opts...)
+	if *metricsAddr != "" {
+		listener, err := net.Listen("tcp", *metricsAddr)
+		if err != nil {
+			log.Fatalf("Invalid --metrics-addr: %v", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", srv.MetricsHandler())
+		log.Printf("Serving metrics on http://%s/metrics", listener.Addr())
+		go func() {
+			if err := http.Serve(listener, mux); err != nil {
+				log.Printf("Metrics endpoint stopped: %v", err)
+			}
+		}()
+	}
 	if err := srv.Start(); err != nil {
+		if errors.Is(err, server.ErrIdleTimeout) {
+			log.Printf("No requests for %s, shutting down", *idleTimeout)
+			return
+		}
+		if errors.Is(err, server.ErrInitTimeout) {
+			log.Printf("Client sent no initialize within %s, dropping the connection", *initTimeout)
+			return
+		}
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// usage prints the flag defaults followed by how to set them from the
+// environment
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nEvery flag except -version can also be set with an environment variable: %s\n"+
+		"followed by the flag name in upper case with dashes as underscores, e.g. %s.\n"+
+		"Flags on the command line take precedence over the environment, which takes\n"+
+		"precedence over the -config file.\n", envPrefix, envName("poll-interval"))
+}
+
+// applyEnv sets each flag in fs that wasn't given on the command line from
+// its environment variable, if that is set. Precedence is therefore
+// command line, then environment, then the flag's default.
+func applyEnv(fs *flag.FlagSet) error {
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || given[f.Name] || f.Name == "version" {
+			return
+		}
+		name := envName(f.Name)
+		if value, ok := os.LookupEnv(name); ok {
+			if setErr := fs.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %v", value, name, setErr)
+			}
+		}
+	})
+	return err
+}
+
+// applyConfig sets each flag in fs that wasn't given on the command line or
+// in the environment from the configuration file, so that file values only
+// override defaults
+func applyConfig(fs *flag.FlagSet, cfg *config.Config) error {
+	// applyEnv sets flags through fs, so Visit sees those too
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	for name, value := range cfg.Flags() {
+		if given[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s in config: %v", value, name, err)
+		}
+	}
+	// A repeatable flag given elsewhere replaces the file's list rather
+	// than adding to it
+	for name, values := range cfg.RepeatedFlags() {
+		if given[name] {
+			continue
+		}
+		for _, value := range values {
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("invalid value %q for %s in config: %v", value, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// envName returns the environment variable for a flag, e.g.
+// WINGMAN_POLL_INTERVAL for poll-interval
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// tmuxStatus describes the installed tmux for --version output
+func tmuxStatus() string {
+	v, err := tmux.Version()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "not installed"
+	}
+	if err != nil {
+		return fmt.Sprintf("unavailable (%v)", err)
+	}
+	return v
+}
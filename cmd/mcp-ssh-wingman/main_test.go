@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/config"
+)
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "mcp-wingman"},
+		{name: "environment", env: map[string]string{"WINGMAN_SESSION": "work"}, want: "work"},
+		{name: "flag wins", args: []string{"-session", "cli"}, env: map[string]string{"WINGMAN_SESSION": "work"}, want: "cli"},
+		{name: "invalid value", env: map[string]string{"WINGMAN_POLL_INTERVAL": "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			session := fs.String("session", "mcp-wingman", "")
+			fs.Duration("poll-interval", 0, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			err := applyEnv(fs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && *session != tt.want {
+				t.Errorf("session = %q, want %q", *session, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got, want := envName("max-line-width"), "WINGMAN_MAX_LINE_WIDTH"; got != want {
+		t.Errorf("envName() = %q, want %q", got, want)
+	}
+}
+
+func TestApplyConfig_Precedence(t *testing.T) {
+	cfg, err := config.Parse("wingman.yaml", []byte("session: file\nserver_name: file\nserver_version: file\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	t.Setenv("WINGMAN_SERVER_NAME", "env")
+	t.Setenv("WINGMAN_SESSION", "env")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	session := fs.String("session", "default", "")
+	name := fs.String("server-name", "default", "")
+	version := fs.String("server-version", "default", "")
+	prompt := fs.String("prompt-regex", "default", "")
+	if err := fs.Parse([]string{"-session", "cli"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := applyEnv(fs); err != nil {
+		t.Fatalf("applyEnv() error = %v", err)
+	}
+	if err := applyConfig(fs, cfg); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+
+	// Command line, then environment, then file, then default
+	for flagName, tt := range map[string]struct{ got, want string }{
+		"session":        {*session, "cli"},
+		"server-name":    {*name, "env"},
+		"server-version": {*version, "file"},
+		"prompt-regex":   {*prompt, "default"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", flagName, tt.got, tt.want)
+		}
+	}
+}
+
+func TestApplyConfig_DebugFlags(t *testing.T) {
+	cfg, err := config.Parse("wingman.yaml", []byte("trace_file: file.jsonl\nrecord: file.jsonl\nmetrics_addr: 127.0.0.1:9464\nsingle_shot: true\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	trace := fs.String("trace-file", "", "")
+	record := fs.String("record", "", "")
+	metricsAddr := fs.String("metrics-addr", "", "")
+	singleShot := fs.Bool("single-shot", false, "")
+	if err := fs.Parse([]string{"-record", "cli.jsonl"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := applyConfig(fs, cfg); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+
+	if *trace != "file.jsonl" || *record != "cli.jsonl" || *metricsAddr != "127.0.0.1:9464" || !*singleShot {
+		t.Errorf("trace-file, record, metrics-addr, single-shot = %q, %q, %q, %v; want the file's values with -record from the command line",
+			*trace, *record, *metricsAddr, *singleShot)
+	}
+}
+
+func TestApplyConfig_RepeatedFlags(t *testing.T) {
+	cfg, err := config.Parse("wingman.yaml", []byte("startup_commands: [cd /srv, make]\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want stringList
+	}{
+		{name: "from file", want: stringList{"cd /srv", "make"}},
+		{name: "command line replaces file", args: []string{"-startup-command", "ls"}, want: stringList{"ls"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			var commands stringList
+			fs.Var(&commands, "startup-command", "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if err := applyConfig(fs, cfg); err != nil {
+				t.Fatalf("applyConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(commands, tt.want) {
+				t.Errorf("startup-command = %q, want %q", commands, tt.want)
+			}
+		})
+	}
+}
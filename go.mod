@@ -1,3 +1,5 @@
 module github.com/conall-obrien/mcp-ssh-wingman
 
 go 1.25
+
+require gopkg.in/yaml.v3 v3.0.1
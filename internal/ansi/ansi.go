@@ -0,0 +1,295 @@
+// Package ansi parses ANSI escape sequences in captured terminal output
+package ansi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Style describes the SGR attributes applied to a run of text. Colors are
+// CSS hex strings (e.g. "#cd0000"); an empty string means the terminal
+// default.
+type Style struct {
+	Foreground string `json:"fg,omitempty"`
+	Background string `json:"bg,omitempty"`
+	Bold       bool   `json:"bold,omitempty"`
+	Dim        bool   `json:"dim,omitempty"`
+	Italic     bool   `json:"italic,omitempty"`
+	Underline  bool   `json:"underline,omitempty"`
+	Reverse    bool   `json:"reverse,omitempty"`
+	Strike     bool   `json:"strike,omitempty"`
+}
+
+// IsZero reports whether s has no attributes set
+func (s Style) IsZero() bool {
+	return s == Style{}
+}
+
+// Span is a run of text sharing a single style
+type Span struct {
+	Text string `json:"text"`
+	Style
+}
+
+// basePalette holds the 16 standard colors (xterm defaults)
+var basePalette = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// Color256 returns the CSS color for an xterm 256-color palette index
+func Color256(index int) string {
+	switch {
+	case index < 0 || index > 255:
+		return ""
+	case index < 16:
+		return basePalette[index]
+	case index < 232:
+		// 6x6x6 color cube
+		index -= 16
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		return rgb(levels[index/36], levels[(index/6)%6], levels[index%6])
+	default:
+		// 24-step grayscale ramp
+		gray := 8 + (index-232)*10
+		return rgb(gray, gray, gray)
+	}
+}
+
+func rgb(r, g, b int) string {
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// Parse splits s into styled spans, interpreting SGR ("ESC [ ... m")
+// sequences and discarding every other escape sequence. Adjacent text with
+// the same style is merged into a single span.
+func Parse(s string) []Span {
+	var spans []Span
+	var text strings.Builder
+	var style Style
+
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		if n := len(spans); n > 0 && spans[n-1].Style == style {
+			spans[n-1].Text += text.String()
+		} else {
+			spans = append(spans, Span{Text: text.String(), Style: style})
+		}
+		text.Reset()
+	}
+
+	for i := 0; i < len(s); {
+		if s[i] != 0x1b {
+			text.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		seq, final, next := scanEscape(s, i)
+		i = next
+		if final == 'm' {
+			flush()
+			style = applySGR(style, seq)
+		}
+	}
+	flush()
+
+	return spans
+}
+
+// Strip removes every escape sequence from s, leaving only the text
+func Strip(s string) string {
+	if !strings.ContainsRune(s, 0x1b) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] != 0x1b {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		_, _, i = scanEscape(s, i)
+	}
+	return b.String()
+}
+
+// Truncate shortens s to at most width visible runes. Escape sequences are
+// kept but not counted, and the cut always falls on a rune boundary. ok
+// reports whether anything was removed.
+func Truncate(s string, width int) (result string, ok bool) {
+	if utf8.RuneCountInString(s) <= width {
+		return s, false
+	}
+
+	count := 0
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			_, _, i = scanEscape(s, i)
+			continue
+		}
+		if count == width {
+			return s[:i], true
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		count++
+	}
+	return s, false
+}
+
+// scanEscape scans the escape sequence starting at s[start] (an ESC byte).
+// For CSI sequences it returns the parameter string and final byte; for
+// anything else final is 0. next is the index just past the sequence.
+func scanEscape(s string, start int) (params string, final byte, next int) {
+	i := start + 1
+	if i >= len(s) {
+		return "", 0, i
+	}
+
+	switch s[i] {
+	case '[':
+		// CSI: parameter and intermediate bytes, then a final byte in 0x40-0x7e
+		i++
+		paramStart := i
+		for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+			i++
+		}
+		if i >= len(s) {
+			return "", 0, i
+		}
+		return s[paramStart:i], s[i], i + 1
+
+	case ']', 'P', '_', '^':
+		// OSC/DCS/APC/PM: terminated by BEL or ST (ESC \)
+		i++
+		for i < len(s) {
+			if s[i] == 0x07 {
+				return s[start+2 : i], 0, i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return s[start+2 : i], 0, i + 2
+			}
+			i++
+		}
+		return s[start+2:], 0, i
+
+	default:
+		// Two-byte escape such as ESC ( B
+		if s[i] >= 0x20 && s[i] <= 0x2f && i+1 < len(s) {
+			return "", 0, i + 2
+		}
+		return "", 0, i + 1
+	}
+}
+
+// applySGR returns style updated by the SGR parameter string params
+func applySGR(style Style, params string) Style {
+	if params == "" {
+		return Style{}
+	}
+
+	codes := strings.Split(strings.ReplaceAll(params, ":", ";"), ";")
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			if codes[i] == "" {
+				code = 0
+			} else {
+				continue
+			}
+		}
+
+		switch {
+		case code == 0:
+			style = Style{}
+		case code == 1:
+			style.Bold = true
+		case code == 2:
+			style.Dim = true
+		case code == 3:
+			style.Italic = true
+		case code == 4:
+			style.Underline = true
+		case code == 7:
+			style.Reverse = true
+		case code == 9:
+			style.Strike = true
+		case code == 22:
+			style.Bold = false
+			style.Dim = false
+		case code == 23:
+			style.Italic = false
+		case code == 24:
+			style.Underline = false
+		case code == 27:
+			style.Reverse = false
+		case code == 29:
+			style.Strike = false
+		case code >= 30 && code <= 37:
+			style.Foreground = basePalette[code-30]
+		case code == 39:
+			style.Foreground = ""
+		case code >= 40 && code <= 47:
+			style.Background = basePalette[code-40]
+		case code == 49:
+			style.Background = ""
+		case code >= 90 && code <= 97:
+			style.Foreground = basePalette[code-90+8]
+		case code >= 100 && code <= 107:
+			style.Background = basePalette[code-100+8]
+		case code == 38 || code == 48:
+			color, consumed := extendedColor(codes[i+1:])
+			i += consumed
+			if code == 38 {
+				style.Foreground = color
+			} else {
+				style.Background = color
+			}
+		}
+	}
+
+	return style
+}
+
+// extendedColor decodes the arguments following a 38/48 SGR code: either
+// "5;n" (256-color palette) or "2;r;g;b" (truecolor). It returns the color
+// and the number of arguments consumed.
+func extendedColor(args []string) (string, int) {
+	if len(args) == 0 {
+		return "", 0
+	}
+
+	switch args[0] {
+	case "5":
+		if len(args) < 2 {
+			return "", len(args)
+		}
+		index, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", 2
+		}
+		return Color256(index), 2
+
+	case "2":
+		if len(args) < 4 {
+			return "", len(args)
+		}
+		var c [3]int
+		for j := range c {
+			v, err := strconv.Atoi(args[j+1])
+			if err != nil || v < 0 || v > 255 {
+				return "", 4
+			}
+			c[j] = v
+		}
+		return rgb(c[0], c[1], c[2]), 4
+	}
+
+	return "", 1
+}
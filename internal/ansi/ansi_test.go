@@ -0,0 +1,147 @@
+package ansi
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Span
+	}{
+		{
+			name:  "plain text",
+			input: "hello",
+			want:  []Span{{Text: "hello"}},
+		},
+		{
+			name:  "empty input",
+			input: "",
+			want:  nil,
+		},
+		{
+			name:  "base foreground color and reset",
+			input: "\x1b[31mred\x1b[0m plain",
+			want: []Span{
+				{Text: "red", Style: Style{Foreground: "#cd0000"}},
+				{Text: " plain"},
+			},
+		},
+		{
+			name:  "bright background",
+			input: "\x1b[102mgreen\x1b[49m",
+			want:  []Span{{Text: "green", Style: Style{Background: "#00ff00"}}},
+		},
+		{
+			name:  "bold and underline combined",
+			input: "\x1b[1;4mstrong\x1b[22mweak\x1b[m",
+			want: []Span{
+				{Text: "strong", Style: Style{Bold: true, Underline: true}},
+				{Text: "weak", Style: Style{Underline: true}},
+			},
+		},
+		{
+			name:  "256 color",
+			input: "\x1b[38;5;196mx\x1b[48;5;232my",
+			want: []Span{
+				{Text: "x", Style: Style{Foreground: "#ff0000"}},
+				{Text: "y", Style: Style{Foreground: "#ff0000", Background: "#080808"}},
+			},
+		},
+		{
+			name:  "truecolor",
+			input: "\x1b[38;2;1;2;3mrgb",
+			want:  []Span{{Text: "rgb", Style: Style{Foreground: "#010203"}}},
+		},
+		{
+			name:  "non-SGR sequences are discarded",
+			input: "a\x1b[2Kb\x1b]0;title\x07c\x1b(Bd",
+			want:  []Span{{Text: "abcd"}},
+		},
+		{
+			name:  "redundant SGR does not split spans",
+			input: "\x1b[32ma\x1b[32mb",
+			want:  []Span{{Text: "ab", Style: Style{Foreground: "#00cd00"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_BaseColors(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		normal := Parse("\x1b[" + strconv.Itoa(30+i) + "mx")
+		if len(normal) != 1 || normal[0].Foreground != basePalette[i] {
+			t.Errorf("SGR %d foreground = %+v, want %s", 30+i, normal, basePalette[i])
+		}
+		bright := Parse("\x1b[" + strconv.Itoa(90+i) + "mx")
+		if len(bright) != 1 || bright[0].Foreground != basePalette[i+8] {
+			t.Errorf("SGR %d foreground = %+v, want %s", 90+i, bright, basePalette[i+8])
+		}
+	}
+}
+
+func TestColor256(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{index: 0, want: "#000000"},
+		{index: 15, want: "#ffffff"},
+		{index: 16, want: "#000000"},
+		{index: 21, want: "#0000ff"},
+		{index: 231, want: "#ffffff"},
+		{index: 244, want: "#808080"},
+		{index: 255, want: "#eeeeee"},
+		{index: 256, want: ""},
+		{index: -1, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := Color256(tt.index); got != tt.want {
+			t.Errorf("Color256(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	input := "\x1b[1;31merror\x1b[0m: \x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\"
+	if got, want := Strip(input), "error: link"; got != want {
+		t.Errorf("Strip() = %q, want %q", got, want)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		width  int
+		want   string
+		wantOk bool
+	}{
+		{name: "fits", input: "abc", width: 3, want: "abc"},
+		{name: "cut", input: "abcd", width: 3, want: "abc", wantOk: true},
+		{name: "multibyte", input: "日本語です", width: 2, want: "日本", wantOk: true},
+		{name: "escape before cut", input: "\x1b[1mab\x1b[0mcd", width: 3, want: "\x1b[1mab\x1b[0mc", wantOk: true},
+		{name: "escapes only make it long", input: "\x1b[1mab\x1b[0m", width: 2, want: "\x1b[1mab\x1b[0m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Truncate(tt.input, tt.width)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Truncate(%q, %d) = %q, %v, want %q, %v", tt.input, tt.width, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
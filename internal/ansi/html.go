@@ -0,0 +1,76 @@
+package ansi
+
+import (
+	"html"
+	"strings"
+)
+
+// ToHTML converts text containing ANSI SGR sequences into a self-contained
+// HTML <pre> block. Styled runs become <span> elements with inline CSS and
+// all text is HTML-escaped.
+func ToHTML(s string) string {
+	var b strings.Builder
+	b.WriteString(`<pre style="font-family:monospace">`)
+
+	for _, span := range Parse(s) {
+		text := html.EscapeString(span.Text)
+		css := span.Style.css()
+		if css == "" {
+			b.WriteString(text)
+			continue
+		}
+		b.WriteString(`<span style="`)
+		b.WriteString(css)
+		b.WriteString(`">`)
+		b.WriteString(text)
+		b.WriteString(`</span>`)
+	}
+
+	b.WriteString("</pre>")
+	return b.String()
+}
+
+// css renders the style as an inline CSS declaration list
+func (s Style) css() string {
+	fg, bg := s.Foreground, s.Background
+	if s.Reverse {
+		fg, bg = bg, fg
+		// Reversing the terminal defaults still needs visible colors
+		if fg == "" {
+			fg = basePalette[0]
+		}
+		if bg == "" {
+			bg = basePalette[7]
+		}
+	}
+
+	var decls []string
+	if fg != "" {
+		decls = append(decls, "color:"+fg)
+	}
+	if bg != "" {
+		decls = append(decls, "background-color:"+bg)
+	}
+	if s.Bold {
+		decls = append(decls, "font-weight:bold")
+	}
+	if s.Dim {
+		decls = append(decls, "opacity:0.7")
+	}
+	if s.Italic {
+		decls = append(decls, "font-style:italic")
+	}
+
+	var lines []string
+	if s.Underline {
+		lines = append(lines, "underline")
+	}
+	if s.Strike {
+		lines = append(lines, "line-through")
+	}
+	if len(lines) > 0 {
+		decls = append(decls, "text-decoration:"+strings.Join(lines, " "))
+	}
+
+	return strings.Join(decls, ";")
+}
@@ -0,0 +1,50 @@
+package ansi
+
+import "testing"
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "plain text",
+			input: "hello\n",
+			want:  `<pre style="font-family:monospace">hello` + "\n" + `</pre>`,
+		},
+		{
+			name:  "escapes HTML special characters",
+			input: `<a href="x">&</a>`,
+			want:  `<pre style="font-family:monospace">&lt;a href=&#34;x&#34;&gt;&amp;&lt;/a&gt;</pre>`,
+		},
+		{
+			name:  "base color",
+			input: "\x1b[34mblue\x1b[0m",
+			want:  `<pre style="font-family:monospace"><span style="color:#0000ee">blue</span></pre>`,
+		},
+		{
+			name:  "256 color background",
+			input: "\x1b[48;5;208mo",
+			want:  `<pre style="font-family:monospace"><span style="background-color:#ff8700">o</span></pre>`,
+		},
+		{
+			name:  "bold and underline",
+			input: "\x1b[1;4mb<",
+			want:  `<pre style="font-family:monospace"><span style="font-weight:bold;text-decoration:underline">b&lt;</span></pre>`,
+		},
+		{
+			name:  "reverse video swaps colors",
+			input: "\x1b[7;31mr",
+			want:  `<pre style="font-family:monospace"><span style="color:#000000;background-color:#cd0000">r</span></pre>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToHTML(tt.input); got != tt.want {
+				t.Errorf("ToHTML(%q) =\n%s\nwant\n%s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
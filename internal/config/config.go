@@ -0,0 +1,273 @@
+// Package config loads the optional YAML configuration file. Its keys
+// mirror the command-line flags, with underscores for dashes.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/server"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings read from a configuration file. A nil field
+// was not set in the file and leaves the flag's value alone.
+type Config struct {
+	Session       *string        `yaml:"session"`
+	ServerName    *string        `yaml:"server_name"`
+	ServerVersion *string        `yaml:"server_version"`
+	AllowWrites   *bool          `yaml:"allow_writes"`
+	PromptRegex   *string        `yaml:"prompt_regex"`
+	ExitSentinel  *string        `yaml:"exit_sentinel"`
+	PollInterval  *time.Duration `yaml:"poll_interval"`
+	IdleTimeout   *time.Duration `yaml:"idle_timeout"`
+	CaptureArgs   *string        `yaml:"capture_args"`
+	MaxLineWidth  *int           `yaml:"max_line_width"`
+	Pretty        *bool          `yaml:"pretty"`
+
+	NormalizeNewlines  *bool          `yaml:"normalize_newlines"`
+	PromptStrategy     *string        `yaml:"prompt_strategy"`
+	SaveDir            *string        `yaml:"save_dir"`
+	AllowRuntimeUnlock *bool          `yaml:"allow_runtime_unlock"`
+	UnlockSecret       *string        `yaml:"unlock_secret"`
+	MaxRequestBytes    *int64         `yaml:"max_request_bytes"`
+	AllowAbsPaths      *bool          `yaml:"allow_abs_paths"`
+	KeepWarm           *time.Duration `yaml:"keep_warm"`
+	InitTimeout        *time.Duration `yaml:"init_timeout"`
+	OutputTTL          *time.Duration `yaml:"output_ttl"`
+	Metrics            *bool          `yaml:"metrics"`
+	MetricsAddr        *string        `yaml:"metrics_addr"`
+	SingleShot         *bool          `yaml:"single_shot"`
+	Record             *string        `yaml:"record"`
+	Replay             *string        `yaml:"replay"`
+	TraceFile          *string        `yaml:"trace_file"`
+	// StartupCommands is a list, the counterpart of repeating
+	// --startup-command
+	StartupCommands []string `yaml:"startup_commands"`
+	// AllowSessions is the counterpart of repeating --allow-session
+	AllowSessions []string `yaml:"allow_sessions"`
+	// JobsCommands is the counterpart of repeating --jobs-command, with
+	// entries of the form SHELL=COMMAND
+	JobsCommands []string `yaml:"jobs_commands"`
+}
+
+// Load reads and validates the configuration file at path
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return Parse(path, data)
+}
+
+// yamlLine matches the line reference at the start of yaml.v3's messages
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// Parse decodes and validates configuration data. name identifies the
+// source in errors, which are reported as name:line: message.
+func Parse(name string, data []byte) (*Config, error) {
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			msgs := make([]string, 0, len(typeErr.Errors))
+			for _, msg := range typeErr.Errors {
+				msgs = append(msgs, located(name, msg))
+			}
+			return nil, errors.New(strings.Join(msgs, "\n"))
+		}
+		return nil, errors.New(located(name, err.Error()))
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, errors.New(located(name, err.Error()))
+	}
+	if err := cfg.validate(name, keyLines(&root)); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// located rewrites a yaml.v3 message such as "line 3: ..." as
+// "name:3: ...", or prefixes name when the message has no line
+func located(name, msg string) string {
+	// Unknown keys are reported against the Go type, which means nothing
+	// to someone writing the file
+	msg = strings.Replace(msg, " in type config.Config", "", 1)
+	if m := yamlLine.FindStringSubmatch(msg); m != nil {
+		return fmt.Sprintf("%s:%s: %s", name, m[1], msg[len(m[0]):])
+	}
+	return fmt.Sprintf("%s: %s", name, strings.TrimPrefix(msg, "yaml: "))
+}
+
+// keyLines maps each top-level key of a YAML document to its line
+func keyLines(root *yaml.Node) map[string]int {
+	lines := map[string]int{}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return lines
+	}
+	mapping := root.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		lines[mapping.Content[i].Value] = mapping.Content[i].Line
+	}
+	return lines
+}
+
+// validate checks the values the YAML types alone don't constrain, with
+// the same rules the corresponding flags are held to
+func (c *Config) validate(name string, lines map[string]int) error {
+	fail := func(key string, err error) error {
+		return fmt.Errorf("%s:%d: %s: %v", name, lines[key], key, err)
+	}
+
+	if c.PromptRegex != nil {
+		if _, err := regexp.Compile(*c.PromptRegex); err != nil {
+			return fail("prompt_regex", err)
+		}
+	}
+	if c.PromptStrategy != nil {
+		if _, err := tmux.NewPromptDetector(*c.PromptStrategy, nil); err != nil {
+			return fail("prompt_strategy", err)
+		}
+	}
+	if c.ExitSentinel != nil {
+		if err := tmux.ValidateExitSentinel(*c.ExitSentinel); err != nil {
+			return fail("exit_sentinel", err)
+		}
+	}
+	if c.PollInterval != nil {
+		if err := tmux.ValidatePollInterval(*c.PollInterval); err != nil {
+			return fail("poll_interval", err)
+		}
+	}
+	if c.IdleTimeout != nil && *c.IdleTimeout < 0 {
+		return fail("idle_timeout", errors.New("must not be negative"))
+	}
+	if c.KeepWarm != nil && *c.KeepWarm < 0 {
+		return fail("keep_warm", errors.New("must not be negative"))
+	}
+	if c.InitTimeout != nil && *c.InitTimeout < 0 {
+		return fail("init_timeout", errors.New("must not be negative"))
+	}
+	if c.OutputTTL != nil && *c.OutputTTL <= 0 {
+		return fail("output_ttl", errors.New("must be positive"))
+	}
+	if c.CaptureArgs != nil {
+		if _, err := tmux.ParseCaptureArgs(*c.CaptureArgs); err != nil {
+			return fail("capture_args", err)
+		}
+	}
+	if c.MaxLineWidth != nil && *c.MaxLineWidth < 0 {
+		return fail("max_line_width", errors.New("must not be negative"))
+	}
+	if c.MaxRequestBytes != nil && *c.MaxRequestBytes < 0 {
+		return fail("max_request_bytes", errors.New("must not be negative"))
+	}
+	for _, command := range c.StartupCommands {
+		if err := tmux.ValidateCommandLine(command); err != nil {
+			return fail("startup_commands", err)
+		}
+	}
+	for _, pattern := range c.AllowSessions {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fail("allow_sessions", fmt.Errorf("invalid pattern %q: %v", pattern, err))
+		}
+	}
+	for _, entry := range c.JobsCommands {
+		if _, _, err := server.ParseJobsCommand(entry); err != nil {
+			return fail("jobs_commands", err)
+		}
+	}
+	return nil
+}
+
+// Flags returns the settings present in the file as flag values keyed by
+// flag name, ready for flag.Set
+func (c *Config) Flags() map[string]string {
+	flags := map[string]string{}
+	setString := func(name string, v *string) {
+		if v != nil {
+			flags[name] = *v
+		}
+	}
+	setDuration := func(name string, v *time.Duration) {
+		if v != nil {
+			flags[name] = v.String()
+		}
+	}
+
+	setString("session", c.Session)
+	setString("server-name", c.ServerName)
+	setString("server-version", c.ServerVersion)
+	if c.AllowWrites != nil {
+		flags["allow-writes"] = strconv.FormatBool(*c.AllowWrites)
+	}
+	setString("prompt-regex", c.PromptRegex)
+	setString("prompt-strategy", c.PromptStrategy)
+	setString("exit-sentinel", c.ExitSentinel)
+	setDuration("poll-interval", c.PollInterval)
+	setDuration("idle-timeout", c.IdleTimeout)
+	setDuration("keep-warm", c.KeepWarm)
+	setDuration("init-timeout", c.InitTimeout)
+	setDuration("output-ttl", c.OutputTTL)
+	setString("capture-args", c.CaptureArgs)
+	setString("save-dir", c.SaveDir)
+	if c.MaxLineWidth != nil {
+		flags["max-line-width"] = strconv.Itoa(*c.MaxLineWidth)
+	}
+	if c.MaxRequestBytes != nil {
+		flags["max-request-bytes"] = strconv.FormatInt(*c.MaxRequestBytes, 10)
+	}
+	if c.Pretty != nil {
+		flags["pretty"] = strconv.FormatBool(*c.Pretty)
+	}
+	if c.NormalizeNewlines != nil {
+		flags["normalize-newlines"] = strconv.FormatBool(*c.NormalizeNewlines)
+	}
+	if c.AllowRuntimeUnlock != nil {
+		flags["allow-runtime-unlock"] = strconv.FormatBool(*c.AllowRuntimeUnlock)
+	}
+	setString("unlock-secret", c.UnlockSecret)
+	if c.AllowAbsPaths != nil {
+		flags["allow-abs-paths"] = strconv.FormatBool(*c.AllowAbsPaths)
+	}
+	if c.Metrics != nil {
+		flags["metrics"] = strconv.FormatBool(*c.Metrics)
+	}
+	setString("metrics-addr", c.MetricsAddr)
+	if c.SingleShot != nil {
+		flags["single-shot"] = strconv.FormatBool(*c.SingleShot)
+	}
+	setString("record", c.Record)
+	setString("replay", c.Replay)
+	setString("trace-file", c.TraceFile)
+	return flags
+}
+
+// RepeatedFlags returns the settings for repeatable flags present in the
+// file, each value to be passed to flag.Set in order
+func (c *Config) RepeatedFlags() map[string][]string {
+	flags := map[string][]string{}
+	if len(c.StartupCommands) > 0 {
+		flags["startup-command"] = c.StartupCommands
+	}
+	if len(c.AllowSessions) > 0 {
+		flags["allow-session"] = c.AllowSessions
+	}
+	if len(c.JobsCommands) > 0 {
+		flags["jobs-command"] = c.JobsCommands
+	}
+	return flags
+}
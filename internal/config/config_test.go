@@ -0,0 +1,164 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	data := `# Shared agent session
+session: work
+allow_writes: true
+poll_interval: 250ms
+idle_timeout: 30m
+max_line_width: 500
+normalize_newlines: false
+save_dir: /var/tmp/wingman
+allow_runtime_unlock: true
+allow_abs_paths: true
+keep_warm: 5m
+init_timeout: 30s
+output_ttl: 1h
+metrics: true
+metrics_addr: 127.0.0.1:9464
+single_shot: false
+record: /tmp/session.jsonl
+trace_file: /tmp/trace.jsonl
+`
+	cfg, err := Parse("wingman.yaml", []byte(data))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if cfg.Session == nil || *cfg.Session != "work" {
+		t.Errorf("Session = %v, want work", cfg.Session)
+	}
+	if cfg.PollInterval == nil || *cfg.PollInterval != 250*time.Millisecond {
+		t.Errorf("PollInterval = %v, want 250ms", cfg.PollInterval)
+	}
+	if cfg.PromptRegex != nil {
+		t.Errorf("PromptRegex = %q, want unset", *cfg.PromptRegex)
+	}
+
+	want := map[string]string{
+		"session":        "work",
+		"allow-writes":   "true",
+		"poll-interval":  "250ms",
+		"idle-timeout":   "30m0s",
+		"max-line-width": "500",
+
+		"normalize-newlines": "false",
+		"save-dir":           "/var/tmp/wingman",
+
+		"allow-runtime-unlock": "true",
+		"allow-abs-paths":      "true",
+		"keep-warm":            "5m0s",
+		"init-timeout":         "30s",
+		"output-ttl":           "1h0m0s",
+		"metrics":              "true",
+		"metrics-addr":         "127.0.0.1:9464",
+		"single-shot":          "false",
+		"record":               "/tmp/session.jsonl",
+		"trace-file":           "/tmp/trace.jsonl",
+	}
+	got := cfg.Flags()
+	if len(got) != len(want) {
+		t.Errorf("Flags() = %v, want %v", got, want)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("Flags()[%q] = %q, want %q", name, got[name], value)
+		}
+	}
+}
+
+func TestParse_StartupCommands(t *testing.T) {
+	data := `startup_commands:
+  - cd ~/project
+  - source .venv/bin/activate
+allow_sessions: [build-*]
+jobs_commands: ["nu=job list"]
+`
+	cfg, err := Parse("wingman.yaml", []byte(data))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := map[string][]string{
+		"startup-command": {"cd ~/project", "source .venv/bin/activate"},
+		"allow-session":   {"build-*"},
+		"jobs-command":    {"nu=job list"},
+	}
+	if got := cfg.RepeatedFlags(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RepeatedFlags() = %q, want %q", got, want)
+	}
+	if flags := cfg.Flags(); len(flags) != 0 {
+		t.Errorf("Flags() = %v, want none", flags)
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	cfg, err := Parse("wingman.yaml", nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if flags := cfg.Flags(); len(flags) != 0 {
+		t.Errorf("Flags() = %v, want none", flags)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "unknown key", data: "session: work\nterminal: xterm\n", want: "wingman.yaml:2: field terminal not found"},
+		{name: "wrong type", data: "session: work\n\nmax_line_width: wide\n", want: "wingman.yaml:3: cannot unmarshal"},
+		{name: "bad duration", data: "poll_interval: soon\n", want: "wingman.yaml:1:"},
+		{name: "syntax error", data: "session: [work\n", want: "wingman.yaml:"},
+		{name: "invalid regex", data: "session: work\nprompt_regex: \"[\"\n", want: "wingman.yaml:2: prompt_regex:"},
+		{name: "poll interval too short", data: "poll_interval: 1ns\n", want: "wingman.yaml:1: poll_interval:"},
+		{name: "unsafe capture args", data: "\n\ncapture_args: -t other\n", want: "wingman.yaml:3: capture_args:"},
+		{name: "negative width", data: "max_line_width: -1\n", want: "wingman.yaml:1: max_line_width: must not be negative"},
+		{name: "negative keep-warm", data: "keep_warm: -1m\n", want: "wingman.yaml:1: keep_warm: must not be negative"},
+		{name: "negative request limit", data: "max_request_bytes: -1\n", want: "wingman.yaml:1: max_request_bytes: must not be negative"},
+		{name: "multi-line startup command", data: "startup_commands:\n  - \"cd /tmp\\nls\"\n", want: "wingman.yaml:1: startup_commands: command must be a single line"},
+		{name: "unknown prompt strategy", data: "prompt_strategy: magic\n", want: "wingman.yaml:1: prompt_strategy: unknown prompt strategy"},
+		{name: "jobs command without shell", data: "jobs_commands: [\"jobs -l\"]\n", want: "wingman.yaml:1: jobs_commands: \"jobs -l\" is not of the form SHELL=COMMAND"},
+		{name: "bad session pattern", data: "allow_sessions: [\"build-[\"]\n", want: "wingman.yaml:1: allow_sessions: invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("wingman.yaml", []byte(tt.data))
+			if err == nil {
+				t.Fatalf("Parse() succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wingman.yaml")
+	if err := os.WriteFile(path, []byte("session: from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Session == nil || *cfg.Session != "from-file" {
+		t.Errorf("Session = %v, want from-file", cfg.Session)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Load() of a missing file succeeded, want error")
+	}
+}
@@ -0,0 +1,150 @@
+// Package git reads the state of a git working tree by running git
+// directly, rather than typing into a terminal and scraping the result.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotRepository is returned when a directory is not inside a git
+// working tree
+var ErrNotRepository = errors.New("not a git repository")
+
+// Status is the state of a working tree as reported by git status
+type Status struct {
+	// Branch is the checked-out branch, or empty when HEAD is detached
+	Branch   string
+	Detached bool
+	// Upstream is the branch Branch tracks, if any. Ahead and Behind count
+	// the commits each side has that the other lacks; UpstreamGone is set
+	// when the upstream branch no longer exists.
+	Upstream     string
+	Ahead        int
+	Behind       int
+	UpstreamGone bool
+	// NoCommits is set in a repository whose branch has no commits yet
+	NoCommits bool
+	Files     []FileStatus
+}
+
+// FileStatus is one changed path. Code is git's two-letter XY status, e.g.
+// " M" for a modified file not yet staged, "A " for a staged addition or
+// "??" for an untracked file.
+type FileStatus struct {
+	Code string
+	Path string
+	// OrigPath is the path a renamed or copied file had before
+	OrigPath string
+}
+
+// killWait is how long ReadStatus waits for git's output to close after
+// killing it when ctx ends, in case a child process still holds it open
+const killWait = time.Second
+
+// ReadStatus runs git status in dir. git is killed if ctx ends first, as
+// it can take a long time in a huge tree. The repository's own
+// core.fsmonitor is overridden: dir may be an untrusted clone, and its hook
+// would run as the server's user, out of sight of the trace.
+func ReadStatus(ctx context.Context, dir string) (*Status, error) {
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.fsmonitor=false", "-C", dir, "status", "--porcelain", "-b", "-z")
+	cmd.WaitDelay = killWait
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("git status in %s did not finish: %w", dir, ctx.Err())
+		}
+		if strings.Contains(stderr.String(), "not a git repository") {
+			return nil, fmt.Errorf("%s: %w", dir, ErrNotRepository)
+		}
+		return nil, fmt.Errorf("git status failed: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseStatus(stdout.String())
+}
+
+// parseStatus parses the output of git status --porcelain -b -z: a branch
+// header followed by one entry per changed path, each NUL-terminated.
+// Renames and copies are followed by an extra field with the original path.
+func parseStatus(output string) (*Status, error) {
+	fields := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "## ") {
+		return nil, fmt.Errorf("unexpected git status output: %q", output)
+	}
+
+	status := &Status{Files: []FileStatus{}}
+	if err := status.parseBranch(strings.TrimPrefix(fields[0], "## ")); err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(fields); i++ {
+		entry := fields[i]
+		if len(entry) < 4 || entry[2] != ' ' {
+			return nil, fmt.Errorf("unexpected git status entry: %q", entry)
+		}
+		file := FileStatus{Code: entry[:2], Path: entry[3:]}
+		if entry[0] == 'R' || entry[0] == 'C' {
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("git status entry %q is missing its original path", entry)
+			}
+			i++
+			file.OrigPath = fields[i]
+		}
+		status.Files = append(status.Files, file)
+	}
+	return status, nil
+}
+
+// parseBranch parses the branch header without its leading "## ", e.g.
+// "main...origin/main [ahead 1, behind 2]", "HEAD (no branch)" or
+// "No commits yet on main"
+func (s *Status) parseBranch(header string) error {
+	for _, prefix := range []string{"No commits yet on ", "Initial commit on "} {
+		if strings.HasPrefix(header, prefix) {
+			s.NoCommits = true
+			s.Branch = strings.TrimPrefix(header, prefix)
+			return nil
+		}
+	}
+	if header == "HEAD (no branch)" {
+		s.Detached = true
+		return nil
+	}
+
+	tracking := ""
+	if open := strings.Index(header, " ["); open >= 0 && strings.HasSuffix(header, "]") {
+		header, tracking = header[:open], header[open+2:len(header)-1]
+	}
+	s.Branch, s.Upstream, _ = strings.Cut(header, "...")
+
+	if tracking == "" {
+		return nil
+	}
+	if tracking == "gone" {
+		s.UpstreamGone = true
+		return nil
+	}
+	for _, part := range strings.Split(tracking, ", ") {
+		kind, count, ok := strings.Cut(part, " ")
+		n, err := strconv.Atoi(count)
+		if !ok || err != nil {
+			return fmt.Errorf("unexpected git branch header: %q", header)
+		}
+		switch kind {
+		case "ahead":
+			s.Ahead = n
+		case "behind":
+			s.Behind = n
+		default:
+			return fmt.Errorf("unexpected git branch header: %q", header)
+		}
+	}
+	return nil
+}
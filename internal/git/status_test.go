@@ -0,0 +1,152 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    *Status
+		wantErr bool
+	}{
+		{
+			name:   "clean branch",
+			output: "## main\x00",
+			want:   &Status{Branch: "main", Files: []FileStatus{}},
+		},
+		{
+			name:   "ahead and behind with changes",
+			output: "## main...origin/main [ahead 1, behind 2]\x00 M README.md\x00A  new.go\x00?? notes.txt\x00",
+			want: &Status{Branch: "main", Upstream: "origin/main", Ahead: 1, Behind: 2, Files: []FileStatus{
+				{Code: " M", Path: "README.md"},
+				{Code: "A ", Path: "new.go"},
+				{Code: "??", Path: "notes.txt"},
+			}},
+		},
+		{
+			name:   "rename with spaces",
+			output: "## main...origin/main [ahead 1]\x00RM b.txt\x00sp ace.txt\x00",
+			want: &Status{Branch: "main", Upstream: "origin/main", Ahead: 1, Files: []FileStatus{
+				{Code: "RM", Path: "b.txt", OrigPath: "sp ace.txt"},
+			}},
+		},
+		{
+			name:   "upstream gone",
+			output: "## topic...origin/topic [gone]\x00",
+			want:   &Status{Branch: "topic", Upstream: "origin/topic", UpstreamGone: true, Files: []FileStatus{}},
+		},
+		{
+			name:   "detached",
+			output: "## HEAD (no branch)\x00",
+			want:   &Status{Detached: true, Files: []FileStatus{}},
+		},
+		{
+			name:   "no commits",
+			output: "## No commits yet on main\x00?? a\x00",
+			want:   &Status{Branch: "main", NoCommits: true, Files: []FileStatus{{Code: "??", Path: "a"}}},
+		},
+		{name: "no header", output: " M a\x00", wantErr: true},
+		{name: "rename without original", output: "## main\x00R  b\x00", wantErr: true},
+		{name: "bad tracking", output: "## main...origin/main [diverged]\x00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatus(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadStatus(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed, skipping test")
+	}
+
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", "-b", "main", dir).CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v (%s)", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := ReadStatus(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("ReadStatus() error = %v", err)
+	}
+	want := &Status{Branch: "main", NoCommits: true, Files: []FileStatus{{Code: "??", Path: "new.txt"}}}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("ReadStatus() = %+v, want %+v", status, want)
+	}
+}
+
+func TestReadStatus_IgnoresFSMonitor(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed, skipping test")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell script")
+	}
+
+	// A cloned repository can name any program as its fsmonitor hook
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", "-b", "main", dir).CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v (%s)", err, out)
+	}
+	marker := filepath.Join(t.TempDir(), "ran")
+	hook := filepath.Join(t.TempDir(), "hook")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\ntouch "+marker+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("git", "-C", dir, "config", "core.fsmonitor", hook).CombinedOutput(); err != nil {
+		t.Fatalf("git config failed: %v (%s)", err, out)
+	}
+
+	if _, err := ReadStatus(context.Background(), dir); err != nil {
+		t.Fatalf("ReadStatus() error = %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("ReadStatus() ran the repository's fsmonitor hook")
+	}
+}
+
+func TestReadStatus_NotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed, skipping test")
+	}
+
+	// GIT_CEILING_DIRECTORIES stops git finding a repository above the
+	// temporary directory
+	dir := t.TempDir()
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+	if _, err := ReadStatus(context.Background(), dir); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("ReadStatus() error = %v, want ErrNotRepository", err)
+	}
+}
+
+func TestReadStatus_Cancelled(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed, skipping test")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ReadStatus(ctx, t.TempDir()); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadStatus() error = %v, want context.Canceled", err)
+	}
+}
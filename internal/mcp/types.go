@@ -1,5 +1,7 @@
 package mcp
 
+import "encoding/json"
+
 // JSON-RPC 2.0 message types
 type JSONRPCRequest struct {
 	JSONRPC string      `json:"jsonrpc"`
@@ -9,12 +11,33 @@ type JSONRPCRequest struct {
 }
 
 type JSONRPCResponse struct {
-	JSONRPC string      `json:"jsonrpc"`
-	ID      interface{} `json:"id,omitempty"`
-	Result  interface{} `json:"result,omitempty"`
+	JSONRPC string        `json:"jsonrpc"`
+	ID      interface{}   `json:"id,omitempty"`
+	Result  interface{}   `json:"result,omitempty"`
 	Error   *JSONRPCError `json:"error,omitempty"`
 }
 
+// Standard JSON-RPC 2.0 error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// CodeServerNotInitialized is returned for requests that arrive before the
+// initialize handshake has completed
+const CodeServerNotInitialized = -32002
+
+// JSONRPCNotification is a message sent without an ID, which expects no
+// response
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 type JSONRPCError struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
@@ -23,9 +46,9 @@ type JSONRPCError struct {
 
 // MCP Protocol types
 type InitializeRequest struct {
-	ProtocolVersion string         `json:"protocolVersion"`
+	ProtocolVersion string                 `json:"protocolVersion"`
 	Capabilities    map[string]interface{} `json:"capabilities"`
-	ClientInfo      ClientInfo     `json:"clientInfo"`
+	ClientInfo      ClientInfo             `json:"clientInfo"`
 }
 
 type ClientInfo struct {
@@ -34,9 +57,9 @@ type ClientInfo struct {
 }
 
 type InitializeResult struct {
-	ProtocolVersion string         `json:"protocolVersion"`
+	ProtocolVersion string             `json:"protocolVersion"`
 	Capabilities    ServerCapabilities `json:"capabilities"`
-	ServerInfo      ServerInfo     `json:"serverInfo"`
+	ServerInfo      ServerInfo         `json:"serverInfo"`
 }
 
 type ServerCapabilities struct {
@@ -67,12 +90,14 @@ type Tool struct {
 	Name        string      `json:"name"`
 	Description string      `json:"description"`
 	InputSchema InputSchema `json:"inputSchema"`
+	// OutputSchema describes the tool's structuredContent, if it returns any
+	OutputSchema *InputSchema `json:"outputSchema,omitempty"`
 }
 
 type InputSchema struct {
-	Type       string                 `json:"type"`
-	Properties map[string]Property    `json:"properties,omitempty"`
-	Required   []string               `json:"required,omitempty"`
+	Type       string              `json:"type"`
+	Properties map[string]Property `json:"properties,omitempty"`
+	Required   []string            `json:"required,omitempty"`
 }
 
 type Property struct {
@@ -83,16 +108,68 @@ type Property struct {
 type CallToolRequest struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
+}
+
+// RequestMeta is the _meta object a client may attach to a request
+type RequestMeta struct {
+	// ProgressToken, when set, asks for notifications/progress messages
+	// carrying this token while the request runs
+	ProgressToken interface{} `json:"progressToken,omitempty"`
+}
+
+// ProgressParams are the params of a notifications/progress message
+type ProgressParams struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         *float64    `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
+// CancelledParams are the params of a notifications/cancelled message,
+// which asks the server to stop working on an earlier request
+type CancelledParams struct {
+	RequestID interface{} `json:"requestId"`
+	Reason    string      `json:"reason,omitempty"`
 }
 
 type CallToolResult struct {
 	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
+	// StructuredContent is a machine-readable form of Content that conforms
+	// to the tool's OutputSchema
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
 }
 
 type Content struct {
 	Type string `json:"type"`
 	Text string `json:"text"`
+
+	// URI, Name, Description and MimeType describe a "resource_link" item,
+	// which points at a resource the client can fetch with resources/read
+	// instead of carrying its text
+	URI         string `json:"uri,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceLink returns a "resource_link" content item
+func ResourceLink(uri, name, description, mimeType string) Content {
+	return Content{Type: "resource_link", URI: uri, Name: name, Description: description, MimeType: mimeType}
+}
+
+// MarshalJSON leaves text out of resource links, which have none; a text
+// item keeps it even when empty, as the protocol requires
+func (c Content) MarshalJSON() ([]byte, error) {
+	type content Content
+	if c.Type != "resource_link" {
+		return json.Marshal(content(c))
+	}
+	return json.Marshal(struct {
+		content
+		Text string `json:"text,omitempty"`
+	}{content: content(c)})
 }
 
 // Resource types
@@ -111,6 +188,18 @@ type ReadResourceRequest struct {
 	URI string `json:"uri"`
 }
 
+// SubscribeRequest is the params of resources/subscribe and
+// resources/unsubscribe
+type SubscribeRequest struct {
+	URI string `json:"uri"`
+}
+
+// ResourceUpdatedParams is the params of notifications/resources/updated,
+// sent for a subscribed resource whose content has changed
+type ResourceUpdatedParams struct {
+	URI string `json:"uri"`
+}
+
 type ReadResourceResult struct {
 	Contents []ResourceContent `json:"contents"`
 }
@@ -120,3 +209,16 @@ type ResourceContent struct {
 	MimeType string `json:"mimeType,omitempty"`
 	Text     string `json:"text,omitempty"`
 }
+
+// Prompt types
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
@@ -376,6 +376,33 @@ func TestCallToolResult_Marshal(t *testing.T) {
 	}
 }
 
+func TestContent_Marshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		content Content
+		want    string
+	}{
+		{name: "empty text", content: Content{Type: "text"}, want: `{"type":"text","text":""}`},
+		{
+			name:    "resource link",
+			content: ResourceLink("terminal://command-output/c1", "make", "", "text/plain"),
+			want:    `{"type":"resource_link","uri":"terminal://command-output/c1","name":"make","mimeType":"text/plain"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.content)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("json.Marshal() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
 func TestListResourcesResult_Marshal(t *testing.T) {
 	result := ListResourcesResult{
 		Resources: []Resource{
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// coerceInt converts a decoded JSON value to an integer. Whole numbers and
+// strings holding one, such as "50", are accepted; fractions, booleans,
+// arrays, objects and null are not.
+func coerceInt(v interface{}) (int, error) {
+	switch v := v.(type) {
+	case float64:
+		if v != math.Trunc(v) || v > math.MaxInt32 || v < math.MinInt32 {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		return int(v), nil
+	case int:
+		return v, nil
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an integer", v)
+		}
+		return n, nil
+	case nil:
+		return 0, fmt.Errorf("null is not an integer")
+	}
+	return 0, fmt.Errorf("%s is not an integer", jsonTypeName(v))
+}
+
+// jsonTypeName names the JSON type of a decoded value for error messages
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// intArgument returns the integer tool argument name, or def when it is
+// absent. A value coerceInt rejects is reported as invalid params.
+func intArgument(arguments map[string]interface{}, name string, def int) (int, error) {
+	value, ok := arguments[name]
+	if !ok {
+		return def, nil
+	}
+	n, err := coerceInt(value)
+	if err != nil {
+		return 0, invalidParams(fmt.Sprintf("invalid %s: %v", name, err),
+			paramError{Field: name, Expected: "integer or numeric string"})
+	}
+	return n, nil
+}
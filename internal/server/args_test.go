@@ -0,0 +1,90 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestCoerceInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    int
+		wantErr bool
+	}{
+		{name: "JSON number", value: float64(50), want: 50},
+		{name: "negative number", value: float64(-1), want: -1},
+		{name: "int", value: 75, want: 75},
+		{name: "numeric string", value: "50", want: 50},
+		{name: "padded string", value: " 20 ", want: 20},
+		{name: "negative string", value: "-3", want: -3},
+		{name: "fraction", value: 1.5, wantErr: true},
+		{name: "huge number", value: 1e20, wantErr: true},
+		{name: "word", value: "fifty", wantErr: true},
+		{name: "empty string", value: "", wantErr: true},
+		{name: "fractional string", value: "1.5", wantErr: true},
+		{name: "boolean", value: true, wantErr: true},
+		{name: "array", value: []interface{}{float64(1)}, wantErr: true},
+		{name: "object", value: map[string]interface{}{}, wantErr: true},
+		{name: "null", value: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := coerceInt(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("coerceInt(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("coerceInt(%#v) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntArgument(t *testing.T) {
+	if got, err := intArgument(map[string]interface{}{}, "timeout_ms", 500); err != nil || got != 500 {
+		t.Errorf("intArgument(absent) = %d, %v, want 500, nil", got, err)
+	}
+	if got, err := intArgument(map[string]interface{}{"timeout_ms": "250"}, "timeout_ms", 500); err != nil || got != 250 {
+		t.Errorf("intArgument(\"250\") = %d, %v, want 250, nil", got, err)
+	}
+
+	_, err := intArgument(map[string]interface{}{"timeout_ms": false}, "timeout_ms", 500)
+	if rpcErr := (&Server{}).jsonRPCError(err); rpcErr.Code != mcp.CodeInvalidParams {
+		t.Errorf("intArgument(false) error code = %d, want %d", rpcErr.Code, mcp.CodeInvalidParams)
+	}
+}
+
+func TestServer_callTool_NumericStringArguments(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		return "", "", nil
+	})
+
+	tests := []struct {
+		name      string
+		tool      string
+		arguments map[string]interface{}
+		wantErr   bool
+	}{
+		{name: "string delay", tool: "is_active", arguments: map[string]interface{}{"delay_ms": "10"}},
+		{name: "boolean delay", tool: "is_active", arguments: map[string]interface{}{"delay_ms": true}, wantErr: true},
+		{name: "object delay", tool: "is_active", arguments: map[string]interface{}{"delay_ms": map[string]interface{}{}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := callFakeTool(srv, tt.tool, tt.arguments)
+			if tt.wantErr {
+				if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+					t.Fatalf("response.Error = %v, want invalid params", response.Error)
+				}
+				return
+			}
+			if response.Error != nil {
+				t.Fatalf("response.Error = %v, want nil", response.Error)
+			}
+		})
+	}
+}
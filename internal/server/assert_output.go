@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// assertResult is the structured content of assert_output
+type assertResult struct {
+	Passed bool `json:"passed"`
+	// Present is what was asserted: true for "the pattern is there",
+	// false for "the pattern is not there"
+	Present bool `json:"present"`
+	Matches int  `json:"matches"`
+	// Match is the newest line the pattern matched, if any
+	Match         *scrollbackLine `json:"match,omitempty"`
+	LinesSearched int             `json:"lines_searched"`
+}
+
+// text summarises the result for the text content of the tool result
+func (r assertResult) text(pattern string) string {
+	verdict := "FAIL"
+	if r.Passed {
+		verdict = "PASS"
+	}
+	if r.Match == nil {
+		return fmt.Sprintf("%s: /%s/ not found in %d lines", verdict, pattern, r.LinesSearched)
+	}
+	return fmt.Sprintf("%s: /%s/ matched %d of %d lines, newest on line %d:\n%s",
+		verdict, pattern, r.Matches, r.LinesSearched, r.Match.LineNumber, r.Match.Text)
+}
+
+// assertOutput handles the assert_output tool: it searches the pane's
+// scrollback for a pattern and reports pass or fail according to whether
+// the pattern was expected to be present or absent
+func (s *Server) assertOutput(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	patternText, _ := arguments["pattern"].(string)
+	if patternText == "" {
+		return nil, invalidParams("pattern is required",
+			paramError{Field: "pattern", Expected: "RE2 regular expression"})
+	}
+	pattern, err := regexp.Compile(patternText)
+	if err != nil {
+		return nil, invalidParams(fmt.Sprintf("invalid pattern: %v", err),
+			paramError{Field: "pattern", Expected: "RE2 regular expression"})
+	}
+	present := true
+	if presentVal, ok := arguments["present"]; ok {
+		if present, ok = presentVal.(bool); !ok {
+			return nil, invalidParams(fmt.Sprintf("present must be a boolean, got %s", jsonTypeName(presentVal)),
+				paramError{Field: "present", Expected: "boolean"})
+		}
+	}
+	lines := defaultScrollbackLines
+	if linesVal, ok := arguments["lines"]; ok {
+		lines = tmux.AllLines
+		if linesVal != "all" {
+			if lines, err = coerceInt(linesVal); err != nil || (lines < 1 && lines != tmux.AllLines) {
+				return nil, invalidParams(fmt.Sprintf("invalid lines: %v", linesVal),
+					paramError{Field: "lines", Expected: `positive integer, or -1 or "all" for the entire history`})
+			}
+		}
+	}
+
+	content, err := s.tmuxManager.GetScrollbackHistoryWithOptions(lines, tmux.CaptureOptions{Target: target})
+	if err != nil {
+		return toolError(err)
+	}
+
+	captured := splitLines(trimContent(content))
+	result := assertResult{Present: present, LinesSearched: len(captured)}
+	for i, line := range captured {
+		if pattern.MatchString(line) {
+			result.Matches++
+			result.Match = &scrollbackLine{LineNumber: i + 1, Text: truncateLines(line, s.maxLineWidth)}
+		}
+	}
+	result.Passed = (result.Matches > 0) == present
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: result.text(patternText)}},
+		StructuredContent: result,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_AssertOutput(t *testing.T) {
+	const output = "$ ./deploy.sh\nerror: retrying\nDeploy succeeded\n$ \n\n"
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      assertResult
+		wantStart string
+		wantText  string
+	}{
+		{
+			name:      "present and found",
+			arguments: map[string]interface{}{"pattern": "Deploy succeeded"},
+			want: assertResult{Passed: true, Present: true, Matches: 1, LinesSearched: 4,
+				Match: &scrollbackLine{LineNumber: 3, Text: "Deploy succeeded"}},
+			wantStart: "-100",
+			wantText:  "PASS: /Deploy succeeded/ matched 1 of 4 lines, newest on line 3:\nDeploy succeeded",
+		},
+		{
+			name:      "present and missing",
+			arguments: map[string]interface{}{"pattern": "Deploy failed", "lines": "all"},
+			want:      assertResult{Passed: false, Present: true, LinesSearched: 4},
+			wantStart: "-",
+			wantText:  "FAIL: /Deploy failed/ not found in 4 lines",
+		},
+		{
+			name:      "absent but found",
+			arguments: map[string]interface{}{"pattern": "(?i)^error", "present": false, "lines": float64(500)},
+			want: assertResult{Passed: false, Present: false, Matches: 1, LinesSearched: 4,
+				Match: &scrollbackLine{LineNumber: 2, Text: "error: retrying"}},
+			wantStart: "-500",
+			wantText:  "FAIL: /(?i)^error/ matched 1 of 4 lines, newest on line 2:\nerror: retrying",
+		},
+		{
+			name:      "absent and missing",
+			arguments: map[string]interface{}{"pattern": "Traceback", "present": false},
+			want:      assertResult{Passed: true, Present: false, LinesSearched: 4},
+			wantStart: "-100",
+			wantText:  "PASS: /Traceback/ not found in 4 lines",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var start string
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				if args[0] == "capture-pane" {
+					start = args[5]
+					return output, "", nil
+				}
+				return "", "", nil
+			})
+
+			response := callFakeTool(srv, "assert_output", tt.arguments)
+			if text := toolText(t, response); text != tt.wantText {
+				t.Errorf("assert_output text = %q, want %q", text, tt.wantText)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "assert_output").OutputSchema, result.StructuredContent)
+			if got := result.StructuredContent.(assertResult); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assert_output = %+v, want %+v", got, tt.want)
+			}
+			if start != tt.wantStart {
+				t.Errorf("capture-pane -S %q, want %q", start, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_AssertOutput_InvalidParams(t *testing.T) {
+	for _, arguments := range []map[string]interface{}{
+		{},
+		{"pattern": "("},
+		{"pattern": "ok", "present": "no"},
+		{"pattern": "ok", "lines": float64(0)},
+	} {
+		srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+		response := callFakeTool(srv, "assert_output", arguments)
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("assert_output(%v) error = %+v, want invalid params", arguments, response.Error)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/ansi"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// passwordPrompt matches the last line of a pane waiting for a password or
+// passphrase, such as sudo's "[sudo] password for me: " or ssh's "Enter
+// passphrase for key '...': "
+var passwordPrompt = regexp.MustCompile(`(?i)(password|passphrase|passcode|\bpin\b)[^:]*:\s*$`)
+
+// Values of promptResult.OSC133
+const (
+	oscIdle    = "prompt"
+	oscRunning = "running"
+)
+
+// promptResult is the structured content of at_prompt
+type promptResult struct {
+	AtPrompt bool `json:"at_prompt"`
+	// Confidence is how far the signals below agree, from 0 to 1
+	Confidence float64 `json:"confidence"`
+	// Reason names the signal that decided AtPrompt
+	Reason     string `json:"reason"`
+	Command    string `json:"command"`
+	Shell      bool   `json:"shell"`
+	Remote     bool   `json:"remote"`
+	ScreenMode string `json:"screen_mode"`
+	PaneDead   bool   `json:"pane_dead"`
+	// LastLine is the last non-blank line, without escape sequences
+	LastLine       string `json:"last_line"`
+	RegexMatch     bool   `json:"regex_match"`
+	PasswordPrompt bool   `json:"password_prompt"`
+	// OSC133 is what the shell's markers say: "prompt", "running", or
+	// empty without markers
+	OSC133 string `json:"osc133,omitempty"`
+	// Typed is text already typed at a marked prompt
+	Typed string `json:"typed,omitempty"`
+}
+
+// atPrompt handles the at_prompt tool: it combines the pane's foreground
+// command, the screen mode, the last line and the shell's OSC 133 markers
+// into a verdict on whether the pane is at an interactive shell prompt, so
+// the agent can check before typing a command
+func (s *Server) atPrompt(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	command, err := s.tmuxManager.CurrentCommand(target)
+	if err != nil {
+		return toolError(err)
+	}
+	info, err := s.tmuxManager.GetPaneInfoFor(target)
+	if err != nil {
+		return toolError(err)
+	}
+	content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{
+		Target:          target,
+		EscapeSequences: true,
+		KeepPromptMarks: true,
+	})
+	if err != nil {
+		return toolError(err)
+	}
+
+	result := s.judgePrompt(command, newTerminalInfo(info), content)
+	verdict := "not at a prompt"
+	if result.AtPrompt {
+		verdict = "at a prompt"
+	}
+	text := fmt.Sprintf("%s (confidence %.2f): %s", verdict, result.Confidence, result.Reason)
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}, nil
+}
+
+// judgePrompt weighs the signals for at_prompt. The checks run from the
+// most to the least decisive, and the first that applies gives the verdict
+// and its confidence.
+func (s *Server) judgePrompt(command string, terminal terminalInfo, content string) promptResult {
+	result := promptResult{
+		Command:    command,
+		Shell:      shellName(command) != "",
+		Remote:     remoteCommands[path.Base(command)],
+		ScreenMode: terminal.ScreenMode,
+		PaneDead:   terminal.PaneDead,
+	}
+	line, _ := lastNonBlankLine(content)
+	result.LastLine = strings.TrimRight(ansi.Strip(line), " ")
+	result.RegexMatch = s.promptPattern().MatchString(result.LastLine)
+	result.PasswordPrompt = passwordPrompt.MatchString(result.LastLine)
+	kind, typed := tmux.LastPromptMark(content)
+	switch kind {
+	case "B":
+		result.OSC133, result.Typed = oscIdle, typed
+	case "C":
+		result.OSC133 = oscRunning
+	}
+
+	decide := func(atPrompt bool, confidence float64, reason string) promptResult {
+		result.AtPrompt, result.Confidence, result.Reason = atPrompt, confidence, reason
+		return result
+	}
+	switch {
+	case result.PaneDead:
+		return decide(false, 1, "the pane's process has exited")
+	case result.ScreenMode == screenAlternate:
+		return decide(false, 0.95, fmt.Sprintf("%s has the alternate screen, as full-screen programs like vim and less do", command))
+	case result.PasswordPrompt:
+		return decide(false, 0.9, "the last line asks for a password")
+	case !result.Shell && !result.Remote:
+		return decide(false, 0.9, fmt.Sprintf("the pane is running %s, not a shell", command))
+	case result.OSC133 == oscRunning:
+		return decide(false, 0.9, "the shell's OSC 133 markers show a command running")
+	case result.OSC133 == oscIdle && result.Typed != "":
+		return decide(true, 0.85, fmt.Sprintf("the shell's OSC 133 markers show a prompt, with %q already typed", result.Typed))
+	case result.OSC133 == oscIdle:
+		return decide(true, 0.95, "the shell's OSC 133 markers show an empty prompt")
+	case result.RegexMatch && result.Remote:
+		return decide(true, 0.6, fmt.Sprintf("the last line matches the prompt regex, but the pane is running %s, so it is taken to be a remote shell's prompt", command))
+	case result.RegexMatch:
+		return decide(true, 0.8, "the pane is running a shell and the last line matches the prompt regex")
+	case result.Remote:
+		return decide(false, 0.5, fmt.Sprintf("the pane is running %s and the last line doesn't match the prompt regex", command))
+	}
+	return decide(false, 0.7, "the pane is running a shell, but the last line doesn't match the prompt regex: a command may be running, or the prompt regex doesn't fit this prompt (see detect_shell)")
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_judgePrompt(t *testing.T) {
+	osc := func(mark string) string { return "\x1b]133;" + mark + "\x07" }
+	prompt := osc("A") + "~ $ " + osc("B")
+	normal := terminalInfo{ScreenMode: screenNormal}
+
+	tests := []struct {
+		name           string
+		command        string
+		terminal       terminalInfo
+		content        string
+		wantAtPrompt   bool
+		wantConfidence float64
+	}{
+		{name: "dead pane", command: "bash", terminal: terminalInfo{ScreenMode: screenNormal, PaneDead: true}, content: "$ exit\n", wantConfidence: 1},
+		{name: "vim", command: "vim", terminal: terminalInfo{ScreenMode: screenAlternate}, content: "~\n~\n", wantConfidence: 0.95},
+		{name: "sudo password", command: "sudo", terminal: normal, content: "$ sudo make install\n[sudo] password for me: \n", wantConfidence: 0.9},
+		{name: "python REPL", command: "python3", terminal: normal, content: ">>> \n", wantConfidence: 0.9},
+		{name: "marked command running", command: "zsh", terminal: normal, content: prompt + "make" + osc("C") + "\nbuilding\n", wantConfidence: 0.9},
+		{name: "marked prompt with typed text", command: "zsh", terminal: normal, content: prompt + "git st\n", wantAtPrompt: true, wantConfidence: 0.85},
+		{name: "marked empty prompt", command: "zsh", terminal: normal, content: prompt + "\n\n", wantAtPrompt: true, wantConfidence: 0.95},
+		{name: "regex prompt", command: "-bash", terminal: normal, content: "$ ls\nfile\nuser@host:~$ \n", wantAtPrompt: true, wantConfidence: 0.8},
+		{name: "remote regex prompt", command: "ssh", terminal: normal, content: "deploy@web1:~$ \n", wantAtPrompt: true, wantConfidence: 0.6},
+		{name: "remote without a prompt", command: "ssh", terminal: normal, content: "Last login: today\n", wantConfidence: 0.5},
+		{name: "shell without a prompt", command: "bash", terminal: normal, content: "$ make\nbuilding\n", wantConfidence: 0.7},
+	}
+
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := srv.judgePrompt(tt.command, tt.terminal, tt.content)
+			if got.AtPrompt != tt.wantAtPrompt || got.Confidence != tt.wantConfidence {
+				t.Errorf("judgePrompt() = %v at %.2f (%s), want %v at %.2f", got.AtPrompt, got.Confidence, got.Reason, tt.wantAtPrompt, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_AtPrompt(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			if args[len(args)-1] == "#{pane_current_command}" {
+				return "nvim\n", "", nil
+			}
+			return "80,24,/src,0,0,,1\n", "", nil
+		case "capture-pane":
+			return "~\n~\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "at_prompt", map[string]interface{}{})
+	if text := toolText(t, response); !strings.HasPrefix(text, "not at a prompt (confidence 0.95): nvim has the alternate screen") {
+		t.Errorf("at_prompt text = %q", text)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "at_prompt").OutputSchema, result.StructuredContent)
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// automaticRenameState is the structured content of get_automatic_rename
+// and set_automatic_rename
+type automaticRenameState struct {
+	AutomaticRename bool `json:"automatic_rename"`
+}
+
+// text describes the state for the text content of a result
+func (a automaticRenameState) text() string {
+	if a.AutomaticRename {
+		return "automatic-rename on: tmux names the window after its running command"
+	}
+	return "automatic-rename off: the window keeps its name"
+}
+
+// getAutomaticRename handles the get_automatic_rename tool
+func (s *Server) getAutomaticRename(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.tmuxManager.AutomaticRename(target)
+	if err != nil {
+		return toolError(err)
+	}
+	state := automaticRenameState{AutomaticRename: enabled}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: state.text()}},
+		StructuredContent: state,
+	}, nil
+}
+
+// setAutomaticRename handles the set_automatic_rename tool: it turns a
+// window's automatic-rename option on or off and reports the state tmux
+// ends up in
+func (s *Server) setAutomaticRename(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("set_automatic_rename"); result != nil {
+		return result, nil
+	}
+
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	enabled, ok := arguments["enabled"].(bool)
+	if !ok {
+		return nil, invalidParams("enabled is required",
+			paramError{Field: "enabled", Expected: "boolean"})
+	}
+	if err := s.tmuxManager.SetAutomaticRename(target, enabled); err != nil {
+		return toolError(err)
+	}
+	return s.getAutomaticRename(arguments)
+}
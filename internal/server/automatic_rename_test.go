@@ -0,0 +1,72 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_AutomaticRename(t *testing.T) {
+	tests := []struct {
+		name          string
+		writesEnabled bool
+		arguments     map[string]interface{}
+		wantSet       []string
+		wantState     bool
+		wantIsError   bool
+		wantCode      int
+	}{
+		{name: "read-only rejects", arguments: map[string]interface{}{"enabled": true}, wantIsError: true},
+		{name: "turns on", writesEnabled: true, arguments: map[string]interface{}{"enabled": true},
+			wantSet: []string{"set-option", "-w", "-t", "fake-session", "automatic-rename", "on"}, wantState: true},
+		{name: "turns off in a window", writesEnabled: true, arguments: map[string]interface{}{"enabled": false, "pane": "%4"},
+			wantSet: []string{"set-option", "-w", "-t", "%4", "automatic-rename", "off"}},
+		{name: "missing enabled", writesEnabled: true, arguments: map[string]interface{}{}, wantCode: mcp.CodeInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := "off"
+			var set []string
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "set-option":
+					set = args
+					state = args[len(args)-1]
+				case "show-options":
+					return state + "\n", "", nil
+				case "list-panes":
+					return fakePaneListing, "", nil
+				}
+				return "", "", nil
+			})
+			srv.writesEnabled = tt.writesEnabled
+
+			response := callFakeTool(srv, "set_automatic_rename", tt.arguments)
+			if tt.wantCode != 0 {
+				if response.Error == nil || response.Error.Code != tt.wantCode {
+					t.Fatalf("response.Error = %v, want code %d", response.Error, tt.wantCode)
+				}
+				return
+			}
+			if response.Error != nil {
+				t.Fatalf("response.Error = %v, want nil", response.Error)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			if result.IsError != tt.wantIsError {
+				t.Fatalf("result.IsError = %v, want %v (%v)", result.IsError, tt.wantIsError, result.Content)
+			}
+			if !reflect.DeepEqual(set, tt.wantSet) {
+				t.Errorf("set-option args = %v, want %v", set, tt.wantSet)
+			}
+			if tt.wantIsError {
+				return
+			}
+			validateStructuredContent(t, findTool(t, srv, "set_automatic_rename").OutputSchema, result.StructuredContent)
+			if got := result.StructuredContent.(automaticRenameState).AutomaticRename; got != tt.wantState {
+				t.Errorf("automatic_rename = %v, want %v", got, tt.wantState)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/ansi"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+const (
+	// binaryMinRunes is the shortest line checked for binary content;
+	// shorter lines are too small to judge
+	binaryMinRunes = 16
+
+	// binaryRatio is the share of non-text characters above which a line
+	// counts as binary output
+	binaryRatio = 0.3
+
+	// binaryDumpBytes is how many bytes of suppressed output are shown
+	// as hex
+	binaryDumpBytes = 16
+)
+
+// nonText reports whether r is something text output doesn't contain:
+// invalid UTF-8, the replacement character, or a control or otherwise
+// unprintable character other than tab. Private-use characters are text,
+// since prompts draw icons with them.
+func nonText(r rune) bool {
+	switch {
+	case r == utf8.RuneError:
+		return true
+	case r == '\t', unicode.Is(unicode.Co, r):
+		return false
+	}
+	return unicode.IsControl(r) || !unicode.IsPrint(r)
+}
+
+// binaryLine reports whether a line looks like binary data printed to the
+// terminal, e.g. after cat-ing an executable. Escape sequences are
+// ignored, so colored text isn't mistaken for binary.
+func binaryLine(line string) bool {
+	text := ansi.Strip(line)
+	total, bad := 0, 0
+	for _, r := range text {
+		total++
+		if nonText(r) {
+			bad++
+		}
+	}
+	return total >= binaryMinRunes && float64(bad) > binaryRatio*float64(total)
+}
+
+// suppressBinary replaces each run of lines that look like binary output
+// with a one-line note giving its size and its first bytes in hex, so
+// that garbage doesn't waste tokens or upset the client. The rest of the
+// content is kept.
+func suppressBinary(content string) string {
+	lines := strings.Split(content, "\n")
+	suppressed := false
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); {
+		if !binaryLine(lines[i]) {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(lines) && binaryLine(lines[end]) {
+			end++
+		}
+		run := strings.Join(lines[i:end], "\n")
+		out = append(out, binaryNote(run))
+		suppressed = true
+		i = end
+	}
+	if !suppressed {
+		return content
+	}
+	return strings.Join(out, "\n")
+}
+
+// binaryNote describes a suppressed run of binary output
+func binaryNote(run string) string {
+	dump := run
+	if len(dump) > binaryDumpBytes {
+		dump = dump[:binaryDumpBytes]
+	}
+	return fmt.Sprintf("[binary or non-text output detected, %d bytes suppressed; starts % x]", len(run), dump)
+}
+
+// rawProperty is the raw argument of the tools that return pane content
+var rawProperty = mcp.Property{
+	Type:        "boolean",
+	Description: "Return runs of lines that look like binary data as they are, instead of replacing them with a note and a short hex dump (default: false)",
+}
+
+// formatCapture prepares captured pane content for a tool result: runs of
+// lines that look like binary output are suppressed unless the raw
+// argument is set, and lines are cut to --max-line-width
+func (s *Server) formatCapture(content string, arguments map[string]interface{}) string {
+	if raw, _ := arguments["raw"].(bool); !raw {
+		content = suppressBinary(content)
+	}
+	return truncateLines(content, s.maxLineWidth)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+// elfBlob is the start of an executable as a terminal shows it after
+// cat: the header and a run of control and invalid bytes
+const elfBlob = "\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x03\x00>\x00\x01\x00\x00\x00\xb0\x9f\x00\x00\n" +
+	"\x00\x00\x00\x00@\x00\x00\x00\x00\x00\x00\x00\xe8\xd7\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00@\x008\x00"
+
+func TestSuppressBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "text kept",
+			content: "$ ls -l\ntotal 8\n-rw-r--r-- 1 me me 120 café.txt\n",
+			want:    "$ ls -l\ntotal 8\n-rw-r--r-- 1 me me 120 café.txt\n",
+		},
+		{
+			name:    "colored and icon prompts kept",
+			content: "\x1b[1;32m ~/src/app  main\x1b[0m $ make\n",
+			want:    "\x1b[1;32m ~/src/app  main\x1b[0m $ make\n",
+		},
+		{
+			name:    "binary run replaced",
+			content: "$ cat /bin/ls\n" + elfBlob + "\n$ \n",
+			want: "$ cat /bin/ls\n" +
+				"[binary or non-text output detected, 57 bytes suppressed; starts 7f 45 4c 46 02 01 01 00 00 00 00 00 00 00 00 00]\n" +
+				"$ \n",
+		},
+		{
+			name:    "short control line kept",
+			content: "ok\x07\n",
+			want:    "ok\x07\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suppressBinary(tt.content); got != tt.want {
+				t.Errorf("suppressBinary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_ReadTerminal_Binary(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "capture-pane":
+			return "$ cat /bin/ls\n" + elfBlob + "\n$ \n", "", nil
+		case "list-panes":
+			return fakePaneListing, "", nil
+		case "display-message":
+			return "1000,24\n", "", nil
+		}
+		return "", "", nil
+	})
+	WithWritesEnabled(true)(srv)
+
+	// Every tool returning pane content suppresses binary the same way
+	for _, call := range []struct {
+		tool      string
+		arguments map[string]interface{}
+	}{
+		{"read_terminal", nil},
+		{"read_scrollback", nil},
+		{"read_range", map[string]interface{}{"start": -10, "end": 23}},
+		{"read_scrollback_page", map[string]interface{}{"page": 1}},
+		{"read_with_context", nil},
+		{"read_window", nil},
+		{"send_and_read", map[string]interface{}{"keys": []interface{}{"Enter"}, "wait_ms": 1}},
+	} {
+		arguments := map[string]interface{}{}
+		for name, value := range call.arguments {
+			arguments[name] = value
+		}
+		text := toolText(t, callFakeTool(srv, call.tool, arguments))
+		if strings.Contains(text, "ELF") || !strings.Contains(text, "[binary or non-text output detected, 57 bytes suppressed") {
+			t.Errorf("%s text = %q, want the binary run suppressed", call.tool, text)
+		}
+
+		arguments["raw"] = true
+		text = toolText(t, callFakeTool(srv, call.tool, arguments))
+		if !strings.Contains(text, elfBlob) {
+			t.Errorf("%s raw text = %q, want the capture unchanged", call.tool, text)
+		}
+	}
+}
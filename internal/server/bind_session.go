@@ -0,0 +1,98 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// boundSession is the structured content of bind_session
+type boundSession struct {
+	Session  string           `json:"session"`
+	Previous string           `json:"previous"`
+	Windows  []windowOverview `json:"windows"`
+}
+
+// bindSession handles the bind_session tool: it points the server at
+// another existing session, so tools called without a target act on it
+// from then on. Only the session the server started with and those
+// matching --allow-session may be bound. The session may be given by a
+// unique prefix or substring of its name, as ResolveSession matches it.
+func (s *Server) bindSession(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if len(s.bindableSessions) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: "Error: bind_session is disabled: start the server with --allow-session to choose which sessions may be bound"}},
+			IsError: true,
+		}, nil
+	}
+
+	query, _ := arguments["session"].(string)
+	if query == "" {
+		return nil, invalidParams("session is required",
+			paramError{Field: "session", Expected: "name of an existing tmux session"})
+	}
+	name, err := s.tmuxManager.ResolveSession(query, s.sessionBindable)
+	var ambiguous *tmux.AmbiguousSessionError
+	switch {
+	case errors.As(err, &ambiguous):
+		return nil, invalidParams(err.Error(),
+			paramError{Field: "session", Expected: "one of " + strings.Join(ambiguous.Candidates, ", ")})
+	case errors.Is(err, tmux.ErrSessionNotFound):
+		// A bindable name that doesn't exist is reported as missing
+		// below; anything else is refused as not allowed
+		name = query
+	case err != nil:
+		return toolError(err)
+	}
+	if !s.sessionBindable(name) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: session %q is not allowed: bindable sessions are %q and those matching --allow-session (%s)",
+				name, s.homeSession, strings.Join(s.bindableSessions, ", "))}},
+			IsError: true,
+		}, nil
+	}
+
+	previous := s.tmuxManager.SessionName()
+	if err := s.tmuxManager.SetSession(name); err != nil {
+		if errors.Is(err, tmux.ErrSessionNotFound) {
+			return nil, invalidParams(err.Error(),
+				paramError{Field: "session", Expected: "a session listed by overview"})
+		}
+		return toolError(err)
+	}
+
+	result := boundSession{Session: name, Previous: previous, Windows: []windowOverview{}}
+	lines := []string{fmt.Sprintf("bound to session %q (was %q)", name, previous)}
+	sessions, err := s.tmuxManager.Overview()
+	if err != nil {
+		return toolError(err)
+	}
+	for _, session := range sessions {
+		if session.Name == name {
+			entry := newSessionOverview(session, true)
+			result.Windows = entry.Windows
+			lines = append(lines, entry.text()[1:]...)
+		}
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: strings.Join(lines, "\n")}},
+		StructuredContent: result,
+	}, nil
+}
+
+// sessionBindable reports whether bind_session may switch to name
+func (s *Server) sessionBindable(name string) bool {
+	if name == s.homeSession {
+		return true
+	}
+	for _, pattern := range s.bindableSessions {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
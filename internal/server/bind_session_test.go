@@ -0,0 +1,104 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// bindRunner answers the tmux invocations bind_session makes for a server
+// with fake-session, build-1 and other sessions
+func bindRunner(args ...string) (string, string, error) {
+	switch args[0] {
+	case "list-sessions":
+		return "fake-session\nbuild-1\nother\n", "", nil
+	case "list-windows":
+		return "fake-session\t0\t0\tshell\t1\t%0\tbash\n" +
+			"build-1\t1\t0\tmake\t0\t%4\tmake\n" +
+			"build-1\t1\t1\tlogs\t1\t%5\ttail\n", "", nil
+	}
+	return "", "", nil
+}
+
+func TestServer_callTool_BindSession(t *testing.T) {
+	srv := newFakeServer(bindRunner)
+	WithBindableSessions([]string{"build-*"})(srv)
+
+	response := callFakeTool(srv, "bind_session", map[string]interface{}{"session": "build-1"})
+	want := "bound to session \"build-1\" (was \"fake-session\")\n" +
+		"  window 0 \"make\": %4 make\n" +
+		"  window 1 \"logs\": %5 tail (active)"
+	if text := toolText(t, response); text != want {
+		t.Errorf("bind_session text = %q, want %q", text, want)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "bind_session").OutputSchema, result.StructuredContent)
+	got := result.StructuredContent.(boundSession)
+	if got.Session != "build-1" || got.Previous != "fake-session" || len(got.Windows) != 2 {
+		t.Errorf("bind_session = %+v", got)
+	}
+	if srv.tmuxManager.SessionName() != "build-1" {
+		t.Errorf("SessionName() = %q, want build-1", srv.tmuxManager.SessionName())
+	}
+
+	// The starting session can always be bound again
+	response = callFakeTool(srv, "bind_session", map[string]interface{}{"session": "fake-session"})
+	toolText(t, response)
+	if srv.tmuxManager.SessionName() != "fake-session" {
+		t.Errorf("SessionName() = %q, want fake-session", srv.tmuxManager.SessionName())
+	}
+}
+
+func TestServer_callTool_BindSession_Refused(t *testing.T) {
+	tests := []struct {
+		name      string
+		patterns  []string
+		arguments map[string]interface{}
+		wantCode  int
+	}{
+		{name: "disabled", arguments: map[string]interface{}{"session": "build-1"}},
+		{name: "not allowed", patterns: []string{"build-*"}, arguments: map[string]interface{}{"session": "other"}},
+		{name: "missing session", patterns: []string{"*"}, arguments: map[string]interface{}{}, wantCode: mcp.CodeInvalidParams},
+		{name: "no such session", patterns: []string{"build-*"}, arguments: map[string]interface{}{"session": "build-2"}, wantCode: mcp.CodeInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeServer(bindRunner)
+			WithBindableSessions(tt.patterns)(srv)
+
+			response := callFakeTool(srv, "bind_session", tt.arguments)
+			if tt.wantCode != 0 {
+				if response.Error == nil || response.Error.Code != tt.wantCode {
+					t.Errorf("bind_session error = %+v, want code %d", response.Error, tt.wantCode)
+				}
+			} else if result, ok := response.Result.(*mcp.CallToolResult); !ok || !result.IsError {
+				t.Errorf("bind_session = %+v, want an error result", response.Result)
+			}
+			if srv.tmuxManager.SessionName() != "fake-session" {
+				t.Errorf("SessionName() = %q after a refused bind", srv.tmuxManager.SessionName())
+			}
+		})
+	}
+}
+
+func TestServer_callTool_BindSession_Fuzzy(t *testing.T) {
+	srv := newFakeServer(bindRunner)
+	WithBindableSessions([]string{"build-*", "oth*"})(srv)
+
+	response := callFakeTool(srv, "bind_session", map[string]interface{}{"session": "bui"})
+	if got := response.Result.(*mcp.CallToolResult).StructuredContent.(boundSession); got.Session != "build-1" {
+		t.Errorf("bind_session(bui) = %+v, want build-1", got)
+	}
+
+	response = callFakeTool(srv, "bind_session", map[string]interface{}{"session": "TH"})
+	if got := response.Result.(*mcp.CallToolResult).StructuredContent.(boundSession); got.Session != "other" {
+		t.Errorf("bind_session(TH) = %+v, want other", got)
+	}
+
+	// "e" is in fake-session and other, which may both be bound
+	response = callFakeTool(srv, "bind_session", map[string]interface{}{"session": "e"})
+	if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("bind_session(e) error = %+v, want -32602 for an ambiguous name", response.Error)
+	}
+}
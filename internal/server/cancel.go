@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// cancellations records the requests a client has cancelled with
+// notifications/cancelled. Messages are read ahead of the one being
+// handled, so a long-running tool can see that its own request was
+// cancelled while it still runs and stop early. Only the message right
+// after the running request is read ahead: a cancellation queued behind
+// another request arrives too late to interrupt it.
+type cancellations struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+// requestKey identifies a request ID; IDs may be numbers or strings, and
+// 1 and "1" are different requests
+func requestKey(id interface{}) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+// note records the request a notifications/cancelled message names
+func (c *cancellations) note(request *mcp.JSONRPCRequest) {
+	data, err := json.Marshal(request.Params)
+	if err != nil {
+		return
+	}
+	var params mcp.CancelledParams
+	if err := json.Unmarshal(data, &params); err != nil || params.RequestID == nil {
+		return
+	}
+	c.cancel(params.RequestID)
+}
+
+// cancel marks the request with this ID as cancelled
+func (c *cancellations) cancel(id interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ids == nil {
+		c.ids = map[string]bool{}
+	}
+	c.ids[requestKey(id)] = true
+}
+
+// cancelled reports whether the request with this ID has been cancelled
+func (c *cancellations) cancelled(id interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ids[requestKey(id)]
+}
+
+// forget drops the record for a request once it has been answered
+func (c *cancellations) forget(id interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.ids, requestKey(id))
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// sizedCapture is the structured content of capture_at_size
+type sizedCapture struct {
+	Content string `json:"content"`
+	// Resized is false when clients were attached and the pane was
+	// captured at its current size instead
+	Resized bool   `json:"resized"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Note    string `json:"note,omitempty"`
+}
+
+// captureAtSize handles the capture_at_size tool: it captures the pane
+// with its window temporarily resized, so output wrapped at the current
+// width comes back whole. Nobody sees the resize in a detached session;
+// with clients attached it would redraw their view, so the pane is
+// captured at its current size instead.
+func (s *Server) captureAtSize(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("capture_at_size"); result != nil {
+		return result, nil
+	}
+
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := arguments["width"]; !ok {
+		return nil, invalidParams("width is required",
+			paramError{Field: "width", Expected: fmt.Sprintf("number of columns from 1 to %d", tmux.MaxWindowSize)})
+	}
+	width, err := intArgument(arguments, "width", 0)
+	if err != nil {
+		return nil, err
+	}
+	height, err := intArgument(arguments, "height", 0)
+	if err != nil {
+		return nil, err
+	}
+	if width < 1 || width > tmux.MaxWindowSize {
+		return nil, invalidParams(fmt.Sprintf("width must be between 1 and %d", tmux.MaxWindowSize),
+			paramError{Field: "width", Expected: fmt.Sprintf("number of columns from 1 to %d", tmux.MaxWindowSize)})
+	}
+	if height < 0 || height > tmux.MaxWindowSize {
+		return nil, invalidParams(fmt.Sprintf("height must be between 1 and %d", tmux.MaxWindowSize),
+			paramError{Field: "height", Expected: fmt.Sprintf("number of rows from 1 to %d, or 0 to keep the current height", tmux.MaxWindowSize)})
+	}
+
+	attached, err := s.tmuxManager.AttachedClients()
+	if err != nil {
+		return toolError(err)
+	}
+	if attached > 0 {
+		content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{JoinLines: true, Target: target})
+		if err != nil {
+			return toolError(err)
+		}
+		curWidth, curHeight, err := s.tmuxManager.WindowSize(target)
+		if err != nil {
+			return toolError(err)
+		}
+		result := sizedCapture{
+			Content: content,
+			Width:   curWidth,
+			Height:  curHeight,
+			Note: fmt.Sprintf("%d client(s) attached; captured at the current %dx%d rather than resizing their view",
+				attached, curWidth, curHeight),
+		}
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{{Type: "text", Text: appendNote(strings.TrimRight(content, "\n"), result.Note)}},
+			StructuredContent: result,
+		}, nil
+	}
+
+	if height == 0 {
+		if _, height, err = s.tmuxManager.WindowSize(target); err != nil {
+			return toolError(err)
+		}
+	}
+	content, err := s.tmuxManager.CaptureAtSize(target, width, height)
+	if err != nil {
+		return toolError(err)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: content}},
+		StructuredContent: sizedCapture{Content: content, Resized: true, Width: width, Height: height},
+	}, nil
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_CaptureAtSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		clients     string
+		want        sizedCapture
+		wantResizes int
+	}{
+		{
+			name:        "detached",
+			want:        sizedCapture{Content: "wide row\n", Resized: true, Width: 200, Height: 24},
+			wantResizes: 2,
+		},
+		{
+			name:    "attached",
+			clients: "/dev/pts/1 80x24 1700000000\n",
+			want: sizedCapture{Content: "wide row\n", Width: 80, Height: 24,
+				Note: "1 client(s) attached; captured at the current 80x24 rather than resizing their view"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resizes := 0
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "list-clients":
+					return tt.clients, "", nil
+				case "display-message":
+					return "80x24\n", "", nil
+				case "capture-pane":
+					return "wide row\n", "", nil
+				case "resize-window":
+					resizes++
+				}
+				return "", "", nil
+			})
+			WithWritesEnabled(true)(srv)
+
+			response := callFakeTool(srv, "capture_at_size", map[string]interface{}{"width": float64(200)})
+			toolText(t, response)
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "capture_at_size").OutputSchema, result.StructuredContent)
+			if got := result.StructuredContent.(sizedCapture); got != tt.want {
+				t.Errorf("capture_at_size = %+v, want %+v", got, tt.want)
+			}
+			if resizes != tt.wantResizes {
+				t.Errorf("resize-window ran %d times, want %d", resizes, tt.wantResizes)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_CaptureAtSize_InvalidParams(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	WithWritesEnabled(true)(srv)
+
+	for _, arguments := range []map[string]interface{}{
+		{},
+		{"width": float64(0)},
+		{"width": float64(10001)},
+		{"width": float64(100), "height": float64(-1)},
+	} {
+		response := callFakeTool(srv, "capture_at_size", arguments)
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("capture_at_size(%v) error = %+v, want invalid params", arguments, response.Error)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// betweenResult is the structured content of capture_between
+type betweenResult struct {
+	StartFound bool `json:"start_found"`
+	EndFound   bool `json:"end_found"`
+	// Content is the lines after the last start marker line, up to the
+	// first end marker line after it, or to the end of the capture when
+	// there is none yet
+	Content string `json:"content"`
+	Lines   int    `json:"lines"`
+	Note    string `json:"note,omitempty"`
+}
+
+// captureBetween handles the capture_between tool: it returns the output
+// between two marker lines the agent printed itself, e.g. with echo before
+// and after a command, which isolates that output without relying on
+// prompt detection
+func (s *Server) captureBetween(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	var markers []string
+	for _, name := range []string{"start_marker", "end_marker"} {
+		marker, _ := arguments[name].(string)
+		if marker == "" || strings.ContainsAny(marker, "\r\n") {
+			return nil, invalidParams(name+" is required and must be a single line",
+				paramError{Field: name, Expected: "non-empty string without newlines"})
+		}
+		markers = append(markers, marker)
+	}
+	lines := tmux.AllLines
+	if linesVal, ok := arguments["lines"]; ok && linesVal != "all" {
+		if lines, err = coerceInt(linesVal); err != nil || (lines < 1 && lines != tmux.AllLines) {
+			return nil, invalidParams(fmt.Sprintf("invalid lines: %v", linesVal),
+				paramError{Field: "lines", Expected: `positive integer, or -1 or "all" for the entire history`})
+		}
+	}
+
+	// Join wrapped lines so a marker split across rows is still found
+	content, err := s.tmuxManager.GetScrollbackHistoryWithOptions(lines, tmux.CaptureOptions{Target: target, JoinLines: true})
+	if err != nil {
+		return toolError(err)
+	}
+
+	result := findBetween(splitLines(trimContent(content)), markers[0], markers[1])
+	result.Content = s.formatCapture(result.Content, arguments)
+
+	text := result.Content
+	if result.Note != "" {
+		text = appendNote(text, result.Note)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}, nil
+}
+
+// findBetween finds the last line containing start and returns the lines
+// after it up to the first following line containing end. The lines with
+// the markers are left out. Without an end marker, the lines run to the
+// end of captured, since the command may still be printing.
+func findBetween(captured []string, start, end string) betweenResult {
+	first := -1
+	for i := len(captured) - 1; i >= 0; i-- {
+		if strings.Contains(captured[i], start) {
+			first = i + 1
+			break
+		}
+	}
+	if first < 0 {
+		return betweenResult{Note: fmt.Sprintf("start marker %q not found", start)}
+	}
+
+	result := betweenResult{StartFound: true}
+	last := len(captured)
+	for i := first; i < len(captured); i++ {
+		if strings.Contains(captured[i], end) {
+			last = i
+			result.EndFound = true
+			break
+		}
+	}
+	if !result.EndFound {
+		result.Note = fmt.Sprintf("end marker %q not found after the start marker; the output may be incomplete", end)
+	}
+	result.Lines = last - first
+	result.Content = strings.Join(captured[first:last], "\n")
+	return result
+}
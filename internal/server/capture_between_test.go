@@ -0,0 +1,90 @@
+package server
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestFindBetween(t *testing.T) {
+	// The typed command line holds both markers, and an earlier run left
+	// its own pair above
+	captured := splitLines(`$ echo ==S==; ls; echo ==E==
+==S==
+old.txt
+==E==
+$ echo ==S==; make; echo ==E==
+==S==
+cc -o app main.c
+main.c:3: warning: unused variable
+==E==
+$ `)
+
+	tests := []struct {
+		name     string
+		captured []string
+		want     betweenResult
+	}{
+		{
+			name:     "last start and the following end",
+			captured: captured,
+			want:     betweenResult{StartFound: true, EndFound: true, Lines: 2, Content: "cc -o app main.c\nmain.c:3: warning: unused variable"},
+		},
+		{
+			name:     "still running",
+			captured: captured[:7],
+			want:     betweenResult{StartFound: true, Lines: 1, Content: "cc -o app main.c", Note: `end marker "==E==" not found after the start marker; the output may be incomplete`},
+		},
+		{
+			name:     "empty output",
+			captured: []string{"==S==", "==E=="},
+			want:     betweenResult{StartFound: true, EndFound: true},
+		},
+		{
+			name:     "no start",
+			captured: []string{"$ make", "done"},
+			want:     betweenResult{Note: `start marker "==S==" not found`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findBetween(tt.captured, "==S==", "==E=="); got != tt.want {
+				t.Errorf("findBetween() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_CaptureBetween(t *testing.T) {
+	var captureArgs []string
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			captureArgs = args
+			return "$ echo BEGIN; go test; echo END\nBEGIN\nok\nEND\n$ \n\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "capture_between", map[string]interface{}{"start_marker": "BEGIN", "end_marker": "END"})
+	if text := toolText(t, response); text != "ok" {
+		t.Errorf("capture_between text = %q, want %q", text, "ok")
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "capture_between").OutputSchema, result.StructuredContent)
+	if !slices.Contains(captureArgs, "-J") || !slices.Contains(captureArgs, "-") {
+		t.Errorf("capture-pane args = %q, want the whole history with wrapped lines joined", captureArgs)
+	}
+
+	for _, arguments := range []map[string]interface{}{
+		{"end_marker": "END"},
+		{"start_marker": "BEGIN", "end_marker": "a\nb"},
+		{"start_marker": "BEGIN", "end_marker": "END", "lines": float64(0)},
+	} {
+		response := callFakeTool(srv, "capture_between", arguments)
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("capture_between(%v) error = %v, want invalid params", arguments, response.Error)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// maxGridCells bounds the panes capture_grid will lay out. Every cell is a
+// separate JSON string, so a grid costs several times the bytes of the same
+// text; 20000 cells is a 250x80 pane.
+const maxGridCells = 20000
+
+// gridResult is the structured content of capture_grid
+type gridResult struct {
+	Width  int        `json:"width"`
+	Height int        `json:"height"`
+	Rows   [][]string `json:"rows"`
+}
+
+// captureGrid handles the capture_grid tool: the visible pane as a grid of
+// cells, for addressing text by row and column. It is also the baseline
+// for the next capture_grid_diff.
+func (s *Server) captureGrid(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	grid, _, refused, err := s.captureGridFor(target)
+	if err != nil {
+		return toolError(err)
+	}
+	if refused != nil {
+		return refused, nil
+	}
+
+	result := gridResult{Width: grid.Width, Height: grid.Height, Rows: grid.Rows}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: result.text()}},
+		StructuredContent: result,
+	}, nil
+}
+
+// text renders the grid with its row numbers down the left and a ruler of
+// column numbers along the top: tens on the first line, units on the second
+func (g gridResult) text() string {
+	margin := len(fmt.Sprint(max(g.Height-1, 0))) + 1
+	var tens, units strings.Builder
+	for x := 0; x < g.Width; x++ {
+		if x%10 == 0 {
+			tens.WriteString(fmt.Sprint(x / 10 % 10))
+		} else {
+			tens.WriteByte(' ')
+		}
+		units.WriteString(fmt.Sprint(x % 10))
+	}
+
+	lines := []string{
+		strings.Repeat(" ", margin) + strings.TrimRight(tens.String(), " "),
+		strings.Repeat(" ", margin) + units.String(),
+	}
+	for y, row := range g.Rows {
+		lines = append(lines, fmt.Sprintf("%*d %s", margin-1, y, strings.TrimRight(strings.Join(row, ""), " ")))
+	}
+	return appendNote(strings.Join(lines, "\n"), fmt.Sprintf("%dx%d", g.Width, g.Height))
+}
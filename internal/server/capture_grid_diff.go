@@ -0,0 +1,144 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// gridBaseline is the last grid captured by capture_grid or
+// capture_grid_diff, which capture_grid_diff compares the pane against
+type gridBaseline struct {
+	// session and the grid's PaneID identify the pane it came from. A
+	// capture of any other pane starts over, including when the focus or
+	// the active window moves so that the same target names another pane.
+	session string
+	grid    *tmux.Grid
+}
+
+// gridUpdate is a run of changed cells within one row in capture_grid_diff
+type gridUpdate struct {
+	Row  int    `json:"row"`
+	Col  int    `json:"col"`
+	Text string `json:"text"`
+}
+
+// gridDiffResult is the structured content of capture_grid_diff
+type gridDiffResult struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Full is set when there was no usable baseline, so the updates
+	// repaint every row
+	Full         bool         `json:"full"`
+	ChangedCells int          `json:"changed_cells"`
+	Updates      []gridUpdate `json:"updates"`
+}
+
+// captureGridFor captures the grid of target, refusing panes over
+// maxGridCells, and makes it the baseline for capture_grid_diff. The
+// previous baseline is returned when it was of the same pane.
+func (s *Server) captureGridFor(target tmux.Target) (grid, previous *tmux.Grid, refused *mcp.CallToolResult, err error) {
+	grid, err = s.tmuxManager.CaptureGrid(target)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if cells := grid.Width * grid.Height; cells > maxGridCells {
+		return nil, nil, &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf(
+				"Error: the pane is %dx%d, %d cells, over the grid limit of %d; use read_terminal or read_range instead",
+				grid.Width, grid.Height, cells, maxGridCells)}},
+			IsError: true,
+		}, nil
+	}
+
+	session := s.tmuxManager.SessionName()
+	if s.gridBaseline.grid != nil && s.gridBaseline.session == session && s.gridBaseline.grid.PaneID == grid.PaneID {
+		previous = s.gridBaseline.grid
+	}
+	s.gridBaseline = gridBaseline{session: session, grid: grid}
+	return grid, previous, nil, nil
+}
+
+// captureGridDiff handles the capture_grid_diff tool: the cells of the
+// visible pane that changed since the last grid capture, for watching a
+// full-screen program without reading the whole screen each time
+func (s *Server) captureGridDiff(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	grid, previous, refused, err := s.captureGridFor(target)
+	if err != nil {
+		return toolError(err)
+	}
+	if refused != nil {
+		return refused, nil
+	}
+
+	result := diffGrids(previous, grid)
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: result.text()}},
+		StructuredContent: result,
+	}, nil
+}
+
+// diffGrids lists the runs of cells in grid that differ from previous. With
+// no previous grid, or one of another size, every row is a single update.
+func diffGrids(previous, grid *tmux.Grid) gridDiffResult {
+	result := gridDiffResult{Width: grid.Width, Height: grid.Height, Updates: []gridUpdate{}}
+	if previous == nil || previous.Width != grid.Width || previous.Height != grid.Height {
+		result.Full = true
+		for y, row := range grid.Rows {
+			result.Updates = append(result.Updates, gridUpdate{Row: y, Col: 0, Text: strings.Join(row, "")})
+			result.ChangedCells += len(row)
+		}
+		return result
+	}
+
+	for y, row := range grid.Rows {
+		old := previous.Rows[y]
+		for x := 0; x < len(row); {
+			if row[x] == old[x] {
+				x++
+				continue
+			}
+			start := x
+			// Start a run on a wide character's first cell rather than
+			// the empty cell it covers
+			if start > 0 && row[start] == "" {
+				start--
+			}
+			for x < len(row) && row[x] != old[x] {
+				x++
+			}
+			// A wide character at the end of the run carries its
+			// empty second cell with it
+			if x < len(row) && row[x] == "" {
+				x++
+			}
+			result.Updates = append(result.Updates, gridUpdate{Row: y, Col: start, Text: strings.Join(row[start:x], "")})
+			result.ChangedCells += x - start
+		}
+	}
+	return result
+}
+
+// text lists the updates one per line as "row:col text", quoted so
+// trailing spaces that blank out old text stay visible
+func (d gridDiffResult) text() string {
+	if len(d.Updates) == 0 {
+		return appendNote("", fmt.Sprintf("%dx%d, no cells changed since the last grid capture", d.Width, d.Height))
+	}
+	lines := make([]string, 0, len(d.Updates))
+	for _, u := range d.Updates {
+		lines = append(lines, fmt.Sprintf("%d:%d %q", u.Row, u.Col, u.Text))
+	}
+	note := fmt.Sprintf("%dx%d, %d cells changed", d.Width, d.Height, d.ChangedCells)
+	if d.Full {
+		note = fmt.Sprintf("%dx%d, no earlier grid of this pane at this size: every row is listed", d.Width, d.Height)
+	}
+	return appendNote(strings.Join(lines, "\n"), note)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+func TestDiffGrids(t *testing.T) {
+	grid := func(rows ...[]string) *tmux.Grid {
+		return &tmux.Grid{Width: len(rows[0]), Height: len(rows), Rows: rows}
+	}
+
+	tests := []struct {
+		name     string
+		previous *tmux.Grid
+		grid     *tmux.Grid
+		want     []gridUpdate
+		wantFull bool
+	}{
+		{
+			name:     "no baseline",
+			grid:     grid([]string{"a", "b"}, []string{"c", " "}),
+			want:     []gridUpdate{{Row: 0, Col: 0, Text: "ab"}, {Row: 1, Col: 0, Text: "c "}},
+			wantFull: true,
+		},
+		{
+			name:     "resized",
+			previous: grid([]string{"a"}),
+			grid:     grid([]string{"a", "b"}),
+			want:     []gridUpdate{{Row: 0, Col: 0, Text: "ab"}},
+			wantFull: true,
+		},
+		{
+			name:     "unchanged",
+			previous: grid([]string{"a", "b"}),
+			grid:     grid([]string{"a", "b"}),
+			want:     []gridUpdate{},
+		},
+		{
+			name:     "runs",
+			previous: grid([]string{"1", "2", "3", "4", "5"}, []string{"x", "x", "x", "x", "x"}),
+			grid:     grid([]string{"9", "2", "3", "8", "8"}, []string{"x", "x", "x", "x", "x"}),
+			want:     []gridUpdate{{Row: 0, Col: 0, Text: "9"}, {Row: 0, Col: 3, Text: "88"}},
+		},
+		{
+			name:     "wide character",
+			previous: grid([]string{"a", "日", "", "b"}),
+			grid:     grid([]string{"a", "本", "", "b"}),
+			want:     []gridUpdate{{Row: 0, Col: 1, Text: "本"}},
+		},
+		{
+			name:     "wide character over narrow ones",
+			previous: grid([]string{"a", "b", "c", "d"}),
+			grid:     grid([]string{"a", "日", "", "d"}),
+			want:     []gridUpdate{{Row: 0, Col: 1, Text: "日"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffGrids(tt.previous, tt.grid)
+			if !reflect.DeepEqual(got.Updates, tt.want) || got.Full != tt.wantFull {
+				t.Errorf("diffGrids() = %+v (full %v), want %+v (full %v)", got.Updates, got.Full, tt.want, tt.wantFull)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_CaptureGridDiff(t *testing.T) {
+	screens := []string{
+		"10,2,%0\nCPU  10%\nMEM  40%\n",
+		"10,2,%0\nCPU  85%\nMEM  40%\n",
+		"10,2,%1\n$\n\n",
+	}
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "display-message" {
+			screen := screens[0]
+			screens = screens[1:]
+			return screen, "", nil
+		}
+		return "", "", nil
+	})
+
+	if result := callFakeTool(srv, "capture_grid", nil).Result.(*mcp.CallToolResult); result.IsError {
+		t.Fatalf("capture_grid = %+v", result)
+	}
+
+	response := callFakeTool(srv, "capture_grid_diff", nil)
+	if text, want := toolText(t, response), "0:5 \"85\"\n[10x2, 2 cells changed]"; text != want {
+		t.Errorf("capture_grid_diff text = %q, want %q", text, want)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "capture_grid_diff").OutputSchema, result.StructuredContent)
+
+	// Another pane, as when the focus moves, starts over
+	result = callFakeTool(srv, "capture_grid_diff", nil).Result.(*mcp.CallToolResult)
+	if got := result.StructuredContent.(gridDiffResult); !got.Full || len(got.Updates) != 2 {
+		t.Errorf("capture_grid_diff of another pane = %+v, want every row", got)
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_CaptureGrid(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "display-message" {
+			return "12,2,%0\nName: 日本\n[ OK ]\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "capture_grid", map[string]interface{}{})
+	wantText := "  0         1\n" +
+		"  012345678901\n" +
+		"0 Name: 日本\n" +
+		"1 [ OK ]\n" +
+		"[12x2]"
+	if text := toolText(t, response); text != wantText {
+		t.Errorf("capture_grid text =\n%s\nwant\n%s", text, wantText)
+	}
+
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "capture_grid").OutputSchema, result.StructuredContent)
+	got := result.StructuredContent.(gridResult)
+	want := gridResult{Width: 12, Height: 2, Rows: [][]string{
+		{"N", "a", "m", "e", ":", " ", "日", "", "本", "", " ", " "},
+		{"[", " ", "O", "K", " ", "]", " ", " ", " ", " ", " ", " "},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("capture_grid structuredContent = %q, want %q", got, want)
+	}
+}
+
+func TestServer_callTool_CaptureGrid_TooLarge(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "display-message" {
+			return "500,100,%0\n$\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	result := callFakeTool(srv, "capture_grid", map[string]interface{}{}).Result.(*mcp.CallToolResult)
+	if !result.IsError {
+		t.Errorf("capture_grid of a 500x100 pane: IsError = false, want true")
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// lineState is the structured content of clear_line
+type lineState struct {
+	// Prompt is the text before the cursor, which is the bare prompt once
+	// the line is clear
+	Prompt string `json:"prompt"`
+	// Leftover is any text still after the cursor
+	Leftover string `json:"leftover"`
+	// AtPrompt reports whether Prompt matches the prompt regex and nothing
+	// is left after the cursor, so a command can be typed safely
+	AtPrompt bool `json:"at_prompt"`
+}
+
+// clearLineKeys empty the shell's input line: C-e moves to its end so that
+// C-u, which only discards back from the cursor in readline, takes it all
+var clearLineKeys = []string{"C-e", "C-u"}
+
+// clearLine handles the clear_line tool: it discards whatever is typed at
+// the shell prompt, or interrupts the foreground program for a fresh
+// prompt, and reports the line the pane ends up on
+func (s *Server) clearLine(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("clear_line"); result != nil {
+		return result, nil
+	}
+
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	interrupt, _ := arguments["interrupt"].(bool)
+	pollInterval, err := s.pollIntervalArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := clearLineKeys
+	if interrupt {
+		keys = []string{"C-c"}
+	}
+	if err := s.tmuxManager.SendKeysTo(target, keys...); err != nil {
+		return toolError(err)
+	}
+
+	// Give the shell a moment to redraw before looking at the result
+	time.Sleep(pollInterval)
+	content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{Target: target})
+	if err != nil {
+		return toolError(err)
+	}
+	cursor, err := s.tmuxManager.GetCursor(target)
+	if err != nil {
+		return toolError(err)
+	}
+
+	state := lineState{
+		Prompt:   promptLine(content, cursor),
+		Leftover: strings.TrimSpace(textAfterCursor(content, cursor)),
+	}
+	state.AtPrompt = state.Leftover == "" &&
+		s.promptPattern().MatchString(strings.TrimRight(state.Prompt, " "))
+
+	text := fmt.Sprintf("prompt: %q", state.Prompt)
+	if state.Leftover != "" {
+		text = appendNote(text, fmt.Sprintf("text remains after the cursor: %q", state.Leftover))
+	} else if !state.AtPrompt {
+		text = appendNote(text, "the line does not match the prompt regex; the pane may not be at a shell prompt")
+	}
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: state,
+	}, nil
+}
+
+// textAfterCursor returns the text from the cursor to the end of its row,
+// or "" when the cursor row isn't in the capture
+func textAfterCursor(content string, cursor tmux.Cursor) string {
+	lines := splitLines(content)
+	row, ok := cursorRow(lines, cursor.Y, cursor.PaneHeight)
+	if !ok || cursor.X < 0 {
+		return ""
+	}
+	runes := []rune(lines[row])
+	if cursor.X >= len(runes) {
+		return ""
+	}
+	return string(runes[cursor.X:])
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_ClearLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		capture   string
+		cursor    string
+		wantKeys  []string
+		want      lineState
+	}{
+		{
+			name:      "cleared",
+			arguments: map[string]interface{}{},
+			capture:   "$ ls\nfile\nuser@host:~$ \n",
+			cursor:    "13,2,3\n",
+			wantKeys:  []string{"send-keys", "-t", "fake-session", "C-e", "C-u"},
+			want:      lineState{Prompt: "user@host:~$ ", AtPrompt: true},
+		},
+		{
+			name:      "interrupt",
+			arguments: map[string]interface{}{"interrupt": true},
+			capture:   "$ sleep 100\n^C\n$ \n",
+			cursor:    "2,2,3\n",
+			wantKeys:  []string{"send-keys", "-t", "fake-session", "C-c"},
+			want:      lineState{Prompt: "$ ", AtPrompt: true},
+		},
+		{
+			name:      "text left after cursor",
+			arguments: map[string]interface{}{},
+			capture:   "$ git status\n",
+			cursor:    "2,0,1\n",
+			wantKeys:  []string{"send-keys", "-t", "fake-session", "C-e", "C-u"},
+			want:      lineState{Prompt: "$ ", Leftover: "git status"},
+		},
+		{
+			name:      "not at a prompt",
+			arguments: map[string]interface{}{},
+			capture:   "Password: \n",
+			cursor:    "10,0,1\n",
+			wantKeys:  []string{"send-keys", "-t", "fake-session", "C-e", "C-u"},
+			want:      lineState{Prompt: "Password: "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "send-keys":
+					sent = args
+				case "capture-pane":
+					return tt.capture, "", nil
+				case "display-message":
+					return tt.cursor, "", nil
+				}
+				return "", "", nil
+			})
+			srv.writesEnabled = true
+
+			response := callFakeTool(srv, "clear_line", tt.arguments)
+			toolText(t, response)
+			if !reflect.DeepEqual(sent, tt.wantKeys) {
+				t.Errorf("send-keys args = %v, want %v", sent, tt.wantKeys)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "clear_line").OutputSchema, result.StructuredContent)
+			if result.StructuredContent != tt.want {
+				t.Errorf("structuredContent = %+v, want %+v", result.StructuredContent, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_ClearLine_ReadOnly(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "send-keys" {
+			t.Errorf("clear_line sent keys with writes disabled: %v", args)
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "clear_line", map[string]interface{}{})
+	if result := response.Result.(*mcp.CallToolResult); !result.IsError {
+		t.Error("clear_line with writes disabled: IsError = false, want true")
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// ClientSession is the state of the client on the server's connection:
+// what it negotiated in initialize, whether it has confirmed
+// initialization, and what the request being handled asked for. The server
+// serves one connection, its reader and writer, so it holds one.
+type ClientSession struct {
+	// ProtocolVersion is the version the server answered initialize
+	// with; RequestedVersion is the one the client asked for
+	ProtocolVersion  string
+	RequestedVersion string
+	// Capabilities are the capabilities the client advertised
+	Capabilities map[string]interface{}
+	ClientInfo   mcp.ClientInfo
+
+	// Initialized is set once the client sends notifications/initialized;
+	// until then only initialize and ping are answered
+	Initialized bool
+
+	// progressToken is the token the tool call being handled asked for
+	// progress with, nil when it asked for none
+	progressToken interface{}
+
+	// subscriptions holds the resource URIs the client subscribed to with
+	// resources/subscribe
+	subscriptions map[string]bool
+}
+
+// negotiate records the client's initialize request and the protocol
+// version the server answers with
+func (c *ClientSession) negotiate(request *mcp.JSONRPCRequest) error {
+	var params mcp.InitializeRequest
+	if request.Params != nil {
+		data, err := json.Marshal(request.Params)
+		if err != nil {
+			return fmt.Errorf("failed to marshal params: %w", err)
+		}
+		if err := json.Unmarshal(data, &params); err != nil {
+			return invalidParams(fmt.Sprintf("invalid initialize request: %v", err))
+		}
+	}
+	// Only one version is spoken; a client asking for another decides
+	// from the answer whether it can go on
+	c.ProtocolVersion = ProtocolVersion
+	c.RequestedVersion = params.ProtocolVersion
+	c.Capabilities = params.Capabilities
+	c.ClientInfo = params.ClientInfo
+	return nil
+}
+
+// accepts reports whether the client asked for a notification. Progress
+// is only sent against the token of the tool call being handled, so a
+// client that sent no token gets none; other notifications are always
+// sent.
+func (c *ClientSession) accepts(method string, params interface{}) bool {
+	if method != "notifications/progress" {
+		return true
+	}
+	progress, ok := params.(mcp.ProgressParams)
+	return ok && c.progressToken != nil && requestKey(progress.ProgressToken) == requestKey(c.progressToken)
+}
+
+// subscribe records whether the client wants notifications/resources/updated
+// for uri
+func (c *ClientSession) subscribe(uri string, on bool) {
+	if c.subscriptions == nil {
+		c.subscriptions = map[string]bool{}
+	}
+	if on {
+		c.subscriptions[uri] = true
+	} else {
+		delete(c.subscriptions, uri)
+	}
+}
+
+// subscribed reports whether the client subscribed to uri
+func (c *ClientSession) subscribed(uri string) bool {
+	return c.subscriptions[uri]
+}
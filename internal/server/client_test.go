@@ -0,0 +1,74 @@
+package server
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_handleInitialize_ClientSession(t *testing.T) {
+	srv := NewServer("test-session", &bytes.Buffer{}, &bytes.Buffer{})
+
+	response := srv.handleRequest(&mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "initialize",
+		Params: map[string]interface{}{
+			"protocolVersion": "2099-01-01",
+			"capabilities":    map[string]interface{}{"roots": map[string]interface{}{}},
+			"clientInfo":      map[string]interface{}{"name": "test-client", "version": "1.0.0"},
+		},
+	})
+	if response.Error != nil {
+		t.Fatalf("initialize error = %+v", response.Error)
+	}
+	if got := response.Result.(*mcp.InitializeResult).ProtocolVersion; got != ProtocolVersion {
+		t.Errorf("answered protocol version %q, want %q", got, ProtocolVersion)
+	}
+	client := srv.client
+	if client.ProtocolVersion != ProtocolVersion || client.RequestedVersion != "2099-01-01" {
+		t.Errorf("client versions = %q (requested %q)", client.ProtocolVersion, client.RequestedVersion)
+	}
+	if client.ClientInfo.Name != "test-client" || client.Capabilities["roots"] == nil {
+		t.Errorf("client = %+v, want its info and capabilities recorded", client)
+	}
+
+	response = srv.handleRequest(&mcp.JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "initialize", Params: map[string]interface{}{"protocolVersion": 7}})
+	if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("initialize with a numeric version = %+v, want -32602", response.Error)
+	}
+}
+
+func TestServer_notify_Progress(t *testing.T) {
+	var out bytes.Buffer
+	srv := NewServer("test-session", &bytes.Buffer{}, &out)
+	progress := func(token interface{}) mcp.ProgressParams {
+		return mcp.ProgressParams{ProgressToken: token, Progress: 1}
+	}
+
+	// No tool call asked for progress
+	if err := srv.notify("notifications/progress", progress("t1")); err != nil {
+		t.Fatalf("notify() error = %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("progress sent without a token: %s", out.String())
+	}
+
+	srv.client.progressToken = "t1"
+	_ = srv.notify("notifications/progress", progress("t2"))
+	if out.Len() != 0 {
+		t.Errorf("progress sent against another request's token: %s", out.String())
+	}
+	_ = srv.notify("notifications/progress", progress("t1"))
+	if !strings.Contains(out.String(), `"progressToken":"t1"`) {
+		t.Errorf("progress for the request's token = %q, want it sent", out.String())
+	}
+
+	out.Reset()
+	_ = srv.notify("notifications/message", map[string]interface{}{"level": "info"})
+	if out.Len() == 0 {
+		t.Error("other notifications were dropped")
+	}
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// commandOutputPrefix starts the URI of every output stored by
+// run_command_to_resource; the output's ID follows it
+const commandOutputPrefix = "terminal://command-output/"
+
+const (
+	// DefaultOutputTTL is how long run_command_to_resource keeps an output
+	// readable
+	DefaultOutputTTL = 15 * time.Minute
+
+	// maxStoredOutputs bounds the outputs held at once; storing another
+	// drops the oldest even if it hasn't expired
+	maxStoredOutputs = 32
+
+	// defaultOutputTailLines is how many of the output's last lines
+	// run_command_to_resource returns inline
+	defaultOutputTailLines = 5
+)
+
+// storedOutput is a command's output held for resources/read
+type storedOutput struct {
+	id      string
+	command string
+	output  string
+	expires time.Time
+}
+
+// uri returns the resource URI the output is read from
+func (o *storedOutput) uri() string {
+	return commandOutputPrefix + o.id
+}
+
+// outputStore keeps the outputs of run_command_to_resource in memory until
+// they expire, oldest first. IDs are never reused within a server's life,
+// so a URI never comes to name a different output. Expired outputs are
+// dropped whenever the store is used.
+type outputStore struct {
+	next    int
+	outputs []*storedOutput
+}
+
+// save stores a command's output until now plus ttl and returns it
+func (st *outputStore) save(command, output string, now time.Time, ttl time.Duration) *storedOutput {
+	st.prune(now)
+	st.next++
+	stored := &storedOutput{
+		id:      fmt.Sprintf("c%d", st.next),
+		command: command,
+		output:  output,
+		expires: now.Add(ttl),
+	}
+	st.outputs = append(st.outputs, stored)
+	if len(st.outputs) > maxStoredOutputs {
+		st.outputs = st.outputs[1:]
+	}
+	return stored
+}
+
+// get returns the output stored under id, if it hasn't expired
+func (st *outputStore) get(id string, now time.Time) (*storedOutput, bool) {
+	st.prune(now)
+	for _, stored := range st.outputs {
+		if stored.id == id {
+			return stored, true
+		}
+	}
+	return nil, false
+}
+
+// live returns the outputs that haven't expired, oldest first
+func (st *outputStore) live(now time.Time) []*storedOutput {
+	st.prune(now)
+	return st.outputs
+}
+
+// prune drops the outputs that have expired by now
+func (st *outputStore) prune(now time.Time) {
+	kept := st.outputs[:0]
+	for _, stored := range st.outputs {
+		if now.Before(stored.expires) {
+			kept = append(kept, stored)
+		}
+	}
+	st.outputs = kept
+}
+
+// outputResourceResult is the structured content of run_command_to_resource
+type outputResourceResult struct {
+	URI       string `json:"uri"`
+	Command   string `json:"command"`
+	Completed bool   `json:"completed"`
+	ExitCode  *int   `json:"exit_code"`
+	Bytes     int    `json:"bytes"`
+	Lines     int    `json:"lines"`
+	// Tail is the output's last lines, so a failure shows without
+	// fetching the resource
+	Tail      string `json:"tail"`
+	ExpiresAt string `json:"expires_at"`
+	Note      string `json:"note,omitempty"`
+}
+
+// runCommandToResource handles the run_command_to_resource tool: it runs a
+// command as run_command does, but stores the output on the server and
+// returns a link to it instead, so a long output doesn't fill the
+// conversation unless the client fetches it with resources/read
+func (s *Server) runCommandToResource(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("run_command_to_resource"); result != nil {
+		return result, nil
+	}
+	tailLines, err := intArgument(arguments, "tail_lines", defaultOutputTailLines)
+	if err != nil || tailLines < 0 {
+		return nil, invalidParams(fmt.Sprintf("invalid tail_lines: %v", arguments["tail_lines"]),
+			paramError{Field: "tail_lines", Expected: "non-negative integer"})
+	}
+
+	result, err := s.runCommand(arguments)
+	if err != nil || result.IsError {
+		return result, err
+	}
+	ran := result.StructuredContent.(commandResult)
+
+	// The resource is the whole output; only the inline tail is cut to
+	// --max-line-width
+	stored := s.outputs.save(ran.Command, ran.fullOutput, time.Now(), s.outputTTL)
+	lines := splitLines(trimContent(ran.fullOutput))
+	tail := lines
+	if len(tail) > tailLines {
+		tail = tail[len(tail)-tailLines:]
+	}
+	structured := outputResourceResult{
+		URI:       stored.uri(),
+		Command:   ran.Command,
+		Completed: ran.Completed,
+		ExitCode:  ran.ExitCode,
+		Bytes:     len(ran.fullOutput),
+		Lines:     len(lines),
+		Tail:      truncateLines(strings.Join(tail, "\n"), s.maxLineWidth),
+		ExpiresAt: stored.expires.UTC().Format(time.RFC3339),
+		Note:      ran.Note,
+	}
+
+	status := "finished"
+	if !ran.Completed {
+		status = "still running at the timeout; the output may be incomplete"
+	} else if ran.ExitCode != nil {
+		status = fmt.Sprintf("exited with %d", *ran.ExitCode)
+	}
+	text := fmt.Sprintf("%s %s: %d lines (%d bytes) stored at %s until %s; fetch them with resources/read",
+		ran.Command, status, structured.Lines, structured.Bytes, structured.URI, structured.ExpiresAt)
+	if structured.Tail != "" {
+		text += fmt.Sprintf("\nLast %d lines:\n%s", len(tail), structured.Tail)
+	}
+	if ran.Note != "" {
+		text = appendNote(text, ran.Note)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			{Type: "text", Text: text},
+			mcp.ResourceLink(structured.URI, "Output of "+ran.Command, "Expires "+structured.ExpiresAt, "text/plain"),
+		},
+		StructuredContent: structured,
+	}, nil
+}
+
+// readCommandOutput reads a terminal://command-output/<id> resource
+func (s *Server) readCommandOutput(uri string) (*mcp.ReadResourceResult, error) {
+	stored, ok := s.outputs.get(strings.TrimPrefix(uri, commandOutputPrefix), time.Now())
+	if !ok {
+		return nil, invalidParams(fmt.Sprintf("no command output at %s: it has expired or never existed", uri),
+			paramError{Field: "uri", Expected: "a URI returned by run_command_to_resource within the last " + s.outputTTL.String()})
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []mcp.ResourceContent{
+			{
+				URI:      uri,
+				MimeType: "text/plain",
+				Text:     stored.output,
+			},
+		},
+	}, nil
+}
+
+// commandOutputResources lists the stored outputs for resources/list
+func (s *Server) commandOutputResources() []mcp.Resource {
+	resources := []mcp.Resource{}
+	for _, stored := range s.outputs.live(time.Now()) {
+		resources = append(resources, mcp.Resource{
+			URI:         stored.uri(),
+			Name:        "Output of " + stored.command,
+			Description: "Stored by run_command_to_resource; expires " + stored.expires.UTC().Format(time.RFC3339),
+			MimeType:    "text/plain",
+		})
+	}
+	return resources
+}
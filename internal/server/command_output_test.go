@@ -0,0 +1,91 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestOutputStore(t *testing.T) {
+	var st outputStore
+	start := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	first := st.save("ls", "a\nb\n", start, time.Minute)
+	second := st.save("make", "ok\n", start.Add(30*time.Second), time.Minute)
+	if first.id == second.id {
+		t.Fatalf("both outputs stored as %s", first.id)
+	}
+	if got, ok := st.get(first.id, start.Add(59*time.Second)); !ok || got.output != "a\nb\n" {
+		t.Errorf("get(%s) before expiry = %v, %v", first.id, got, ok)
+	}
+	if _, ok := st.get(first.id, start.Add(time.Minute)); ok {
+		t.Errorf("get(%s) at expiry found the output, want it gone", first.id)
+	}
+	if live := st.live(start.Add(time.Minute)); len(live) != 1 || live[0] != second {
+		t.Errorf("live() = %v, want only %s", live, second.id)
+	}
+
+	for i := 0; i < maxStoredOutputs; i++ {
+		st.save("true", "", start.Add(time.Minute), time.Minute)
+	}
+	if _, ok := st.get(second.id, start.Add(time.Minute)); ok {
+		t.Errorf("get(%s) found the output after %d more were stored, want it dropped", second.id, maxStoredOutputs)
+	}
+}
+
+func TestServer_callTool_RunCommandToResource(t *testing.T) {
+	var windows int
+	var sentTo []string
+	srv := newFakeScratchServer(&windows, &sentTo)
+
+	response := callFakeTool(srv, "run_command_to_resource", map[string]interface{}{"command": "make"})
+	if result := response.Result.(*mcp.CallToolResult); !result.IsError {
+		t.Fatalf("run_command_to_resource in read-only mode = %+v, want an error", result)
+	}
+
+	WithWritesEnabled(true)(srv)
+	response = callFakeTool(srv, "run_command_to_resource", map[string]interface{}{"command": "make", "timeout_ms": float64(2000)})
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "run_command_to_resource").OutputSchema, result.StructuredContent)
+	got := result.StructuredContent.(outputResourceResult)
+	if got.URI != commandOutputPrefix+"c1" || !got.Completed || got.Lines != 1 || got.Tail != "ok" {
+		t.Errorf("structuredContent = %+v", got)
+	}
+	if len(result.Content) != 2 || result.Content[1].Type != "resource_link" || result.Content[1].URI != got.URI {
+		t.Errorf("content = %+v, want text and a resource link to %s", result.Content, got.URI)
+	}
+
+	read := readFakeResource(srv, got.URI)
+	if read.Error != nil {
+		t.Fatalf("resources/read error = %v", read.Error)
+	}
+	if text := read.Result.(*mcp.ReadResourceResult).Contents[0].Text; text != "ok" {
+		t.Errorf("resource text = %q, want %q", text, "ok")
+	}
+	if listed := srv.listResources().Resources; listed[len(listed)-1].URI != got.URI {
+		t.Errorf("resources/list = %+v, want it to end with %s", listed, got.URI)
+	}
+
+	if read := readFakeResource(srv, commandOutputPrefix+"c9"); read.Error == nil || read.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("resources/read of an unknown output error = %v, want invalid params", read.Error)
+	}
+}
+
+func TestServer_callTool_RunCommandToResource_MaxLineWidth(t *testing.T) {
+	var windows int
+	var sentTo []string
+	srv := newFakeScratchServer(&windows, &sentTo)
+	WithWritesEnabled(true)(srv)
+	WithMaxLineWidth(1)(srv)
+
+	response := callFakeTool(srv, "run_command_to_resource", map[string]interface{}{"command": "make", "timeout_ms": float64(2000)})
+	got := response.Result.(*mcp.CallToolResult).StructuredContent.(outputResourceResult)
+	if got.Tail != "o"+lineEllipsis {
+		t.Errorf("tail = %q, want it cut to the line width", got.Tail)
+	}
+	// The resource keeps what the inline tail cut off
+	if text := readFakeResource(srv, got.URI).Result.(*mcp.ReadResourceResult).Contents[0].Text; text != "ok" {
+		t.Errorf("resource text = %q, want the whole line %q", text, "ok")
+	}
+}
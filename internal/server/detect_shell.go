@@ -0,0 +1,159 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// knownShells are the pane commands detect_shell recognises as shells
+var knownShells = map[string]bool{
+	"bash": true, "zsh": true, "fish": true, "sh": true, "dash": true,
+	"ksh": true, "mksh": true, "tcsh": true, "csh": true, "nu": true,
+	"pwsh": true, "xonsh": true, "elvish": true,
+}
+
+// shellInfo is the structured content of detect_shell
+type shellInfo struct {
+	// Shell is the detected shell, or empty when the pane is running
+	// something else
+	Shell   string `json:"shell"`
+	Command string `json:"command"`
+	// Prompt is the text before the cursor on its line
+	Prompt string `json:"prompt"`
+	// SuggestedPromptRegex is a pattern for --prompt-regex that matches
+	// Prompt; PromptRegexMatches reports whether the one in use already does
+	SuggestedPromptRegex string `json:"suggested_prompt_regex"`
+	PromptRegexMatches   bool   `json:"prompt_regex_matches"`
+}
+
+// detectShell handles the detect_shell tool: it identifies the shell in a
+// pane and suggests a prompt pattern from the prompt it is showing, so
+// --prompt-regex can be set with confidence
+func (s *Server) detectShell(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	command, err := s.tmuxManager.CurrentCommand(target)
+	if err != nil {
+		return toolError(err)
+	}
+	content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{Target: target})
+	if err != nil {
+		return toolError(err)
+	}
+	cursor, err := s.tmuxManager.GetCursor(target)
+	if err != nil {
+		return toolError(err)
+	}
+
+	prompt := promptLine(content, cursor)
+	info := shellInfo{
+		Shell:                shellName(command),
+		Command:              command,
+		Prompt:               prompt,
+		SuggestedPromptRegex: suggestPromptRegex(prompt),
+		PromptRegexMatches:   s.promptPattern().MatchString(strings.TrimRight(prompt, " ")),
+	}
+
+	var text string
+	if info.Shell == "" {
+		text = fmt.Sprintf("pane is running %s, not a recognised shell", command)
+	} else {
+		text = fmt.Sprintf("shell: %s", info.Shell)
+	}
+	text += fmt.Sprintf("\nprompt: %q\nsuggested --prompt-regex: %s", info.Prompt, info.SuggestedPromptRegex)
+	if !info.PromptRegexMatches {
+		text = appendNote(text, "the current prompt regex does not match this prompt")
+	}
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: info,
+	}, nil
+}
+
+// promptPattern returns the prompt regex run_command uses
+func (s *Server) promptPattern() *regexp.Regexp {
+	if s.promptRegex != nil {
+		return s.promptRegex
+	}
+	return regexp.MustCompile(tmux.DefaultPromptPattern)
+}
+
+// detector returns the prompt detector run_command uses
+func (s *Server) detector() tmux.PromptDetector {
+	if s.promptDetector != nil {
+		return s.promptDetector
+	}
+	return &tmux.RegexDetector{Prompt: s.promptPattern()}
+}
+
+// shellName returns the shell a pane command names, or "" if it isn't one.
+// Login shells show up with a leading dash, e.g. "-bash".
+func shellName(command string) string {
+	name := path.Base(strings.TrimPrefix(command, "-"))
+	if knownShells[name] {
+		return name
+	}
+	return ""
+}
+
+// promptLine returns the text before the cursor on its row, which at an
+// idle shell is the prompt. When the cursor row isn't in the capture the
+// last non-blank line is used instead.
+func promptLine(content string, cursor tmux.Cursor) string {
+	lines := splitLines(content)
+	if row, ok := cursorRow(lines, cursor.Y, cursor.PaneHeight); ok && cursor.X >= 0 {
+		runes := []rune(lines[row])
+		if cursor.X < len(runes) {
+			runes = runes[:cursor.X]
+		}
+		if prompt := string(runes); strings.TrimSpace(prompt) != "" {
+			return prompt
+		}
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			return lines[i]
+		}
+	}
+	return ""
+}
+
+// suggestPromptRegex builds a prompt pattern from the symbols that end
+// prompt (e.g. "$", "]#", "❯" or ">>>"), leaving out the directory, host
+// and other parts that change between prompts. A prompt that doesn't end in
+// a symbol is matched in full.
+func suggestPromptRegex(prompt string) string {
+	trimmed := strings.TrimRight(prompt, " ")
+	if trimmed == "" {
+		return tmux.DefaultPromptPattern
+	}
+
+	runes := []rune(trimmed)
+	start := len(runes)
+	for start > 0 && isPromptSymbol(runes[start-1]) {
+		start--
+	}
+	if start == len(runes) {
+		return "^" + regexp.QuoteMeta(trimmed) + `\s*$`
+	}
+	return regexp.QuoteMeta(string(runes[start:])) + `\s*$`
+}
+
+// isPromptSymbol reports whether r can be part of a prompt's closing symbols.
+// Path characters are excluded so "~/src$" suggests "$" alone.
+func isPromptSymbol(r rune) bool {
+	if r == '/' || r == '~' || r == '.' || r == '-' || r == '_' {
+		return false
+	}
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
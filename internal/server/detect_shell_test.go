@@ -0,0 +1,88 @@
+package server
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+func TestShellName(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "bash", want: "bash"},
+		{command: "-zsh", want: "zsh"},
+		{command: "/usr/bin/fish", want: "fish"},
+		{command: "vim", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := shellName(tt.command); got != tt.want {
+			t.Errorf("shellName(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestPromptRegex(t *testing.T) {
+	tests := []struct {
+		name      string
+		prompt    string
+		want      string
+		alsoMatch string
+	}{
+		{name: "bash", prompt: "user@host:~/src$ ", want: `\$\s*$`, alsoMatch: "user@host:/tmp$"},
+		{name: "bracketed root", prompt: "[root@box etc]# ", want: `\]#\s*$`, alsoMatch: "[root@box ~]#"},
+		{name: "starship", prompt: "❯ ", want: `❯\s*$`, alsoMatch: "~/other ❯"},
+		{name: "python", prompt: ">>> ", want: `>>>\s*$`},
+		{name: "path only", prompt: "~/src", want: `^~/src\s*$`},
+		{name: "empty", prompt: "", want: tmux.DefaultPromptPattern},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestPromptRegex(tt.prompt)
+			if got != tt.want {
+				t.Fatalf("suggestPromptRegex(%q) = %q, want %q", tt.prompt, got, tt.want)
+			}
+			re := regexp.MustCompile(got)
+			for _, line := range []string{tt.prompt, tt.alsoMatch} {
+				if line != "" && !re.MatchString(line) {
+					t.Errorf("suggested pattern %q does not match %q", got, line)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_callTool_DetectShell(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "capture-pane":
+			return "$ make\nok\n[me@box src]# \n", "", nil
+		case "display-message":
+			if args[len(args)-1] == "#{pane_current_command}" {
+				return "-bash\n", "", nil
+			}
+			return "14,2,24\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "detect_shell", map[string]interface{}{})
+	toolText(t, response)
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "detect_shell").OutputSchema, result.StructuredContent)
+	want := shellInfo{
+		Shell:                "bash",
+		Command:              "-bash",
+		Prompt:               "[me@box src]# ",
+		SuggestedPromptRegex: `\]#\s*$`,
+		PromptRegexMatches:   true,
+	}
+	if result.StructuredContent != want {
+		t.Errorf("structuredContent = %+v, want %+v", result.StructuredContent, want)
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOp is one line of a line diff: ' ' for a line both sides share, '-'
+// for a line only in the old side and '+' for one only in the new side
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// maxDiffCells bounds the table diffLines builds for the lines between the
+// common head and tail of the two sides. Past it, that middle is reported
+// as wholly removed and re-added rather than diffed line by line.
+const maxDiffCells = 1 << 20
+
+// diffLines returns the edit script turning old into new, built from their
+// longest common subsequence. Removals come before additions within a run
+// of changes, as in diff -u.
+func diffLines(old, new []string) []diffOp {
+	// Captures include the whole history, so most lines are usually shared
+	// at the start and end; only the middle needs the quadratic table
+	head := 0
+	for head < len(old) && head < len(new) && old[head] == new[head] {
+		head++
+	}
+	tail := 0
+	for tail < len(old)-head && tail < len(new)-head && old[len(old)-1-tail] == new[len(new)-1-tail] {
+		tail++
+	}
+
+	ops := make([]diffOp, 0, len(old)+len(new))
+	for _, line := range old[:head] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(old[head:len(old)-tail], new[head:len(new)-tail])...)
+	for _, line := range old[len(old)-tail:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// diffMiddle is diffLines without the common head and tail
+func diffMiddle(old, new []string) []diffOp {
+	ops := make([]diffOp, 0, len(old)+len(new))
+	if (len(old)+1)*(len(new)+1) > maxDiffCells {
+		for _, line := range old {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range new {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// common[i][j] is the length of the longest common subsequence of
+	// old[i:] and new[j:]
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			ops = append(ops, diffOp{' ', old[i]})
+			i++
+			j++
+		case j == len(new) || (i < len(old) && common[i+1][j] >= common[i][j+1]):
+			ops = append(ops, diffOp{'-', old[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', new[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// unifiedDiff formats the differences between old and new as diff -u hunks
+// without the file header, keeping context unchanged lines around each
+// change. It returns "" when the two are identical.
+func unifiedDiff(old, new []string, context int) (diff string, added, removed int) {
+	ops := diffLines(old, new)
+
+	var changes []int
+	for i, op := range ops {
+		switch op.kind {
+		case '+':
+			added++
+			changes = append(changes, i)
+		case '-':
+			removed++
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return "", 0, 0
+	}
+
+	var b strings.Builder
+	for first := 0; first < len(changes); {
+		// Extend the hunk while the next change is close enough for the
+		// context around the two to touch
+		last := first
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*context+1 {
+			last++
+		}
+		start := max(changes[first]-context, 0)
+		end := min(changes[last]+context+1, len(ops))
+		writeHunk(&b, ops, start, end)
+		first = last + 1
+	}
+	return b.String(), added, removed
+}
+
+// writeHunk writes ops[start:end] as one hunk with its @@ header
+func writeHunk(b *strings.Builder, ops []diffOp, start, end int) {
+	oldStart, newStart := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			oldStart++
+		}
+		if op.kind != '-' {
+			newStart++
+		}
+	}
+	oldLen, newLen := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			oldLen++
+		}
+		if op.kind != '-' {
+			newLen++
+		}
+	}
+	// An empty side is numbered by the line before it, as diff -u does
+	if oldLen == 0 {
+		oldStart--
+	}
+	if newLen == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldLen), hunkRange(newStart, newLen))
+	for _, op := range ops[start:end] {
+		b.WriteByte(op.kind)
+		b.WriteString(op.line)
+		b.WriteByte('\n')
+	}
+}
+
+// hunkRange renders one side of a hunk header, omitting a length of one
+func hunkRange(start, length int) string {
+	if length == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
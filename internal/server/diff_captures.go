@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// defaultDiffContext is the number of unchanged lines diff_captures keeps
+// around each change, as diff -u does
+const defaultDiffContext = 3
+
+// diffResult is the structured content of diff_captures
+type diffResult struct {
+	// Diff holds diff -u style hunks, or "" when nothing changed
+	Diff    string `json:"diff"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	// Token names the capture just taken, for diffing against next time
+	Token string `json:"token"`
+}
+
+// diffCaptures handles the diff_captures tool: it captures the pane,
+// compares it line by line with the snapshot stored under token and stores
+// the new capture under a fresh token
+func (s *Server) diffCaptures(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	token, _ := arguments["token"].(string)
+	before, ok := s.snapshots.get(token)
+	if !ok {
+		return nil, invalidParams(fmt.Sprintf("unknown snapshot token %q", token),
+			paramError{Field: "token", Expected: fmt.Sprintf("a token from one of the last %d snapshot or diff_captures calls", maxSnapshots)})
+	}
+	context, err := intArgument(arguments, "context", defaultDiffContext)
+	if err != nil {
+		return nil, err
+	}
+	if context < 0 {
+		return nil, invalidParams("context must not be negative",
+			paramError{Field: "context", Expected: "integer >= 0"})
+	}
+
+	after, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{Target: target})
+	if err != nil {
+		return toolError(err)
+	}
+	next := s.snapshots.save(after)
+
+	// Trimming keeps tmux's padding below the output from showing up as
+	// changes when the cursor moves down the pane
+	diff, added, removed := unifiedDiff(splitLines(trimContent(before)), splitLines(trimContent(after)), context)
+	diff = truncateLines(diff, s.maxLineWidth)
+
+	text := appendNote(strings.TrimRight(diff, "\n"), fmt.Sprintf("+%d -%d since %s, token %s", added, removed, token, next))
+	if diff == "" {
+		text = fmt.Sprintf("[no changes since %s, token %s]", token, next)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: diffResult{Diff: diff, Added: added, Removed: removed, Token: next},
+	}, nil
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_DiffCaptures(t *testing.T) {
+	screen := "name: web\nstatus: starting\n\n\n"
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			return "80,24,/srv/app,0,0,,0\n", "", nil
+		case "capture-pane":
+			return screen, "", nil
+		}
+		return "", "", nil
+	})
+
+	snapshot := callFakeTool(srv, "snapshot", map[string]interface{}{})
+	token := snapshot.Result.(*mcp.CallToolResult).StructuredContent.(snapshotResult).Token
+
+	screen = "name: web\nstatus: running\nport: 8080\n\n"
+	response := callFakeTool(srv, "diff_captures", map[string]interface{}{"token": token})
+	wantText := "@@ -1,2 +1,3 @@\n name: web\n-status: starting\n+status: running\n+port: 8080\n[+2 -1 since s1, token s2]"
+	if text := toolText(t, response); text != wantText {
+		t.Errorf("diff_captures text = %q, want %q", text, wantText)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "diff_captures").OutputSchema, result.StructuredContent)
+	got := result.StructuredContent.(diffResult)
+	if got.Added != 2 || got.Removed != 1 || got.Token != "s2" {
+		t.Errorf("diff_captures structuredContent = %+v, want +2 -1 with token s2", got)
+	}
+
+	// The new token chains: nothing has changed since s2
+	response = callFakeTool(srv, "diff_captures", map[string]interface{}{"token": got.Token})
+	if text := toolText(t, response); text != "[no changes since s2, token s3]" {
+		t.Errorf("diff_captures text = %q, want no changes", text)
+	}
+}
+
+func TestServer_callTool_DiffCaptures_InvalidParams(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	token := srv.snapshots.save("")
+
+	for _, args := range []map[string]interface{}{
+		{},
+		{"token": "s99"},
+		{"token": token, "context": float64(-1)},
+	} {
+		response := callFakeTool(srv, "diff_captures", args)
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("diff_captures(%v) error = %+v, want invalid params", args, response.Error)
+		}
+	}
+}
+
+func TestSnapshotStore_Evicts(t *testing.T) {
+	var st snapshotStore
+	first := st.save("first")
+	for i := 0; i < maxSnapshots; i++ {
+		st.save("later")
+	}
+	if _, ok := st.get(first); ok {
+		t.Errorf("snapshot %s still held after %d newer saves", first, maxSnapshots)
+	}
+	if content, ok := st.get(st.tokens[0]); !ok || content != "later" {
+		t.Errorf("oldest held snapshot = %q, %v, want \"later\"", content, ok)
+	}
+}
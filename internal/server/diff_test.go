@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name        string
+		old         string
+		new         string
+		context     int
+		want        string
+		wantAdded   int
+		wantRemoved int
+	}{
+		{
+			name: "identical",
+			old:  "a\nb",
+			new:  "a\nb",
+			want: "",
+		},
+		{
+			name:        "changed field",
+			old:         "name: web\nstatus: starting\ncpu: 0%",
+			new:         "name: web\nstatus: running\ncpu: 3%",
+			context:     1,
+			want:        "@@ -1,3 +1,3 @@\n name: web\n-status: starting\n-cpu: 0%\n+status: running\n+cpu: 3%\n",
+			wantAdded:   2,
+			wantRemoved: 2,
+		},
+		{
+			name:      "appended output",
+			old:       "$ ls",
+			new:       "$ ls\na.txt\n$",
+			context:   3,
+			want:      "@@ -1 +1,3 @@\n $ ls\n+a.txt\n+$\n",
+			wantAdded: 2,
+		},
+		{
+			name:        "separate hunks",
+			old:         "1\n2\n3\n4\n5\n6\n7\n8",
+			new:         "one\n2\n3\n4\n5\n6\n7\neight",
+			context:     1,
+			want:        "@@ -1,2 +1,2 @@\n-1\n+one\n 2\n@@ -7,2 +7,2 @@\n 7\n-8\n+eight\n",
+			wantAdded:   2,
+			wantRemoved: 2,
+		},
+		{
+			name:        "nearby changes share a hunk",
+			old:         "1\n2\n3\n4",
+			new:         "one\n2\n3\nfour",
+			context:     1,
+			want:        "@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n-4\n+four\n",
+			wantAdded:   2,
+			wantRemoved: 2,
+		},
+		{
+			name:        "cleared screen",
+			old:         "a\nb",
+			new:         "",
+			context:     3,
+			want:        "@@ -1,2 +0,0 @@\n-a\n-b\n",
+			wantRemoved: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, added, removed := unifiedDiff(splitLines(tt.old), splitLines(tt.new), tt.context)
+			if got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if added != tt.wantAdded || removed != tt.wantRemoved {
+				t.Errorf("unifiedDiff() counts = +%d -%d, want +%d -%d", added, removed, tt.wantAdded, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestDiffLines_Reconstructs(t *testing.T) {
+	old := strings.Split("a b c a b b a", " ")
+	new := strings.Split("c b a b a c", " ")
+
+	var gotOld, gotNew []string
+	for _, op := range diffLines(old, new) {
+		if op.kind != '+' {
+			gotOld = append(gotOld, op.line)
+		}
+		if op.kind != '-' {
+			gotNew = append(gotNew, op.line)
+		}
+	}
+	if strings.Join(gotOld, " ") != strings.Join(old, " ") || strings.Join(gotNew, " ") != strings.Join(new, " ") {
+		t.Errorf("diffLines() sides = %v / %v, want %v / %v", gotOld, gotNew, old, new)
+	}
+}
+
+func TestDiffLines_Large(t *testing.T) {
+	// Appending to a long history diffs only the new lines
+	old := make([]string, 5000)
+	for i := range old {
+		old[i] = fmt.Sprint(i)
+	}
+	new := append(append([]string{}, old...), "added")
+	if _, added, removed := unifiedDiff(old, new, 3); added != 1 || removed != 0 {
+		t.Errorf("unifiedDiff() appended = +%d -%d, want +1 -0", added, removed)
+	}
+
+	// A scrolled history with nothing in common at either end is too big to
+	// diff line by line, and is replaced wholesale
+	if _, added, removed := unifiedDiff(old, new[1:], 3); added != 5000 || removed != 5000 {
+		t.Errorf("unifiedDiff() scrolled = +%d -%d, want +5000 -5000", added, removed)
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// maxErrorStderr caps how much tmux stderr is echoed back in error data
+const maxErrorStderr = 512
+
+// requestError is returned by request handlers for failures that map to a
+// specific JSON-RPC error code rather than an internal error
+type requestError struct {
+	code    int
+	message string
+	data    interface{}
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// paramError names a request parameter that failed validation and what
+// was expected of it
+type paramError struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+}
+
+// invalidParamsData is the error data for -32602 responses
+type invalidParamsData struct {
+	Params []paramError `json:"params"`
+}
+
+// sessionNotFoundData is the error data when the managed session is
+// missing, listing the sessions that do exist so a client can pick one
+type sessionNotFoundData struct {
+	Session          string   `json:"session"`
+	ExistingSessions []string `json:"existing_sessions"`
+}
+
+// commandFailedData is the error data when a tmux invocation fails
+type commandFailedData struct {
+	Command []string `json:"command"`
+	Stderr  string   `json:"stderr"`
+}
+
+// invalidParams reports bad tool or resource arguments as -32602
+func invalidParams(message string, params ...paramError) error {
+	return &requestError{
+		code:    mcp.CodeInvalidParams,
+		message: message,
+		data:    invalidParamsData{Params: params},
+	}
+}
+
+// jsonRPCError converts a handler error into a JSON-RPC error, attaching
+// machine-readable data for failures a client can recover from
+func (s *Server) jsonRPCError(err error) *mcp.JSONRPCError {
+	rpcErr := &mcp.JSONRPCError{
+		Code:    mcp.CodeInternalError,
+		Message: err.Error(),
+	}
+
+	var reqErr *requestError
+	var cmdErr *tmux.CommandError
+	switch {
+	case errors.As(err, &reqErr):
+		rpcErr.Code = reqErr.code
+		rpcErr.Data = reqErr.data
+	case errors.Is(err, tmux.ErrSessionNotFound):
+		data := sessionNotFoundData{
+			Session:          s.tmuxManager.SessionName(),
+			ExistingSessions: []string{},
+		}
+		if sessions, err := s.tmuxManager.Sessions(); err == nil {
+			data.ExistingSessions = sessions
+		}
+		rpcErr.Data = data
+	case errors.As(err, &cmdErr):
+		rpcErr.Data = commandFailedData{
+			Command: append([]string{"tmux"}, cmdErr.Args...),
+			Stderr:  truncate(strings.TrimSpace(cmdErr.Stderr), maxErrorStderr),
+		}
+	}
+	return rpcErr
+}
+
+// truncate shortens s to at most max bytes, marking the cut with "..."
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "") + "..."
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// exitStatus simulates tmux exiting with a non-zero status
+type exitStatus int
+
+func (e exitStatus) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitStatus) ExitCode() int { return int(e) }
+
+// readFakeResource issues a resources/read request against srv
+func readFakeResource(srv *Server, uri string) *mcp.JSONRPCResponse {
+	return srv.handleRequest(&mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "resources/read",
+		Params:  map[string]interface{}{"uri": uri},
+	})
+}
+
+func TestServer_ErrorData_SessionNotFound(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "has-session":
+			return "", "can't find session: fake-session", exitStatus(1)
+		case "list-sessions":
+			return "main\nwork\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := readFakeResource(srv, "terminal://current")
+	if response.Error == nil {
+		t.Fatal("response.Error is nil, want session-not-found error")
+	}
+	data, ok := response.Error.Data.(sessionNotFoundData)
+	if !ok {
+		t.Fatalf("response.Error.Data = %T, want sessionNotFoundData", response.Error.Data)
+	}
+	want := sessionNotFoundData{Session: "fake-session", ExistingSessions: []string{"main", "work"}}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("response.Error.Data = %+v, want %+v", data, want)
+	}
+}
+
+func TestServer_ErrorData_InvalidParams(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+
+	response := callFakeTool(srv, "read_terminal", map[string]interface{}{"format": "xml"})
+	if response.Error == nil {
+		t.Fatal("response.Error is nil, want invalid params error")
+	}
+	if response.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("response.Error.Code = %d, want %d", response.Error.Code, mcp.CodeInvalidParams)
+	}
+	data, ok := response.Error.Data.(invalidParamsData)
+	if !ok {
+		t.Fatalf("response.Error.Data = %T, want invalidParamsData", response.Error.Data)
+	}
+	if len(data.Params) != 1 || data.Params[0].Field != "format" || data.Params[0].Expected == "" {
+		t.Errorf("response.Error.Data = %+v, want the format field and its expected type", data)
+	}
+}
+
+func TestServer_ErrorData_CommandFailed(t *testing.T) {
+	stderr := strings.Repeat("x", maxErrorStderr+100)
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			return "", stderr, exitStatus(1)
+		}
+		return "", "", nil
+	})
+
+	response := readFakeResource(srv, "terminal://current")
+	if response.Error == nil {
+		t.Fatal("response.Error is nil, want command failure")
+	}
+	if response.Error.Code != mcp.CodeInternalError {
+		t.Errorf("response.Error.Code = %d, want %d", response.Error.Code, mcp.CodeInternalError)
+	}
+	data, ok := response.Error.Data.(commandFailedData)
+	if !ok {
+		t.Fatalf("response.Error.Data = %T, want commandFailedData", response.Error.Data)
+	}
+	if len(data.Command) < 2 || data.Command[0] != "tmux" || data.Command[1] != "capture-pane" {
+		t.Errorf("data.Command = %v, want a tmux capture-pane invocation", data.Command)
+	}
+	if want := maxErrorStderr + len("..."); len(data.Stderr) != want {
+		t.Errorf("len(data.Stderr) = %d, want %d (truncated)", len(data.Stderr), want)
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// focusResult is the structured content of focus_pane
+type focusResult struct {
+	Focused bool       `json:"focused"`
+	Pane    *paneEntry `json:"pane,omitempty"`
+}
+
+// focusPane handles the focus_pane tool: it selects a pane in tmux and
+// makes it the default target of later calls, or with no target clears
+// that default
+func (s *Server) focusPane(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("focus_pane"); result != nil {
+		return result, nil
+	}
+
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	pane, err := s.tmuxManager.FocusPane(target)
+	if err != nil {
+		return toolError(err)
+	}
+
+	if pane == nil {
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{{Type: "text", Text: "Focus cleared; tools without a target follow tmux's active pane"}},
+			StructuredContent: focusResult{},
+		}, nil
+	}
+	entry := newPaneEntry(*pane)
+	entry.Focused = true
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Focused %s (window %d %q pane %d: %s)",
+			pane.ID, pane.WindowIndex, pane.WindowName, pane.PaneIndex, pane.Command)}},
+		StructuredContent: focusResult{Focused: true, Pane: &entry},
+	}, nil
+}
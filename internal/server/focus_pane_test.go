@@ -0,0 +1,70 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_FocusPane(t *testing.T) {
+	var calls [][]string
+	srv := newFakePaneServer(&calls)
+
+	response := callFakeTool(srv, "focus_pane", map[string]interface{}{"window": "main"})
+	if text := toolText(t, response); text != `Focused %0 (window 0 "main" pane 0: bash)` {
+		t.Errorf("focus_pane text = %q", text)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "focus_pane").OutputSchema, result.StructuredContent)
+	if got := result.StructuredContent.(focusResult); !got.Focused || got.Pane == nil || got.Pane.PaneID != "%0" {
+		t.Errorf("focus_pane structuredContent = %+v, want pane %%0 focused", got)
+	}
+
+	// send_keys without a target now goes to the focused pane rather than
+	// tmux's active pane %4
+	calls = nil
+	toolText(t, callFakeTool(srv, "send_keys", map[string]interface{}{"keys": []interface{}{"Enter"}}))
+	var sent [][]string
+	for _, call := range calls {
+		if call[0] == "send-keys" {
+			sent = append(sent, call)
+		}
+	}
+	if want := [][]string{{"send-keys", "-t", "%0", "Enter"}}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("send-keys calls = %v, want %v", sent, want)
+	}
+
+	if text := toolText(t, callFakeTool(srv, "list_panes", map[string]interface{}{})); !strings.Contains(text, "%0 window 0 \"main\" pane 0: bash 80x24 (focused)") {
+		t.Errorf("list_panes text = %q, want %%0 marked focused", text)
+	}
+
+	response = callFakeTool(srv, "focus_pane", map[string]interface{}{})
+	result = response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "focus_pane").OutputSchema, result.StructuredContent)
+	if got := result.StructuredContent.(focusResult); got.Focused {
+		t.Errorf("focus_pane with no target = %+v, want the focus cleared", got)
+	}
+}
+
+func TestServer_callTool_FocusPane_Rejected(t *testing.T) {
+	var calls [][]string
+	srv := newFakePaneServer(&calls)
+
+	response := callFakeTool(srv, "focus_pane", map[string]interface{}{"pane": "%9"})
+	if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("focus_pane unknown pane error = %+v, want invalid params", response.Error)
+	}
+
+	srv.writesEnabled = false
+	result := callFakeTool(srv, "focus_pane", map[string]interface{}{"pane": "%0"}).Result.(*mcp.CallToolResult)
+	if !result.IsError {
+		t.Error("focus_pane with writes disabled: IsError = false, want true")
+	}
+	for _, call := range calls {
+		if call[0] == "select-window" {
+			t.Errorf("focus_pane selected a pane despite being rejected: %v", call)
+		}
+	}
+}
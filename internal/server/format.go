@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/ansi"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// scrollbackLine is one element of read_scrollback's JSON output
+type scrollbackLine struct {
+	LineNumber int         `json:"line_number"`
+	Text       string      `json:"text"`
+	Spans      []ansi.Span `json:"spans,omitempty"`
+}
+
+// splitLines splits captured content into lines, dropping the empty element
+// produced by the trailing newline tmux terminates the capture with
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// cursorMarker is inserted at the cursor position when a read asks for it
+const cursorMarker = "‸"
+
+// cursorRow returns the index in lines of visible row y of a pane height
+// rows tall: rows count from the top of the last height lines, or of all of
+// them when the capture is shorter. ok is false when the row isn't in lines.
+func cursorRow(lines []string, y, height int) (row int, ok bool) {
+	row = len(lines) - height + y
+	if len(lines) < height {
+		// A capture shorter than the pane has no history above the
+		// visible area
+		row = y
+	}
+	if y < 0 || y >= height || row < 0 || row >= len(lines) {
+		return 0, false
+	}
+	return row, true
+}
+
+// insertCursor inserts cursorMarker at column x of row y, where rows count
+// from the top of the visible pane: the last height lines of content, or
+// all of it when the capture is shorter. A line shorter than x is padded
+// with spaces. ok is false, and content is
+// returned unchanged, when that row is not part of content.
+func insertCursor(content string, x, y, height int) (result string, ok bool) {
+	lines := splitLines(content)
+	row, ok := cursorRow(lines, y, height)
+	if x < 0 || !ok {
+		return content, false
+	}
+
+	runes := []rune(lines[row])
+	if x > len(runes) {
+		runes = append(runes, []rune(strings.Repeat(" ", x-len(runes)))...)
+	}
+	lines[row] = string(runes[:x]) + cursorMarker + string(runes[x:])
+
+	result = strings.Join(lines, "\n")
+	if strings.HasSuffix(content, "\n") {
+		result += "\n"
+	}
+	return result, true
+}
+
+// markCursor inserts the cursor marker into content and appends a note
+// with the cursor's coordinates, or explaining why it could not be placed
+func markCursor(content string, cursor tmux.Cursor) string {
+	marked, ok := insertCursor(content, cursor.X, cursor.Y, cursor.PaneHeight)
+	if !ok {
+		return appendNote(strings.TrimRight(content, "\n"),
+			fmt.Sprintf("cursor at column %d, row %d is outside the captured lines", cursor.X, cursor.Y))
+	}
+	return appendNote(strings.TrimRight(marked, "\n"),
+		fmt.Sprintf("cursor at column %d, row %d", cursor.X, cursor.Y))
+}
+
+// lineEllipsis marks the end of a line cut short by truncateLines
+const lineEllipsis = "…"
+
+// truncateLines cuts every line of content longer than width runes down to
+// width runes followed by lineEllipsis, so one runaway line (a progress bar,
+// minified JSON) can't swamp the output. Escape sequences don't count
+// towards the width. A width of zero or less leaves content unchanged.
+func truncateLines(content string, width int) string {
+	if width <= 0 {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if truncated, ok := ansi.Truncate(line, width); ok {
+			lines[i] = truncated + lineEllipsis
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// humanAge renders d coarsely for people, to the two largest units:
+// "45s", "12m", "3h12m" or "2d4h"
+func humanAge(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Truncate(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	days := int(d.Hours()) / 24
+	return fmt.Sprintf("%dd%dh", days, int(d.Hours())%24)
+}
+
+// timestamp renders t as an RFC 3339 string in UTC, or "" for the zero time
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// trimContent removes trailing whitespace from each line and drops the
+// blank lines tmux pads the pane with below the last output
+func trimContent(content string) string {
+	lines := splitLines(content)
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	end := len(lines)
+	for end > 0 && lines[end-1] == "" {
+		end--
+	}
+	if end == 0 {
+		return ""
+	}
+	return strings.Join(lines[:end], "\n") + "\n"
+}
+
+// wrappedLinesJSON renders visual lines and their wrap flags as a JSON array
+func wrappedLinesJSON(lines []tmux.WrappedLine) (string, error) {
+	data, err := json.Marshal(lines)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// scrollbackJSON renders captured content as a JSON array of numbered lines.
+// Text is always plain; when withSpans is set each line also carries the
+// styled spans parsed from its escape sequences.
+func scrollbackJSON(content string, withSpans bool) (string, error) {
+	lines := splitLines(content)
+	result := make([]scrollbackLine, 0, len(lines))
+	for i, line := range lines {
+		entry := scrollbackLine{
+			LineNumber: i + 1,
+			Text:       ansi.Strip(line),
+		}
+		if withSpans {
+			entry.Spans = ansi.Parse(line)
+		}
+		result = append(result, entry)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/ansi"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "empty", content: "", want: nil},
+		{name: "trailing newline", content: "a\nb\n", want: []string{"a", "b"}},
+		{name: "no trailing newline", content: "a\nb", want: []string{"a", "b"}},
+		{name: "blank lines kept", content: "a\n\nb\n", want: []string{"a", "", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitLines(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitLines(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrollbackJSON(t *testing.T) {
+	content := "$ ls\n\x1b[34mdir\x1b[0m file\n"
+
+	tests := []struct {
+		name      string
+		withSpans bool
+		want      []scrollbackLine
+	}{
+		{
+			name: "plain",
+			want: []scrollbackLine{
+				{LineNumber: 1, Text: "$ ls"},
+				{LineNumber: 2, Text: "dir file"},
+			},
+		},
+		{
+			name:      "with spans",
+			withSpans: true,
+			want: []scrollbackLine{
+				{LineNumber: 1, Text: "$ ls", Spans: []ansi.Span{{Text: "$ ls"}}},
+				{LineNumber: 2, Text: "dir file", Spans: []ansi.Span{
+					{Text: "dir", Style: ansi.Style{Foreground: "#0000ee"}},
+					{Text: " file"},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := scrollbackJSON(content, tt.withSpans)
+			if err != nil {
+				t.Fatalf("scrollbackJSON() error = %v", err)
+			}
+
+			var got []scrollbackLine
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("scrollbackJSON() produced invalid JSON %q: %v", out, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scrollbackJSON() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrollbackJSON_Empty(t *testing.T) {
+	out, err := scrollbackJSON("", false)
+	if err != nil {
+		t.Fatalf("scrollbackJSON() error = %v", err)
+	}
+	if out != "[]" {
+		t.Errorf("scrollbackJSON(\"\") = %q, want []", out)
+	}
+}
+
+func TestTrimContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "empty", content: "", want: ""},
+		{name: "only padding", content: "\n  \n\n", want: ""},
+		{name: "trailing spaces and blank lines", content: "$ ls   \nfile\t\n$ \n\n\n", want: "$ ls\nfile\n$\n"},
+		{name: "keeps interior blank lines", content: "a\n\nb\n", want: "a\n\nb\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimContent(tt.content); got != tt.want {
+				t.Errorf("trimContent(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		width   int
+		want    string
+	}{
+		{name: "disabled", content: "abcdef\n", width: 0, want: "abcdef\n"},
+		{name: "short lines untouched", content: "abc\nde\n", width: 3, want: "abc\nde\n"},
+		{name: "long line cut", content: "abcdef\nxy\n", width: 3, want: "abc…\nxy\n"},
+		// é is two bytes and 世 three: the cut must not split either
+		{name: "two-byte rune at boundary", content: "caféé\n", width: 4, want: "café…\n"},
+		{name: "three-byte rune at boundary", content: "ab世界\n", width: 3, want: "ab世…\n"},
+		{name: "escapes not counted", content: "\x1b[31mabcdef\x1b[0m\n", width: 3, want: "\x1b[31mabc…\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateLines(tt.content, tt.width)
+			if got != tt.want {
+				t.Errorf("truncateLines(%q, %d) = %q, want %q", tt.content, tt.width, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateLines(%q, %d) produced invalid UTF-8", tt.content, tt.width)
+			}
+		})
+	}
+}
+
+func TestInsertCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		x, y    int
+		height  int
+		want    string
+		wantOK  bool
+	}{
+		{name: "middle of line", content: "$ ls\n", x: 2, y: 0, height: 1, want: "$ ‸ls\n", wantOK: true},
+		{name: "pads short line", content: "$\n\n", x: 3, y: 1, height: 2, want: "$\n   ‸\n", wantOK: true},
+		{name: "relative to visible pane", content: "history\n$ \nout\n", x: 2, y: 0, height: 2, want: "history\n$ ‸\nout\n", wantOK: true},
+		{name: "counts runes not bytes", content: "❯ x\n", x: 2, y: 0, height: 1, want: "❯ ‸x\n", wantOK: true},
+		{name: "row beyond capture", content: "$ \n", x: 0, y: 5, height: 3, want: "$ \n", wantOK: false},
+		{name: "row beyond pane", content: "a\nb\n", x: 0, y: 2, height: 2, want: "a\nb\n", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := insertCursor(tt.content, tt.x, tt.y, tt.height)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("insertCursor() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCursorRow(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  int
+		y      int
+		height int
+		want   int
+		wantOk bool
+	}{
+		{name: "history above the pane", lines: 10, y: 1, height: 3, want: 8, wantOk: true},
+		{name: "capture shorter than the pane", lines: 2, y: 1, height: 24, want: 1, wantOk: true},
+		{name: "row past the capture", lines: 2, y: 5, height: 24},
+		{name: "negative row", lines: 10, y: -1, height: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row, ok := cursorRow(make([]string, tt.lines), tt.y, tt.height)
+			if row != tt.want || ok != tt.wantOk {
+				t.Errorf("cursorRow() = %d, %v, want %d, %v", row, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestHumanAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 0, want: "0s"},
+		{d: -time.Second, want: "0s"},
+		{d: 45*time.Second + 600*time.Millisecond, want: "45s"},
+		{d: 12*time.Minute + 30*time.Second, want: "12m"},
+		{d: 3*time.Hour + 12*time.Minute, want: "3h12m"},
+		{d: 52 * time.Hour, want: "2d4h"},
+	}
+
+	for _, tt := range tests {
+		if got := humanAge(tt.d); got != tt.want {
+			t.Errorf("humanAge(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/git"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// gitStatusResult is the structured content of git_status
+type gitStatusResult struct {
+	Path string `json:"path"`
+	// Repository is false when Path is not in a git working tree, in which
+	// case the remaining fields are empty
+	Repository   bool           `json:"repository"`
+	Branch       string         `json:"branch,omitempty"`
+	Detached     bool           `json:"detached,omitempty"`
+	Upstream     string         `json:"upstream,omitempty"`
+	Ahead        int            `json:"ahead"`
+	Behind       int            `json:"behind"`
+	UpstreamGone bool           `json:"upstream_gone,omitempty"`
+	NoCommits    bool           `json:"no_commits,omitempty"`
+	Files        []gitFileEntry `json:"files"`
+}
+
+// gitFileEntry describes one changed path in git_status
+type gitFileEntry struct {
+	Status   string `json:"status"`
+	Path     string `json:"path"`
+	OrigPath string `json:"orig_path,omitempty"`
+}
+
+// gitStatusTimeout bounds how long git_status waits for git, which holds up
+// every other request meanwhile
+const gitStatusTimeout = 10 * time.Second
+
+// gitStatusTool handles the git_status tool: it runs git status in the
+// pane's working directory from the server process, so nothing is typed
+// into the pane
+func (s *Server) gitStatusTool(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Behind ssh, the pane's working directory is the local one ssh was
+	// started from, not the remote one the prompt shows
+	command, err := s.tmuxManager.CurrentCommand(target)
+	if err != nil {
+		return toolError(err)
+	}
+	if remoteCommands[path.Base(command)] {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: the pane is running %s, so its repository is on another machine; git_status only reads repositories on the machine running the server", command)}},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := s.tmuxManager.GetPaneInfoFor(target)
+	if err != nil {
+		return toolError(err)
+	}
+	dir := info["current_path"]
+	if dir == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: "Error: tmux did not report the pane's working directory"}},
+			IsError: true,
+		}, nil
+	}
+
+	result := gitStatusResult{Path: dir, Files: []gitFileEntry{}}
+	ctx, cancel := context.WithTimeout(context.Background(), gitStatusTimeout)
+	defer cancel()
+	status, err := s.gitStatus(ctx, dir)
+	if errors.Is(err, git.ErrNotRepository) {
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{{Type: "text", Text: fmt.Sprintf("%s is not in a git repository", dir)}},
+			StructuredContent: result,
+		}, nil
+	}
+	if err != nil {
+		return toolError(err)
+	}
+
+	result.Repository = true
+	result.Branch = status.Branch
+	result.Detached = status.Detached
+	result.Upstream = status.Upstream
+	result.Ahead = status.Ahead
+	result.Behind = status.Behind
+	result.UpstreamGone = status.UpstreamGone
+	result.NoCommits = status.NoCommits
+	for _, file := range status.Files {
+		result.Files = append(result.Files, gitFileEntry{Status: file.Code, Path: file.Path, OrigPath: file.OrigPath})
+	}
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: result.text()}},
+		StructuredContent: result,
+	}, nil
+}
+
+// text summarises the status like the first lines of git status
+func (r gitStatusResult) text() string {
+	var branch string
+	switch {
+	case r.Detached:
+		branch = "HEAD detached"
+	case r.NoCommits:
+		branch = fmt.Sprintf("on %s, no commits yet", r.Branch)
+	default:
+		branch = "on " + r.Branch
+	}
+	if r.Upstream != "" {
+		switch {
+		case r.UpstreamGone:
+			branch += fmt.Sprintf(", upstream %s is gone", r.Upstream)
+		case r.Ahead == 0 && r.Behind == 0:
+			branch += fmt.Sprintf(", up to date with %s", r.Upstream)
+		default:
+			branch += fmt.Sprintf(", %d ahead and %d behind %s", r.Ahead, r.Behind, r.Upstream)
+		}
+	}
+
+	lines := []string{fmt.Sprintf("%s: %s", r.Path, branch)}
+	if len(r.Files) == 0 {
+		lines = append(lines, "working tree clean")
+	}
+	for _, file := range r.Files {
+		line := file.Status + " " + file.Path
+		if file.OrigPath != "" {
+			line = file.Status + " " + file.OrigPath + " -> " + file.Path
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
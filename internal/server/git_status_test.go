@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/git"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_GitStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   *git.Status
+		err      error
+		want     gitStatusResult
+		wantText string
+	}{
+		{
+			name: "changes",
+			status: &git.Status{Branch: "main", Upstream: "origin/main", Ahead: 1, Behind: 2, Files: []git.FileStatus{
+				{Code: " M", Path: "README.md"},
+				{Code: "R ", Path: "new.go", OrigPath: "old.go"},
+			}},
+			want: gitStatusResult{Path: "/src/app", Repository: true, Branch: "main", Upstream: "origin/main", Ahead: 1, Behind: 2, Files: []gitFileEntry{
+				{Status: " M", Path: "README.md"},
+				{Status: "R ", Path: "new.go", OrigPath: "old.go"},
+			}},
+			wantText: "/src/app: on main, 1 ahead and 2 behind origin/main\n M README.md\nR  old.go -> new.go",
+		},
+		{
+			name:     "clean",
+			status:   &git.Status{Branch: "main", Upstream: "origin/main", Files: []git.FileStatus{}},
+			want:     gitStatusResult{Path: "/src/app", Repository: true, Branch: "main", Upstream: "origin/main", Files: []gitFileEntry{}},
+			wantText: "/src/app: on main, up to date with origin/main\nworking tree clean",
+		},
+		{
+			name:     "not a repository",
+			err:      fmt.Errorf("/src/app: %w", git.ErrNotRepository),
+			want:     gitStatusResult{Path: "/src/app", Files: []gitFileEntry{}},
+			wantText: "/src/app is not in a git repository",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeDirServer("/src/app", "zsh")
+			var dir string
+			srv.gitStatus = func(ctx context.Context, d string) (*git.Status, error) {
+				if _, ok := ctx.Deadline(); !ok {
+					t.Error("git status ran without a deadline")
+				}
+				dir = d
+				return tt.status, tt.err
+			}
+
+			response := callFakeTool(srv, "git_status", map[string]interface{}{})
+			if text := toolText(t, response); text != tt.wantText {
+				t.Errorf("git_status text = %q, want %q", text, tt.wantText)
+			}
+			if dir != "/src/app" {
+				t.Errorf("git status ran in %q, want /src/app", dir)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "git_status").OutputSchema, result.StructuredContent)
+			if got := result.StructuredContent.(gitStatusResult); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("structuredContent = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_GitStatus_RemotePane(t *testing.T) {
+	srv := newFakeDirServer("/home/dev", "ssh")
+	srv.gitStatus = func(ctx context.Context, dir string) (*git.Status, error) {
+		t.Errorf("git status ran in %s behind ssh", dir)
+		return nil, nil
+	}
+
+	response := callFakeTool(srv, "git_status", map[string]interface{}{})
+	if text := toolText(t, response); !response.Result.(*mcp.CallToolResult).IsError || !strings.Contains(text, "another machine") {
+		t.Errorf("git_status behind ssh = %q, want an error", text)
+	}
+}
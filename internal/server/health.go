@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// Health statuses of the terminal://health resource
+const (
+	healthOK = "ok"
+	// healthDegraded means tmux works but the session is missing; the
+	// next tool call recreates it
+	healthDegraded = "degraded"
+	// healthUnhealthy means tmux can't be run, so no tool can work
+	healthUnhealthy = "unhealthy"
+)
+
+// healthReport is the content of the terminal://health resource
+type healthReport struct {
+	Status           string `json:"status"`
+	Backend          string `json:"backend"`
+	BackendAvailable bool   `json:"backend_available"`
+	BackendVersion   string `json:"backend_version,omitempty"`
+	Session          string `json:"session"`
+	SessionExists    bool   `json:"session_exists"`
+	UptimeSeconds    int64  `json:"uptime_seconds"`
+	// Error says what went wrong when the status isn't ok
+	Error string `json:"error,omitempty"`
+}
+
+// checkHealth reports whether tmux runs and the session exists, without
+// capturing anything and without creating the session
+func (s *Server) checkHealth() healthReport {
+	report := healthReport{
+		Status:        healthOK,
+		Backend:       "tmux",
+		Session:       s.tmuxManager.SessionName(),
+		UptimeSeconds: int64(time.Since(s.started) / time.Second),
+	}
+
+	version, err := s.tmuxManager.Version()
+	if err != nil {
+		report.Status = healthUnhealthy
+		report.Error = err.Error()
+		return report
+	}
+	report.BackendAvailable = true
+	report.BackendVersion = version
+
+	exists, err := s.tmuxManager.SessionExists()
+	switch {
+	case err != nil:
+		report.Status = healthUnhealthy
+		report.Error = fmt.Sprintf("failed to check session: %v", err)
+	case !exists:
+		report.Status = healthDegraded
+		report.Error = fmt.Sprintf("session %q does not exist; the next tool call creates it", report.Session)
+	default:
+		report.SessionExists = true
+	}
+	return report
+}
+
+// readHealth reads the terminal://health resource
+func (s *Server) readHealth(uri string) (*mcp.ReadResourceResult, error) {
+	data, err := json.MarshalIndent(s.checkHealth(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode health: %w", err)
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []mcp.ResourceContent{
+			{
+				URI:      uri,
+				MimeType: "application/json",
+				Text:     string(data),
+			},
+		},
+	}, nil
+}
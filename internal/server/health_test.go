@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_readResource_Health(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     error
+		session     error
+		wantStatus  string
+		wantVersion string
+		wantExists  bool
+	}{
+		{name: "ok", wantStatus: healthOK, wantVersion: "tmux 3.4", wantExists: true},
+		{name: "no session", session: exitStatus(1), wantStatus: healthDegraded, wantVersion: "tmux 3.4"},
+		{name: "no tmux", version: fmt.Errorf("exec: %w", exec.ErrNotFound), wantStatus: healthUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				calls = append(calls, args[0])
+				switch args[0] {
+				case "-V":
+					return "tmux 3.4\n", "", tt.version
+				case "has-session":
+					return "", "", tt.session
+				}
+				return "", "", nil
+			})
+
+			response := readFakeResource(srv, "terminal://health")
+			if response.Error != nil {
+				t.Fatalf("response.Error = %v, want nil", response.Error)
+			}
+			var got healthReport
+			if err := json.Unmarshal([]byte(response.Result.(*mcp.ReadResourceResult).Contents[0].Text), &got); err != nil {
+				t.Fatalf("health is not JSON: %v", err)
+			}
+			if got.Status != tt.wantStatus || got.BackendVersion != tt.wantVersion || got.SessionExists != tt.wantExists {
+				t.Errorf("health = %+v, want status %q, version %q, session exists %v", got, tt.wantStatus, tt.wantVersion, tt.wantExists)
+			}
+			if got.Status != healthOK && got.Error == "" {
+				t.Errorf("health = %+v, want an error explaining the status", got)
+			}
+			for _, call := range calls {
+				if call != "-V" && call != "has-session" {
+					t.Errorf("reading health ran tmux %s, want only -V and has-session", call)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// defaultInterruptTimeout bounds how long interrupt waits for the shell to
+// come back
+const defaultInterruptTimeout = 5 * time.Second
+
+// remoteClients are pane commands that relay input to another host. While
+// one of them is in the foreground, tmux can't see what runs at the other
+// end, so an interrupt can't be confirmed.
+var remoteClients = map[string]bool{
+	"ssh": true, "mosh-client": true, "telnet": true, "et": true,
+}
+
+// interruptResult is the structured content of interrupt
+type interruptResult struct {
+	// Interrupted is the foreground command C-c was sent to, and Command
+	// the one in the foreground afterwards
+	Interrupted string `json:"interrupted"`
+	Command     string `json:"command"`
+	// Stopped reports whether the shell came back before the timeout. It
+	// is null when the pane runs a remote client and the outcome can't be
+	// seen.
+	Stopped *bool `json:"stopped"`
+	// Sent is false when the pane was already at a shell, in which case
+	// nothing was sent
+	Sent bool `json:"sent"`
+}
+
+// interrupt handles the interrupt tool: it sends C-c to a pane and watches
+// #{pane_current_command} until a shell is back in the foreground, so a
+// program that ignores SIGINT isn't mistaken for one that stopped
+func (s *Server) interrupt(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("interrupt"); result != nil {
+		return result, nil
+	}
+
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	timeoutMs, err := intArgument(arguments, "timeout_ms", int(defaultInterruptTimeout/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	if timeout < 0 {
+		return nil, invalidParams("timeout_ms must not be negative",
+			paramError{Field: "timeout_ms", Expected: "non-negative number of milliseconds"})
+	}
+	pollInterval, err := s.pollIntervalArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := s.tmuxManager.CurrentCommand(target)
+	if err != nil {
+		return toolError(err)
+	}
+	result := interruptResult{Interrupted: before, Command: before}
+	if shellName(before) != "" {
+		stopped := true
+		result.Stopped = &stopped
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{{Type: "text", Text: fmt.Sprintf("nothing to interrupt: %s is already in the foreground; nothing was sent", before)}},
+			StructuredContent: result,
+		}, nil
+	}
+
+	if err := s.tmuxManager.SendKeysTo(target, "C-c"); err != nil {
+		return toolError(err)
+	}
+	result.Sent = true
+
+	remote := remoteClients[path.Base(before)]
+	deadline := time.Now().Add(timeout)
+	for {
+		time.Sleep(pollInterval)
+		if result.Command, err = s.tmuxManager.CurrentCommand(target); err != nil {
+			return toolError(err)
+		}
+		if shellName(result.Command) != "" || remote || time.Now().After(deadline) {
+			break
+		}
+	}
+
+	var text string
+	switch {
+	case shellName(result.Command) != "":
+		stopped := true
+		result.Stopped = &stopped
+		text = fmt.Sprintf("stopped: %s exited and %s is back", before, result.Command)
+	case remote && result.Command == before:
+		text = fmt.Sprintf("sent C-c through %s; what runs on the remote host can't be seen, so check the screen to confirm it stopped", before)
+	default:
+		stopped := false
+		result.Stopped = &stopped
+		text = fmt.Sprintf("not stopped: %s is still in the foreground after %dms", result.Command, timeout/time.Millisecond)
+		text = appendNote(text, "the program may ignore SIGINT; try keys such as q, C-d or C-\\ with send_keys")
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}, nil
+}
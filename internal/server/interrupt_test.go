@@ -0,0 +1,97 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_Interrupt(t *testing.T) {
+	tests := []struct {
+		name        string
+		commands    []string
+		wantStopped *bool
+		wantSent    bool
+		wantText    string
+	}{
+		{
+			name:        "stops",
+			commands:    []string{"sleep", "sleep", "bash"},
+			wantStopped: boolPtr(true),
+			wantSent:    true,
+			wantText:    "stopped: sleep exited and bash is back",
+		},
+		{
+			name:        "ignores SIGINT",
+			commands:    []string{"stubborn"},
+			wantStopped: boolPtr(false),
+			wantSent:    true,
+			wantText:    "not stopped: stubborn is still in the foreground after 30ms",
+		},
+		{
+			name:        "already at a shell",
+			commands:    []string{"zsh"},
+			wantStopped: boolPtr(true),
+			wantText:    "nothing to interrupt",
+		},
+		{
+			name:     "remote client",
+			commands: []string{"ssh"},
+			wantSent: true,
+			wantText: "sent C-c through ssh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := tt.commands
+			var sent []string
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "display-message":
+					command := commands[0]
+					if len(commands) > 1 {
+						commands = commands[1:]
+					}
+					return command + "\n", "", nil
+				case "send-keys":
+					sent = append(sent, args[len(args)-1])
+				}
+				return "", "", nil
+			})
+			srv.writesEnabled = true
+
+			response := callFakeTool(srv, "interrupt", map[string]interface{}{"timeout_ms": float64(30)})
+			if text := toolText(t, response); !strings.HasPrefix(text, tt.wantText) {
+				t.Errorf("interrupt text = %q, want it to start with %q", text, tt.wantText)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "interrupt").OutputSchema, result.StructuredContent)
+			got := result.StructuredContent.(interruptResult)
+			if (got.Stopped == nil) != (tt.wantStopped == nil) || (got.Stopped != nil && *got.Stopped != *tt.wantStopped) {
+				t.Errorf("stopped = %v, want %v", got.Stopped, tt.wantStopped)
+			}
+			if got.Sent != tt.wantSent || (len(sent) > 0) != tt.wantSent {
+				t.Errorf("sent = %v with keys %v, want %v", got.Sent, sent, tt.wantSent)
+			}
+			if tt.wantSent && sent[0] != "C-c" {
+				t.Errorf("sent keys %v, want C-c", sent)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_Interrupt_ReadOnly(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "send-keys" {
+			t.Errorf("interrupt sent keys with writes disabled: %v", args)
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "interrupt", map[string]interface{}{})
+	if result := response.Result.(*mcp.CallToolResult); !result.IsError {
+		t.Error("interrupt with writes disabled: IsError = false, want true")
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const (
+	// defaultActivityDelay is how long is_active waits between its two
+	// captures when delay_ms is not given
+	defaultActivityDelay = 500 * time.Millisecond
+
+	// maxActivityDelay bounds delay_ms, since the server answers nothing
+	// else while it waits
+	maxActivityDelay = 30 * time.Second
+)
+
+// activityResult is the structured content of is_active
+type activityResult struct {
+	Active  bool `json:"active"`
+	DelayMs int  `json:"delay_ms"`
+	Added   int  `json:"added"`
+	Removed int  `json:"removed"`
+}
+
+// isActive handles the is_active tool: it captures the pane twice, delay_ms
+// apart, and reports whether anything changed in between
+func (s *Server) isActive(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	delayMs, err := intArgument(arguments, "delay_ms", int(defaultActivityDelay/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	delay := time.Duration(delayMs) * time.Millisecond
+	if delay <= 0 || delay > maxActivityDelay {
+		return nil, invalidParams(fmt.Sprintf("delay_ms must be between 1 and %d", maxActivityDelay.Milliseconds()),
+			paramError{Field: "delay_ms", Expected: fmt.Sprintf("number of milliseconds from 1 to %d", maxActivityDelay.Milliseconds())})
+	}
+
+	opts := tmux.CaptureOptions{Target: target}
+	before, err := s.tmuxManager.CapturePaneWithOptions(opts)
+	if err != nil {
+		return toolError(err)
+	}
+	time.Sleep(delay)
+	after, err := s.tmuxManager.CapturePaneWithOptions(opts)
+	if err != nil {
+		return toolError(err)
+	}
+
+	_, added, removed := unifiedDiff(splitLines(trimContent(before)), splitLines(trimContent(after)), 0)
+	result := activityResult{
+		Active:  added > 0 || removed > 0,
+		DelayMs: int(delay.Milliseconds()),
+		Added:   added,
+		Removed: removed,
+	}
+	text := fmt.Sprintf("idle: no change in %dms", result.DelayMs)
+	if result.Active {
+		text = fmt.Sprintf("active: +%d -%d lines in %dms", added, removed, result.DelayMs)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}, nil
+}
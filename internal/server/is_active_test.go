@@ -0,0 +1,70 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_IsActive(t *testing.T) {
+	tests := []struct {
+		name     string
+		captures []string
+		want     activityResult
+		wantText string
+	}{
+		{
+			name:     "new output",
+			captures: []string{"$ make\nbuilding a\n\n", "$ make\nbuilding a\nbuilding b\nbuilding c\n"},
+			want:     activityResult{Active: true, DelayMs: 10, Added: 2},
+			wantText: "active: +2 -0 lines in 10ms",
+		},
+		{
+			name:     "progress redrawn",
+			captures: []string{"$ fetch\n 40%\n", "$ fetch\n 55%\n"},
+			want:     activityResult{Active: true, DelayMs: 10, Added: 1, Removed: 1},
+			wantText: "active: +1 -1 lines in 10ms",
+		},
+		{
+			name:     "idle",
+			captures: []string{"$ make\ndone\n$ \n", "$ make\ndone\n$ \n\n"},
+			want:     activityResult{DelayMs: 10},
+			wantText: "idle: no change in 10ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captures := tt.captures
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				if args[0] == "capture-pane" {
+					out := captures[0]
+					captures = captures[1:]
+					return out, "", nil
+				}
+				return "", "", nil
+			})
+
+			response := callFakeTool(srv, "is_active", map[string]interface{}{"delay_ms": float64(10)})
+			if text := toolText(t, response); text != tt.wantText {
+				t.Errorf("is_active text = %q, want %q", text, tt.wantText)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "is_active").OutputSchema, result.StructuredContent)
+			if got := result.StructuredContent.(activityResult); got != tt.want {
+				t.Errorf("is_active structuredContent = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_IsActive_InvalidDelay(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+
+	for _, delay := range []float64{0, -5, 30001} {
+		response := callFakeTool(srv, "is_active", map[string]interface{}{"delay_ms": delay})
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("is_active(delay_ms=%v) error = %+v, want invalid params", delay, response.Error)
+		}
+	}
+}
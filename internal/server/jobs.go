@@ -0,0 +1,332 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const (
+	// jobsTimeout bounds the wait for the shell to list its jobs
+	jobsTimeout = 5 * time.Second
+
+	// defaultJobWait is how long wait_job waits unless told otherwise;
+	// maxJobWait is the longest it may be told to
+	defaultJobWait = time.Minute
+	maxJobWait     = 10 * time.Minute
+
+	// defaultJobPoll is the delay between listings in wait_job. Each one
+	// types a command into the pane, so it is much longer than the poll
+	// interval for captures.
+	defaultJobPoll = time.Second
+)
+
+// defaultJobsCommands are the commands that list background jobs in each
+// shell known to have job control, used unless --jobs-command sets one
+var defaultJobsCommands = map[string]string{
+	"bash": "jobs -l", "zsh": "jobs -l", "ksh": "jobs -l", "mksh": "jobs -l",
+	"sh": "jobs -l", "dash": "jobs -l", "tcsh": "jobs -l", "csh": "jobs -l",
+	"fish": "jobs",
+}
+
+var (
+	// jobLine matches a job in the bracketed format of bash, zsh, ksh,
+	// dash and tcsh, e.g. "[1]+ 4242 Running  sleep 100 &". The process ID
+	// is only listed with -l, and a finished job's state may carry its
+	// exit status ("Exit 1").
+	jobLine = regexp.MustCompile(`^\[(\d+)\]\s*[+-]?\s*(?:(\d+)\s+)?([A-Za-z]+(?: \d+)?)\s+(.*)$`)
+	// fishJobLine matches a row of fish's jobs table: job, group, CPU,
+	// state and command separated by tabs
+	fishJobLine = regexp.MustCompile(`^(\d+)\t(\d+)\t[^\t]*\t(\S+)\t(.*)$`)
+)
+
+// job is one background job listed by the shell
+type job struct {
+	ID      int    `json:"id"`
+	PID     int    `json:"pid,omitempty"`
+	State   string `json:"state"`
+	Command string `json:"command"`
+	// Running is set while the job is running; stopped and finished jobs
+	// are listed but not running
+	Running bool `json:"running"`
+}
+
+// jobsResult is the structured content of jobs
+type jobsResult struct {
+	Shell       string `json:"shell"`
+	JobsCommand string `json:"jobs_command"`
+	Jobs        []job  `json:"jobs"`
+	Running     int    `json:"running"`
+}
+
+// waitJobResult is the structured content of wait_job
+type waitJobResult struct {
+	jobsResult
+	// Finished is set once no job, or the requested job, is running
+	Finished  bool `json:"finished"`
+	WaitedMs  int  `json:"waited_ms"`
+	Cancelled bool `json:"cancelled"`
+}
+
+// parseJobs reads the jobs listed in the output of a jobs command. Lines
+// it doesn't recognise, such as fish's table header or the continuation
+// lines bash prints for pipelines, are skipped.
+func parseJobs(output string) []job {
+	jobs := []job{}
+	for _, line := range splitLines(output) {
+		line = strings.TrimRight(line, " ")
+		var id, pid, state, command string
+		if m := jobLine.FindStringSubmatch(line); m != nil {
+			id, pid, state, command = m[1], m[2], m[3], m[4]
+		} else if m := fishJobLine.FindStringSubmatch(line); m != nil {
+			id, pid, state, command = m[1], m[2], m[3], m[4]
+		} else {
+			continue
+		}
+		j := job{State: state, Command: strings.TrimSpace(command)}
+		j.ID, _ = strconv.Atoi(id)
+		j.PID, _ = strconv.Atoi(pid)
+		j.Running = strings.EqualFold(state, "running")
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+// jobsCommand returns the command that lists jobs in shell
+func (s *Server) jobsCommand(shell string) (string, bool) {
+	if command, ok := s.jobsCommands[shell]; ok {
+		return command, true
+	}
+	command, ok := defaultJobsCommands[shell]
+	return command, ok
+}
+
+// listJobs types the jobs command for the pane's shell at its prompt and
+// parses what it prints. shell names the shell when the pane's own
+// process isn't it, e.g. behind ssh. A non-nil result reports an error to
+// the client.
+func (s *Server) listJobs(target tmux.Target, shell string) (*jobsResult, *mcp.CallToolResult, error) {
+	if shell == "" {
+		command, err := s.tmuxManager.CurrentCommand(target)
+		if err != nil {
+			result, err := toolError(err)
+			return nil, result, err
+		}
+		if shell = shellName(command); shell == "" {
+			return nil, &mcp.CallToolResult{
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: the pane is running %s, not a shell; pass shell to name the shell at its prompt, e.g. behind ssh", command)}},
+				IsError: true,
+			}, nil
+		}
+	}
+	jobsCmd, ok := s.jobsCommand(shell)
+	if !ok {
+		return nil, &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: no jobs command is known for %s; set one with --jobs-command %s=COMMAND", shell, shell)}},
+			IsError: true,
+		}, nil
+	}
+
+	run, err := s.tmuxManager.RunCommand(jobsCmd, tmux.RunOptions{
+		Prompt:       s.promptRegex,
+		Detector:     s.promptDetector,
+		Timeout:      jobsTimeout,
+		PollInterval: s.pollInterval,
+		Target:       target,
+	})
+	if err != nil {
+		result, err := toolError(err)
+		return nil, result, err
+	}
+	if !run.Completed {
+		return nil, &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: the shell did not return to its prompt after %q; is the pane busy?", jobsCmd)}},
+			IsError: true,
+		}, nil
+	}
+
+	result := &jobsResult{Shell: shell, JobsCommand: jobsCmd, Jobs: parseJobs(run.Output)}
+	for _, j := range result.Jobs {
+		if j.Running {
+			result.Running++
+		}
+	}
+	return result, nil, nil
+}
+
+// text lists the jobs one per line
+func (r *jobsResult) text() string {
+	if len(r.Jobs) == 0 {
+		return "no background jobs"
+	}
+	lines := make([]string, 0, len(r.Jobs))
+	for _, j := range r.Jobs {
+		line := fmt.Sprintf("[%d] %s %s", j.ID, j.State, j.Command)
+		if j.PID != 0 {
+			line = fmt.Sprintf("[%d] %d %s %s", j.ID, j.PID, j.State, j.Command)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jobs handles the jobs tool: it lists the background jobs of the shell
+// in a pane by typing the shell's jobs command at its prompt. A command
+// started with & returns to the prompt at once, so run_command reports it
+// finished while it is still running; this shows what is.
+func (s *Server) jobs(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("jobs"); result != nil {
+		return result, nil
+	}
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	shell, _ := arguments["shell"].(string)
+
+	result, errResult, err := s.listJobs(target, shell)
+	if result == nil {
+		return errResult, err
+	}
+	text := result.text()
+	if result.Running > 0 {
+		text = appendNote(text, fmt.Sprintf("%d running", result.Running))
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}, nil
+}
+
+// waitJob handles the wait_job tool: it lists the shell's jobs until none
+// is running, or the one given by job isn't, and reports the last
+// listing. Stopped jobs don't count as running, since they never finish
+// unless resumed. The wait ends early if the client cancels the request.
+func (s *Server) waitJob(arguments map[string]interface{}, id interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("wait_job"); result != nil {
+		return result, nil
+	}
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	shell, _ := arguments["shell"].(string)
+	jobID, err := intArgument(arguments, "job", 0)
+	if err != nil {
+		return nil, err
+	}
+	if jobID < 0 {
+		return nil, invalidParams(fmt.Sprintf("job must be positive, got %d", jobID),
+			paramError{Field: "job", Expected: "job number, as in %1"})
+	}
+	timeoutMs, err := intArgument(arguments, "timeout_ms", int(defaultJobWait/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	if timeout < 0 || timeout > maxJobWait {
+		return nil, invalidParams(fmt.Sprintf("timeout_ms must be between 0 and %d", maxJobWait.Milliseconds()),
+			paramError{Field: "timeout_ms", Expected: fmt.Sprintf("number of milliseconds from 0 to %d", maxJobWait.Milliseconds())})
+	}
+	pollMs, err := intArgument(arguments, "poll_ms", int(defaultJobPoll/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	pollInterval := time.Duration(pollMs) * time.Millisecond
+	if err := tmux.ValidatePollInterval(pollInterval); err != nil {
+		return nil, invalidParams(err.Error(),
+			paramError{Field: "poll_ms", Expected: fmt.Sprintf("number of milliseconds, at least %d", tmux.MinPollInterval.Milliseconds())})
+	}
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	var result waitJobResult
+	for {
+		listed, errResult, err := s.listJobs(target, shell)
+		if listed == nil {
+			return errResult, err
+		}
+		result.jobsResult = *listed
+		result.Finished = !jobRunning(listed.Jobs, jobID)
+		if result.Finished || !time.Now().Before(deadline) {
+			break
+		}
+		if result.Cancelled = s.cancellations.cancelled(id); result.Cancelled {
+			break
+		}
+		time.Sleep(min(pollInterval, time.Until(deadline)))
+	}
+	result.WaitedMs = int(time.Since(start) / time.Millisecond)
+
+	var note string
+	switch {
+	case result.Finished && jobID != 0:
+		note = fmt.Sprintf("job %d is not running after %dms", jobID, result.WaitedMs)
+	case result.Finished:
+		note = fmt.Sprintf("no jobs running after %dms", result.WaitedMs)
+	case result.Cancelled:
+		note = fmt.Sprintf("cancelled after %dms with %d still running", result.WaitedMs, result.Running)
+	default:
+		note = fmt.Sprintf("timed out after %dms with %d still running", result.WaitedMs, result.Running)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: appendNote(result.text(), note)}},
+		StructuredContent: result,
+	}, nil
+}
+
+// jobRunning reports whether job id, or any job when id is 0, is running
+func jobRunning(jobs []job, id int) bool {
+	for _, j := range jobs {
+		if j.Running && (id == 0 || j.ID == id) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseJobsCommand splits a --jobs-command value of the form
+// SHELL=COMMAND and checks that the command can be typed at a prompt
+func ParseJobsCommand(value string) (shell, command string, err error) {
+	shell, command, ok := strings.Cut(value, "=")
+	shell, command = strings.TrimSpace(shell), strings.TrimSpace(command)
+	if !ok || shell == "" || command == "" {
+		return "", "", fmt.Errorf("%q is not of the form SHELL=COMMAND, e.g. \"bash=jobs -l\"", value)
+	}
+	if err := tmux.ValidateCommandLine(command); err != nil {
+		return "", "", err
+	}
+	return shell, command, nil
+}
+
+// jobsShells lists the shells jobs knows a command for, for the tool
+// description
+func jobsShells() string {
+	shells := make([]string, 0, len(defaultJobsCommands))
+	for shell := range defaultJobsCommands {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	return strings.Join(shells, ", ")
+}
+
+// jobsOutputProperties returns the output schema properties of jobs,
+// adding extra for tools that return more
+func jobsOutputProperties(extra map[string]mcp.Property) map[string]mcp.Property {
+	properties := map[string]mcp.Property{
+		"shell":        {Type: "string", Description: "The shell whose jobs were listed"},
+		"jobs_command": {Type: "string", Description: "The command typed to list them"},
+		"jobs":         {Type: "array", Description: "Jobs as objects with id, pid (when the command lists it), state as the shell words it (e.g. \"Running\", \"Stopped\", \"Done\", \"Exit 1\"), command and running"},
+		"running":      {Type: "integer", Description: "How many of the jobs are running"},
+	}
+	for name, property := range extra {
+		properties[name] = property
+	}
+	return properties
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestParseJobs(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []job
+	}{
+		{
+			name:   "bash",
+			output: "[1]-  4242 Running                 sleep 100 &\n[2]+  4250 Stopped                 vim notes\n[3]   4260 Exit 1                  false",
+			want: []job{
+				{ID: 1, PID: 4242, State: "Running", Command: "sleep 100 &", Running: true},
+				{ID: 2, PID: 4250, State: "Stopped", Command: "vim notes"},
+				{ID: 3, PID: 4260, State: "Exit 1", Command: "false"},
+			},
+		},
+		{
+			name:   "bash pipeline",
+			output: "[1]+  4242 Running                 make 2>&1\n      4243                       | tee build.log &",
+			want:   []job{{ID: 1, PID: 4242, State: "Running", Command: "make 2>&1", Running: true}},
+		},
+		{
+			name:   "zsh",
+			output: "[1]  + 4242 running    sleep 100\n[2]  - 4250 suspended  vim",
+			want: []job{
+				{ID: 1, PID: 4242, State: "running", Command: "sleep 100", Running: true},
+				{ID: 2, PID: 4250, State: "suspended", Command: "vim"},
+			},
+		},
+		{
+			name:   "without process IDs",
+			output: "[1]+  Done                    make",
+			want:   []job{{ID: 1, State: "Done", Command: "make"}},
+		},
+		{
+			name:   "fish",
+			output: "Job\tGroup\tCPU\tState\tCommand\n1\t4242\t0%\trunning\tsleep 100 &",
+			want:   []job{{ID: 1, PID: 4242, State: "running", Command: "sleep 100 &", Running: true}},
+		},
+		{
+			name:   "no jobs",
+			output: "",
+			want:   []job{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseJobs(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseJobs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newFakeJobsServer fakes a pane running shell that answers each jobs
+// command typed at its prompt with the next of listings, repeating the
+// last. It records the lines typed.
+func newFakeJobsServer(shell string, listings []string, typed *[]string) *Server {
+	screen := "$ \n"
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			return shell + "\n", "", nil
+		case "send-keys":
+			if args[len(args)-2] == "--" {
+				*typed = append(*typed, args[len(args)-1])
+				listing := listings[0]
+				if len(listings) > 1 {
+					listings = listings[1:]
+				}
+				screen = screen[:len(screen)-1] + args[len(args)-1] + "\n" + listing + "$ \n"
+			}
+		case "capture-pane":
+			return screen, "", nil
+		}
+		return "", "", nil
+	})
+	srv.writesEnabled = true
+	return srv
+}
+
+func TestServer_callTool_Jobs(t *testing.T) {
+	var typed []string
+	srv := newFakeJobsServer("bash", []string{"[1]+  4242 Running                 sleep 100 &\n"}, &typed)
+
+	response := callFakeTool(srv, "jobs", map[string]interface{}{})
+	if text := toolText(t, response); text != "[1] 4242 Running sleep 100 &\n[1 running]" {
+		t.Errorf("jobs text = %q", text)
+	}
+	if !reflect.DeepEqual(typed, []string{"jobs -l"}) {
+		t.Errorf("typed %q, want jobs -l", typed)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "jobs").OutputSchema, result.StructuredContent)
+	if got := result.StructuredContent.(*jobsResult); got.Shell != "bash" || got.Running != 1 {
+		t.Errorf("structured content = %+v, want one running bash job", got)
+	}
+}
+
+func TestServer_callTool_Jobs_Shell(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		arguments map[string]interface{}
+		commands  map[string]string
+		wantTyped string
+		wantError string
+	}{
+		{name: "fish", command: "fish", wantTyped: "jobs"},
+		{name: "configured", command: "nu", commands: map[string]string{"nu": "job list"}, wantTyped: "job list"},
+		{name: "override", command: "bash", commands: map[string]string{"bash": "jobs -lr"}, wantTyped: "jobs -lr"},
+		{name: "behind ssh", command: "ssh", arguments: map[string]interface{}{"shell": "zsh"}, wantTyped: "jobs -l"},
+		{name: "not a shell", command: "vim", wantError: "Error: the pane is running vim, not a shell; pass shell to name the shell at its prompt, e.g. behind ssh"},
+		{name: "unknown shell", command: "nu", wantError: "Error: no jobs command is known for nu; set one with --jobs-command nu=COMMAND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var typed []string
+			srv := newFakeJobsServer(tt.command, []string{""}, &typed)
+			WithJobsCommands(tt.commands)(srv)
+			arguments := tt.arguments
+			if arguments == nil {
+				arguments = map[string]interface{}{}
+			}
+
+			response := callFakeTool(srv, "jobs", arguments)
+			text := toolText(t, response)
+			if tt.wantError != "" {
+				if text != tt.wantError || len(typed) != 0 {
+					t.Errorf("jobs = %q after typing %q, want %q and nothing typed", text, typed, tt.wantError)
+				}
+				return
+			}
+			if text != "no background jobs" {
+				t.Errorf("jobs text = %q", text)
+			}
+			if len(typed) != 1 || typed[0] != tt.wantTyped {
+				t.Errorf("typed %q, want %q", typed, tt.wantTyped)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_WaitJob(t *testing.T) {
+	var typed []string
+	srv := newFakeJobsServer("bash", []string{
+		"[1]-  4242 Running                 make &\n[2]+  4250 Running                 sleep 100 &\n",
+		"[1]-  4242 Done                    make\n[2]+  4250 Running                 sleep 100 &\n",
+	}, &typed)
+
+	response := callFakeTool(srv, "wait_job", map[string]interface{}{"job": 1, "poll_ms": 10})
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "wait_job").OutputSchema, result.StructuredContent)
+	got := result.StructuredContent.(waitJobResult)
+	if !got.Finished || got.Running != 1 || len(typed) != 2 {
+		t.Errorf("wait_job = %+v after %d listings, want job 1 finished on the second", got, len(typed))
+	}
+
+	// Job 2 never finishes
+	typed = nil
+	response = callFakeTool(srv, "wait_job", map[string]interface{}{"timeout_ms": 50, "poll_ms": 10})
+	got = response.Result.(*mcp.CallToolResult).StructuredContent.(waitJobResult)
+	if got.Finished || got.Cancelled || len(typed) < 2 {
+		t.Errorf("wait_job = %+v after %d listings, want a timeout", got, len(typed))
+	}
+}
+
+func TestServer_callTool_Jobs_ReadOnly(t *testing.T) {
+	for _, tool := range []string{"jobs", "wait_job"} {
+		var typed []string
+		srv := newFakeJobsServer("bash", []string{""}, &typed)
+		srv.writesEnabled = false
+
+		result := callFakeTool(srv, tool, map[string]interface{}{}).Result.(*mcp.CallToolResult)
+		if !result.IsError || len(typed) != 0 {
+			t.Errorf("%s with writes disabled = %+v after typing %q, want an error", tool, result, typed)
+		}
+	}
+}
+
+func TestParseJobsCommand(t *testing.T) {
+	shell, command, err := ParseJobsCommand("nu = job list")
+	if err != nil || shell != "nu" || command != "job list" {
+		t.Errorf("ParseJobsCommand() = (%q, %q, %v)", shell, command, err)
+	}
+	for _, value := range []string{"jobs -l", "=jobs", "bash=", "bash=jobs\nrm -rf /"} {
+		if _, _, err := ParseJobsCommand(value); err == nil {
+			t.Errorf("ParseJobsCommand(%q) succeeded, want an error", value)
+		}
+	}
+}
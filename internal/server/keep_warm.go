@@ -0,0 +1,51 @@
+package server
+
+import (
+	"log"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// keepWarm presses Enter in the bound session's active pane if it shows an
+// idle shell prompt with nothing typed at it, so a shell with TMOUT set
+// doesn't log out during a long gap between requests. An empty line is the
+// smallest input that resets TMOUT, and shells leave it out of their
+// history. Nothing is sent in read-only mode, to a pane running something
+// other than a shell, or while a command is running. It reports whether
+// Enter was sent.
+func (s *Server) keepWarm() (bool, error) {
+	if !s.writesEnabled {
+		return false, nil
+	}
+	command, err := s.tmuxManager.CurrentCommand(tmux.Target{})
+	if err != nil {
+		return false, err
+	}
+	if shellName(command) == "" {
+		return false, nil
+	}
+	content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{
+		EscapeSequences: true,
+		KeepPromptMarks: true,
+	})
+	if err != nil {
+		return false, err
+	}
+	if line, ok := lastNonBlankLine(content); !ok || !s.detector().IsPrompt(line) {
+		return false, nil
+	}
+	if err := s.tmuxManager.SendKeys("Enter"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// logKeepWarm logs what keepWarm did, staying quiet when it had nothing to
+// do
+func (s *Server) logKeepWarm(sent bool, err error) {
+	if err != nil {
+		log.Printf("Keep-warm failed: %v", err)
+	} else if sent {
+		log.Printf("Keep-warm: pressed Enter at the idle prompt in session %s", s.tmuxManager.SessionName())
+	}
+}
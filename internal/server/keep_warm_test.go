@@ -0,0 +1,88 @@
+package server
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestServer_keepWarm(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		screen   string
+		writes   bool
+		wantSent bool
+	}{
+		{name: "idle prompt", command: "bash", screen: "$ ls\nfile\n$ \n", writes: true, wantSent: true},
+		{name: "read-only", command: "bash", screen: "$ \n", writes: false},
+		{name: "command running", command: "bash", screen: "$ make\nbuilding...\n", writes: true},
+		{name: "something typed", command: "bash", screen: "$ rm -rf bu\n", writes: true},
+		{name: "not a shell", command: "python3", screen: ">>> \n", writes: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "display-message":
+					return tt.command + "\n", "", nil
+				case "capture-pane":
+					return tt.screen, "", nil
+				case "send-keys":
+					sent = args
+				}
+				return "", "", nil
+			})
+			srv.writesEnabled = tt.writes
+
+			got, err := srv.keepWarm()
+			if err != nil {
+				t.Fatalf("keepWarm() error = %v", err)
+			}
+			if got != tt.wantSent || (sent != nil) != tt.wantSent {
+				t.Fatalf("keepWarm() = %v after sending %q, want sent: %v", got, sent, tt.wantSent)
+			}
+			if tt.wantSent && sent[len(sent)-1] != "Enter" {
+				t.Errorf("send-keys = %q, want Enter alone", sent)
+			}
+		})
+	}
+}
+
+func TestServer_Start_KeepWarm(t *testing.T) {
+	pressed := make(chan struct{}, 1)
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			return "zsh\n", "", nil
+		case "capture-pane":
+			return "% \n", "", nil
+		case "send-keys":
+			select {
+			case pressed <- struct{}{}:
+			default:
+			}
+		}
+		return "", "", nil
+	})
+	reader, writer := io.Pipe()
+	srv.reader = reader
+	srv.writer = io.Discard
+	srv.writesEnabled = true
+	WithKeepWarm(10 * time.Millisecond)(srv)
+
+	errs := make(chan error, 1)
+	go func() { errs <- srv.Start() }()
+
+	select {
+	case <-pressed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("keep-warm never pressed Enter")
+	}
+	writer.Close()
+	if err := <-errs; err != nil {
+		t.Errorf("Start() error = %v, want nil", err)
+	}
+}
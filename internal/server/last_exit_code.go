@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/ansi"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// Sources of the exit status reported by last_exit_code
+const (
+	exitSourceOSC133 = "osc133"
+	exitSourceShell  = "shell"
+)
+
+// defaultExitQueryTimeout bounds the wait for the shell's answer when
+// last_exit_code asks it; the echo returns at once from an idle prompt
+const defaultExitQueryTimeout = 5 * time.Second
+
+// posixShells are the shells whose syntax the command last_exit_code types
+// is written in
+var posixShells = map[string]bool{
+	"bash": true, "zsh": true, "sh": true, "dash": true, "ksh": true, "mksh": true,
+}
+
+// exitCodeResult is the structured content of last_exit_code
+type exitCodeResult struct {
+	// ExitCode is null when the status could not be found
+	ExitCode *int `json:"exit_code"`
+	// Source says where the status came from, empty when it wasn't found
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// lastExitCode handles the last_exit_code tool: it reports the exit status
+// of the last command run at the pane's shell. The status in the OSC 133
+// command-finished marker is read when the shell prints one, which sends
+// nothing to the pane. Otherwise, unless osc_only is set, the shell is
+// asked by typing a command that echoes $? and then restores it.
+func (s *Server) lastExitCode(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	oscOnly, _ := arguments["osc_only"].(bool)
+	timeoutMs, err := intArgument(arguments, "timeout_ms", int(defaultExitQueryTimeout/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	pollInterval, err := s.pollIntervalArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{
+		Target:          target,
+		EscapeSequences: true,
+		KeepPromptMarks: true,
+	})
+	if err != nil {
+		return toolError(err)
+	}
+	if status, ok := tmux.LastExitStatus(content); ok {
+		return exitCodeResult{ExitCode: &status, Source: exitSourceOSC133}.toolResult(), nil
+	}
+	if oscOnly {
+		return exitCodeResult{Note: "no OSC 133 exit status in the pane; the shell may lack terminal integration, or a command is still running"}.toolResult(), nil
+	}
+
+	if result := s.requireWrites("last_exit_code"); result != nil {
+		result.Content[0].Text += "; without it, last_exit_code can only read OSC 133 markers, and the pane has none with an exit status"
+		return result, nil
+	}
+	command, err := s.tmuxManager.CurrentCommand(target)
+	if err != nil {
+		return toolError(err)
+	}
+	if shell := shellName(command); !posixShells[shell] {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: the pane is running %s; last_exit_code can only ask a POSIX shell (bash, zsh, sh, dash, ksh) for $?", command)}},
+			IsError: true,
+		}, nil
+	}
+	if line, ok := lastNonBlankLine(content); !ok || !s.detector().IsPrompt(line) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: "Error: the pane is not at a shell prompt; a command may still be running"}},
+			IsError: true,
+		}, nil
+	}
+
+	echoed, err := s.tmuxManager.EchoExitStatus(tmux.RunOptions{
+		Prompt:       s.promptRegex,
+		Detector:     s.promptDetector,
+		Timeout:      time.Duration(timeoutMs) * time.Millisecond,
+		PollInterval: pollInterval,
+		ExitSentinel: s.exitSentinel,
+		Target:       target,
+	})
+	if err != nil {
+		return toolError(err)
+	}
+	result := exitCodeResult{ExitCode: echoed.ExitCode, Source: exitSourceShell}
+	if echoed.ExitCode == nil {
+		result.Source = ""
+		result.Note = "the shell did not echo its exit status before the timeout"
+	}
+	return result.toolResult(), nil
+}
+
+// lastNonBlankLine returns the last line of content with visible text,
+// escape sequences included
+func lastNonBlankLine(content string) (string, bool) {
+	lines := splitLines(content)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(ansi.Strip(lines[i])) != "" {
+			return lines[i], true
+		}
+	}
+	return "", false
+}
+
+// toolResult renders the result as a tool result
+func (r exitCodeResult) toolResult() *mcp.CallToolResult {
+	text := "exit code unknown"
+	switch r.Source {
+	case exitSourceOSC133:
+		text = fmt.Sprintf("exit code: %d (from the shell's OSC 133 marker)", *r.ExitCode)
+	case exitSourceShell:
+		text = fmt.Sprintf("exit code: %d (echoed by the shell)", *r.ExitCode)
+	}
+	if r.Note != "" {
+		text = appendNote(text, r.Note)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: r,
+	}
+}
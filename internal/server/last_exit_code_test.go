@@ -0,0 +1,130 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// newFakeExitServer fakes a pane running command whose capture is screen
+// until something is typed, after which the shell echoes status. typed
+// records the line typed.
+func newFakeExitServer(command, screen, status string, typed *string) *Server {
+	return newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			return command + "\n", "", nil
+		case "send-keys":
+			if args[len(args)-2] == "--" {
+				*typed = args[len(args)-1]
+			}
+		case "capture-pane":
+			if *typed == "" {
+				return screen, "", nil
+			}
+			return screen + *typed + "\n__EXIT__" + status + "\n$ \n", "", nil
+		}
+		return "", "", nil
+	})
+}
+
+func TestServer_callTool_LastExitCode(t *testing.T) {
+	osc := func(mark string) string { return "\x1b]133;" + mark + "\x07" }
+	prompt := osc("A") + "$ " + osc("B")
+
+	tests := []struct {
+		name       string
+		command    string
+		screen     string
+		arguments  map[string]interface{}
+		writes     bool
+		wantCode   int
+		wantFound  bool
+		wantSource string
+		wantTyped  bool
+		wantError  string
+	}{
+		{
+			name:       "from OSC 133 in read-only mode",
+			command:    "bash",
+			screen:     prompt + "false" + osc("C") + "\n" + osc("D;1") + prompt + "\n",
+			wantCode:   1,
+			wantFound:  true,
+			wantSource: exitSourceOSC133,
+		},
+		{
+			name:       "asks the shell",
+			command:    "zsh",
+			screen:     "$ false\n$ ",
+			writes:     true,
+			wantCode:   2,
+			wantFound:  true,
+			wantSource: exitSourceShell,
+			wantTyped:  true,
+		},
+		{
+			name:      "osc_only never types",
+			command:   "bash",
+			screen:    "$ false\n$ ",
+			arguments: map[string]interface{}{"osc_only": true},
+			writes:    true,
+		},
+		{
+			name:      "asking needs writes",
+			command:   "bash",
+			screen:    "$ ",
+			wantError: "--allow-writes",
+		},
+		{
+			name:      "not a POSIX shell",
+			command:   "fish",
+			screen:    "> ",
+			writes:    true,
+			wantError: "POSIX shell",
+		},
+		{
+			name:      "command still running",
+			command:   "bash",
+			screen:    "$ make\nbuilding...\n",
+			writes:    true,
+			wantError: "not at a shell prompt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var typed string
+			srv := newFakeExitServer(tt.command, tt.screen, "2", &typed)
+			srv.writesEnabled = tt.writes
+
+			arguments := map[string]interface{}{"poll_ms": float64(10)}
+			for name, value := range tt.arguments {
+				arguments[name] = value
+			}
+			response := callFakeTool(srv, "last_exit_code", arguments)
+			result := response.Result.(*mcp.CallToolResult)
+			if tt.wantError != "" {
+				if text := toolText(t, response); !result.IsError || !strings.Contains(text, tt.wantError) {
+					t.Errorf("last_exit_code = %q, want an error mentioning %q", text, tt.wantError)
+				}
+				if typed != "" {
+					t.Errorf("typed %q, want nothing typed", typed)
+				}
+				return
+			}
+
+			validateStructuredContent(t, findTool(t, srv, "last_exit_code").OutputSchema, result.StructuredContent)
+			got := result.StructuredContent.(exitCodeResult)
+			if found := got.ExitCode != nil; found != tt.wantFound || (found && *got.ExitCode != tt.wantCode) {
+				t.Errorf("exit_code = %v, want %d (found: %v)", got.ExitCode, tt.wantCode, tt.wantFound)
+			}
+			if got.Source != tt.wantSource {
+				t.Errorf("source = %q, want %q", got.Source, tt.wantSource)
+			}
+			if (typed != "") != tt.wantTyped {
+				t.Errorf("typed %q, want typing: %v", typed, tt.wantTyped)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// windowLayout is the content of the terminal://layout resource
+type windowLayout struct {
+	// Layout is tmux's #{window_layout} string the tree was parsed from
+	Layout string     `json:"layout"`
+	Root   layoutNode `json:"root"`
+}
+
+// layoutNode is one cell of the layout tree: a pane, identified by PaneID,
+// or a region split left-right or top-bottom among Children
+type layoutNode struct {
+	Width    int          `json:"width"`
+	Height   int          `json:"height"`
+	X        int          `json:"x"`
+	Y        int          `json:"y"`
+	Split    string       `json:"split,omitempty"`
+	Children []layoutNode `json:"children,omitempty"`
+	PaneID   string       `json:"pane_id,omitempty"`
+}
+
+// newLayoutNode converts a parsed tmux layout cell and its children
+func newLayoutNode(cell tmux.LayoutCell) layoutNode {
+	node := layoutNode{
+		Width:  cell.Width,
+		Height: cell.Height,
+		X:      cell.X,
+		Y:      cell.Y,
+		Split:  cell.Split,
+		PaneID: cell.PaneID,
+	}
+	for _, child := range cell.Children {
+		node.Children = append(node.Children, newLayoutNode(child))
+	}
+	return node
+}
+
+// readLayout reads the terminal://layout resource: the layout of the
+// window holding the default pane, as a JSON tree
+func (s *Server) readLayout(uri string) (*mcp.ReadResourceResult, error) {
+	root, raw, err := s.tmuxManager.WindowLayout(tmux.Target{})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(windowLayout{Layout: raw, Root: newLayoutNode(root)}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode layout: %w", err)
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []mcp.ResourceContent{
+			{
+				URI:      uri,
+				MimeType: "application/json",
+				Text:     string(data),
+			},
+		},
+	}, nil
+}
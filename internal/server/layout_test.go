@@ -0,0 +1,46 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_readResource_Layout(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "display-message" {
+			return "1780,80x24,0,0{40x24,0,0,1,39x24,41,0[39x12,41,0,2,39x11,41,13,3]}\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := srv.handleRequest(&mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "resources/read",
+		Params:  map[string]interface{}{"uri": "terminal://layout"},
+	})
+	if response.Error != nil {
+		t.Fatalf("response.Error = %v, want nil", response.Error)
+	}
+	content := response.Result.(*mcp.ReadResourceResult).Contents[0]
+	if content.MimeType != "application/json" {
+		t.Errorf("MimeType = %q, want application/json", content.MimeType)
+	}
+
+	var got windowLayout
+	if err := json.Unmarshal([]byte(content.Text), &got); err != nil {
+		t.Fatalf("layout is not JSON: %v\n%s", err, content.Text)
+	}
+	if got.Root.Split != "left-right" || len(got.Root.Children) != 2 {
+		t.Fatalf("root = %+v, want a left-right split in two", got.Root)
+	}
+	right := got.Root.Children[1]
+	if right.Split != "top-bottom" || len(right.Children) != 2 {
+		t.Fatalf("right = %+v, want a top-bottom split in two", right)
+	}
+	if bottom := right.Children[1]; bottom.PaneID != "%3" || bottom.X != 41 || bottom.Y != 13 || bottom.Width != 39 || bottom.Height != 11 {
+		t.Errorf("bottom-right pane = %+v", bottom)
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxRequestBytes is the default limit on the size of one message
+// from the client
+const DefaultMaxRequestBytes = 1 << 20
+
+// ErrRequestTooLarge is matched (via errors.Is) by the error reported for a
+// message over the server's size limit
+var ErrRequestTooLarge = errors.New("request too large")
+
+// requestTooLargeError reports a message over Limit bytes
+type requestTooLargeError struct {
+	Limit int64
+}
+
+func (e *requestTooLargeError) Error() string {
+	return fmt.Sprintf("request exceeds the limit of %d bytes (--max-request-bytes)", e.Limit)
+}
+
+// Is makes errors.Is(err, ErrRequestTooLarge) succeed
+func (e *requestTooLargeError) Is(target error) bool {
+	return target == ErrRequestTooLarge
+}
+
+// messageLimiter bounds how far a json.Decoder may read ahead of the start
+// of the message it is decoding, so one huge message can't make it buffer
+// without limit. The decoder reads ahead into later messages, so the bound
+// is moved forward from the decoder's input offset after every message.
+type messageLimiter struct {
+	r io.Reader
+	// read is the number of bytes passed to the decoder and end the offset
+	// it may not read past
+	read int64
+	end  int64
+	max  int64
+}
+
+func (l *messageLimiter) Read(p []byte) (int, error) {
+	if l.read >= l.end {
+		return 0, &requestTooLargeError{Limit: l.max}
+	}
+	if remaining := l.end - l.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
+
+// next allows the message starting at offset to be read
+func (l *messageLimiter) next(offset int64) {
+	l.end = offset + l.max
+}
+
+// skipLine discards the line of the oversized message, reading what the
+// decoder had buffered first, and returns a reader for whatever follows.
+// The buffered input may start with the whitespace, newline included, that
+// ended the previous message, so that is skipped before looking for the
+// newline. Clients send one message per line, so this resynchronises after
+// an oversized message without holding the rest of it in memory.
+func skipLine(buffered, rest io.Reader) (io.Reader, error) {
+	reader := bufio.NewReader(io.MultiReader(buffered, rest))
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			break
+		}
+	}
+	for {
+		_, err := reader.ReadSlice('\n')
+		if err == nil {
+			return reader, nil
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+	}
+}
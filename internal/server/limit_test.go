@@ -0,0 +1,83 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_Start_MaxRequestBytes(t *testing.T) {
+	// An oversized message between two small ones, the second small one
+	// pretty-printed across lines, and a last oversized message cut off by
+	// the end of the input
+	huge := `{"jsonrpc":"2.0","id":9,"method":"ping","params":{"padding":"` + strings.Repeat("x", 1000) + `"}}`
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+		huge,
+		"{\n  \"jsonrpc\": \"2.0\",\n  \"id\": 2,\n  \"method\": \"ping\"\n}",
+		huge[:600],
+	}, "\n")
+	output := &bytes.Buffer{}
+
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	srv.reader = strings.NewReader(input)
+	srv.writer = output
+	WithMaxRequestBytes(256)(srv)
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error = %v, want nil", err)
+	}
+
+	var responses []mcp.JSONRPCResponse
+	decoder := json.NewDecoder(output)
+	for decoder.More() {
+		var response mcp.JSONRPCResponse
+		if err := decoder.Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		responses = append(responses, response)
+	}
+	if len(responses) != 4 {
+		t.Fatalf("got %d responses, want 4: %+v", len(responses), responses)
+	}
+	for i, wantID := range []interface{}{1.0, nil, 2.0, nil} {
+		response := responses[i]
+		if response.ID != wantID {
+			t.Errorf("response %d ID = %v, want %v", i, response.ID, wantID)
+		}
+		if wantID != nil {
+			if response.Error != nil {
+				t.Errorf("response %d error = %v, want nil", i, response.Error)
+			}
+			continue
+		}
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidRequest ||
+			!strings.Contains(response.Error.Message, "limit of 256 bytes") {
+			t.Errorf("response %d error = %+v, want an invalid request naming the limit", i, response.Error)
+		}
+	}
+}
+
+func TestServer_Start_MaxRequestBytesDisabled(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"padding":"` + strings.Repeat("x", 1000) + `"}}` + "\n"
+	output := &bytes.Buffer{}
+
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	srv.reader = strings.NewReader(input)
+	srv.writer = output
+	WithMaxRequestBytes(0)(srv)
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error = %v, want nil", err)
+	}
+	var response mcp.JSONRPCResponse
+	if err := json.Unmarshal(output.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Error != nil {
+		t.Errorf("response error = %v, want nil without a limit", response.Error)
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// keyBinding is one entry of list_keys
+type keyBinding struct {
+	Table   string `json:"table"`
+	Key     string `json:"key"`
+	Command string `json:"command"`
+	Repeat  bool   `json:"repeat,omitempty"`
+}
+
+// keyBindings is the structured content of list_keys
+type keyBindings struct {
+	Table    string       `json:"table,omitempty"`
+	Bindings []keyBinding `json:"bindings"`
+}
+
+// listKeys handles the list_keys tool: it reports tmux's key bindings, so
+// an agent can tell what a key does in the user's configuration before
+// sending it
+func (s *Server) listKeys(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	table, _ := arguments["table"].(string)
+	if strings.IndexFunc(table, func(r rune) bool { return r <= ' ' }) >= 0 || strings.HasPrefix(table, "-") {
+		return nil, invalidParams(fmt.Sprintf("invalid table %q", table),
+			paramError{Field: "table", Expected: "key table name, e.g. \"prefix\", \"root\" or \"copy-mode-vi\""})
+	}
+
+	bindings, err := s.tmuxManager.ListKeys(table)
+	if err != nil {
+		return toolError(err)
+	}
+
+	result := keyBindings{Table: table, Bindings: make([]keyBinding, 0, len(bindings))}
+	width := 0
+	for _, b := range bindings {
+		result.Bindings = append(result.Bindings, keyBinding(b))
+		width = max(width, len(b.Table)+1+len(b.Key))
+	}
+	lines := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		line := fmt.Sprintf("%-*s  %s", width, b.Table+" "+b.Key, b.Command)
+		if b.Repeat {
+			line += " (repeatable)"
+		}
+		lines = append(lines, line)
+	}
+	text := strings.Join(lines, "\n")
+	if len(lines) == 0 {
+		text = "no key bindings"
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}, nil
+}
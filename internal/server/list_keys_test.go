@@ -0,0 +1,32 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_ListKeys(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "list-keys" {
+			return "bind-key    -T prefix       c                 new-window\nbind-key -r -T prefix       Up                select-pane -U\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "list_keys", map[string]interface{}{"table": "prefix"})
+	want := "prefix c   new-window\nprefix Up  select-pane -U (repeatable)"
+	if text := toolText(t, response); text != want {
+		t.Errorf("list_keys text = %q, want %q", text, want)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "list_keys").OutputSchema, result.StructuredContent)
+	got := result.StructuredContent.(keyBindings)
+	if len(got.Bindings) != 2 || got.Bindings[1] != (keyBinding{Table: "prefix", Key: "Up", Command: "select-pane -U", Repeat: true}) {
+		t.Errorf("bindings = %+v", got.Bindings)
+	}
+
+	if response := callFakeTool(srv, "list_keys", map[string]interface{}{"table": "-a"}); response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("list_keys with table -a error = %+v, want -32602", response.Error)
+	}
+}
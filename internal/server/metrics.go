@@ -0,0 +1,190 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// maxLatencySamples bounds the latencies kept per tool. Percentiles are
+// computed over the most recent calls, so memory stays flat however long
+// the server runs.
+const maxLatencySamples = 512
+
+// metrics accumulates per-tool call statistics. A nil *metrics records
+// nothing, which is how collection is switched off.
+type metrics struct {
+	mu    sync.Mutex
+	tools map[string]*toolMetrics
+	// known holds the names from tools/list. Calls to any other name are
+	// counted under "unknown", so a client can't grow the map without bound.
+	known map[string]bool
+}
+
+// toolMetrics holds the statistics for one tool
+type toolMetrics struct {
+	calls         int64
+	errors        int64
+	capturedBytes int64
+	totalLatency  time.Duration
+	// latencies is a ring of the last maxLatencySamples call durations,
+	// with next the slot the following call overwrites
+	latencies []time.Duration
+	next      int
+}
+
+// toolStats is a point-in-time summary of one tool's metrics
+type toolStats struct {
+	Tool          string  `json:"tool"`
+	Calls         int64   `json:"calls"`
+	Errors        int64   `json:"errors"`
+	CapturedBytes int64   `json:"captured_bytes"`
+	P50Ms         float64 `json:"p50_ms"`
+	P95Ms         float64 `json:"p95_ms"`
+	// totalLatency feeds the Prometheus summary's _sum
+	totalLatency time.Duration
+}
+
+func newMetrics(tools []mcp.Tool) *metrics {
+	m := &metrics{tools: map[string]*toolMetrics{}, known: map[string]bool{}}
+	for _, tool := range tools {
+		m.known[tool.Name] = true
+	}
+	return m
+}
+
+// record adds one call of tool that took elapsed and returned captured
+// bytes of content
+func (m *metrics) record(tool string, elapsed time.Duration, failed bool, captured int) {
+	if m == nil {
+		return
+	}
+	if !m.known[tool] {
+		tool = "unknown"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.tools[tool]
+	if t == nil {
+		t = &toolMetrics{}
+		m.tools[tool] = t
+	}
+	t.calls++
+	if failed {
+		t.errors++
+	}
+	t.capturedBytes += int64(captured)
+	t.totalLatency += elapsed
+	if len(t.latencies) < maxLatencySamples {
+		t.latencies = append(t.latencies, elapsed)
+	} else {
+		t.latencies[t.next] = elapsed
+		t.next = (t.next + 1) % maxLatencySamples
+	}
+}
+
+// snapshot summarises every tool called so far, sorted by name
+func (m *metrics) snapshot() []toolStats {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make([]toolStats, 0, len(m.tools))
+	for name, t := range m.tools {
+		sorted := append([]time.Duration(nil), t.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats = append(stats, toolStats{
+			Tool:          name,
+			Calls:         t.calls,
+			Errors:        t.errors,
+			CapturedBytes: t.capturedBytes,
+			P50Ms:         milliseconds(percentile(sorted, 0.50)),
+			P95Ms:         milliseconds(percentile(sorted, 0.95)),
+			totalLatency:  t.totalLatency,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Tool < stats[j].Tool })
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// milliseconds converts d to fractional milliseconds, rounded to
+// microseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// contentBytes counts the text a tool returned to the client
+func contentBytes(result *mcp.CallToolResult) int {
+	if result == nil {
+		return 0
+	}
+	n := 0
+	for _, content := range result.Content {
+		n += len(content.Text)
+	}
+	return n
+}
+
+// toolName returns the tool named in a tools/call request's params, or ""
+func toolName(request *mcp.JSONRPCRequest) string {
+	params, _ := request.Params.(map[string]interface{})
+	name, _ := params["name"].(string)
+	return name
+}
+
+// writePrometheus writes stats in the Prometheus text exposition format
+func writePrometheus(w io.Writer, stats []toolStats) {
+	fmt.Fprintln(w, "# HELP wingman_tool_calls_total Tool calls handled.")
+	fmt.Fprintln(w, "# TYPE wingman_tool_calls_total counter")
+	for _, s := range stats {
+		fmt.Fprintf(w, "wingman_tool_calls_total{tool=%q} %d\n", s.Tool, s.Calls)
+	}
+	fmt.Fprintln(w, "# HELP wingman_tool_errors_total Tool calls that failed or returned an error result.")
+	fmt.Fprintln(w, "# TYPE wingman_tool_errors_total counter")
+	for _, s := range stats {
+		fmt.Fprintf(w, "wingman_tool_errors_total{tool=%q} %d\n", s.Tool, s.Errors)
+	}
+	fmt.Fprintln(w, "# HELP wingman_captured_bytes_total Bytes of text content returned by tools.")
+	fmt.Fprintln(w, "# TYPE wingman_captured_bytes_total counter")
+	for _, s := range stats {
+		fmt.Fprintf(w, "wingman_captured_bytes_total{tool=%q} %d\n", s.Tool, s.CapturedBytes)
+	}
+	fmt.Fprintln(w, "# HELP wingman_tool_latency_seconds Tool call latency over recent calls.")
+	fmt.Fprintln(w, "# TYPE wingman_tool_latency_seconds summary")
+	for _, s := range stats {
+		fmt.Fprintf(w, "wingman_tool_latency_seconds{tool=%q,quantile=\"0.5\"} %g\n", s.Tool, s.P50Ms/1000)
+		fmt.Fprintf(w, "wingman_tool_latency_seconds{tool=%q,quantile=\"0.95\"} %g\n", s.Tool, s.P95Ms/1000)
+		fmt.Fprintf(w, "wingman_tool_latency_seconds_sum{tool=%q} %g\n", s.Tool, s.totalLatency.Seconds())
+		fmt.Fprintf(w, "wingman_tool_latency_seconds_count{tool=%q} %d\n", s.Tool, s.Calls)
+	}
+}
+
+// MetricsHandler serves the tool metrics for Prometheus to scrape. It
+// reports nothing unless the server was created with WithMetrics.
+func (s *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writePrometheus(w, s.metrics.snapshot())
+	})
+}
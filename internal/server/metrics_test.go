@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{name: "empty", sorted: nil, p: 0.5, want: 0},
+		{name: "single sample", sorted: []time.Duration{7 * time.Millisecond}, p: 0.95, want: 7 * time.Millisecond},
+		{name: "median", sorted: sorted, p: 0.50, want: 50 * time.Millisecond},
+		{name: "p95", sorted: sorted, p: 0.95, want: 95 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetrics_record(t *testing.T) {
+	m := newMetrics([]mcp.Tool{{Name: "read_terminal"}})
+	for i := 0; i < maxLatencySamples+10; i++ {
+		m.record("read_terminal", time.Millisecond, i == 0, 100)
+	}
+	m.record("no_such_tool", time.Millisecond, true, 0)
+
+	stats := m.snapshot()
+	if len(stats) != 2 {
+		t.Fatalf("snapshot() has %d tools, want 2: %+v", len(stats), stats)
+	}
+	got := stats[0]
+	if got.Tool != "read_terminal" || got.Calls != maxLatencySamples+10 || got.Errors != 1 || got.CapturedBytes != 100*(maxLatencySamples+10) {
+		t.Errorf("read_terminal stats = %+v", got)
+	}
+	if got.P50Ms != 1 || got.P95Ms != 1 {
+		t.Errorf("read_terminal p50/p95 = %v/%v, want 1/1", got.P50Ms, got.P95Ms)
+	}
+	if stats[1].Tool != "unknown" {
+		t.Errorf("unlisted tool recorded as %q, want \"unknown\"", stats[1].Tool)
+	}
+
+	var disabled *metrics
+	disabled.record("read_terminal", time.Millisecond, false, 1)
+	if stats := disabled.snapshot(); stats != nil {
+		t.Errorf("nil metrics snapshot() = %+v, want nil", stats)
+	}
+}
+
+func TestServer_callTool_ServerStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		metrics     bool
+		wantText    string
+		wantMetrics []string
+	}{
+		{name: "metrics off", wantText: "[metrics are off; start the server with --metrics to collect them]"},
+		{name: "metrics on", metrics: true, wantText: "read_terminal", wantMetrics: []string{"read_terminal", "server_status"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer("fake-session", &bytes.Buffer{}, &bytes.Buffer{}, WithServerName("wingman-test"), WithMetrics(tt.metrics))
+			srv.tmuxManager = newFakeServer(func(args ...string) (string, string, error) {
+				return "hello\n", "", nil
+			}).tmuxManager
+
+			callFakeTool(srv, "read_terminal", map[string]interface{}{})
+			callFakeTool(srv, "server_status", map[string]interface{}{})
+			response := callFakeTool(srv, "server_status", map[string]interface{}{})
+			text := toolText(t, response)
+			if !strings.HasPrefix(text, "wingman-test dev on session fake-session, read-only, up ") {
+				t.Errorf("server_status text = %q", text)
+			}
+			if !strings.Contains(text, tt.wantText) {
+				t.Errorf("server_status text = %q, want it to contain %q", text, tt.wantText)
+			}
+
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "server_status").OutputSchema, result.StructuredContent)
+			status := result.StructuredContent.(statusResult)
+			var tools []string
+			for _, stats := range status.Metrics {
+				tools = append(tools, stats.Tool)
+			}
+			if strings.Join(tools, ",") != strings.Join(tt.wantMetrics, ",") {
+				t.Errorf("metrics for %v, want %v", tools, tt.wantMetrics)
+			}
+			if tt.metrics && status.Metrics[0].CapturedBytes != int64(len("hello\n")) {
+				t.Errorf("read_terminal captured_bytes = %d, want %d", status.Metrics[0].CapturedBytes, len("hello\n"))
+			}
+		})
+	}
+}
+
+func TestServer_MetricsHandler(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	srv.metrics = newMetrics(srv.listTools().Tools)
+	srv.metrics.record("read_terminal", 20*time.Millisecond, false, 42)
+
+	recorder := httptest.NewRecorder()
+	srv.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+	for _, want := range []string{
+		"# TYPE wingman_tool_calls_total counter\n",
+		`wingman_tool_calls_total{tool="read_terminal"} 1` + "\n",
+		`wingman_captured_bytes_total{tool="read_terminal"} 42` + "\n",
+		`wingman_tool_latency_seconds{tool="read_terminal",quantile="0.95"} 0.02` + "\n",
+		`wingman_tool_latency_seconds_count{tool="read_terminal"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
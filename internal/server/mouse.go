@@ -0,0 +1,49 @@
+package server
+
+import (
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// mouseState is the structured content of get_mouse and set_mouse
+type mouseState struct {
+	Mouse bool `json:"mouse"`
+}
+
+// text describes the state for the text content of a result
+func (m mouseState) text() string {
+	if m.Mouse {
+		return "mouse on"
+	}
+	return "mouse off"
+}
+
+// getMouse handles the get_mouse tool
+func (s *Server) getMouse() (*mcp.CallToolResult, error) {
+	enabled, err := s.tmuxManager.Mouse()
+	if err != nil {
+		return toolError(err)
+	}
+	state := mouseState{Mouse: enabled}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: state.text()}},
+		StructuredContent: state,
+	}, nil
+}
+
+// setMouse handles the set_mouse tool: it turns mouse mode on or off and
+// reports the state tmux ends up in
+func (s *Server) setMouse(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("set_mouse"); result != nil {
+		return result, nil
+	}
+
+	enabled, ok := arguments["enabled"].(bool)
+	if !ok {
+		return nil, invalidParams("enabled is required",
+			paramError{Field: "enabled", Expected: "boolean"})
+	}
+	if err := s.tmuxManager.SetMouse(enabled); err != nil {
+		return toolError(err)
+	}
+	return s.getMouse()
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const (
+	// defaultOrientLines is the number of output lines orient returns when
+	// lines is not given
+	defaultOrientLines = 10
+	// maxOrientLines bounds lines; orient is meant to be cheap
+	maxOrientLines = 200
+)
+
+// orientResult is the structured content of orient
+type orientResult struct {
+	Session     string `json:"session"`
+	WindowIndex int    `json:"window_index"`
+	WindowName  string `json:"window_name"`
+	PaneID      string `json:"pane_id"`
+	PaneIndex   int    `json:"pane_index"`
+	Command     string `json:"command"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	CurrentPath string `json:"current_path"`
+	Windows     int    `json:"windows"`
+	Panes       int    `json:"panes"`
+	ScreenMode  string `json:"screen_mode"`
+	PaneDead    bool   `json:"pane_dead,omitempty"`
+	// SizeChanged is set when the pane's size differs from the last time
+	// orient or snapshot read it; Previous* is the size then
+	SizeChanged    bool `json:"size_changed"`
+	PreviousWidth  int  `json:"previous_width,omitempty"`
+	PreviousHeight int  `json:"previous_height,omitempty"`
+	// Tail is the last lines of output, trimmed of trailing blanks
+	Tail string `json:"tail"`
+}
+
+// orient handles the orient tool: where the agent is and what was last
+// printed, in as few tokens as possible, for the first call of a turn in
+// place of get_terminal_info, list_panes and read_terminal
+func (s *Server) orient(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := intArgument(arguments, "lines", defaultOrientLines)
+	if err != nil {
+		return nil, err
+	}
+	if lines < 0 || lines > maxOrientLines {
+		return nil, invalidParams(fmt.Sprintf("lines must be between 0 and %d", maxOrientLines),
+			paramError{Field: "lines", Expected: fmt.Sprintf("integer from 0 to %d", maxOrientLines)})
+	}
+
+	panes, err := s.tmuxManager.ListPanes()
+	if err != nil {
+		return toolError(err)
+	}
+	pane, err := s.tmuxManager.PaneFor(panes, target)
+	if err != nil {
+		return toolError(err)
+	}
+	// Resolve the rest by ID so every read below sees the same pane
+	byID := tmux.Target{Pane: pane.ID}
+	info, err := s.tmuxManager.GetPaneInfoFor(byID)
+	if err != nil {
+		return toolError(err)
+	}
+	terminal := newTerminalInfo(info)
+
+	result := orientResult{
+		Session:     s.tmuxManager.SessionName(),
+		WindowIndex: pane.WindowIndex,
+		WindowName:  pane.WindowName,
+		PaneID:      pane.ID,
+		PaneIndex:   pane.PaneIndex,
+		Command:     pane.Command,
+		Width:       pane.Width,
+		Height:      pane.Height,
+		CurrentPath: terminal.CurrentPath,
+		Panes:       len(panes),
+		ScreenMode:  terminal.ScreenMode,
+		PaneDead:    pane.Dead,
+	}
+	windows := map[int]bool{}
+	for _, p := range panes {
+		windows[p.WindowIndex] = true
+	}
+	result.Windows = len(windows)
+	size := paneSize{Width: pane.Width, Height: pane.Height}
+	if changed, previous := s.checkSize(pane.ID, size); changed {
+		result.SizeChanged, result.PreviousWidth, result.PreviousHeight = true, previous.Width, previous.Height
+	}
+
+	if lines > 0 {
+		// Reach into the history as well, in case the bottom of the
+		// screen is blank
+		content, err := s.tmuxManager.GetScrollbackHistoryWithOptions(lines, tmux.CaptureOptions{Target: byID})
+		if err != nil {
+			return toolError(err)
+		}
+		tail := splitLines(trimContent(suppressBinary(content)))
+		if len(tail) > lines {
+			tail = tail[len(tail)-lines:]
+		}
+		result.Tail = truncateLines(strings.Join(tail, "\n"), s.maxLineWidth)
+	}
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: result.text(terminal)}},
+		StructuredContent: result,
+	}, nil
+}
+
+// text summarises the result in a header line, a line of counts and the
+// tail of the output
+func (o orientResult) text(terminal terminalInfo) string {
+	lines := []string{
+		fmt.Sprintf("session %q, window %d %q, pane %s: %s, %dx%d, cwd %s",
+			o.Session, o.WindowIndex, o.WindowName, o.PaneID, o.Command, o.Width, o.Height, o.CurrentPath),
+		fmt.Sprintf("%d windows, %d panes", o.Windows, o.Panes),
+	}
+	var resized string
+	if o.SizeChanged {
+		resized = resizeNote(paneSize{o.PreviousWidth, o.PreviousHeight}, paneSize{o.Width, o.Height})
+	}
+	for _, note := range []string{resized, terminal.deadNote(), terminal.screenNote()} {
+		if note != "" {
+			lines = append(lines, "["+note+"]")
+		}
+	}
+	if o.Tail != "" {
+		lines = append(lines, "---", o.Tail)
+	}
+	return strings.Join(lines, "\n")
+}
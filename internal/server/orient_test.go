@@ -0,0 +1,52 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_Orient(t *testing.T) {
+	var captured []string
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "list-panes":
+			return "%0\t0\teditor\t0\t1\t0\t80\t24\tvim\t0\t\t1700000000\t1699990000\n" +
+				"%1\t1\tbuild\t0\t0\t1\t100\t30\tbash\t0\t\t1700000000\t1699990000\n" +
+				"%2\t1\tbuild\t1\t1\t1\t100\t30\tmake\t0\t\t1700000000\t1699990000\n", "", nil
+		case "display-message":
+			return "100,30,/home/dev/src,1,0,,0\n", "", nil
+		case "capture-pane":
+			captured = args
+			return "cc -c a.c\ncc -c b.c   \nld -o app\n\n\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "orient", map[string]interface{}{"lines": 2})
+	want := "session \"fake-session\", window 1 \"build\", pane %2: make, 100x30, cwd /home/dev/src\n" +
+		"2 windows, 3 panes\n" +
+		"---\n" +
+		"cc -c b.c\n" +
+		"ld -o app"
+	if text := toolText(t, response); text != want {
+		t.Errorf("orient text = %q, want %q", text, want)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "orient").OutputSchema, result.StructuredContent)
+	if !strings.Contains(strings.Join(captured, " "), "-t %2") {
+		t.Errorf("captured with %q, want the resolved pane %%2", captured)
+	}
+
+	response = callFakeTool(srv, "orient", map[string]interface{}{"window": "editor", "lines": 0})
+	if got := response.Result.(*mcp.CallToolResult).StructuredContent.(orientResult); got.PaneID != "%0" || got.Tail != "" {
+		t.Errorf("orient of editor = %+v, want pane %%0 without a tail", got)
+	}
+
+	for _, lines := range []interface{}{-1, 201, "ten"} {
+		if response := callFakeTool(srv, "orient", map[string]interface{}{"lines": lines}); response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("orient(lines: %v) error = %+v, want -32602", lines, response.Error)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// overview is the structured content of the overview tool
+type overview struct {
+	Sessions []sessionOverview `json:"sessions"`
+}
+
+// sessionOverview describes one session in overview
+type sessionOverview struct {
+	Name            string `json:"name"`
+	AttachedClients int    `json:"attached_clients"`
+	// Managed marks the session this server reads from and types into
+	Managed bool             `json:"managed"`
+	Windows []windowOverview `json:"windows"`
+	// Created, in RFC 3339 form, and Width and Height, the size of the
+	// session's active window, come from list-sessions and are left out
+	// if tmux didn't report them
+	Created string `json:"created,omitempty"`
+	Width   int    `json:"width,omitempty"`
+	Height  int    `json:"height,omitempty"`
+}
+
+// windowOverview describes one window and its active pane in overview
+type windowOverview struct {
+	WindowIndex int    `json:"window_index"`
+	WindowName  string `json:"window_name"`
+	Active      bool   `json:"active"`
+	PaneID      string `json:"pane_id"`
+	Command     string `json:"command"`
+}
+
+// overviewTool handles the overview tool: a summary of every session on the
+// tmux server, their windows and what each window's active pane is running.
+// With --allow-session, sessions the server may not bind are left out.
+func (s *Server) overviewTool() (*mcp.CallToolResult, error) {
+	sessions, err := s.tmuxManager.Overview()
+	if err != nil {
+		return toolError(err)
+	}
+	details, err := s.tmuxManager.SessionsDetailed()
+	if err != nil {
+		return toolError(err)
+	}
+	infos := make(map[string]tmux.SessionInfo, len(details))
+	for _, info := range details {
+		infos[info.Name] = info
+	}
+
+	result := overview{Sessions: make([]sessionOverview, 0, len(sessions))}
+	var lines []string
+	for _, session := range sessions {
+		managed := session.Name == s.tmuxManager.SessionName()
+		if !s.sessionListed(session.Name, managed) {
+			continue
+		}
+		entry := newSessionOverview(session, managed)
+		if info, ok := infos[session.Name]; ok {
+			entry.addInfo(info)
+		}
+		result.Sessions = append(result.Sessions, entry)
+		lines = append(lines, entry.text()...)
+	}
+	text := strings.Join(lines, "\n")
+	if len(result.Sessions) == 0 {
+		text = "no tmux sessions"
+	}
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}, nil
+}
+
+// sessionListed reports whether overview may show the session called name.
+// Without --allow-session every session is listed; with it, only the managed
+// session and those bind_session may switch to.
+func (s *Server) sessionListed(name string, managed bool) bool {
+	return len(s.bindableSessions) == 0 || managed || s.sessionBindable(name)
+}
+
+// newSessionOverview converts a tmux session summary for overview
+func newSessionOverview(session tmux.SessionSummary, managed bool) sessionOverview {
+	entry := sessionOverview{
+		Name:            session.Name,
+		AttachedClients: session.Attached,
+		Managed:         managed,
+		Windows:         make([]windowOverview, 0, len(session.Windows)),
+	}
+	for _, window := range session.Windows {
+		entry.Windows = append(entry.Windows, windowOverview{
+			WindowIndex: window.Index,
+			WindowName:  window.Name,
+			Active:      window.Active,
+			PaneID:      window.PaneID,
+			Command:     window.Command,
+		})
+	}
+	return entry
+}
+
+// addInfo fills in the details list-sessions reports about the session
+func (o *sessionOverview) addInfo(info tmux.SessionInfo) {
+	if !info.Created.IsZero() {
+		o.Created = info.Created.Format(time.RFC3339)
+	}
+	o.Width = info.Width
+	o.Height = info.Height
+}
+
+// text lists the session and its windows, one per line
+func (o sessionOverview) text() []string {
+	var notes []string
+	if o.Managed {
+		notes = append(notes, "managed")
+	}
+	if o.AttachedClients > 0 {
+		notes = append(notes, fmt.Sprintf("%d attached", o.AttachedClients))
+	}
+	if o.Width > 0 && o.Height > 0 {
+		notes = append(notes, fmt.Sprintf("%dx%d", o.Width, o.Height))
+	}
+	header := fmt.Sprintf("session %q", o.Name)
+	if len(notes) > 0 {
+		header += " (" + strings.Join(notes, ", ") + ")"
+	}
+
+	lines := []string{header}
+	for _, window := range o.Windows {
+		marker := ""
+		if window.Active {
+			marker = " (active)"
+		}
+		lines = append(lines, fmt.Sprintf("  window %d %q: %s %s%s",
+			window.WindowIndex, window.WindowName, window.PaneID, window.Command, marker))
+	}
+	return lines
+}
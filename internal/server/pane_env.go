@@ -0,0 +1,174 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// redactedValue replaces the value of a variable that looks like a secret
+const redactedValue = "[REDACTED]"
+
+// sensitiveName matches environment variable names whose values are
+// likely secrets, such as GITHUB_TOKEN, AWS_SECRET_ACCESS_KEY, PGPASSWORD,
+// STRIPE_KEY, NPM_CONFIG__AUTH or SENTRY_DSN. "auth" must end the name, so
+// neither GIT_AUTHOR_NAME nor SSH_AUTH_SOCK is caught.
+var sensitiveName = regexp.MustCompile(`(?i)token|secret|passw|pwd$|api_?key|private_?key|access_?key|credential|cookie|session_?key|(^|_)key$|auth$|dsn`)
+
+var (
+	// urlUserinfo matches the user:password@ part of a URL, as in
+	// DATABASE_URL=postgres://app:pass@db/app, or a token alone in its
+	// place, as in https://ghp_...@github.com
+	urlUserinfo = regexp.MustCompile(`([A-Za-z][A-Za-z0-9+.-]*://)[^/@\s]+@`)
+
+	// tokenShaped matches well-known credential formats wherever they
+	// appear in a value: JWTs, GitHub, GitLab, Slack, OpenAI-style and
+	// Stripe keys, and AWS access key IDs
+	tokenShaped = regexp.MustCompile(`eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}(\.[A-Za-z0-9_-]*)?|` +
+		`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|glpat-[A-Za-z0-9_-]{20,}|xox[abposr]-[A-Za-z0-9-]{10,}|` +
+		`(sk|pk|rk)[-_][A-Za-z0-9_-]{20,}|A[KS]IA[0-9A-Z]{16})\b`)
+
+	// opaqueWord matches a run of base64 or URL-safe characters long
+	// enough to be a generated secret; highEntropy decides whether it is.
+	// "/" is left out so paths split into short runs.
+	opaqueWord = regexp.MustCompile(`[A-Za-z0-9+=_-]{32,}`)
+)
+
+// minSecretEntropy is the Shannon entropy, in bits per character, above
+// which an opaqueWord is taken for a random secret. Hex digests, such as
+// commit hashes, can't exceed 4.
+const minSecretEntropy = 4.2
+
+// redactValue hides the parts of a value that look like credentials,
+// whatever the variable is called: URL userinfo, well-known token formats
+// and long random strings. It reports whether anything was hidden.
+func redactValue(value string) (string, bool) {
+	redacted := urlUserinfo.ReplaceAllString(value, "${1}"+redactedValue+"@")
+	redacted = tokenShaped.ReplaceAllString(redacted, redactedValue)
+	redacted = opaqueWord.ReplaceAllStringFunc(redacted, func(word string) string {
+		if highEntropy(word) {
+			return redactedValue
+		}
+		return word
+	})
+	return redacted, redacted != value
+}
+
+// highEntropy reports whether word mixes letters and digits with the
+// character spread of a randomly generated string
+func highEntropy(word string) bool {
+	if !strings.ContainsAny(word, "0123456789") || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+		return false
+	}
+	counts := map[rune]int{}
+	for _, r := range word {
+		counts[r]++
+	}
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(len(word))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minSecretEntropy
+}
+
+// envResult is the structured content of pane_env
+type envResult struct {
+	Session []envEntry `json:"session"`
+	// Process is the environment of the pane's process, present only when
+	// requested and readable; ProcessNote says why it is missing otherwise
+	Process     []envEntry `json:"process,omitempty"`
+	ProcessNote string     `json:"process_note,omitempty"`
+	PID         int        `json:"pid,omitempty"`
+}
+
+// envEntry is one variable in pane_env
+type envEntry struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Removed  bool   `json:"removed,omitempty"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// readProcEnviron reads a process's environment from /proc, which only
+// exists on Linux and a few other systems
+func readProcEnviron(pid int) ([]byte, error) {
+	return os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+}
+
+// newEnvEntries converts variables for pane_env, hiding the values of any
+// whose names look like they hold secrets, and the parts of other values
+// that look like credentials
+func newEnvEntries(vars []tmux.EnvVar) []envEntry {
+	entries := make([]envEntry, 0, len(vars))
+	for _, v := range vars {
+		entry := envEntry{Name: v.Name, Value: v.Value, Removed: v.Removed}
+		if v.Value != "" && sensitiveName.MatchString(v.Name) {
+			entry.Value, entry.Redacted = redactedValue, true
+		} else {
+			entry.Value, entry.Redacted = redactValue(v.Value)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// paneEnv handles the pane_env tool: the session environment tmux gives new
+// panes and, with process set, the live environment of the pane's process,
+// read without typing anything into the pane
+func (s *Server) paneEnv(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	withProcess, _ := arguments["process"].(bool)
+
+	vars, err := s.tmuxManager.SessionEnvironment()
+	if err != nil {
+		return toolError(err)
+	}
+	result := envResult{Session: newEnvEntries(vars)}
+	lines := []string{"Session environment:"}
+	lines = append(lines, envLines(result.Session)...)
+
+	if withProcess {
+		pid, err := s.tmuxManager.PanePID(target)
+		if err != nil {
+			return toolError(err)
+		}
+		result.PID = pid
+		data, err := s.readEnviron(pid)
+		if err != nil {
+			result.ProcessNote = fmt.Sprintf("the environment of process %d can't be read: %v", pid, err)
+			lines = append(lines, "", appendNote("", result.ProcessNote))
+		} else {
+			result.Process = newEnvEntries(tmux.ParseEnviron(data))
+			lines = append(lines, "", fmt.Sprintf("Process %d environment:", pid))
+			lines = append(lines, envLines(result.Process)...)
+		}
+	}
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: strings.Join(lines, "\n")}},
+		StructuredContent: result,
+	}, nil
+}
+
+// envLines renders variables one per line, as NAME=value or -NAME
+func envLines(entries []envEntry) []string {
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Removed {
+			lines = append(lines, "-"+entry.Name)
+			continue
+		}
+		lines = append(lines, entry.Name+"="+entry.Value)
+	}
+	return lines
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// childProcess is a process started by the tmux server
+type childProcess struct {
+	PID int
+	// TTY is whether the process has a controlling terminal. tmux gives
+	// one to panes and popups, but not to run-shell or #() jobs.
+	TTY     bool
+	Command string
+}
+
+// popupProcess is one open popup in popup_state
+type popupProcess struct {
+	PID     int    `json:"pid"`
+	Command string `json:"command"`
+}
+
+// popupResult is the structured content of popup_state
+type popupResult struct {
+	TmuxVersion string `json:"tmux_version"`
+	// Supported is whether this tmux has popups at all
+	Supported bool `json:"supported"`
+	// Detectable is whether the server's processes could be inspected;
+	// PopupOpen means nothing when it is false
+	Detectable bool           `json:"detectable"`
+	PopupOpen  bool           `json:"popup_open"`
+	Popups     []popupProcess `json:"popups"`
+	// Clients is the number of clients attached to the bound session
+	Clients int    `json:"clients"`
+	Note    string `json:"note,omitempty"`
+}
+
+// readProcChildren lists the children of parent by scanning /proc, which
+// only exists on Linux and a few other systems
+func readProcChildren(parent int) ([]childProcess, error) {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, fmt.Errorf("no processes under /proc")
+	}
+	children := []childProcess{}
+	for _, path := range stats {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// The process exited while scanning
+			continue
+		}
+		pid, ppid, tty, comm, ok := parseProcStat(string(data))
+		if !ok || ppid != parent {
+			continue
+		}
+		command := comm
+		if cmdline, err := os.ReadFile(filepath.Join(filepath.Dir(path), "cmdline")); err == nil && len(cmdline) > 0 {
+			command = string(bytes.ReplaceAll(bytes.TrimRight(cmdline, "\x00"), []byte{0}, []byte{' '}))
+		}
+		children = append(children, childProcess{PID: pid, TTY: tty != 0, Command: command})
+	}
+	return children, nil
+}
+
+// parseProcStat reads the pid, parent pid, controlling terminal and command
+// name from a /proc/<pid>/stat line: "pid (comm) state ppid pgrp session
+// tty_nr ...". The command name may itself hold spaces and parentheses, so
+// the fields after it are found from the last ")".
+func parseProcStat(stat string) (pid, ppid, tty int, comm string, ok bool) {
+	open := strings.Index(stat, "(")
+	end := strings.LastIndex(stat, ")")
+	if open < 0 || end < open {
+		return 0, 0, 0, "", false
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 5 {
+		return 0, 0, 0, "", false
+	}
+	var errs [3]error
+	pid, errs[0] = strconv.Atoi(strings.TrimSpace(stat[:open]))
+	ppid, errs[1] = strconv.Atoi(fields[1])
+	tty, errs[2] = strconv.Atoi(fields[4])
+	for _, err := range errs {
+		if err != nil {
+			return 0, 0, 0, "", false
+		}
+	}
+	return pid, ppid, tty, stat[open+1 : end], true
+}
+
+// popupState handles the popup_state tool. tmux has no format or command
+// that reports an open popup, let alone its content, so it is found the
+// only way available: a popup's command runs as a child of the tmux server
+// with a terminal of its own, like a pane's, but isn't any pane's process.
+func (s *Server) popupState() (*mcp.CallToolResult, error) {
+	version, err := s.tmuxManager.Version()
+	if err != nil {
+		return toolError(err)
+	}
+	result := popupResult{TmuxVersion: version, Popups: []popupProcess{}}
+	if result.Clients, err = s.tmuxManager.AttachedClients(); err != nil {
+		return toolError(err)
+	}
+	if !tmux.SupportsPopups(version) {
+		result.Note = fmt.Sprintf("%s has no popups (display-popup arrived in tmux %s), so nothing can cover the panes", version, tmux.PopupMinVersion)
+		return popupStateResult(result, result.Note)
+	}
+	result.Supported = true
+
+	serverPID, err := s.tmuxManager.ServerPID()
+	if err != nil {
+		return toolError(err)
+	}
+	panePIDs, err := s.tmuxManager.ServerPanePIDs()
+	if err != nil {
+		return toolError(err)
+	}
+	children, err := s.childProcesses(serverPID)
+	if err != nil {
+		result.Note = fmt.Sprintf("can't tell whether a popup is open: popups are found among the tmux server's processes, which can't be read here (%v)", err)
+		return popupStateResult(result, result.Note)
+	}
+	result.Detectable = true
+
+	panes := map[int]bool{}
+	for _, pid := range panePIDs {
+		panes[pid] = true
+	}
+	for _, child := range children {
+		if child.TTY && !panes[child.PID] {
+			result.Popups = append(result.Popups, popupProcess{PID: child.PID, Command: child.Command})
+		}
+	}
+	result.PopupOpen = len(result.Popups) > 0
+
+	if !result.PopupOpen {
+		return popupStateResult(result, "no popup open")
+	}
+	var commands []string
+	for _, popup := range result.Popups {
+		commands = append(commands, fmt.Sprintf("%s (pid %d)", popup.Command, popup.PID))
+	}
+	result.Note = "a popup draws over the panes of the client that opened it, which may be attached to another session. " +
+		"Captures show the pane underneath, so they can look unchanged while the popup has the keyboard, " +
+		"and tmux has no way to capture a popup's content"
+	if result.Clients == 0 {
+		result.Note += ". No client is attached to this session, so the popup is over another session"
+	}
+	return popupStateResult(result, appendNote("popup open: "+strings.Join(commands, ", "), result.Note))
+}
+
+// popupStateResult wraps a popup_state result with its text
+func popupStateResult(result popupResult, text string) (*mcp.CallToolResult, error) {
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}, nil
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestParseProcStat(t *testing.T) {
+	pid, ppid, tty, comm, ok := parseProcStat("4242 (my (odd) cmd) S 100 4242 4242 34817 4242 4194560 ...")
+	if !ok || pid != 4242 || ppid != 100 || tty != 34817 || comm != "my (odd) cmd" {
+		t.Errorf("parseProcStat() = %d, %d, %d, %q, %v", pid, ppid, tty, comm, ok)
+	}
+	if _, _, _, _, ok := parseProcStat("garbage"); ok {
+		t.Error("parseProcStat(garbage) ok = true, want false")
+	}
+}
+
+func TestServer_callTool_PopupState(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    string
+		children   []childProcess
+		childErr   error
+		wantResult popupResult
+		wantText   string
+	}{
+		{
+			name:    "popup open",
+			version: "tmux 3.3a",
+			children: []childProcess{
+				{PID: 101, TTY: true, Command: "-bash"},
+				{PID: 300, TTY: false, Command: "sh -c date"},
+				{PID: 301, TTY: true, Command: "lazygit"},
+			},
+			wantResult: popupResult{Supported: true, Detectable: true, PopupOpen: true, Popups: []popupProcess{{PID: 301, Command: "lazygit"}}, Clients: 1},
+			wantText:   "popup open: lazygit (pid 301)",
+		},
+		{
+			name:       "no popup",
+			version:    "tmux 3.4",
+			children:   []childProcess{{PID: 101, TTY: true, Command: "-bash"}},
+			wantResult: popupResult{Supported: true, Detectable: true, Popups: []popupProcess{}, Clients: 1},
+			wantText:   "no popup open",
+		},
+		{
+			name:       "too old",
+			version:    "tmux 3.1c",
+			wantResult: popupResult{Popups: []popupProcess{}, Clients: 1},
+			wantText:   "tmux 3.1c has no popups",
+		},
+		{
+			name:       "no /proc",
+			version:    "tmux 3.3a",
+			childErr:   errors.New("no processes under /proc"),
+			wantResult: popupResult{Supported: true, Popups: []popupProcess{}, Clients: 1},
+			wantText:   "can't tell whether a popup is open",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "-V":
+					return tt.version + "\n", "", nil
+				case "list-clients":
+					return "/dev/pts/3 120x40 1700000000\n", "", nil
+				case "display-message":
+					return "100\n", "", nil
+				case "list-panes":
+					return "101\n", "", nil
+				}
+				return "", "", nil
+			})
+			srv.childProcesses = func(parent int) ([]childProcess, error) {
+				if parent != 100 {
+					t.Errorf("childProcesses(%d), want the server pid 100", parent)
+				}
+				return tt.children, tt.childErr
+			}
+
+			response := callFakeTool(srv, "popup_state", map[string]interface{}{})
+			if text := toolText(t, response); !strings.Contains(text, tt.wantText) {
+				t.Errorf("popup_state text = %q, want it to contain %q", text, tt.wantText)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "popup_state").OutputSchema, result.StructuredContent)
+			got := result.StructuredContent.(popupResult)
+			got.TmuxVersion, got.Note = "", ""
+			if !reflect.DeepEqual(got, tt.wantResult) {
+				t.Errorf("popup_state = %+v, want %+v", got, tt.wantResult)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// prefixState is the structured content of get_prefix
+type prefixState struct {
+	Prefix      string `json:"prefix"`
+	Description string `json:"description"`
+	Prefix2     string `json:"prefix2,omitempty"`
+}
+
+// text describes the prefix keys for the text content of a result
+func (p prefixState) text() string {
+	text := fmt.Sprintf("prefix %s (%s)", p.Prefix, p.Description)
+	if p.Prefix == "" {
+		text = "no prefix key set"
+	}
+	if p.Prefix2 != "" {
+		text += fmt.Sprintf(", prefix2 %s (%s)", p.Prefix2, tmux.DescribeKey(p.Prefix2))
+	}
+	return text
+}
+
+// getPrefix handles the get_prefix tool
+func (s *Server) getPrefix() (*mcp.CallToolResult, error) {
+	prefix, prefix2, err := s.tmuxManager.Prefix()
+	if err != nil {
+		return toolError(err)
+	}
+	state := prefixState{Prefix: prefix, Description: tmux.DescribeKey(prefix), Prefix2: prefix2}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: state.text()}},
+		StructuredContent: state,
+	}, nil
+}
+
+// prefixNote warns when one of keys is also this tmux's prefix key. tmux
+// delivers such a key to the program like any other, but a tmux running
+// inside the pane, such as on a remote host over ssh, may use the same
+// prefix and swallow it. It returns "" when there is no collision or the
+// prefix can't be read.
+func (s *Server) prefixNote(keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	prefix, prefix2, err := s.tmuxManager.Prefix()
+	if err != nil {
+		return ""
+	}
+	for _, key := range keys {
+		for _, p := range []string{prefix, prefix2} {
+			if tmux.SameKey(key, p) {
+				return fmt.Sprintf("%s is also this tmux's prefix key (%s). It was sent to the program, but a tmux running inside the pane with the same prefix would take it as its own prefix", key, tmux.DescribeKey(p))
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// newFakePrefixServer fakes a session whose prefix options have the given
+// values
+func newFakePrefixServer(prefix, prefix2 string) *Server {
+	return newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "show-options" {
+			switch args[len(args)-1] {
+			case "prefix":
+				return prefix + "\n", "", nil
+			case "prefix2":
+				return prefix2 + "\n", "", nil
+			}
+		}
+		return "", "", nil
+	})
+}
+
+func TestServer_callTool_GetPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		prefix2  string
+		want     prefixState
+		wantText string
+	}{
+		{
+			name:     "default",
+			prefix:   "C-b",
+			prefix2:  "None",
+			want:     prefixState{Prefix: "C-b", Description: "Ctrl+b"},
+			wantText: "prefix C-b (Ctrl+b)",
+		},
+		{
+			name:     "with prefix2",
+			prefix:   "C-a",
+			prefix2:  "M-a",
+			want:     prefixState{Prefix: "C-a", Description: "Ctrl+a", Prefix2: "M-a"},
+			wantText: "prefix C-a (Ctrl+a), prefix2 M-a (Alt+a)",
+		},
+		{
+			name:     "none",
+			prefix:   "None",
+			prefix2:  "None",
+			want:     prefixState{},
+			wantText: "no prefix key set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakePrefixServer(tt.prefix, tt.prefix2)
+
+			response := callFakeTool(srv, "get_prefix", map[string]interface{}{})
+			if text := toolText(t, response); text != tt.wantText {
+				t.Errorf("get_prefix text = %q, want %q", text, tt.wantText)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "get_prefix").OutputSchema, result.StructuredContent)
+			if got := result.StructuredContent.(prefixState); got != tt.want {
+				t.Errorf("get_prefix structuredContent = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_SendKeys_PrefixNote(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []interface{}
+		wantNote bool
+	}{
+		{name: "prefix", keys: []interface{}{"C-b", "d"}, wantNote: true},
+		{name: "caret spelling", keys: []interface{}{"^B"}, wantNote: true},
+		{name: "other keys", keys: []interface{}{"C-c", "Enter"}, wantNote: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakePrefixServer("C-b", "None")
+			srv.writesEnabled = true
+
+			text := toolText(t, callFakeTool(srv, "send_keys", map[string]interface{}{"keys": tt.keys}))
+			if got := strings.Contains(text, "prefix key (Ctrl+b)"); got != tt.wantNote {
+				t.Errorf("send_keys text = %q, want prefix note %v", text, tt.wantNote)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+const (
+	// defaultReadFileBytes is how much of a file read_file returns when
+	// max_bytes is not given
+	defaultReadFileBytes = 64 << 10
+	// maxReadFileBytes bounds max_bytes
+	maxReadFileBytes = 1 << 20
+)
+
+// remoteCommands are pane commands whose working directory is on another
+// machine, so a path relative to it means nothing here
+var remoteCommands = map[string]bool{
+	"ssh": true, "mosh": true, "mosh-client": true, "et": true, "telnet": true,
+}
+
+// readFileResult is the structured content of read_file
+type readFileResult struct {
+	// Path is the file read, absolute and with symlinks resolved
+	Path string `json:"path"`
+	// Size is the size of the whole file; Bytes of it were returned
+	Size      int64  `json:"size"`
+	Bytes     int    `json:"bytes"`
+	Truncated bool   `json:"truncated"`
+	Content   string `json:"content"`
+}
+
+// readFile handles the read_file tool: it reads a file relative to the
+// pane's working directory straight from disk, so the agent needn't cat it
+// into the terminal. Paths must stay under that directory unless the
+// server allows absolute paths.
+func (s *Server) readFile(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	name, _ := arguments["path"].(string)
+	if name == "" {
+		return nil, invalidParams("path is required",
+			paramError{Field: "path", Expected: "a file path relative to the pane's working directory"})
+	}
+	if filepath.IsAbs(name) && !s.allowAbsPaths {
+		return nil, invalidParams("absolute paths are disabled (start the server with --allow-abs-paths)",
+			paramError{Field: "path", Expected: "a file path relative to the pane's working directory"})
+	}
+	maxBytes, err := intArgument(arguments, "max_bytes", defaultReadFileBytes)
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes < 1 || maxBytes > maxReadFileBytes {
+		return nil, invalidParams(fmt.Sprintf("max_bytes must be between 1 and %d", maxReadFileBytes),
+			paramError{Field: "max_bytes", Expected: fmt.Sprintf("integer from 1 to %d", maxReadFileBytes)})
+	}
+
+	command, err := s.tmuxManager.CurrentCommand(target)
+	if err != nil {
+		return toolError(err)
+	}
+	if remoteCommands[path.Base(command)] {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: the pane is running %s, so its files are on another machine; read_file only reads files on the machine running the server", command)}},
+			IsError: true,
+		}, nil
+	}
+	info, err := s.tmuxManager.GetPaneInfoFor(target)
+	if err != nil {
+		return toolError(err)
+	}
+	dir := info["current_path"]
+	if dir == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: "Error: tmux did not report the pane's working directory"}},
+			IsError: true,
+		}, nil
+	}
+
+	resolved, err := resolveReadPath(dir, name, s.allowAbsPaths)
+	if err != nil {
+		return toolError(err)
+	}
+	result, err := readFileHead(resolved, maxBytes)
+	if err != nil {
+		return toolError(err)
+	}
+
+	text := result.Content
+	if result.Truncated {
+		text = appendNote(text, fmt.Sprintf("showing the first %d of %d bytes; raise max_bytes (up to %d) for more", result.Bytes, result.Size, maxReadFileBytes))
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}, nil
+}
+
+// resolveReadPath returns the file name refers to, relative to dir unless
+// it is absolute, with symlinks resolved. Unless free is set, the file must
+// be inside dir once symlinks are resolved, so neither ".." nor a symlink
+// can lead out of it.
+func resolveReadPath(dir, name string, free bool) (string, error) {
+	target := name
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %q: %w", name, err)
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %q: %w", name, err)
+	}
+	if !free {
+		root, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return "", fmt.Errorf("pane working directory unavailable: %w", err)
+		}
+		if root, err = filepath.Abs(root); err != nil {
+			return "", fmt.Errorf("pane working directory unavailable: %w", err)
+		}
+		if !within(root, resolved) {
+			return "", fmt.Errorf("%q is outside the pane's working directory (start the server with --allow-abs-paths to allow it)", name)
+		}
+	}
+	return resolved, nil
+}
+
+// readFileHead reads up to limit bytes of the regular file at path. Files
+// that look binary are refused, and a truncated read is cut back to the
+// last whole UTF-8 character.
+func readFileHead(path string, limit int) (readFileResult, error) {
+	// Checked before opening, since opening a FIFO blocks until something
+	// writes to it, and with it the whole request loop
+	if info, err := os.Stat(path); err != nil {
+		return readFileResult{}, fmt.Errorf("failed to read file: %w", err)
+	} else if !info.Mode().IsRegular() {
+		return readFileResult{}, fmt.Errorf("%s is not a regular file", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return readFileResult{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer f.Close()
+	// Checked again in case the path was replaced in between
+	info, err := f.Stat()
+	if err != nil {
+		return readFileResult{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return readFileResult{}, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, int64(limit)+1))
+	if err != nil {
+		return readFileResult{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	truncated := len(data) > limit
+	if truncated {
+		data = data[:limit]
+		// Drop a character the limit cut in two
+		for end := len(data); end > 0 && len(data)-end < utf8.UTFMax; end-- {
+			if utf8.RuneStart(data[end-1]) {
+				if !utf8.FullRune(data[end-1:]) {
+					data = data[:end-1]
+				}
+				break
+			}
+		}
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return readFileResult{}, fmt.Errorf("%s looks like a binary file; read_file only returns text", path)
+	}
+
+	return readFileResult{
+		Path:      path,
+		Size:      max(info.Size(), int64(len(data))),
+		Bytes:     len(data),
+		Truncated: truncated,
+		Content:   string(data),
+	}, nil
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// newFakeDirServer fakes a pane running command in dir
+func newFakeDirServer(dir, command string) *Server {
+	return newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "display-message" {
+			if args[len(args)-1] == "#{pane_current_command}" {
+				return command + "\n", "", nil
+			}
+			return "80,24," + dir + ",0,0,,0\n", "", nil
+		}
+		return "", "", nil
+	})
+}
+
+func TestServer_callTool_ReadFile(t *testing.T) {
+	// Resolved, since read_file reports paths with symlinks resolved
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "project")
+	outside := filepath.Join(root, "secret.txt")
+	for name, content := range map[string]string{
+		filepath.Join(dir, "go.mod"):        "module example\n",
+		filepath.Join(dir, "docs", "a.txt"): "héllo",
+		filepath.Join(dir, "app.bin"):       "\x7fELF\x00\x01",
+		outside:                             "password\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		command       string
+		arguments     map[string]interface{}
+		allowAbs      bool
+		want          readFileResult
+		wantError     string
+		wantParamsErr bool
+	}{
+		{
+			name:      "relative path",
+			arguments: map[string]interface{}{"path": "go.mod"},
+			want:      readFileResult{Path: filepath.Join(dir, "go.mod"), Size: 15, Bytes: 15, Content: "module example\n"},
+		},
+		{
+			name:      "truncated at a whole character",
+			arguments: map[string]interface{}{"path": "docs/a.txt", "max_bytes": float64(2)},
+			want:      readFileResult{Path: filepath.Join(dir, "docs", "a.txt"), Size: 6, Bytes: 1, Truncated: true, Content: "h"},
+		},
+		{name: "parent directory", arguments: map[string]interface{}{"path": "../secret.txt"}, wantError: "outside the pane's working directory"},
+		{name: "symlink out of the directory", arguments: map[string]interface{}{"path": "link.txt"}, wantError: "outside the pane's working directory"},
+		{name: "absolute path", arguments: map[string]interface{}{"path": outside}, wantParamsErr: true},
+		{
+			name:      "absolute path allowed",
+			arguments: map[string]interface{}{"path": outside},
+			allowAbs:  true,
+			want:      readFileResult{Path: outside, Size: 9, Bytes: 9, Content: "password\n"},
+		},
+		{name: "binary file", arguments: map[string]interface{}{"path": "app.bin"}, wantError: "binary"},
+		{name: "missing file", arguments: map[string]interface{}{"path": "nope.txt"}, wantError: "cannot read"},
+		{name: "directory", arguments: map[string]interface{}{"path": "docs"}, wantError: "not a regular file"},
+		{name: "remote pane", command: "ssh", arguments: map[string]interface{}{"path": "go.mod"}, wantError: "another machine"},
+		{name: "max_bytes too large", arguments: map[string]interface{}{"path": "go.mod", "max_bytes": float64(maxReadFileBytes + 1)}, wantParamsErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := tt.command
+			if command == "" {
+				command = "zsh"
+			}
+			srv := newFakeDirServer(dir, command)
+			WithAbsPaths(tt.allowAbs)(srv)
+
+			response := callFakeTool(srv, "read_file", tt.arguments)
+			if tt.wantParamsErr {
+				if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+					t.Fatalf("response.Error = %v, want invalid params", response.Error)
+				}
+				return
+			}
+			text := toolText(t, response)
+			result := response.Result.(*mcp.CallToolResult)
+			if tt.wantError != "" {
+				if !result.IsError || !strings.Contains(text, tt.wantError) {
+					t.Errorf("read_file = %q, want an error mentioning %q", text, tt.wantError)
+				}
+				return
+			}
+
+			validateStructuredContent(t, findTool(t, srv, "read_file").OutputSchema, result.StructuredContent)
+			if got := result.StructuredContent.(readFileResult); got != tt.want {
+				t.Errorf("read_file = %+v, want %+v", got, tt.want)
+			}
+			if !strings.HasPrefix(text, tt.want.Content) || strings.Contains(text, "[showing") != tt.want.Truncated {
+				t.Errorf("read_file text = %q", text)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_ReadFile_FIFO(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exec.Command("mkfifo", filepath.Join(dir, "pipe")).Run(); err != nil {
+		t.Skipf("mkfifo unavailable: %v", err)
+	}
+	srv := newFakeDirServer(dir, "zsh")
+
+	// Opening a FIFO with no writer would block forever
+	done := make(chan *mcp.JSONRPCResponse, 1)
+	go func() { done <- callFakeTool(srv, "read_file", map[string]interface{}{"path": "pipe"}) }()
+	select {
+	case response := <-done:
+		if text := toolText(t, response); !response.Result.(*mcp.CallToolResult).IsError || !strings.Contains(text, "not a regular file") {
+			t.Errorf("read_file = %q, want it to refuse the FIFO", text)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("read_file blocked opening a FIFO")
+	}
+}
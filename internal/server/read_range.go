@@ -0,0 +1,73 @@
+package server
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// rangeResult is the structured content of read_range
+type rangeResult struct {
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Content string `json:"content"`
+}
+
+// readRange handles the read_range tool: it captures an inclusive range of
+// pane lines and reports the range actually used after clamping
+func (s *Server) readRange(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	start, startErr := requiredIntArgument(arguments, "start")
+	end, endErr := requiredIntArgument(arguments, "end")
+	var invalid []paramError
+	if startErr != nil {
+		invalid = append(invalid, paramError{Field: "start", Expected: "integer"})
+	}
+	if endErr != nil {
+		invalid = append(invalid, paramError{Field: "end", Expected: "integer"})
+	}
+	if len(invalid) > 0 {
+		return nil, invalidParams("start and end must both be integers", invalid...)
+	}
+	if start > end {
+		return nil, invalidParams(fmt.Sprintf("invalid range: start (%d) must not be after end (%d)", start, end),
+			paramError{Field: "start", Expected: "integer no greater than end"})
+	}
+
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	content, used, err := s.tmuxManager.CaptureRange(start, end, tmux.CaptureOptions{Target: target})
+	if err != nil {
+		return toolError(err)
+	}
+	content = s.formatCapture(content, arguments)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{{Type: "text", Text: content}},
+		StructuredContent: rangeResult{
+			Start:   used.Start,
+			End:     used.End,
+			Content: content,
+		},
+	}, nil
+}
+
+// requiredIntArgument returns the integer tool argument name, failing when
+// it is absent or not a whole number
+func requiredIntArgument(arguments map[string]interface{}, name string) (int, error) {
+	switch v := arguments[name].(type) {
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), nil
+		}
+	case int:
+		return v, nil
+	case nil:
+		return 0, fmt.Errorf("missing argument %q", name)
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const (
+	// defaultPageSize is the number of lines per page read_scrollback_page
+	// uses when page_size is not given
+	defaultPageSize = 200
+	// maxPageSize bounds page_size
+	maxPageSize = 5000
+)
+
+// scrollbackPage is the structured content of read_scrollback_page
+type scrollbackPage struct {
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	TotalPages int    `json:"total_pages"`
+	HasMore    bool   `json:"has_more"`
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+	Content    string `json:"content"`
+}
+
+// readScrollbackPage handles the read_scrollback_page tool: one page of
+// the pane's history and screen, counted from the oldest line, so a long
+// log can be walked through in order a page at a time
+func (s *Server) readScrollbackPage(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	page, err := requiredIntArgument(arguments, "page")
+	if err != nil || page < 1 {
+		return nil, invalidParams("page must be an integer of at least 1",
+			paramError{Field: "page", Expected: "integer from 1, the oldest page"})
+	}
+	size, err := intArgument(arguments, "page_size", defaultPageSize)
+	if err != nil {
+		return nil, err
+	}
+	if size < 1 || size > maxPageSize {
+		return nil, invalidParams(fmt.Sprintf("page_size must be between 1 and %d", maxPageSize),
+			paramError{Field: "page_size", Expected: fmt.Sprintf("integer from 1 to %d", maxPageSize)})
+	}
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	content, located, err := s.tmuxManager.CapturePage(page, size, tmux.CaptureOptions{Target: target})
+	if err != nil {
+		return toolError(err)
+	}
+	if page > located.Total {
+		return nil, invalidParams(fmt.Sprintf("page %d is past the last page: the pane has %d pages of %d lines", page, located.Total, size),
+			paramError{Field: "page", Expected: fmt.Sprintf("integer from 1 to %d", located.Total)})
+	}
+	content = s.formatCapture(content, arguments)
+
+	result := scrollbackPage{
+		Page:       located.Number,
+		PageSize:   located.Size,
+		TotalPages: located.Total,
+		HasMore:    located.Number < located.Total,
+		Start:      located.Lines.Start,
+		End:        located.Lines.End,
+		Content:    content,
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{{Type: "text", Text: appendNote(content,
+			fmt.Sprintf("page %d of %d, lines %d to %d", result.Page, result.TotalPages, result.Start, result.End))}},
+		StructuredContent: result,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// newFakePageServer fakes a pane with 1000 history lines and 24 visible,
+// recording the -S and -E of each capture
+func newFakePageServer(ranges *[][]string) *Server {
+	return newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			return "1000,24\n", "", nil
+		case "capture-pane":
+			*ranges = append(*ranges, []string{args[5], args[7]})
+			return "build step 300\nbuild step 301\n", "", nil
+		}
+		return "", "", nil
+	})
+}
+
+func TestServer_callTool_ReadScrollbackPage(t *testing.T) {
+	var ranges [][]string
+	srv := newFakePageServer(&ranges)
+
+	response := callFakeTool(srv, "read_scrollback_page", map[string]interface{}{"page": 2, "page_size": 300})
+	want := "build step 300\nbuild step 301\n\n[page 2 of 4, lines -700 to -401]"
+	if text := toolText(t, response); text != want {
+		t.Errorf("read_scrollback_page text = %q, want %q", text, want)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "read_scrollback_page").OutputSchema, result.StructuredContent)
+	if got := result.StructuredContent.(scrollbackPage); got.TotalPages != 4 || !got.HasMore {
+		t.Errorf("structuredContent = %+v, want 4 pages with more to come", got)
+	}
+	if want := [][]string{{"-700", "-401"}}; !reflect.DeepEqual(ranges, want) {
+		t.Errorf("captured %v, want %v", ranges, want)
+	}
+
+	response = callFakeTool(srv, "read_scrollback_page", map[string]interface{}{"page": 4, "page_size": 300})
+	if got := response.Result.(*mcp.CallToolResult).StructuredContent.(scrollbackPage); got.HasMore || got.Start != -100 || got.End != 23 {
+		t.Errorf("last page = %+v, want lines -100 to 23 with no more", got)
+	}
+}
+
+func TestServer_callTool_ReadScrollbackPage_Invalid(t *testing.T) {
+	var ranges [][]string
+	srv := newFakePageServer(&ranges)
+
+	for _, arguments := range []map[string]interface{}{
+		{},
+		{"page": 0},
+		{"page": 1.5},
+		{"page": 1, "page_size": 0},
+		{"page": 1, "page_size": 5001},
+		{"page": 7},
+	} {
+		if response := callFakeTool(srv, "read_scrollback_page", arguments); response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("read_scrollback_page(%v) error = %+v, want -32602", arguments, response.Error)
+		}
+	}
+	if len(ranges) != 0 {
+		t.Errorf("invalid calls captured %v", ranges)
+	}
+}
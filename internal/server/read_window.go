@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// windowResult is the structured content of read_window
+type windowResult struct {
+	WindowIndex int          `json:"window_index"`
+	WindowName  string       `json:"window_name"`
+	Panes       []windowPane `json:"panes"`
+}
+
+// windowPane is one pane of read_window: its list_panes description plus
+// what it shows
+type windowPane struct {
+	paneEntry
+	Content string `json:"content"`
+}
+
+// readWindow handles the read_window tool: it captures every pane of a
+// window, so a split layout can be read in one call
+func (s *Server) readWindow(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if target.Pane != "" {
+		return nil, invalidParams("read_window reads every pane of a window; use window, not pane",
+			paramError{Field: "pane", Expected: "omitted"})
+	}
+	withANSI, _ := arguments["ansi"].(bool)
+	trim, _ := arguments["trim"].(bool)
+
+	panes, err := s.tmuxManager.WindowPanes(target.Window)
+	if err != nil {
+		return toolError(err)
+	}
+
+	result := windowResult{
+		WindowIndex: panes[0].WindowIndex,
+		WindowName:  panes[0].WindowName,
+		Panes:       make([]windowPane, 0, len(panes)),
+	}
+	var sections []string
+	for _, pane := range panes {
+		content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{
+			EscapeSequences: withANSI,
+			Target:          tmux.Target{Pane: pane.ID},
+		})
+		if err != nil {
+			return toolError(err)
+		}
+		if trim {
+			content = trimContent(content)
+		}
+		content = s.formatCapture(content, arguments)
+
+		result.Panes = append(result.Panes, windowPane{paneEntry: newPaneEntry(pane), Content: content})
+		sections = append(sections, fmt.Sprintf("--- pane %s (index %d) ---\n%s",
+			pane.ID, pane.PaneIndex, strings.TrimRight(content, "\n")))
+	}
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: strings.Join(sections, "\n")}},
+		StructuredContent: result,
+	}, nil
+}
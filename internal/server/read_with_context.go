@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const (
+	// defaultContextLines is the number of history lines read_with_context
+	// adds above the screen when context_lines is not given
+	defaultContextLines = 20
+	// maxContextLines bounds context_lines
+	maxContextLines = 2000
+)
+
+// contextResult is the structured content of read_with_context
+type contextResult struct {
+	// ContextLines is the number of history lines included, fewer than
+	// asked for when the history is shorter
+	ContextLines int `json:"context_lines"`
+	VisibleLines int `json:"visible_lines"`
+	// Start and End are the lines captured, as in read_range
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Content string `json:"content"`
+}
+
+// readWithContext handles the read_with_context tool: the visible pane with
+// the history lines just above it, taken in one capture so the two join up
+// without a gap or an overlap even while output scrolls
+func (s *Server) readWithContext(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	contextLines, err := intArgument(arguments, "context_lines", defaultContextLines)
+	if err != nil {
+		return nil, err
+	}
+	if contextLines < 0 || contextLines > maxContextLines {
+		return nil, invalidParams(fmt.Sprintf("context_lines must be between 0 and %d", maxContextLines),
+			paramError{Field: "context_lines", Expected: fmt.Sprintf("integer from 0 to %d", maxContextLines)})
+	}
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	// The end is clamped to the bottom of the screen, whatever its height
+	content, used, err := s.tmuxManager.CaptureRange(-contextLines, math.MaxInt32, tmux.CaptureOptions{Target: target})
+	if err != nil {
+		return toolError(err)
+	}
+	content = s.formatCapture(content, arguments)
+
+	result := contextResult{
+		ContextLines: -used.Start,
+		VisibleLines: used.End + 1,
+		Start:        used.Start,
+		End:          used.End,
+		Content:      content,
+	}
+	note := fmt.Sprintf("%d lines of scrollback, then the %d visible lines", result.ContextLines, result.VisibleLines)
+	if result.ContextLines < contextLines {
+		note += fmt.Sprintf("; the history holds only %d of the %d lines asked for", result.ContextLines, contextLines)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: appendNote(content, note)}},
+		StructuredContent: result,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_ReadWithContext(t *testing.T) {
+	var ranges [][]string
+	srv := newFakePageServer(&ranges)
+
+	response := callFakeTool(srv, "read_with_context", map[string]interface{}{"context_lines": 50})
+	want := "build step 300\nbuild step 301\n\n[50 lines of scrollback, then the 24 visible lines]"
+	if text := toolText(t, response); text != want {
+		t.Errorf("read_with_context text = %q, want %q", text, want)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "read_with_context").OutputSchema, result.StructuredContent)
+	if got := result.StructuredContent.(contextResult); got.ContextLines != 50 || got.VisibleLines != 24 {
+		t.Errorf("structuredContent = %+v, want 50 context lines and 24 visible", got)
+	}
+	// History and screen come from one capture
+	if want := [][]string{{"-50", "23"}}; !reflect.DeepEqual(ranges, want) {
+		t.Errorf("captured %v, want %v", ranges, want)
+	}
+}
+
+func TestServer_callTool_ReadWithContext_ShortHistory(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "display-message" {
+			return "5,24\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "read_with_context", map[string]interface{}{"context_lines": 50})
+	got := response.Result.(*mcp.CallToolResult).StructuredContent.(contextResult)
+	if got.ContextLines != 5 || got.Start != -5 {
+		t.Errorf("structuredContent = %+v, want the 5 history lines there are", got)
+	}
+	want := "[5 lines of scrollback, then the 24 visible lines; the history holds only 5 of the 50 lines asked for]"
+	if text := toolText(t, response); text != want {
+		t.Errorf("read_with_context text = %q, want %q", text, want)
+	}
+}
+
+func TestServer_callTool_ReadWithContext_Invalid(t *testing.T) {
+	var ranges [][]string
+	srv := newFakePageServer(&ranges)
+
+	for _, arguments := range []map[string]interface{}{
+		{"context_lines": -1},
+		{"context_lines": 2001},
+		{"context_lines": 1.5},
+	} {
+		if response := callFakeTool(srv, "read_with_context", arguments); response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("read_with_context(%v) error = %+v, want -32602", arguments, response.Error)
+		}
+	}
+	if len(ranges) != 0 {
+		t.Errorf("invalid calls captured %v", ranges)
+	}
+}
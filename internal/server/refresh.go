@@ -0,0 +1,86 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const (
+	// defaultRefreshDelay is how long the program is given to repaint
+	// after a refresh before the pane is captured
+	defaultRefreshDelay = 200 * time.Millisecond
+	// maxRefreshDelay bounds delay_ms
+	maxRefreshDelay = 10 * time.Second
+)
+
+// refreshResult is the structured content of refresh
+type refreshResult struct {
+	Method  string `json:"method"`
+	Content string `json:"content"`
+}
+
+// refreshArguments reads the method and delay_ms arguments of refresh
+func refreshArguments(arguments map[string]interface{}) (string, time.Duration, error) {
+	method := tmux.RefreshResize
+	if value, ok := arguments["method"].(string); ok && value != "" {
+		method = value
+	}
+	if method != tmux.RefreshResize && method != tmux.RefreshRedraw {
+		return "", 0, invalidParams(fmt.Sprintf("unsupported method: %s (expected %q or %q)", method, tmux.RefreshResize, tmux.RefreshRedraw),
+			paramError{Field: "method", Expected: fmt.Sprintf("string: %q or %q", tmux.RefreshResize, tmux.RefreshRedraw)})
+	}
+	delayMs, err := intArgument(arguments, "delay_ms", int(defaultRefreshDelay/time.Millisecond))
+	if err != nil {
+		return "", 0, err
+	}
+	delay := time.Duration(delayMs) * time.Millisecond
+	if delay < 0 || delay > maxRefreshDelay {
+		return "", 0, invalidParams(fmt.Sprintf("delay_ms must be between 0 and %d", maxRefreshDelay.Milliseconds()),
+			paramError{Field: "delay_ms", Expected: fmt.Sprintf("number of milliseconds from 0 to %d", maxRefreshDelay.Milliseconds())})
+	}
+	return method, delay, nil
+}
+
+// refreshPane makes the program in the pane repaint and gives it delay to
+// do so
+func (s *Server) refreshPane(target tmux.Target, method string, delay time.Duration) error {
+	if err := s.tmuxManager.Refresh(target, method); err != nil {
+		return err
+	}
+	time.Sleep(delay)
+	return nil
+}
+
+// refresh handles the refresh tool: it nudges the program in a pane into
+// repainting, by a resize or C-l, and returns the screen once it has had
+// time to. Programs that only repaint when something happens otherwise
+// leave a stale frame to capture.
+func (s *Server) refresh(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("refresh"); result != nil {
+		return result, nil
+	}
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	method, delay, err := refreshArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshPane(target, method, delay); err != nil {
+		return toolError(err)
+	}
+	content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{Target: target})
+	if err != nil {
+		return toolError(err)
+	}
+	content = s.formatCapture(content, arguments)
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: content}},
+		StructuredContent: refreshResult{Method: method, Content: content},
+	}, nil
+}
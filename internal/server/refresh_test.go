@@ -0,0 +1,84 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// newFakeRefreshServer fakes a pane that shows a stale frame until it is
+// resized or sent C-l, recording the tmux commands that change it
+func newFakeRefreshServer(calls *[]string) *Server {
+	frame := "stale"
+	return newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			return "80x24\n", "", nil
+		case "resize-window", "send-keys":
+			*calls = append(*calls, args[0])
+			frame = "fresh"
+		case "capture-pane":
+			return frame + "\n", "", nil
+		}
+		return "", "", nil
+	})
+}
+
+func TestServer_callTool_Refresh(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		wantCalls []string
+	}{
+		{name: "resize", arguments: map[string]interface{}{"delay_ms": 0}, wantCalls: []string{"resize-window", "resize-window"}},
+		{name: "redraw", arguments: map[string]interface{}{"method": "redraw", "delay_ms": 0}, wantCalls: []string{"send-keys"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			srv := newFakeRefreshServer(&calls)
+			WithWritesEnabled(true)(srv)
+
+			response := callFakeTool(srv, "refresh", tt.arguments)
+			if text := toolText(t, response); text != "fresh\n" {
+				t.Errorf("refresh text = %q, want the repainted screen", text)
+			}
+			if len(calls) != len(tt.wantCalls) || calls[0] != tt.wantCalls[0] {
+				t.Errorf("calls = %q, want %q", calls, tt.wantCalls)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "refresh").OutputSchema, result.StructuredContent)
+		})
+	}
+}
+
+func TestServer_callTool_Refresh_Invalid(t *testing.T) {
+	var calls []string
+	srv := newFakeRefreshServer(&calls)
+	WithWritesEnabled(true)(srv)
+
+	for _, arguments := range []map[string]interface{}{{"method": "shake"}, {"delay_ms": -1}, {"delay_ms": 60000}} {
+		if response := callFakeTool(srv, "refresh", arguments); response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("refresh(%v) error = %+v, want -32602", arguments, response.Error)
+		}
+	}
+	if len(calls) != 0 {
+		t.Errorf("invalid calls reached tmux: %q", calls)
+	}
+}
+
+func TestServer_callTool_ReadTerminal_Refresh(t *testing.T) {
+	var calls []string
+	srv := newFakeRefreshServer(&calls)
+
+	result := callFakeTool(srv, "read_terminal", map[string]interface{}{"refresh": true}).Result.(*mcp.CallToolResult)
+	if !result.IsError || len(calls) != 0 {
+		t.Errorf("read_terminal with refresh in read-only mode = %+v after %q, want an error", result, calls)
+	}
+
+	WithWritesEnabled(true)(srv)
+	if text := toolText(t, callFakeTool(srv, "read_terminal", map[string]interface{}{"refresh": true})); text != "fresh\n" {
+		t.Errorf("read_terminal with refresh = %q, want the repainted screen", text)
+	}
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// maxCommandTimeout bounds timeout_ms for run_command and the tools built on
+// it, since the server answers nothing else while it waits
+const maxCommandTimeout = time.Hour
+
+// commandResult is the structured content of run_command
+type commandResult struct {
+	Command   string `json:"command"`
+	Output    string `json:"output"`
+	Completed bool   `json:"completed"`
+	// ExitCode is null unless exit_code was requested and the sentinel
+	// line was seen; Note explains why it is missing
+	ExitCode *int   `json:"exit_code"`
+	Note     string `json:"note,omitempty"`
+	// Silent is set by run_command_stream when the output stopped changing
+	// for silence_ms without a prompt appearing
+	Silent bool `json:"silent,omitempty"`
+	// fullOutput is Output before lines were cut to --max-line-width, for
+	// run_command_to_resource to store
+	fullOutput string
+}
+
+// runCommand handles the run_command tool: it types the command into the
+// session, waits for the next prompt and returns what was printed in between.
+// With scratch set it runs in the session's scratch pane, which is created
+// if need be, instead of a pane the user is working in.
+func (s *Server) runCommand(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("run_command"); result != nil {
+		return result, nil
+	}
+	if scratch, _ := arguments["scratch"].(bool); !scratch {
+		return s.executeCommand(arguments, nil)
+	}
+	if arguments["window"] != nil || arguments["pane"] != nil {
+		return nil, invalidParams("scratch cannot be combined with window or pane",
+			paramError{Field: "scratch", Expected: "false or absent when window or pane is given"})
+	}
+	id, _, err := s.scratchPane()
+	if err != nil {
+		return toolError(err)
+	}
+	return s.executeCommand(arguments, func(opts *tmux.RunOptions) {
+		opts.Target = tmux.Target{Pane: id}
+	})
+}
+
+// executeCommand runs the command described by the run_command arguments.
+// customize, when set, adjusts the options before the command is sent.
+func (s *Server) executeCommand(arguments map[string]interface{}, customize func(*tmux.RunOptions)) (*mcp.CallToolResult, error) {
+	command, _ := arguments["command"].(string)
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	timeoutMs, err := intArgument(arguments, "timeout_ms", int(tmux.DefaultCommandTimeout/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	if timeoutMs < 0 || timeoutMs > int(maxCommandTimeout.Milliseconds()) {
+		return nil, invalidParams(fmt.Sprintf("timeout_ms must be between 0 and %d", maxCommandTimeout.Milliseconds()),
+			paramError{Field: "timeout_ms", Expected: fmt.Sprintf("number of milliseconds from 0 to %d", maxCommandTimeout.Milliseconds())})
+	}
+	wantExitCode, _ := arguments["exit_code"].(bool)
+	pollInterval, err := s.pollIntervalArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := tmux.RunOptions{
+		Prompt:       s.promptRegex,
+		Detector:     s.promptDetector,
+		Timeout:      time.Duration(timeoutMs) * time.Millisecond,
+		PollInterval: pollInterval,
+		Target:       target,
+	}
+	if wantExitCode {
+		opts.ExitSentinel = s.exitSentinel
+	}
+	if customize != nil {
+		customize(&opts)
+	}
+
+	result, err := s.tmuxManager.RunCommand(command, opts)
+	if err != nil {
+		return toolError(err)
+	}
+
+	output := truncateLines(result.Output, s.maxLineWidth)
+	structured := commandResult{
+		Command:    command,
+		Output:     output,
+		Completed:  result.Completed,
+		ExitCode:   result.ExitCode,
+		Silent:     result.Silent,
+		fullOutput: result.Output,
+	}
+	if opts.ExitSentinel != "" && result.ExitCode == nil {
+		structured.Note = "exit status line not seen before the timeout; exit code unknown"
+	}
+
+	text := output
+	if result.Silent {
+		text = appendNote(text, fmt.Sprintf("no new output for %dms and no shell prompt; the command may still be running", opts.Silence/time.Millisecond))
+	} else if !result.Completed {
+		text = appendNote(text, fmt.Sprintf("timed out after %dms waiting for the shell prompt; output may be incomplete", timeoutMs))
+	}
+	if result.ExitCode != nil {
+		text = appendNote(text, fmt.Sprintf("exit code: %d", *result.ExitCode))
+	} else if structured.Note != "" {
+		text = appendNote(text, structured.Note)
+	}
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: structured,
+	}, nil
+}
+
+// pollIntervalArgument returns the per-call poll_ms override, or the
+// server's default poll interval when it is absent
+func (s *Server) pollIntervalArgument(arguments map[string]interface{}) (time.Duration, error) {
+	pollMs, err := intArgument(arguments, "poll_ms", 0)
+	if err != nil {
+		return 0, err
+	}
+	if pollMs == 0 {
+		return s.pollInterval, nil
+	}
+	interval := time.Duration(pollMs) * time.Millisecond
+	if err := tmux.ValidatePollInterval(interval); err != nil {
+		return 0, invalidParams(err.Error(),
+			paramError{Field: "poll_ms", Expected: fmt.Sprintf("number of milliseconds, at least %d", tmux.MinPollInterval.Milliseconds())})
+	}
+	return interval, nil
+}
+
+// appendNote appends a bracketed note on its own line after text
+func appendNote(text, note string) string {
+	if text == "" {
+		return "[" + note + "]"
+	}
+	return text + "\n[" + note + "]"
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// runCommandStream handles the run_command_stream tool. It runs a command
+// like run_command, but when the request carries a progress token every new
+// piece of output is sent as a notifications/progress message while the
+// command runs, and the wait can also end once the output goes quiet.
+func (s *Server) runCommandStream(arguments map[string]interface{}, meta *mcp.RequestMeta) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("run_command_stream"); result != nil {
+		return result, nil
+	}
+
+	silenceMs, err := intArgument(arguments, "silence_ms", 0)
+	if err != nil {
+		return nil, err
+	}
+	if silenceMs < 0 {
+		return nil, invalidParams(fmt.Sprintf("silence_ms must not be negative, got %d", silenceMs),
+			paramError{Field: "silence_ms", Expected: "non-negative number of milliseconds"})
+	}
+
+	var token interface{}
+	if meta != nil {
+		token = meta.ProgressToken
+	}
+
+	return s.executeCommand(arguments, func(opts *tmux.RunOptions) {
+		opts.Silence = time.Duration(silenceMs) * time.Millisecond
+		if token == nil {
+			return
+		}
+		var sent string
+		updates := 0
+		opts.OnOutput = func(output string) {
+			chunk := outputChunk(sent, output)
+			sent = output
+			updates++
+			// A client that has gone away only loses the progress; the
+			// final result is written, and fails, through the usual path
+			_ = s.notify("notifications/progress", mcp.ProgressParams{
+				ProgressToken: token,
+				Progress:      float64(updates),
+				Message:       truncateLines(chunk, s.maxLineWidth),
+			})
+		}
+	})
+}
+
+// outputChunk returns what output adds to the output already streamed. When
+// the earlier output is no longer a prefix, because the program redrew its
+// lines, the whole output is sent again.
+func outputChunk(streamed, output string) string {
+	if streamed == "" || !strings.HasPrefix(output, streamed) {
+		return output
+	}
+	return strings.TrimPrefix(output[len(streamed):], "\n")
+}
+
+// notify writes a JSON-RPC notification to the client, unless the client
+// didn't ask for it (see ClientSession.accepts)
+func (s *Server) notify(method string, params interface{}) error {
+	if !s.client.accepts(method, params) {
+		return nil
+	}
+	notification := mcp.JSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params}
+	if err := s.newEncoder().Encode(notification); err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_RunCommandStream(t *testing.T) {
+	captures := []string{"$ \n", "$ make\nbuilding\n", "$ make\nbuilding\nlinking\n", "$ make\nbuilding\nlinking\n$ \n"}
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			out := captures[0]
+			if len(captures) > 1 {
+				captures = captures[1:]
+			}
+			return out, "", nil
+		}
+		return "", "", nil
+	})
+	srv.writesEnabled = true
+
+	response := srv.handleRequest(&mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params: map[string]interface{}{
+			"name":      "run_command_stream",
+			"arguments": map[string]interface{}{"command": "make", "timeout_ms": float64(2000)},
+			"_meta":     map[string]interface{}{"progressToken": "build-1"},
+		},
+	})
+	if text := toolText(t, response); text != "building\nlinking" {
+		t.Errorf("run_command_stream text = %q, want %q", text, "building\nlinking")
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "run_command_stream").OutputSchema, result.StructuredContent)
+
+	var messages []string
+	decoder := json.NewDecoder(srv.writer.(*bytes.Buffer))
+	for decoder.More() {
+		var notification struct {
+			Method string             `json:"method"`
+			Params mcp.ProgressParams `json:"params"`
+		}
+		if err := decoder.Decode(&notification); err != nil {
+			t.Fatalf("failed to decode notification: %v", err)
+		}
+		if notification.Method != "notifications/progress" || notification.Params.ProgressToken != "build-1" {
+			t.Errorf("notification = %+v, want notifications/progress for build-1", notification)
+		}
+		if want := float64(len(messages) + 1); notification.Params.Progress != want {
+			t.Errorf("progress = %v, want %v", notification.Params.Progress, want)
+		}
+		messages = append(messages, notification.Params.Message)
+	}
+	if want := []string{"building", "linking"}; !reflect.DeepEqual(messages, want) {
+		t.Errorf("streamed messages = %q, want %q", messages, want)
+	}
+}
+
+func TestServer_callTool_RunCommandStream_Silence(t *testing.T) {
+	captures := []string{"$ \n", "$ tail -f app.log\nstarted\n"}
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			out := captures[0]
+			if len(captures) > 1 {
+				captures = captures[1:]
+			}
+			return out, "", nil
+		}
+		return "", "", nil
+	})
+	srv.writesEnabled = true
+
+	response := callFakeTool(srv, "run_command_stream", map[string]interface{}{
+		"command":    "tail -f app.log",
+		"timeout_ms": float64(2000),
+		"silence_ms": float64(20),
+	})
+	result := response.Result.(*mcp.CallToolResult)
+	got, ok := result.StructuredContent.(commandResult)
+	if !ok || !got.Silent || got.Completed || got.Output != "started" {
+		t.Errorf("structuredContent = %+v, want silent with output \"started\"", result.StructuredContent)
+	}
+	if srv.writer.(*bytes.Buffer).Len() != 0 {
+		t.Errorf("notifications sent without a progress token: %s", srv.writer)
+	}
+}
+
+func TestOutputChunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		streamed string
+		output   string
+		want     string
+	}{
+		{name: "first output", streamed: "", output: "a\nb", want: "a\nb"},
+		{name: "new lines", streamed: "a", output: "a\nb\nc", want: "b\nc"},
+		{name: "line grew", streamed: "progress 10%", output: "progress 10%...", want: "..."},
+		{name: "redrawn", streamed: "progress 10%", output: "progress 20%", want: "progress 20%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputChunk(tt.streamed, tt.output); got != tt.want {
+				t.Errorf("outputChunk(%q, %q) = %q, want %q", tt.streamed, tt.output, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const (
+	// defaultInterpreter runs run_script's script unless another is named
+	defaultInterpreter = "bash"
+	// maxScriptBytes bounds the script run_script writes to disk
+	maxScriptBytes = 1 << 20
+	// minScriptTimeout is the shortest timeout_ms run_script accepts; the
+	// file is only removed once the prompt returns, so a timeout too short
+	// for any script would leave a file behind on every call
+	minScriptTimeout = time.Second
+)
+
+// interpreterPattern restricts interpreter to a program name or path, since
+// it is typed into the pane unquoted
+var interpreterPattern = regexp.MustCompile(`^[A-Za-z0-9_./+-]+$`)
+
+// runScript handles the run_script tool. The script is written to a
+// temporary file in the pane's working directory by the server process, not
+// through the terminal, so a multi-line script never meets autocompletion,
+// auto-indent or paste timing. Only a one-line command that runs the file
+// is typed into the pane, and the file is removed once the prompt returns.
+func (s *Server) runScript(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("run_script"); result != nil {
+		return result, nil
+	}
+
+	script, _ := arguments["script"].(string)
+	if strings.TrimSpace(script) == "" {
+		return nil, invalidParams("script must not be empty",
+			paramError{Field: "script", Expected: "non-empty string"})
+	}
+	if len(script) > maxScriptBytes {
+		return nil, invalidParams(fmt.Sprintf("script is %d bytes, more than the %d allowed", len(script), maxScriptBytes),
+			paramError{Field: "script", Expected: fmt.Sprintf("at most %d bytes", maxScriptBytes)})
+	}
+	source, _ := arguments["source"].(bool)
+	interpreter, _ := arguments["interpreter"].(string)
+	switch {
+	case source && interpreter != "":
+		return nil, invalidParams("interpreter can't be combined with source, which runs the script in the pane's own shell",
+			paramError{Field: "interpreter", Expected: "absent when source is true"})
+	case interpreter == "":
+		interpreter = defaultInterpreter
+	case !interpreterPattern.MatchString(interpreter):
+		return nil, invalidParams(fmt.Sprintf("invalid interpreter %q", interpreter),
+			paramError{Field: "interpreter", Expected: "program name or path, e.g. \"bash\" or \"/usr/bin/python3\""})
+	}
+	if arguments["timeout_ms"] != nil {
+		timeoutMs, err := intArgument(arguments, "timeout_ms", 0)
+		if err != nil {
+			return nil, err
+		}
+		if timeoutMs < int(minScriptTimeout.Milliseconds()) {
+			return nil, invalidParams(fmt.Sprintf("timeout_ms must be at least %d", minScriptTimeout.Milliseconds()),
+				paramError{Field: "timeout_ms", Expected: fmt.Sprintf("number of milliseconds, at least %d", minScriptTimeout.Milliseconds())})
+		}
+	}
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	// The file is written on this host, so the pane must be at a local
+	// shell: behind ssh, the command would run where the file doesn't exist
+	command, err := s.tmuxManager.CurrentCommand(target)
+	if err != nil {
+		return toolError(err)
+	}
+	if shellName(command) == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: the pane is running %s, not a shell; run_script needs a local shell prompt", command)}},
+			IsError: true,
+		}, nil
+	}
+	info, err := s.tmuxManager.GetPaneInfoFor(target)
+	if err != nil {
+		return toolError(err)
+	}
+	dir := info["current_path"]
+	if dir == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: "Error: tmux did not report the pane's working directory"}},
+			IsError: true,
+		}, nil
+	}
+
+	path, err := writeScript(dir, script)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s", err)}},
+			IsError: true,
+		}, nil
+	}
+	line := interpreter + " " + shellQuote(path)
+	if source {
+		line = ". " + shellQuote(path)
+	}
+	commandArguments := map[string]interface{}{}
+	for name, value := range arguments {
+		commandArguments[name] = value
+	}
+	commandArguments["command"] = line
+	result, err := s.executeCommand(commandArguments, func(opts *tmux.RunOptions) {
+		opts.ExitSentinel = s.exitSentinel
+	})
+	// The interpreter may not have opened the file, let alone read all of
+	// it, until the prompt is back, so a script still running keeps it
+	if structured, ok := resultCommand(result); ok && !structured.Completed {
+		result.Content[0].Text = appendNote(result.Content[0].Text,
+			fmt.Sprintf("the script file %s is left in place while the script may still be running; remove it once it finishes", path))
+		return result, err
+	}
+	os.Remove(path)
+	return result, err
+}
+
+// resultCommand returns the commandResult held by result, if any
+func resultCommand(result *mcp.CallToolResult) (commandResult, bool) {
+	if result == nil {
+		return commandResult{}, false
+	}
+	structured, ok := result.StructuredContent.(commandResult)
+	return structured, ok
+}
+
+// writeScript saves script to a new private file in dir and returns its path
+func writeScript(dir, script string) (string, error) {
+	f, err := os.CreateTemp(dir, ".wingman-script-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create script file: %w", err)
+	}
+	if !strings.HasSuffix(script, "\n") {
+		script += "\n"
+	}
+	if _, err := f.WriteString(script); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write script file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write script file: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// shellQuote quotes s as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// newFakeScriptServer fakes a pane at a bash prompt in dir that runs
+// whatever line is typed, printing output and exiting with exitCode. It
+// records the typed line and the script file's contents at that moment.
+func newFakeScriptServer(t *testing.T, dir, command string, typed, script *string) *Server {
+	t.Helper()
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			if args[len(args)-1] == "#{pane_current_command}" {
+				return command + "\n", "", nil
+			}
+			return "80,24," + dir + ",0,0,,0\n", "", nil
+		case "send-keys":
+			if args[len(args)-2] == "--" {
+				*typed = args[len(args)-1]
+				line := strings.SplitN(*typed, ";", 2)[0]
+				path := strings.Trim(line[strings.Index(line, " ")+1:], "'")
+				content, err := os.ReadFile(path)
+				if err != nil {
+					t.Errorf("script file not readable when the command was typed: %v", err)
+				}
+				*script = string(content)
+			}
+		case "capture-pane":
+			if *typed == "" {
+				return "$ \n", "", nil
+			}
+			return "$ " + *typed + "\nhello\n__EXIT__3\n$ \n", "", nil
+		}
+		return "", "", nil
+	})
+	srv.writesEnabled = true
+	return srv
+}
+
+func TestServer_callTool_RunScript(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		wantStart string
+	}{
+		{name: "default interpreter", arguments: map[string]interface{}{}, wantStart: "bash '"},
+		{name: "python", arguments: map[string]interface{}{"interpreter": "python3"}, wantStart: "python3 '"},
+		{name: "source", arguments: map[string]interface{}{"source": true}, wantStart: ". '"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var typed, script string
+			srv := newFakeScriptServer(t, dir, "bash", &typed, &script)
+
+			arguments := map[string]interface{}{"script": "if true; then\n    echo hello\nfi\nexit 3", "timeout_ms": float64(2000)}
+			for name, value := range tt.arguments {
+				arguments[name] = value
+			}
+			response := callFakeTool(srv, "run_script", arguments)
+			if text := toolText(t, response); text != "hello\n[exit code: 3]" {
+				t.Errorf("run_script text = %q", text)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "run_script").OutputSchema, result.StructuredContent)
+			got := result.StructuredContent.(commandResult)
+			if !strings.HasPrefix(got.Command, tt.wantStart+filepath.Join(dir, ".wingman-script-")) {
+				t.Errorf("command = %q, want it to start with %q and the script path", got.Command, tt.wantStart)
+			}
+			if got.ExitCode == nil || *got.ExitCode != 3 {
+				t.Errorf("exit_code = %v, want 3", got.ExitCode)
+			}
+			if script != "if true; then\n    echo hello\nfi\nexit 3\n" {
+				t.Errorf("script file held %q", script)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("script file left behind: %v", entries)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_RunScript_Rejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		arguments map[string]interface{}
+		wantCode  bool
+		wantText  string
+	}{
+		{name: "empty script", command: "bash", arguments: map[string]interface{}{"script": " \n"}, wantCode: true},
+		{name: "interpreter with shell syntax", command: "bash", arguments: map[string]interface{}{"script": "ls", "interpreter": "sh; rm -rf ~"}, wantCode: true},
+		{name: "interpreter with source", command: "bash", arguments: map[string]interface{}{"script": "ls", "interpreter": "sh", "source": true}, wantCode: true},
+		{name: "timeout too short", command: "bash", arguments: map[string]interface{}{"script": "ls", "timeout_ms": float64(50)}, wantCode: true},
+		{name: "not at a shell", command: "ssh", arguments: map[string]interface{}{"script": "ls"}, wantText: "Error: the pane is running ssh, not a shell"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var typed, script string
+			srv := newFakeScriptServer(t, dir, tt.command, &typed, &script)
+
+			response := callFakeTool(srv, "run_script", tt.arguments)
+			if tt.wantCode {
+				if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+					t.Fatalf("response.Error = %v, want invalid params", response.Error)
+				}
+			} else if text := toolText(t, response); !strings.HasPrefix(text, tt.wantText) {
+				t.Errorf("run_script text = %q, want it to start with %q", text, tt.wantText)
+			}
+			if typed != "" {
+				t.Errorf("run_script typed %q, want nothing", typed)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("script file written: %v", entries)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_RunScript_TimedOut(t *testing.T) {
+	dir := t.TempDir()
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			if args[len(args)-1] == "#{pane_current_command}" {
+				return "bash\n", "", nil
+			}
+			return "80,24," + dir + ",0,0,,0\n", "", nil
+		case "capture-pane":
+			// The script never finishes
+			return "$ bash script\nworking\n", "", nil
+		}
+		return "", "", nil
+	})
+	srv.writesEnabled = true
+
+	response := callFakeTool(srv, "run_script", map[string]interface{}{"script": "sleep 600", "timeout_ms": float64(1000)})
+	if got := response.Result.(*mcp.CallToolResult).StructuredContent.(commandResult); got.Completed {
+		t.Fatalf("structuredContent = %+v, want the script still running", got)
+	}
+	// Removing the file now could pull it from under the interpreter
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("files in the pane's directory = %v, want the script kept", entries)
+	}
+	if text := toolText(t, response); !strings.Contains(text, "the script file "+filepath.Join(dir, entries[0].Name())+" is left in place") {
+		t.Errorf("run_script text = %q, want a note naming the kept file", text)
+	}
+}
+
+func TestServer_callTool_RunScript_ReadOnly(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "send-keys" {
+			t.Errorf("run_script sent keys with writes disabled: %v", args)
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "run_script", map[string]interface{}{"script": "ls"})
+	if result := response.Result.(*mcp.CallToolResult); !result.IsError {
+		t.Error("run_script with writes disabled: IsError = false, want true")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "/tmp/a b", want: `'/tmp/a b'`},
+		{in: "/home/o'brien", want: `'/home/o'\''brien'`},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.in); got != tt.want {
+			t.Errorf("shellQuote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
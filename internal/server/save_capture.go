@@ -0,0 +1,153 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// saveResult is the structured content of save_capture
+type saveResult struct {
+	Path  string `json:"path"`
+	Bytes int    `json:"bytes"`
+}
+
+// saveCapture handles the save_capture tool: it captures the pane and
+// writes the content to a file under the server's --save-dir
+func (s *Server) saveCapture(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("save_capture"); result != nil {
+		return result, nil
+	}
+	if s.saveDir == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: "Error: save_capture is disabled: start the server with --save-dir to choose where captures may be written"}},
+			IsError: true,
+		}, nil
+	}
+
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	name, _ := arguments["path"].(string)
+	if name == "" {
+		return nil, invalidParams("path is required",
+			paramError{Field: "path", Expected: "a file name under the server's --save-dir"})
+	}
+	path, err := resolveSavePath(s.saveDir, name)
+	if err != nil {
+		return nil, invalidParams(err.Error(),
+			paramError{Field: "path", Expected: "a file name under the server's --save-dir"})
+	}
+
+	var content string
+	opts := tmux.CaptureOptions{Target: target}
+	if linesVal, ok := arguments["scrollback_lines"]; ok {
+		lines := tmux.AllLines
+		if linesVal != "all" {
+			if lines, err = coerceInt(linesVal); err != nil || (lines < 1 && lines != tmux.AllLines) {
+				return nil, invalidParams(fmt.Sprintf("invalid scrollback_lines: %v", linesVal),
+					paramError{Field: "scrollback_lines", Expected: `positive integer, or -1 or "all" for the entire history`})
+			}
+		}
+		content, err = s.tmuxManager.GetScrollbackHistoryWithOptions(lines, opts)
+	} else {
+		content, err = s.tmuxManager.CapturePaneWithOptions(opts)
+	}
+	if err != nil {
+		return toolError(err)
+	}
+
+	if err := writeCapture(path, content); err != nil {
+		return toolError(err)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: fmt.Sprintf("Saved %d bytes to %s", len(content), path)}},
+		StructuredContent: saveResult{Path: path, Bytes: len(content)},
+	}, nil
+}
+
+// resolveSavePath returns the absolute path name refers to inside dir. A
+// relative name is taken relative to dir. Names that lead outside dir,
+// whether with ".." or through a symlinked directory, are rejected, as is
+// a name that is itself a symlink. The directory the file goes in must
+// already exist.
+func resolveSavePath(dir, name string) (string, error) {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("save directory unavailable: %v", err)
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("save directory unavailable: %v", err)
+	}
+
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", fmt.Errorf("directory for %q does not exist", name)
+	}
+	parent, err = filepath.Abs(parent)
+	if err != nil {
+		return "", fmt.Errorf("directory for %q does not exist", name)
+	}
+	if !within(root, parent) {
+		return "", fmt.Errorf("%q is outside the save directory", name)
+	}
+	resolved := filepath.Join(parent, filepath.Base(path))
+	if resolved == parent || filepath.Base(path) == "." || filepath.Base(path) == ".." {
+		return "", fmt.Errorf("%q does not name a file", name)
+	}
+
+	if info, err := os.Lstat(resolved); err == nil {
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("%q is a symlink", name)
+		}
+		if !info.Mode().IsRegular() {
+			return "", fmt.Errorf("%q is not a regular file", name)
+		}
+	}
+	return resolved, nil
+}
+
+// within reports whether path is root or inside it. Both must be clean
+// absolute paths.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// writeCapture writes content to path, readable only by the server's user
+// since terminal output can hold anything. The content goes to a new file
+// beside path that is then renamed over it, so a symlink or hard link
+// swapped in at path after resolveSavePath checked it is replaced rather
+// than written through.
+func writeCapture(path, content string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".wingman-capture-*")
+	if err != nil {
+		return fmt.Errorf("failed to save capture: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to save capture: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to save capture: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to save capture: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_SaveCapture(t *testing.T) {
+	dir := t.TempDir()
+	var captureArgs []string
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			captureArgs = args
+			return "$ make\nok\n", "", nil
+		}
+		return "", "", nil
+	})
+	WithWritesEnabled(true)(srv)
+	WithSaveDir(dir)(srv)
+
+	response := callFakeTool(srv, "save_capture", map[string]interface{}{"path": "build.log", "scrollback_lines": float64(50)})
+	toolText(t, response)
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "save_capture").OutputSchema, result.StructuredContent)
+
+	got := result.StructuredContent.(saveResult)
+	root, _ := filepath.EvalSymlinks(dir)
+	if want := filepath.Join(root, "build.log"); got.Path != want || got.Bytes != 10 {
+		t.Errorf("save_capture = %+v, want %s with 10 bytes", got, want)
+	}
+	data, err := os.ReadFile(got.Path)
+	if err != nil || string(data) != "$ make\nok\n" {
+		t.Errorf("saved file = %q, %v", data, err)
+	}
+	if start := captureArgs[5]; start != "-50" {
+		t.Errorf("capture-pane start = %q, want -50", start)
+	}
+}
+
+func TestServer_callTool_SaveCapture_HardLink(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "precious")
+	if err := os.WriteFile(outside, []byte("keep me\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// A hard link looks like any regular file in the save directory
+	if err := os.Link(outside, filepath.Join(dir, "build.log")); err != nil {
+		t.Skipf("hard links unavailable: %v", err)
+	}
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			return "$ make\nok\n", "", nil
+		}
+		return "", "", nil
+	})
+	WithWritesEnabled(true)(srv)
+	WithSaveDir(dir)(srv)
+
+	got := callFakeTool(srv, "save_capture", map[string]interface{}{"path": "build.log"}).Result.(*mcp.CallToolResult).StructuredContent.(saveResult)
+	if data, err := os.ReadFile(outside); err != nil || string(data) != "keep me\n" {
+		t.Errorf("file outside the save directory = %q, %v, want it untouched", data, err)
+	}
+	if data, err := os.ReadFile(got.Path); err != nil || string(data) != "$ make\nok\n" {
+		t.Errorf("saved file = %q, %v", data, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("save directory holds %v, want only build.log", entries)
+	}
+}
+
+func TestServer_callTool_SaveCapture_Rejected(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "target"), filepath.Join(dir, "link.log")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{
+		"../out.log",
+		"sub/../../out.log",
+		filepath.Join(outside, "out.log"),
+		"escape/out.log",
+		"link.log",
+		"missing/out.log",
+		".",
+	} {
+		srv := newFakeServer(func(args ...string) (string, string, error) {
+			if args[0] == "capture-pane" {
+				t.Errorf("captured for rejected path %q", path)
+			}
+			return "", "", nil
+		})
+		WithWritesEnabled(true)(srv)
+		WithSaveDir(dir)(srv)
+
+		response := callFakeTool(srv, "save_capture", map[string]interface{}{"path": path})
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("save_capture(%q) error = %+v, want invalid params", path, response.Error)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("files written outside the save directory: %v", entries)
+	}
+}
+
+func TestServer_callTool_SaveCapture_Disabled(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	WithWritesEnabled(true)(srv)
+
+	result := callFakeTool(srv, "save_capture", map[string]interface{}{"path": "out.log"}).Result.(*mcp.CallToolResult)
+	if !result.IsError {
+		t.Error("save_capture without --save-dir succeeded, want an error result")
+	}
+
+	readOnly := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	WithSaveDir(t.TempDir())(readOnly)
+	if result := callFakeTool(readOnly, "save_capture", map[string]interface{}{"path": "out.log"}).Result.(*mcp.CallToolResult); !result.IsError {
+		t.Error("save_capture on a read-only server succeeded, want an error result")
+	}
+}
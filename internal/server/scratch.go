@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// scratchWindowName names the window get_or_create_scratch keeps for the
+// agent's own work
+const scratchWindowName = "mcp-scratch"
+
+// scratchResult is the structured content of get_or_create_scratch
+type scratchResult struct {
+	Session string `json:"session"`
+	Window  string `json:"window"`
+	Pane    string `json:"pane"`
+	Created bool   `json:"created"`
+}
+
+// scratchPane returns the ID of the session's scratch pane, creating its
+// window on first use. The ID is remembered per session, so the pane is
+// found again after bind_session switches away and back, and even if the
+// user renames the window.
+func (s *Server) scratchPane() (string, bool, error) {
+	session := s.tmuxManager.SessionName()
+	id, created, err := s.tmuxManager.EnsureWindow(scratchWindowName, s.scratchPanes[session])
+	if err != nil {
+		return "", false, err
+	}
+	if s.scratchPanes == nil {
+		s.scratchPanes = make(map[string]string)
+	}
+	s.scratchPanes[session] = id
+	return id, created, nil
+}
+
+// getOrCreateScratch handles the get_or_create_scratch tool: it gives the
+// agent a window of its own to run commands in, away from the panes the
+// user is working in, and the same one on every later call
+func (s *Server) getOrCreateScratch() (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("get_or_create_scratch"); result != nil {
+		return result, nil
+	}
+
+	id, created, err := s.scratchPane()
+	if err != nil {
+		return toolError(err)
+	}
+	result := scratchResult{
+		Session: s.tmuxManager.SessionName(),
+		Window:  scratchWindowName,
+		Pane:    id,
+		Created: created,
+	}
+	text := fmt.Sprintf("reusing scratch pane %s in window %q", id, scratchWindowName)
+	if created {
+		text = fmt.Sprintf("created scratch pane %s in window %q", id, scratchWindowName)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text + "; pass {\"pane\": \"" + id + "\"} or {\"scratch\": true} to run_command to use it"}},
+		StructuredContent: result,
+	}, nil
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// newFakeScratchServer fakes a session with one window, in which
+// new-window creates the mcp-scratch window with pane %9. windows counts
+// the windows created and sentTo records the target of each send-keys.
+func newFakeScratchServer(windows *int, sentTo *[]string) *Server {
+	captures := []string{"$ \n", "$ make\nok\n$ \n"}
+	return newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "list-panes":
+			panes := "%0\t0\tbash\t0\t1\t1\t80\t24\tbash\t0\t\t1700000000\t1699990000\n"
+			if *windows > 0 {
+				panes += "%9\t1\tmcp-scratch\t0\t1\t0\t80\t24\tbash\t0\t\t1700000000\t1699990000\n"
+			}
+			return panes, "", nil
+		case "new-window":
+			*windows++
+			return "%9\n", "", nil
+		case "send-keys":
+			*sentTo = append(*sentTo, args[2])
+		case "capture-pane":
+			out := captures[0]
+			if len(captures) > 1 {
+				captures = captures[1:]
+			}
+			return out, "", nil
+		}
+		return "", "", nil
+	})
+}
+
+func TestServer_callTool_GetOrCreateScratch(t *testing.T) {
+	var windows int
+	var sentTo []string
+	srv := newFakeScratchServer(&windows, &sentTo)
+
+	result := callFakeTool(srv, "get_or_create_scratch", nil).Result.(*mcp.CallToolResult)
+	if !result.IsError || windows != 0 {
+		t.Fatalf("get_or_create_scratch in read-only mode = %+v after %d windows, want an error", result, windows)
+	}
+
+	WithWritesEnabled(true)(srv)
+	for i, wantCreated := range []bool{true, false} {
+		result := callFakeTool(srv, "get_or_create_scratch", nil).Result.(*mcp.CallToolResult)
+		validateStructuredContent(t, findTool(t, srv, "get_or_create_scratch").OutputSchema, result.StructuredContent)
+		got := result.StructuredContent.(scratchResult)
+		if got.Pane != "%9" || got.Created != wantCreated || got.Session != "fake-session" {
+			t.Errorf("call %d: structuredContent = %+v, want pane %%9 created=%v", i, got, wantCreated)
+		}
+	}
+	if windows != 1 {
+		t.Errorf("created %d windows, want 1", windows)
+	}
+}
+
+func TestServer_callTool_RunCommand_Scratch(t *testing.T) {
+	var windows int
+	var sentTo []string
+	srv := newFakeScratchServer(&windows, &sentTo)
+	WithWritesEnabled(true)(srv)
+
+	response := callFakeTool(srv, "run_command", map[string]interface{}{"command": "make", "scratch": true, "timeout_ms": float64(2000)})
+	if text := toolText(t, response); text != "ok" {
+		t.Errorf("run_command text = %q, want %q", text, "ok")
+	}
+	if windows != 1 {
+		t.Errorf("created %d windows, want 1", windows)
+	}
+	if len(sentTo) == 0 {
+		t.Error("run_command sent no keys")
+	}
+	for _, target := range sentTo {
+		if target != "%9" {
+			t.Errorf("send-keys target = %q, want the scratch pane %%9", target)
+		}
+	}
+
+	response = callFakeTool(srv, "run_command", map[string]interface{}{"command": "make", "scratch": true, "pane": "0"})
+	if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("run_command with scratch and pane error = %+v, want -32602", response.Error)
+	}
+}
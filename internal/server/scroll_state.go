@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// scrollState is the structured content of scroll_state
+type scrollState struct {
+	InMode         bool   `json:"in_mode"`
+	Mode           string `json:"mode"`
+	ScrollPosition int    `json:"scroll_position"`
+}
+
+// text describes the state for the text content of a result
+func (s scrollState) text() string {
+	switch {
+	case !s.InMode:
+		return "pane is not in a mode"
+	case s.ScrollPosition > 0:
+		return fmt.Sprintf("pane is in %s, scrolled back %d lines; reads still return the live output", s.Mode, s.ScrollPosition)
+	default:
+		return fmt.Sprintf("pane is in %s at the bottom", s.Mode)
+	}
+}
+
+// getScrollState handles the scroll_state tool: it reports whether someone
+// has put the pane in copy-mode or another mode, and how far it is scrolled
+func (s *Server) getScrollState(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.tmuxManager.GetScrollState(target)
+	if err != nil {
+		return toolError(err)
+	}
+	result := scrollState{InMode: state.InMode, Mode: state.Mode, ScrollPosition: state.Position}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: result.text()}},
+		StructuredContent: result,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const (
+	// defaultSendReadWait is how long send_and_read waits before capturing
+	// when neither wait_ms nor until_pattern is given
+	defaultSendReadWait = 500 * time.Millisecond
+
+	// defaultSendReadTimeout bounds the wait for until_pattern
+	defaultSendReadTimeout = 10 * time.Second
+)
+
+// sendReadResult is the structured content of send_and_read
+type sendReadResult struct {
+	Content string `json:"content"`
+	// Matched reports whether until_pattern appeared before the timeout;
+	// it is omitted when no pattern was given
+	Matched *bool `json:"matched,omitempty"`
+}
+
+// sendAndRead handles the send_and_read tool: it types into a pane like
+// send_keys, waits for a fixed delay or for a pattern to appear, and
+// returns what the pane shows then
+func (s *Server) sendAndRead(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("send_and_read"); result != nil {
+		return result, nil
+	}
+
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	text, keys, err := inputArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	_, hasWait := arguments["wait_ms"]
+	patternText, _ := arguments["until_pattern"].(string)
+	if hasWait && patternText != "" {
+		return nil, invalidParams("wait_ms and until_pattern cannot be combined",
+			paramError{Field: "wait_ms", Expected: "absent when until_pattern is given"})
+	}
+	var pattern *regexp.Regexp
+	if patternText != "" {
+		// Patterns match against the whole capture, line by line
+		if pattern, err = regexp.Compile("(?m)" + patternText); err != nil {
+			return nil, invalidParams(fmt.Sprintf("invalid until_pattern: %v", err),
+				paramError{Field: "until_pattern", Expected: "RE2 regular expression"})
+		}
+	}
+	waitMs, err := intArgument(arguments, "wait_ms", int(defaultSendReadWait/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	timeoutMs, err := intArgument(arguments, "timeout_ms", int(defaultSendReadTimeout/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	wait := time.Duration(waitMs) * time.Millisecond
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	if wait < 0 || timeout < 0 {
+		return nil, invalidParams("wait_ms and timeout_ms must not be negative",
+			paramError{Field: "wait_ms", Expected: "non-negative number of milliseconds"},
+			paramError{Field: "timeout_ms", Expected: "non-negative number of milliseconds"})
+	}
+	pollInterval, err := s.pollIntervalArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sendInput(target, text, keys); err != nil {
+		return toolError(err)
+	}
+
+	var content string
+	var result sendReadResult
+	if pattern == nil {
+		time.Sleep(wait)
+		if content, err = s.captureTarget(target); err != nil {
+			return toolError(err)
+		}
+	} else {
+		deadline := time.Now().Add(timeout)
+		matched := false
+		for {
+			time.Sleep(pollInterval)
+			if content, err = s.captureTarget(target); err != nil {
+				return toolError(err)
+			}
+			if matched = pattern.MatchString(content); matched || time.Now().After(deadline) {
+				break
+			}
+		}
+		result.Matched = &matched
+	}
+
+	result.Content = s.formatCapture(trimContent(content), arguments)
+	out := result.Content
+	if result.Matched != nil && !*result.Matched {
+		out = appendNote(out, fmt.Sprintf("until_pattern did not appear within %dms", timeout/time.Millisecond))
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: out}},
+		StructuredContent: result,
+	}, nil
+}
+
+// captureTarget captures the visible content of the target pane
+func (s *Server) captureTarget(target tmux.Target) (string, error) {
+	return s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{Target: target})
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_SendAndRead(t *testing.T) {
+	matched, missed := true, false
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		captures  []string
+		want      sendReadResult
+		wantNote  bool
+	}{
+		{
+			name:      "fixed wait",
+			arguments: map[string]interface{}{"text": "y", "keys": []interface{}{"Enter"}, "wait_ms": float64(1)},
+			captures:  []string{"Remove file? [y/N] y\nremoved\n$ \n\n\n"},
+			want:      sendReadResult{Content: "Remove file? [y/N] y\nremoved\n$\n"},
+		},
+		{
+			name:      "until pattern",
+			arguments: map[string]interface{}{"keys": []interface{}{"Enter"}, "until_pattern": `\[y/N\]`, "timeout_ms": float64(2000)},
+			captures:  []string{"$ apt remove foo\n", "$ apt remove foo\nContinue? [y/N] \n"},
+			want:      sendReadResult{Content: "$ apt remove foo\nContinue? [y/N]\n", Matched: &matched},
+		},
+		{
+			name:      "pattern never appears",
+			arguments: map[string]interface{}{"keys": []interface{}{"Enter"}, "until_pattern": `^done$`, "timeout_ms": float64(20)},
+			captures:  []string{"working\n"},
+			want:      sendReadResult{Content: "working\n", Matched: &missed},
+			wantNote:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captures := tt.captures
+			var sent [][]string
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "send-keys":
+					sent = append(sent, args)
+				case "capture-pane":
+					out := captures[0]
+					if len(captures) > 1 {
+						captures = captures[1:]
+					}
+					return out, "", nil
+				}
+				return "", "", nil
+			})
+			srv.writesEnabled = true
+
+			response := callFakeTool(srv, "send_and_read", tt.arguments)
+			text := toolText(t, response)
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "send_and_read").OutputSchema, result.StructuredContent)
+			if got := result.StructuredContent.(sendReadResult); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("structuredContent = %+v, want %+v", got, tt.want)
+			}
+			if hasNote := text != tt.want.Content; hasNote != tt.wantNote {
+				t.Errorf("text = %q, want note: %v", text, tt.wantNote)
+			}
+			if len(sent) == 0 {
+				t.Error("send_and_read sent nothing")
+			}
+		})
+	}
+}
+
+func TestServer_callTool_SendAndRead_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+	}{
+		{name: "nothing to send", arguments: map[string]interface{}{"wait_ms": float64(10)}},
+		{name: "wait and pattern", arguments: map[string]interface{}{"keys": []interface{}{"y"}, "wait_ms": float64(10), "until_pattern": "x"}},
+		{name: "bad pattern", arguments: map[string]interface{}{"keys": []interface{}{"y"}, "until_pattern": "["}},
+		{name: "negative wait", arguments: map[string]interface{}{"keys": []interface{}{"y"}, "wait_ms": float64(-1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				if args[0] == "send-keys" {
+					t.Errorf("send_and_read sent keys for invalid params: %v", args)
+				}
+				return "", "", nil
+			})
+			srv.writesEnabled = true
+
+			response := callFakeTool(srv, "send_and_read", tt.arguments)
+			if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+				t.Errorf("response.Error = %v, want code %d", response.Error, mcp.CodeInvalidParams)
+			}
+		})
+	}
+}
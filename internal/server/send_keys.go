@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// sendKeys handles the send_keys tool: it types literal text and/or sends
+// tmux key names to the selected pane
+func (s *Server) sendKeys(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("send_keys"); result != nil {
+		return result, nil
+	}
+
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	text, keys, err := inputArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sendInput(target, text, keys); err != nil {
+		return toolError(err)
+	}
+
+	text = fmt.Sprintf("Sent to %s", target)
+	if note := s.prefixNote(keys); note != "" {
+		text = appendNote(text, note)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{{Type: "text", Text: text}},
+	}, nil
+}
+
+// inputArguments returns the text and keys arguments shared by the tools
+// that type into a pane. At least one of them must be given.
+func inputArguments(arguments map[string]interface{}) (string, []string, error) {
+	text, _ := arguments["text"].(string)
+	var keys []string
+	if raw, ok := arguments["keys"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return "", nil, invalidParams("keys must be an array of strings",
+				paramError{Field: "keys", Expected: "array of tmux key names"})
+		}
+		for _, item := range list {
+			key, ok := item.(string)
+			if !ok || key == "" {
+				return "", nil, invalidParams("keys must be an array of strings",
+					paramError{Field: "keys", Expected: "array of tmux key names"})
+			}
+			keys = append(keys, key)
+		}
+	}
+	if text == "" && len(keys) == 0 {
+		return "", nil, invalidParams("nothing to send: provide text, keys or both",
+			paramError{Field: "text", Expected: "non-empty string"},
+			paramError{Field: "keys", Expected: "array of tmux key names"})
+	}
+	return text, keys, nil
+}
+
+// sendInput types text literally and then sends keys to the target pane
+func (s *Server) sendInput(target tmux.Target, text string, keys []string) error {
+	if text != "" {
+		if err := s.tmuxManager.SendTextTo(target, text); err != nil {
+			return err
+		}
+	}
+	if len(keys) > 0 {
+		if err := s.tmuxManager.SendKeysTo(target, keys...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/ansi"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// pinentryCommands are gpg's password dialogs, which draw a box rather than
+// ending the screen with a prompt
+var pinentryCommands = map[string]bool{
+	"pinentry":        true,
+	"pinentry-curses": true,
+	"pinentry-tty":    true,
+}
+
+// secretResult is the structured content of send_secret. It never holds
+// the secret.
+type secretResult struct {
+	Sent bool `json:"sent"`
+	// AwaitingPassword is whether the pane looked like it was asking for
+	// a password before anything was sent; Reason says why
+	AwaitingPassword bool   `json:"awaiting_password"`
+	Reason           string `json:"reason"`
+	Command          string `json:"command"`
+	Enter            bool   `json:"enter"`
+}
+
+// sendSecret handles the send_secret tool: it types a password or other
+// secret into a pane that is asking for one. The secret is kept out of the
+// trace, the tmux recording and every result. Unless force is set, nothing
+// is sent to a pane that doesn't look like it is asking for a password,
+// where the secret would be echoed or run as a command.
+func (s *Server) sendSecret(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("send_secret"); result != nil {
+		return result, nil
+	}
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	secret, _ := arguments["secret"].(string)
+	if secret == "" || strings.ContainsAny(secret, "\r\n") {
+		// The message must not quote the secret
+		return nil, invalidParams("secret is required and must be a single line",
+			paramError{Field: "secret", Expected: "non-empty string without newlines"})
+	}
+	enter := true
+	if value, ok := arguments["enter"].(bool); ok {
+		enter = value
+	}
+	force, _ := arguments["force"].(bool)
+
+	command, err := s.tmuxManager.CurrentCommand(target)
+	if err != nil {
+		return toolError(err)
+	}
+	content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{Target: target})
+	if err != nil {
+		return toolError(err)
+	}
+	result := secretResult{Command: command, Enter: enter}
+	result.AwaitingPassword, result.Reason = awaitingPassword(command, content)
+
+	if !result.AwaitingPassword && !force {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf(
+				"Error: nothing sent, because the pane doesn't look like it is asking for a password (%s). "+
+					"Typed anywhere else, the secret could be echoed on screen or run as a command and saved in the shell's history. "+
+					"Pass force to send it anyway", result.Reason)}},
+			StructuredContent: result,
+			IsError:           true,
+		}, nil
+	}
+	if err := s.tmuxManager.SendSecret(target, secret, enter); err != nil {
+		return toolError(err)
+	}
+	result.Sent = true
+
+	text := fmt.Sprintf("Sent the secret to %s", command)
+	if enter {
+		text += " and pressed Enter"
+	}
+	if !result.AwaitingPassword {
+		text = appendNote(text, "sent with force although the pane didn't look like it was asking for a password ("+result.Reason+"); check the screen for an echo")
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}, nil
+}
+
+// awaitingPassword judges from the foreground command and the screen
+// whether the pane is waiting for a password, and says why
+func awaitingPassword(command, content string) (bool, string) {
+	line, _ := lastNonBlankLine(content)
+	line = strings.TrimRight(ansi.Strip(line), " ")
+	switch {
+	case passwordPrompt.MatchString(line):
+		return true, fmt.Sprintf("%s shows the password prompt %q", command, line)
+	case pinentryCommands[path.Base(command)]:
+		return true, fmt.Sprintf("%s, gpg's password dialog, is running", command)
+	case shellName(command) != "":
+		return false, fmt.Sprintf("the pane is at %s rather than a password prompt", command)
+	}
+	return false, fmt.Sprintf("%s is running, but the last line %q doesn't ask for a password", command, line)
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const testSecret = "hunter2"
+
+func TestServer_callTool_SendSecret(t *testing.T) {
+	tests := []struct {
+		name         string
+		command      string
+		screen       string
+		force        bool
+		wantSent     bool
+		wantAwaiting bool
+		wantText     string
+	}{
+		{
+			name:         "sudo prompt",
+			command:      "sudo",
+			screen:       "$ sudo apt update\n[sudo] password for conall: \n\n",
+			wantSent:     true,
+			wantAwaiting: true,
+			wantText:     "Sent the secret to sudo and pressed Enter",
+		},
+		{
+			name:         "pinentry",
+			command:      "pinentry-curses",
+			screen:       "┌────────────┐\n│ Passphrase │\n└────────────┘\n",
+			wantSent:     true,
+			wantAwaiting: true,
+			wantText:     "Sent the secret to pinentry-curses",
+		},
+		{
+			name:     "shell prompt",
+			command:  "bash",
+			screen:   "$ \n",
+			wantText: "Error: nothing sent, because the pane doesn't look like it is asking for a password (the pane is at bash",
+		},
+		{
+			name:     "forced",
+			command:  "vim",
+			screen:   "~\n~\n:",
+			force:    true,
+			wantSent: true,
+			wantText: "Sent the secret to vim and pressed Enter\n[sent with force although",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent [][]string
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "display-message":
+					return tt.command + "\n", "", nil
+				case "capture-pane":
+					return tt.screen, "", nil
+				case "send-keys":
+					sent = append(sent, args)
+				}
+				return "", "", nil
+			})
+			srv.writesEnabled = true
+
+			response := callFakeTool(srv, "send_secret", map[string]interface{}{"secret": testSecret, "force": tt.force})
+			text := toolText(t, response)
+			if !strings.HasPrefix(text, tt.wantText) {
+				t.Errorf("send_secret text = %q, want it to start with %q", text, tt.wantText)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "send_secret").OutputSchema, result.StructuredContent)
+			got := result.StructuredContent.(secretResult)
+			if got.Sent != tt.wantSent || got.AwaitingPassword != tt.wantAwaiting {
+				t.Errorf("sent, awaiting_password = %v, %v, want %v, %v", got.Sent, got.AwaitingPassword, tt.wantSent, tt.wantAwaiting)
+			}
+			if result.IsError == tt.wantSent {
+				t.Errorf("isError = %v with sent %v", result.IsError, got.Sent)
+			}
+			if tt.wantSent != (len(sent) == 2) {
+				t.Fatalf("send-keys calls = %v, want the secret and Enter: %v", sent, tt.wantSent)
+			}
+			if tt.wantSent && (sent[0][len(sent[0])-1] != testSecret || sent[1][len(sent[1])-1] != "Enter") {
+				t.Errorf("send-keys calls = %v, want the secret then Enter", sent)
+			}
+
+			encoded, err := json.Marshal(response)
+			if err != nil {
+				t.Fatalf("marshal response: %v", err)
+			}
+			if bytes.Contains(encoded, []byte(testSecret)) {
+				t.Errorf("response %s contains the secret", encoded)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_SendSecret_Invalid(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "send-keys" {
+			t.Errorf("send_secret sent keys for an invalid secret: %v", args)
+		}
+		return "", "", nil
+	})
+	srv.writesEnabled = true
+
+	for _, secret := range []interface{}{nil, "", "two\nlines"} {
+		response := callFakeTool(srv, "send_secret", map[string]interface{}{"secret": secret})
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("send_secret(%q) error = %+v, want invalid params", secret, response.Error)
+			continue
+		}
+		if strings.Contains(response.Error.Message, "two") {
+			t.Errorf("send_secret error %q quotes the secret", response.Error.Message)
+		}
+	}
+}
+
+func TestServer_callTool_SendSecret_ReadOnly(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "send-keys" {
+			t.Errorf("send_secret sent keys with writes disabled: %v", args)
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "send_secret", map[string]interface{}{"secret": testSecret})
+	if result := response.Result.(*mcp.CallToolResult); !result.IsError {
+		t.Errorf("send_secret with writes disabled returned %q, want an error", toolText(t, response))
+	}
+}
+
+func TestServer_Start_TraceRedactsSecret(t *testing.T) {
+	requests := traceHandshake + `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"send_secret","arguments":{"secret":"` + testSecret + `"}}}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"send_keys","arguments":{"text":"echo ` + testSecret + `"}}}` + "\n"
+	trace := &bytes.Buffer{}
+	var typed []string
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			return "sudo\n", "", nil
+		case "capture-pane":
+			return "Password: ", "", nil
+		case "send-keys":
+			typed = append(typed, args[len(args)-1])
+		}
+		return "", "", nil
+	})
+	srv.reader = strings.NewReader(requests)
+	srv.writer = &bytes.Buffer{}
+	srv.writesEnabled = true
+	WithTrace(trace)(srv)
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if !slices.Contains(typed, testSecret) {
+		t.Errorf("typed %q, want the secret among them", typed)
+	}
+	// Only send_secret's argument is redacted; send_keys is traced as sent
+	if got := strings.Count(trace.String(), testSecret); got != 1 {
+		t.Errorf("trace holds the secret %d times, want once (from send_keys):\n%s", got, trace)
+	}
+	if !strings.Contains(trace.String(), tmux.RedactedSecret) {
+		t.Errorf("trace = %s, want the secret replaced by %s", trace, tmux.RedactedSecret)
+	}
+}
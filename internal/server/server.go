@@ -1,10 +1,18 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/ansi"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/git"
 	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
 	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
 )
@@ -12,8 +20,19 @@ import (
 const (
 	ProtocolVersion = "2024-11-05"
 	ServerName      = "mcp-ssh-wingman"
+
+	// defaultScrollbackLines is used when read_scrollback is called without a line count
+	defaultScrollbackLines = 100
 )
 
+// ErrIdleTimeout is returned by Start when the server shuts down because no
+// request arrived within the configured idle timeout
+var ErrIdleTimeout = errors.New("idle timeout: no requests received")
+
+// ErrInitTimeout is returned by Start when the client hasn't sent
+// initialize within the configured initialize timeout
+var ErrInitTimeout = errors.New("initialize timeout: client never sent initialize")
+
 var (
 	// ServerVersion is set via ldflags during build (e.g., -ldflags "-X github.com/conall-obrien/mcp-ssh-wingman/internal/server.Version=v1.0.0")
 	ServerVersion = "dev"
@@ -24,29 +43,446 @@ type Server struct {
 	tmuxManager *tmux.Manager
 	reader      io.Reader
 	writer      io.Writer
+
+	// name and version are reported in the initialize handshake
+	name    string
+	version string
+
+	// writesEnabled permits tools that modify the tmux session. The server
+	// is read-only unless this is explicitly turned on.
+	writesEnabled bool
+
+	// runtimeUnlock permits unlock_writes and lock_writes to change
+	// writesEnabled while the server runs; unlockSecret, when set, must be
+	// passed to unlock_writes
+	runtimeUnlock bool
+	unlockSecret  string
+
+	// promptRegex recognises the shell prompt when detecting that a command
+	// has finished; nil selects tmux.DefaultPromptPattern
+	promptRegex *regexp.Regexp
+
+	// promptDetector, when set, recognises the prompt for run_command in
+	// place of promptRegex
+	promptDetector tmux.PromptDetector
+
+	// exitSentinel prefixes the line run_command echoes to report a
+	// command's exit status
+	exitSentinel string
+
+	// pollInterval is the default delay between captures for every tool
+	// that polls the pane
+	pollInterval time.Duration
+
+	// keepWarmInterval, when non-zero, is how long the server waits
+	// without a request before pressing Enter at an idle prompt (see
+	// keepWarm), and again after each press
+	keepWarmInterval time.Duration
+
+	// idleTimeout, when non-zero, stops the server after this long without
+	// a request
+	idleTimeout time.Duration
+
+	// initTimeout, when non-zero, stops the server if initialize hasn't
+	// arrived this long after Start
+	initTimeout time.Duration
+
+	// maxLineWidth, when non-zero, truncates longer lines in read output
+	maxLineWidth int
+
+	// homeSession is the session the server started with, which
+	// bind_session may always return to
+	homeSession string
+
+	// bindableSessions are the patterns, in path.Match syntax, naming
+	// other sessions bind_session may switch to; empty disables the tool
+	bindableSessions []string
+
+	// saveDir is the only directory save_capture may write to; empty
+	// disables the tool
+	saveDir string
+
+	// allowAbsPaths lets read_file read absolute paths and paths outside
+	// the pane's working directory
+	allowAbsPaths bool
+
+	// scratchPanes maps a session name to the ID of the scratch pane
+	// get_or_create_scratch made or found in it
+	scratchPanes map[string]string
+
+	// jobsCommands maps a shell name to the command that lists its
+	// background jobs, overriding defaultJobsCommands
+	jobsCommands map[string]string
+
+	// pretty indents every message written to the client, for reading the
+	// protocol by eye
+	pretty bool
+
+	// tracer, when set, records every raw message read from and written to
+	// the client
+	tracer *tracer
+
+	// gitStatus reads the git state of a directory for git_status; tests
+	// replace it to avoid depending on a real repository
+	gitStatus func(ctx context.Context, dir string) (*git.Status, error)
+
+	// readEnviron reads a process's environment for pane_env; tests
+	// replace it to avoid depending on /proc
+	readEnviron func(pid int) ([]byte, error)
+
+	// childProcesses lists the tmux server's child processes for
+	// popup_state; tests replace it to avoid depending on /proc
+	childProcesses func(parent int) ([]childProcess, error)
+
+	// cancellations records requests the client has cancelled, for tools
+	// that can stop early
+	cancellations cancellations
+
+	// snapshots holds the captures taken by snapshot and diff_captures for
+	// later comparison
+	snapshots snapshotStore
+
+	// sizes holds the pane sizes orient and snapshot last saw, so they can
+	// report a resize
+	sizes sizeTracker
+
+	// outputs holds the command outputs run_command_to_resource stored,
+	// for resources/read, each for outputTTL
+	outputs   outputStore
+	outputTTL time.Duration
+
+	// gridBaseline is the last grid capture, which capture_grid_diff
+	// compares the pane against
+	gridBaseline gridBaseline
+
+	// client is the state of the client on the connection, from its
+	// initialize request on
+	client ClientSession
+
+	// metrics collects per-tool statistics for server_status and the
+	// Prometheus endpoint; nil when collection is off
+	metrics *metrics
+	// collectMetrics is set by WithMetrics; NewServer creates metrics once
+	// the other options are applied
+	collectMetrics bool
+
+	// started is when the server was created, for server_status uptime
+	started time.Time
+
+	// maxRequestBytes bounds the size of one message from the client;
+	// zero or less means no limit
+	maxRequestBytes int64
+
+	// singleShot makes Start return after the first response, for hosts
+	// that spawn a process per request
+	singleShot bool
+}
+
+// Option configures optional Server behaviour
+type Option func(*Server)
+
+// WithServerName overrides the server name reported to clients during
+// initialize, so multiple instances can be told apart. An empty name keeps
+// the default.
+func WithServerName(name string) Option {
+	return func(s *Server) {
+		if name != "" {
+			s.name = name
+		}
+	}
+}
+
+// WithServerVersion overrides the build-time server version reported to
+// clients during initialize. An empty version keeps the default.
+func WithServerVersion(version string) Option {
+	return func(s *Server) {
+		if version != "" {
+			s.version = version
+		}
+	}
+}
+
+// WithWritesEnabled allows tools that modify the tmux session (renaming
+// windows, sending input). Without it the server is strictly read-only.
+func WithWritesEnabled(enabled bool) Option {
+	return func(s *Server) {
+		s.writesEnabled = enabled
+	}
+}
+
+// WithPromptRegex sets the pattern run_command uses to recognise the shell
+// prompt that signals a command has finished
+func WithPromptRegex(prompt *regexp.Regexp) Option {
+	return func(s *Server) {
+		s.promptRegex = prompt
+	}
+}
+
+// WithPromptDetector sets how run_command recognises the prompt that
+// signals a command has finished, e.g. by OSC 133 markers (see
+// tmux.NewPromptDetector). It takes precedence over WithPromptRegex.
+func WithPromptDetector(detector tmux.PromptDetector) Option {
+	return func(s *Server) {
+		s.promptDetector = detector
+	}
+}
+
+// WithExitSentinel sets the marker run_command appends to commands when
+// asked for their exit code. Change it if the default could collide with
+// real output. An empty sentinel keeps the default.
+func WithExitSentinel(sentinel string) Option {
+	return func(s *Server) {
+		if sentinel != "" {
+			s.exitSentinel = sentinel
+		}
+	}
+}
+
+// WithPollInterval sets the default delay between captures for tools that
+// poll the pane. A zero interval keeps the default.
+func WithPollInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		if interval > 0 {
+			s.pollInterval = interval
+		}
+	}
+}
+
+// WithIdleTimeout makes Start return ErrIdleTimeout once no request has
+// arrived for the given duration. A zero timeout disables the check.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		if timeout > 0 {
+			s.idleTimeout = timeout
+		}
+	}
+}
+
+// WithInitTimeout makes Start return ErrInitTimeout if the client hasn't
+// sent initialize within the given duration, so a client that connects and
+// then hangs doesn't hold the server open. A zero timeout disables the
+// check, as does single-shot mode, which needs no handshake.
+func WithInitTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		if timeout > 0 {
+			s.initTimeout = timeout
+		}
+	}
+}
+
+// WithOutputTTL sets how long run_command_to_resource keeps each output
+// readable. A zero or negative TTL keeps DefaultOutputTTL.
+func WithOutputTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		if ttl > 0 {
+			s.outputTTL = ttl
+		}
+	}
+}
+
+// WithMetrics turns on per-tool call counts, error counts, returned bytes
+// and latency percentiles, reported by server_status and MetricsHandler.
+// Collection is off by default so the dispatch path does no extra work.
+func WithMetrics(enabled bool) Option {
+	return func(s *Server) {
+		s.collectMetrics = enabled
+	}
+}
+
+// WithKeepWarm makes the server press Enter at the bound session's idle
+// shell prompt after every interval without a request, so a shell with
+// TMOUT set stays logged in. It only acts while write tools are enabled.
+// Zero disables it.
+func WithKeepWarm(interval time.Duration) Option {
+	return func(s *Server) {
+		s.keepWarmInterval = interval
+	}
+}
+
+// WithSingleShot makes Start return once it has answered one request,
+// even if more input is pending. Notifications don't count. The lifecycle
+// check is skipped, since a single exchange leaves no room for the
+// initialize handshake.
+func WithSingleShot(enabled bool) Option {
+	return func(s *Server) {
+		s.singleShot = enabled
+	}
+}
+
+// WithMaxLineWidth truncates lines longer than width runes in the output of
+// tools that read the pane. Zero, the default, leaves lines whole.
+func WithMaxLineWidth(width int) Option {
+	return func(s *Server) {
+		if width > 0 {
+			s.maxLineWidth = width
+		}
+	}
+}
+
+// WithRuntimeUnlock enables unlock_writes and lock_writes, which turn
+// write tools on and off while the server runs. A non-empty secret must
+// then be passed to unlock_writes.
+func WithRuntimeUnlock(enabled bool, secret string) Option {
+	return func(s *Server) {
+		s.runtimeUnlock = enabled
+		s.unlockSecret = secret
+	}
+}
+
+// WithMaxRequestBytes rejects messages from the client larger than max
+// bytes, instead of buffering them whole. Zero or less removes the limit.
+func WithMaxRequestBytes(max int64) Option {
+	return func(s *Server) {
+		s.maxRequestBytes = max
+	}
+}
+
+// WithBindableSessions lets bind_session switch to sessions whose names
+// match one of patterns (shell-style, as in path.Match), as well as back
+// to the session the server started with. Without patterns bind_session
+// is disabled.
+func WithBindableSessions(patterns []string) Option {
+	return func(s *Server) {
+		s.bindableSessions = patterns
+	}
+}
+
+// WithSaveDir lets save_capture write captures to files in dir and its
+// subdirectories. Without it save_capture is disabled.
+func WithSaveDir(dir string) Option {
+	return func(s *Server) {
+		s.saveDir = dir
+	}
+}
+
+// WithAbsPaths lets read_file read any file the server's user can, by
+// absolute path or leading out of the pane's working directory
+func WithAbsPaths(allowed bool) Option {
+	return func(s *Server) {
+		s.allowAbsPaths = allowed
+	}
+}
+
+// WithJobsCommands sets the command jobs and wait_job type to list the
+// background jobs of each named shell, e.g. {"bash": "jobs -l"}, in place
+// of the built-in one
+func WithJobsCommands(commands map[string]string) Option {
+	return func(s *Server) {
+		s.jobsCommands = commands
+	}
+}
+
+// WithPrettyJSON indents the JSON written to the client. Each message is
+// still followed by a newline, but spans several lines, so only use it
+// with clients that parse a JSON stream rather than one message per line.
+func WithPrettyJSON(pretty bool) Option {
+	return func(s *Server) {
+		s.pretty = pretty
+	}
+}
+
+// WithTrace appends every JSON-RPC message the server reads or writes to
+// w as a line of JSON with a timestamp and direction ("in" or "out"). The
+// messages are written in the background, so a slow w never delays the
+// client; if it falls far behind, messages are dropped from the trace.
+func WithTrace(w io.Writer) Option {
+	return func(s *Server) {
+		s.tracer = newTracer(w)
+	}
+}
+
+// WithCaptureArgs adds extra flags to every capture-pane invocation (see
+// tmux.ParseCaptureArgs). Arguments that fail validation are ignored and
+// the default capture is kept; callers should validate them first.
+func WithCaptureArgs(args []string) Option {
+	return func(s *Server) {
+		// SetCaptureArgs leaves the manager untouched on error
+		_ = s.tmuxManager.SetCaptureArgs(args)
+	}
+}
+
+// WithNormalizeNewlines sets whether captures have "\r\n" converted to
+// "\n" and carriage-return redraws collapsed to what the terminal shows
+// (see tmux.NormalizeNewlines). It is on by default.
+func WithNormalizeNewlines(enabled bool) Option {
+	return func(s *Server) {
+		s.tmuxManager.SetNormalizeNewlines(enabled)
+	}
+}
+
+// WithStartupCommands types commands into the session when the server has
+// to create it (see tmux.Manager.SetStartupCommands). Invalid commands are
+// ignored; callers should check them with tmux.ValidateCommandLine first.
+func WithStartupCommands(commands []string) Option {
+	return func(s *Server) {
+		// SetStartupCommands leaves the manager untouched on error
+		_ = s.tmuxManager.SetStartupCommands(commands)
+	}
+}
+
+// WithCommandRunner runs tmux through runner instead of the tmux binary,
+// e.g. a tmux.ReplayRunner to serve a recorded session
+func WithCommandRunner(runner tmux.CommandRunner) Option {
+	return func(s *Server) {
+		s.tmuxManager.SetRunner(runner)
+	}
+}
+
+// WithRecording logs every tmux invocation and its output to w, in the
+// format tmux.NewReplayRunner reads back. Apply it after WithCommandRunner
+// to record that runner.
+func WithRecording(w io.Writer) Option {
+	return func(s *Server) {
+		s.tmuxManager.SetRunner(tmux.NewRecordingRunner(s.tmuxManager.Runner(), w))
+	}
 }
 
 // NewServer creates a new MCP server instance
-func NewServer(sessionName string, reader io.Reader, writer io.Writer) *Server {
-	return &Server{
-		tmuxManager: tmux.NewManager(sessionName),
-		reader:      reader,
-		writer:      writer,
+func NewServer(sessionName string, reader io.Reader, writer io.Writer, opts ...Option) *Server {
+	s := &Server{
+		tmuxManager:  tmux.NewManager(sessionName),
+		reader:       reader,
+		writer:       writer,
+		name:         ServerName,
+		version:      ServerVersion,
+		exitSentinel: tmux.DefaultExitSentinel,
+		pollInterval: tmux.DefaultPollInterval,
+		gitStatus:    git.ReadStatus,
+		readEnviron:  readProcEnviron,
+		started:      time.Now(),
+
+		childProcesses:  readProcChildren,
+		maxRequestBytes: DefaultMaxRequestBytes,
+		outputTTL:       DefaultOutputTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.homeSession = s.tmuxManager.SessionName()
+	if s.collectMetrics {
+		s.metrics = newMetrics(s.listTools().Tools)
 	}
+	return s
 }
 
 // Start begins the server message loop
 func (s *Server) Start() error {
+	if s.tracer != nil {
+		// Flush the trace before returning
+		defer s.tracer.close()
+	}
+
 	// Ensure tmux session exists
 	if err := s.tmuxManager.EnsureSession(); err != nil {
 		// Send a proper JSON-RPC error response before returning
-		encoder := json.NewEncoder(s.writer)
+		encoder := s.newEncoder()
 		errorResponse := &mcp.JSONRPCResponse{
 			JSONRPC: "2.0",
 			ID:      nil, // No request ID yet
 			Error: &mcp.JSONRPCError{
-				Code:    -32603, // Internal error
+				Code:    mcp.CodeInternalError,
 				Message: fmt.Sprintf("Failed to setup tmux session: %s. Please ensure tmux is installed and the specified session exists or can be created.", err.Error()),
+				Data:    s.jsonRPCError(err).Data,
 			},
 		}
 		// Best-effort attempt to send error response
@@ -54,23 +490,200 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to setup tmux session: %w", err)
 	}
 
-	decoder := json.NewDecoder(s.reader)
-	encoder := json.NewEncoder(s.writer)
+	encoder := s.newEncoder()
+
+	// Requests are decoded in the background so a blocked read can race
+	// against the idle timer
+	requests := make(chan decodedRequest)
+	done := make(chan struct{})
+	defer close(done)
+	go s.decodeRequests(requests, done)
+
+	var timer *time.Timer
+	var idle <-chan time.Time
+	if s.idleTimeout > 0 {
+		timer = time.NewTimer(s.idleTimeout)
+		defer timer.Stop()
+		idle = timer.C
+	}
+
+	var warmTimer *time.Timer
+	var warm <-chan time.Time
+	if s.keepWarmInterval > 0 {
+		warmTimer = time.NewTimer(s.keepWarmInterval)
+		defer warmTimer.Stop()
+		warm = warmTimer.C
+	}
+
+	var initTimer *time.Timer
+	var initDeadline <-chan time.Time
+	if s.initTimeout > 0 && !s.singleShot {
+		initTimer = time.NewTimer(s.initTimeout)
+		defer initTimer.Stop()
+		initDeadline = initTimer.C
+	}
+
+	for {
+		select {
+		case <-idle:
+			return ErrIdleTimeout
+		case <-initDeadline:
+			return ErrInitTimeout
+		case <-warm:
+			s.logKeepWarm(s.keepWarm())
+			warmTimer.Reset(s.keepWarmInterval)
+		case next := <-requests:
+			if errors.Is(next.err, ErrRequestTooLarge) {
+				// The request's ID is unknown without decoding it
+				response := &mcp.JSONRPCResponse{
+					JSONRPC: "2.0",
+					Error:   &mcp.JSONRPCError{Code: mcp.CodeInvalidRequest, Message: next.err.Error()},
+				}
+				if err := encoder.Encode(response); err != nil {
+					return fmt.Errorf("failed to encode response: %w", err)
+				}
+				if s.singleShot {
+					return nil
+				}
+				continue
+			}
+			if next.err != nil {
+				if next.err == io.EOF {
+					return nil
+				}
+				return fmt.Errorf("failed to decode request: %w", next.err)
+			}
+			if initDeadline != nil && next.request.Method == "initialize" {
+				initTimer.Stop()
+				initDeadline = nil
+			}
+
+			// Notifications get no response
+			if response := s.dispatch(&next.request); response != nil {
+				if err := encoder.Encode(response); err != nil {
+					return fmt.Errorf("failed to encode response: %w", err)
+				}
+				if s.singleShot {
+					return nil
+				}
+			}
+			if timer != nil {
+				timer.Reset(s.idleTimeout)
+			}
+			if warmTimer != nil {
+				warmTimer.Reset(s.keepWarmInterval)
+			}
+		}
+	}
+}
+
+// newEncoder returns an encoder for messages to the client. Encode ends
+// each message with a newline, indented or not.
+func (s *Server) newEncoder() *json.Encoder {
+	var w io.Writer = s.writer
+	if s.tracer != nil {
+		w = traceWriter{w: w, tracer: s.tracer}
+	}
+	encoder := json.NewEncoder(w)
+	if s.pretty {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder
+}
+
+// decodedRequest is one result of reading the request stream
+type decodedRequest struct {
+	request mcp.JSONRPCRequest
+	err     error
+}
 
+// decodeRequests reads requests from the server's reader and sends them on
+// requests until decoding fails or done is closed. A message over
+// maxRequestBytes is reported as ErrRequestTooLarge and skipped to the end
+// of its line, after which decoding carries on.
+func (s *Server) decodeRequests(requests chan<- decodedRequest, done <-chan struct{}) {
+	var limiter *messageLimiter
+	newDecoder := func(r io.Reader) *json.Decoder {
+		if s.maxRequestBytes <= 0 {
+			return json.NewDecoder(r)
+		}
+		limiter = &messageLimiter{r: r, max: s.maxRequestBytes}
+		limiter.next(0)
+		return json.NewDecoder(limiter)
+	}
+	decoder := newDecoder(s.reader)
 	for {
-		var request mcp.JSONRPCRequest
-		if err := decoder.Decode(&request); err != nil {
-			if err == io.EOF {
-				return nil
+		var next decodedRequest
+		if s.tracer == nil {
+			next.err = decoder.Decode(&next.request)
+		} else {
+			// Keep the message as sent for the trace, less any
+			// send_secret secret
+			var raw json.RawMessage
+			if next.err = decoder.Decode(&raw); next.err == nil {
+				s.tracer.record(traceIn, redactSecretArgument(raw))
+				next.err = json.Unmarshal(raw, &next.request)
+			}
+		}
+		var skipErr error
+		oversized := errors.Is(next.err, ErrRequestTooLarge)
+		if oversized {
+			var rest io.Reader
+			if rest, skipErr = skipLine(decoder.Buffered(), limiter.r); skipErr == nil {
+				decoder = newDecoder(rest)
 			}
-			return fmt.Errorf("failed to decode request: %w", err)
+		} else if limiter != nil {
+			limiter.next(decoder.InputOffset())
 		}
+		// Note cancellations now, while an earlier request may still be
+		// running, rather than when the main loop gets to them
+		if next.err == nil && next.request.ID == nil && next.request.Method == "notifications/cancelled" {
+			s.cancellations.note(&next.request)
+		}
+		select {
+		case requests <- next:
+		case <-done:
+			return
+		}
+		if oversized && skipErr != nil {
+			// The input ended, or failed, within the oversized message
+			next = decodedRequest{err: skipErr}
+			select {
+			case requests <- next:
+			case <-done:
+			}
+			return
+		}
+		if next.err != nil && !oversized {
+			return
+		}
+	}
+}
+
+// dispatch applies the MCP lifecycle to an incoming message: notifications
+// are consumed without a response, and requests other than initialize and
+// ping are rejected until the client has confirmed initialization, unless
+// the server is in single-shot mode
+func (s *Server) dispatch(request *mcp.JSONRPCRequest) *mcp.JSONRPCResponse {
+	if request.ID == nil {
+		if request.Method == "notifications/initialized" {
+			s.client.Initialized = true
+		}
+		return nil
+	}
 
-		response := s.handleRequest(&request)
-		if err := encoder.Encode(response); err != nil {
-			return fmt.Errorf("failed to encode response: %w", err)
+	if !s.client.Initialized && !s.singleShot && request.Method != "initialize" && request.Method != "ping" {
+		return &mcp.JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &mcp.JSONRPCError{
+				Code:    mcp.CodeServerNotInitialized,
+				Message: fmt.Sprintf("Server not initialized: send initialize and notifications/initialized before %s", request.Method),
+			},
 		}
 	}
+
+	return s.handleRequest(request)
 }
 
 func (s *Server) handleRequest(request *mcp.JSONRPCRequest) *mcp.JSONRPCResponse {
@@ -83,45 +696,55 @@ func (s *Server) handleRequest(request *mcp.JSONRPCRequest) *mcp.JSONRPCResponse
 	case "initialize":
 		result, err := s.handleInitialize(request)
 		if err != nil {
-			response.Error = &mcp.JSONRPCError{
-				Code:    -32603,
-				Message: err.Error(),
-			}
+			response.Error = s.jsonRPCError(err)
 		} else {
 			response.Result = result
 		}
 
+	case "ping":
+		response.Result = struct{}{}
+
 	case "tools/list":
 		response.Result = s.listTools()
 
 	case "tools/call":
+		var start time.Time
+		if s.metrics != nil {
+			start = time.Now()
+		}
 		result, err := s.callTool(request)
+		s.cancellations.forget(request.ID)
 		if err != nil {
-			response.Error = &mcp.JSONRPCError{
-				Code:    -32603,
-				Message: err.Error(),
-			}
+			response.Error = s.jsonRPCError(err)
 		} else {
 			response.Result = result
 		}
+		if s.metrics != nil {
+			s.metrics.record(toolName(request), time.Since(start), err != nil || result.IsError, contentBytes(result))
+		}
 
 	case "resources/list":
 		response.Result = s.listResources()
 
+	case "resources/subscribe", "resources/unsubscribe":
+		result, err := s.subscribeResource(request, request.Method == "resources/subscribe")
+		if err != nil {
+			response.Error = s.jsonRPCError(err)
+		} else {
+			response.Result = result
+		}
+
 	case "resources/read":
 		result, err := s.readResource(request)
 		if err != nil {
-			response.Error = &mcp.JSONRPCError{
-				Code:    -32603,
-				Message: err.Error(),
-			}
+			response.Error = s.jsonRPCError(err)
 		} else {
 			response.Result = result
 		}
 
 	default:
 		response.Error = &mcp.JSONRPCError{
-			Code:    -32601,
+			Code:    mcp.CodeMethodNotFound,
 			Message: fmt.Sprintf("Method not found: %s", request.Method),
 		}
 	}
@@ -130,24 +753,32 @@ func (s *Server) handleRequest(request *mcp.JSONRPCRequest) *mcp.JSONRPCResponse
 }
 
 func (s *Server) handleInitialize(request *mcp.JSONRPCRequest) (*mcp.InitializeResult, error) {
+	if err := s.client.negotiate(request); err != nil {
+		return nil, err
+	}
 	return &mcp.InitializeResult{
-		ProtocolVersion: ProtocolVersion,
+		ProtocolVersion: s.client.ProtocolVersion,
 		Capabilities: mcp.ServerCapabilities{
 			Tools: &mcp.ToolsCapability{
 				ListChanged: false,
 			},
 			Resources: &mcp.ResourcesCapability{
-				Subscribe:   false,
+				Subscribe:   true,
 				ListChanged: false,
 			},
 		},
-		ServerInfo: mcp.ServerInfo{
-			Name:    ServerName,
-			Version: ServerVersion,
-		},
+		ServerInfo: s.serverInfo(),
 	}, nil
 }
 
+// serverInfo identifies this server to clients
+func (s *Server) serverInfo() mcp.ServerInfo {
+	return mcp.ServerInfo{
+		Name:    s.name,
+		Version: s.version,
+	}
+}
+
 func (s *Server) listTools() *mcp.ListToolsResult {
 	return &mcp.ListToolsResult{
 		Tools: []mcp.Tool{
@@ -155,28 +786,1517 @@ func (s *Server) listTools() *mcp.ListToolsResult {
 				Name:        "read_terminal",
 				Description: "Read the current terminal content from the tmux session",
 				InputSchema: mcp.InputSchema{
-					Type:       "object",
-					Properties: map[string]mcp.Property{},
-					Required:   []string{},
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"format": {
+							Type:        "string",
+							Description: "Output format: \"text\" (default) for plain text, \"html\" for a <pre> block with terminal colors preserved, or \"lines\" for a JSON array of {text, wrapped} objects, one per visual line, where wrapped marks a line the terminal wrapped onto from the one before",
+						},
+						"cursor": {
+							Type:        "boolean",
+							Description: "Insert a ‸ marker at the cursor position and append its coordinates (text format only)",
+						},
+						"warn_dead": {
+							Type:        "boolean",
+							Description: "Append a warning if the pane's process has exited, meaning the content is stale (default: false)",
+						},
+						"raw": rawProperty,
+						"refresh": {
+							Type:        "boolean",
+							Description: "Nudge the program in the pane into repainting first, as the refresh tool does with its defaults, for full-screen programs that show a stale frame; requires --allow-writes (default: false)",
+						},
+					}),
+					Required: []string{},
+				},
+			},
+			{
+				Name:        "read_scrollback",
+				Description: "Read scrollback history from the tmux session",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"lines": {
+							Type:        "number",
+							Description: "Number of lines of scrollback history to retrieve (default: 100). Use -1 or \"all\" to retrieve the entire history; 0 uses the default. Numeric strings such as \"50\" are accepted; booleans and objects are errors",
+						},
+						"format": {
+							Type:        "string",
+							Description: "Output format: \"text\" (default), or \"json\" for an array of {line_number, text} objects",
+						},
+						"ansi": {
+							Type:        "boolean",
+							Description: "Preserve terminal colors and attributes. Text output keeps the raw escape sequences; JSON output adds a parsed \"spans\" list to each line",
+						},
+						"raw": {
+							Type:        "boolean",
+							Description: "Return runs of lines that look like binary data as they are, in text output instead of replacing them with a note and a short hex dump (default: false)",
+						},
+					}),
+					Required: []string{},
+				},
+			},
+			{
+				Name:        "read_range",
+				Description: "Read an inclusive range of pane lines. 0 is the first visible line, negative numbers count back into scrollback history (-1 is the newest history line). The range is clamped to existing lines and the range used is returned",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"raw":   rawProperty,
+						"start": {Type: "integer", Description: "First line to read"},
+						"end":   {Type: "integer", Description: "Last line to read; must not be less than start"},
+					}),
+					Required: []string{"start", "end"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"start":   {Type: "integer", Description: "First line actually captured"},
+						"end":     {Type: "integer", Description: "Last line actually captured"},
+						"content": {Type: "string", Description: "Captured text"},
+					},
+					Required: []string{"start", "end", "content"},
+				},
+			},
+			{
+				Name:        "read_with_context",
+				Description: "Read the visible pane together with the scrollback lines just above it, as one continuous block taken in a single capture. Use it instead of combining read_terminal and read_scrollback when recent history matters",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"raw":           rawProperty,
+						"context_lines": {Type: "integer", Description: fmt.Sprintf("History lines to include above the screen, from 0 to %d (default: %d)", maxContextLines, defaultContextLines)},
+					}),
+					Required: []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"context_lines": {Type: "integer", Description: "History lines included; fewer than asked for when the history is shorter"},
+						"visible_lines": {Type: "integer", Description: "Visible lines included, which follow the history lines"},
+						"start":         {Type: "integer", Description: "First line captured, as in read_range: negative in the history, 0 the first visible line"},
+						"end":           {Type: "integer", Description: "Last line captured, the bottom of the screen"},
+						"content":       {Type: "string", Description: "Captured text"},
+					},
+					Required: []string{"context_lines", "visible_lines", "start", "end", "content"},
+				},
+			},
+			{
+				Name:        "read_scrollback_page",
+				Description: "Read one page of the pane's lines, counting pages from 1 at the oldest history line to the last page, which ends at the bottom of the screen. Returns the total number of pages and whether more follow, for walking through a long log in order without reading it all at once",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"raw":       rawProperty,
+						"page":      {Type: "integer", Description: "Page to read, from 1 (the oldest)"},
+						"page_size": {Type: "integer", Description: fmt.Sprintf("Lines per page, from 1 to %d (default: %d)", maxPageSize, defaultPageSize)},
+					}),
+					Required: []string{"page"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"page":        {Type: "integer", Description: "The page read"},
+						"page_size":   {Type: "integer", Description: "Lines per page; the last page may be shorter"},
+						"total_pages": {Type: "integer", Description: "Number of pages the pane's lines currently make"},
+						"has_more":    {Type: "boolean", Description: "Whether later pages follow"},
+						"start":       {Type: "integer", Description: "First line captured, as in read_range: negative in the history, 0 the first visible line"},
+						"end":         {Type: "integer", Description: "Last line captured"},
+						"content":     {Type: "string", Description: "Captured text"},
+					},
+					Required: []string{"page", "page_size", "total_pages", "has_more", "start", "end", "content"},
+				},
+			},
+			{
+				Name:        "orient",
+				Description: "Summarise the terminal in a few lines, as the first call of a turn: session, window and pane, the command running, size, working directory, how many windows and panes the session has, and the last lines of output with trailing blanks trimmed",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"lines": {Type: "integer", Description: fmt.Sprintf("Lines of output to include, from 0 to %d (default: %d)", maxOrientLines, defaultOrientLines)},
+					}),
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"session":         {Type: "string", Description: "The managed session"},
+						"window_index":    {Type: "integer", Description: "Index of the pane's window"},
+						"window_name":     {Type: "string", Description: "Name of the pane's window"},
+						"pane_id":         {Type: "string", Description: "The pane's ID, e.g. \"%3\""},
+						"pane_index":      {Type: "integer", Description: "The pane's index within its window"},
+						"command":         {Type: "string", Description: "The pane's foreground command"},
+						"width":           {Type: "integer", Description: "Pane width in columns"},
+						"height":          {Type: "integer", Description: "Pane height in rows"},
+						"current_path":    {Type: "string", Description: "The pane's working directory"},
+						"windows":         {Type: "integer", Description: "Number of windows in the session"},
+						"panes":           {Type: "integer", Description: "Number of panes in the session"},
+						"screen_mode":     {Type: "string", Description: "\"normal\", or \"alternate\" while a full-screen program runs"},
+						"pane_dead":       {Type: "boolean", Description: "Present and true if the pane's process has exited"},
+						"size_changed":    {Type: "boolean", Description: "Whether the pane's size differs from the last orient or snapshot of it, so wrapped lines have reflowed"},
+						"previous_width":  {Type: "integer", Description: "Pane width at the last check, present only when size_changed is true"},
+						"previous_height": {Type: "integer", Description: "Pane height at the last check, present only when size_changed is true"},
+						"tail":            {Type: "string", Description: "The last lines of output, trimmed of trailing whitespace and blank lines"},
+					},
+					Required: []string{"session", "window_index", "window_name", "pane_id", "pane_index", "command", "width", "height", "current_path", "windows", "panes", "screen_mode", "size_changed", "tail"},
+				},
+			},
+			{
+				Name:        "snapshot",
+				Description: "Read the pane's content together with its size, working directory and index in one call. Useful for orienting at the start of a turn",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"raw": rawProperty,
+						"ansi": {
+							Type:        "boolean",
+							Description: "Keep terminal colors and attributes as raw escape sequences (default: false)",
+						},
+						"trim": {
+							Type:        "boolean",
+							Description: "Strip trailing whitespace from each line and drop trailing blank lines (default: false)",
+						},
+						"cursor": {
+							Type:        "boolean",
+							Description: "Report the cursor position as cursor_x/cursor_y and, unless ansi is set, insert a ‸ marker there in the content",
+						},
+					}),
+					Required: []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"content":          {Type: "string", Description: "Captured pane content"},
+						"width":            {Type: "integer", Description: "Pane width in columns"},
+						"height":           {Type: "integer", Description: "Pane height in rows"},
+						"current_path":     {Type: "string", Description: "Working directory of the pane's foreground process"},
+						"pane_index":       {Type: "integer", Description: "Index of the pane within its window"},
+						"cursor_x":         {Type: "integer", Description: "Cursor column, counted from 0 (only when cursor was requested)"},
+						"cursor_y":         {Type: "integer", Description: "Cursor row from the top of the visible pane, counted from 0 (only when cursor was requested)"},
+						"pane_dead":        {Type: "boolean", Description: "Whether the pane's process has exited, leaving stale content"},
+						"pane_dead_status": {Type: "integer", Description: "Exit status of the pane's process, present only when pane_dead is true"},
+						"screen_mode":      {Type: "string", Description: "\"alternate\" while a full-screen program such as vim or less has switched the pane to the alternate screen, otherwise \"normal\""},
+						"size_changed":     {Type: "boolean", Description: "Whether the pane's size differs from the last orient or snapshot of it, so wrapped lines have reflowed"},
+						"previous_width":   {Type: "integer", Description: "Pane width at the last check, present only when size_changed is true"},
+						"previous_height":  {Type: "integer", Description: "Pane height at the last check, present only when size_changed is true"},
+						"token":            {Type: "string", Description: "Names this capture for a later diff_captures call"},
+					},
+					Required: []string{"content", "width", "height", "current_path", "pane_index", "pane_dead", "screen_mode", "size_changed", "token"},
+				},
+			},
+			{
+				Name:        "capture_grid",
+				Description: "Read the visible pane as a grid of cells, rows[row][column] counted from 0 at the top-left, for addressing TUI text exactly (\"row 3, column 10\"). A wide character fills its cell and leaves the next one empty. Heavier than read_terminal, and refused for panes over 20000 cells",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: withTargetProperties(map[string]mcp.Property{}),
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"width":  {Type: "integer", Description: "Pane width in columns, the length of every row"},
+						"height": {Type: "integer", Description: "Pane height in rows, the number of rows"},
+						"rows":   {Type: "array", Description: "Rows of cells; each cell is one character with any combining marks, \" \" when blank and \"\" when covered by the wide character to its left"},
+					},
+					Required: []string{"width", "height", "rows"},
+				},
+			},
+			{
+				Name:        "capture_grid_diff",
+				Description: "Return only the cells of the visible pane that changed since the last capture_grid or capture_grid_diff of the same pane, as runs of {row, col, text}, for watching a full-screen program without reading the whole screen each time. The first call, or one after the pane was resized or the focus moved, lists every row",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: withTargetProperties(map[string]mcp.Property{}),
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"width":         {Type: "integer", Description: "Columns in the pane"},
+						"height":        {Type: "integer", Description: "Rows in the pane"},
+						"full":          {Type: "boolean", Description: "Whether there was no earlier grid to compare with, so every row is listed"},
+						"changed_cells": {Type: "integer", Description: "Number of cells covered by the updates"},
+						"updates":       {Type: "array", Description: "Runs of changed cells, each {row, col, text} counting from 0 at the top-left; text replaces the cells starting at col"},
+					},
+					Required: []string{"width", "height", "full", "changed_cells", "updates"},
+				},
+			},
+			{
+				Name:        "diff_captures",
+				Description: "Capture a pane and compare it line by line with an earlier snapshot or diff_captures capture, returning diff -u style hunks with surrounding context. Shows what a command or keystroke changed on screen, such as one field of a TUI, without re-reading the whole pane",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"token": {
+							Type:        "string",
+							Description: "Token returned by an earlier snapshot or diff_captures call",
+						},
+						"context": {
+							Type:        "number",
+							Description: "Unchanged lines to show around each change (default: 3)",
+						},
+					}),
+					Required: []string{"token"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"diff":    {Type: "string", Description: "Hunks of changed lines, each line prefixed with ' ', '-' or '+'; empty when nothing changed"},
+						"added":   {Type: "integer", Description: "Number of lines added"},
+						"removed": {Type: "integer", Description: "Number of lines removed; a changed line counts as one removed and one added"},
+						"token":   {Type: "string", Description: "Names the capture just taken, for the next diff_captures call"},
+					},
+					Required: []string{"diff", "added", "removed", "token"},
+				},
+			},
+			{
+				Name:        "read_window",
+				Description: "Read every pane of a window in one call, each under a \"--- pane %1 (index 0) ---\" header. Useful for split layouts",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"window": targetProperties["window"],
+						"raw":    rawProperty,
+						"ansi": {
+							Type:        "boolean",
+							Description: "Keep terminal colors and attributes as raw escape sequences (default: false)",
+						},
+						"trim": {
+							Type:        "boolean",
+							Description: "Strip trailing whitespace from each line and drop trailing blank lines (default: false)",
+						},
+					},
+					Required: []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"window_index": {Type: "integer", Description: "Index of the window that was read"},
+						"window_name":  {Type: "string", Description: "Name of the window that was read"},
+						"panes":        {Type: "array", Description: "Panes in layout order, with the fields of list_panes plus content"},
+					},
+					Required: []string{"window_index", "window_name", "panes"},
+				},
+			},
+			{
+				Name:        "get_terminal_info",
+				Description: "Get information about the terminal (dimensions, current path, etc.)",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"width":            {Type: "integer", Description: "Pane width in columns"},
+						"height":           {Type: "integer", Description: "Pane height in rows"},
+						"current_path":     {Type: "string", Description: "Working directory of the pane's foreground process"},
+						"pane_index":       {Type: "integer", Description: "Index of the pane within its window"},
+						"attached_clients": {Type: "integer", Description: "Number of tmux clients attached to the session"},
+						"pane_dead":        {Type: "boolean", Description: "Whether the pane's process has exited, leaving stale content"},
+						"pane_dead_status": {Type: "integer", Description: "Exit status of the pane's process, present only when pane_dead is true"},
+						"screen_mode":      {Type: "string", Description: "\"alternate\" while a full-screen program such as vim or less has switched the pane to the alternate screen, otherwise \"normal\""},
+					},
+					Required: []string{"width", "height", "current_path", "pane_index", "attached_clients", "pane_dead", "screen_mode"},
+				},
+			},
+			{
+				Name:        "terminal_caps",
+				Description: "Report the terminal type programs in the session see, whether 256 colors and truecolor are likely supported, and whether the locale is UTF-8. A best guess from tmux's default-terminal, the attached client and the session environment",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"term":        {Type: "string", Description: "TERM set for programs in the session's panes (tmux's default-terminal)"},
+						"client_term": {Type: "string", Description: "TERM of the terminal the attached client runs in; absent when no client is attached"},
+						"colorterm":   {Type: "string", Description: "COLORTERM from the session environment, if set"},
+						"locale":      {Type: "string", Description: "LC_ALL, LC_CTYPE or LANG from the session environment, the first that is set"},
+						"color_256":   {Type: "boolean", Description: "Whether 256 colors are likely supported"},
+						"truecolor":   {Type: "boolean", Description: "Whether 24-bit color is likely supported"},
+						"utf8":        {Type: "boolean", Description: "Whether the locale or the attached client uses UTF-8"},
+					},
+					Required: []string{"term", "color_256", "truecolor", "utf8"},
+				},
+			},
+			{
+				Name:        "server_status",
+				Description: "Report this server's name, version, session, uptime and whether writes are enabled. With --metrics, also report per-tool call counts, errors, bytes returned and p50/p95 latency",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"server":         {Type: "string", Description: "Server name reported during initialize"},
+						"version":        {Type: "string", Description: "Server version"},
+						"session":        {Type: "string", Description: "tmux session the server is attached to"},
+						"writes_enabled": {Type: "boolean", Description: "Whether tools that modify the session are allowed"},
+						"uptime":         {Type: "string", Description: "Time since the server started, e.g. \"1h2m3s\""},
+						"metrics":        {Type: "array", Description: "Per-tool objects with tool, calls, errors, captured_bytes (text returned), p50_ms and p95_ms over recent calls; absent unless the server runs with --metrics"},
+					},
+					Required: []string{"server", "version", "session", "writes_enabled", "uptime"},
+				},
+			},
+			{
+				Name:        "list_panes",
+				Description: "List every pane in every window of the session, with the IDs and indexes accepted by the window and pane arguments of other tools",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"panes":           {Type: "array", Description: "Panes as objects with pane_id, window_index, window_name, pane_index, active, window_active, width, height, command, pane_dead, (for dead panes) pane_dead_status, window_activity (RFC 3339) with window_idle (e.g. \"12m\"), and focused for the pane chosen with focus_pane"},
+						"session_created": {Type: "string", Description: "When the session was created (RFC 3339)"},
+						"session_age":     {Type: "string", Description: "How long ago the session was created, e.g. \"3h12m\"; useful for spotting abandoned sessions"},
+					},
+					Required: []string{"panes"},
+				},
+			},
+			{
+				Name:        "overview",
+				Description: "Summarise every session on the tmux server, not just the managed one: each session's windows and the command running in each window's active pane. With --allow-session, only sessions bind_session may switch to are listed",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"sessions": {Type: "array", Description: "Sessions in tmux's order, each with name, attached_clients, managed, windows (window_index, window_name, active, pane_id, command) and, when tmux reports them, created (RFC 3339) and the width and height of its active window"},
+					},
+					Required: []string{"sessions"},
+				},
+			},
+			{
+				Name:        "bind_session",
+				Description: "Switch the server to another existing tmux session, so tools called without window or pane act on it from now on. Only the session the server started with and those allowed with --allow-session can be bound. Returns the session's windows",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"session": {Type: "string", Description: "Session to bind, as listed by overview: its exact name, or a prefix or part of it that matches only one bindable session"},
+					},
+					Required: []string{"session"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"session":  {Type: "string", Description: "The session now bound"},
+						"previous": {Type: "string", Description: "The session bound before"},
+						"windows":  {Type: "array", Description: "The session's windows (window_index, window_name, active, pane_id, command)"},
+					},
+					Required: []string{"session", "previous", "windows"},
+				},
+			},
+			{
+				Name:        "is_attached",
+				Description: "Report whether anyone is attached to the tmux session. Check this before sending input so you don't type over a human who is actively working",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"attached":         {Type: "boolean", Description: "Whether at least one client is attached"},
+						"attached_clients": {Type: "integer", Description: "Number of attached clients"},
+					},
+					Required: []string{"attached", "attached_clients"},
+				},
+			},
+			{
+				Name:        "list_clients",
+				Description: "List the tmux clients attached to the session with each client's tty, size and last activity time",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"clients": {Type: "array", Description: "Attached clients as objects with tty, width, height and last_activity (RFC 3339)"},
+					},
+					Required: []string{"clients"},
+				},
+			},
+			{
+				Name:        "popup_state",
+				Description: "Report whether a tmux popup (display-popup) is open and what it runs. A popup covers the panes but isn't part of them, so captures look unchanged while it has the keyboard; tmux offers no way to capture its content. Popups need tmux 3.2 or later",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"tmux_version": {Type: "string", Description: "tmux version string, e.g. \"tmux 3.3a\""},
+						"supported":    {Type: "boolean", Description: "Whether this tmux has popups"},
+						"detectable":   {Type: "boolean", Description: "Whether the tmux server's processes could be inspected; popup_open is meaningless when false"},
+						"popup_open":   {Type: "boolean", Description: "Whether any popup is open on the tmux server"},
+						"popups":       {Type: "array", Description: "Open popups as objects with the pid and command line of the program each runs"},
+						"clients":      {Type: "integer", Description: "Clients attached to the session"},
+						"note":         {Type: "string", Description: "Why popup_open can't be trusted, or what an open popup means for captures"},
+					},
+					Required: []string{"tmux_version", "supported", "detectable", "popup_open", "popups", "clients"},
+				},
+			},
+			{
+				Name:        "visible_size",
+				Description: "Report just the pane's visible width and height, a cheap call for sizing read_range and capture_grid requests without the full get_terminal_info",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: withTargetProperties(map[string]mcp.Property{}),
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"pane_width":  {Type: "integer", Description: "Visible columns"},
+						"pane_height": {Type: "integer", Description: "Visible rows"},
+					},
+					Required: []string{"pane_width", "pane_height"},
+				},
+			},
+			{
+				Name:        "scrollback_size",
+				Description: "Report how many lines of scrollback history the pane holds and the configured history limit",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"history_size":  {Type: "integer", Description: "Lines currently held in the scrollback history"},
+						"history_limit": {Type: "integer", Description: "Maximum number of history lines tmux retains (history-limit)"},
+					},
+					Required: []string{"history_size", "history_limit"},
+				},
+			},
+			{
+				Name:        "get_option",
+				Description: "Read a tmux option such as history-limit, mouse or prefix, to understand how the session is configured",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"name": {
+							Type:        "string",
+							Description: "Option name, e.g. \"history-limit\"; server, session and window options are all accepted",
+						},
+						"global": {
+							Type:        "boolean",
+							Description: "Read the global value instead of the value in effect for this session (default: false)",
+						},
+					},
+					Required: []string{"name"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"name":   {Type: "string", Description: "The option that was read"},
+						"value":  {Type: "string", Description: "The option's value; empty if it is unset"},
+						"global": {Type: "boolean", Description: "Whether the global value was read"},
+					},
+					Required: []string{"name", "value", "global"},
+				},
+			},
+			{
+				Name:        "read_file",
+				Description: "Read a text file straight from disk instead of cat-ing it into the terminal, which keeps the scrollback clean. A relative path is resolved against the pane's working directory and may not lead out of it, unless the server was started with --allow-abs-paths, which also allows absolute paths. Only files on the machine running the server can be read, so a pane running ssh is refused",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"path": {
+							Type:        "string",
+							Description: "Path of the file, relative to the pane's working directory",
+						},
+						"max_bytes": {
+							Type:        "number",
+							Description: fmt.Sprintf("Return at most this many bytes from the start of the file (default: %d, max: %d)", defaultReadFileBytes, maxReadFileBytes),
+						},
+					}),
+					Required: []string{"path"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"path":      {Type: "string", Description: "Absolute path of the file read, with symlinks resolved"},
+						"size":      {Type: "integer", Description: "Size of the whole file in bytes"},
+						"bytes":     {Type: "integer", Description: "Number of bytes returned"},
+						"truncated": {Type: "boolean", Description: "Whether the file is longer than what was returned"},
+						"content":   {Type: "string", Description: "The file's text"},
+					},
+					Required: []string{"path", "size", "bytes", "truncated", "content"},
+				},
+			},
+			{
+				Name:        "at_prompt",
+				Description: "Check whether a pane is at an interactive shell prompt before typing a command into it, rather than inside vim, less, a REPL or a password prompt. Combines the pane's foreground command, the alternate screen, a password prompt check, the shell's OSC 133 markers when it prints them and the prompt regex on the last line into a verdict with a confidence from 0 to 1",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: withTargetProperties(map[string]mcp.Property{}),
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"at_prompt":       {Type: "boolean", Description: "Whether the pane is at a shell prompt"},
+						"confidence":      {Type: "number", Description: "How sure the verdict is, from 0 to 1"},
+						"reason":          {Type: "string", Description: "The signal that decided the verdict"},
+						"command":         {Type: "string", Description: "The pane's foreground command"},
+						"shell":           {Type: "boolean", Description: "Whether the command is a known shell"},
+						"remote":          {Type: "boolean", Description: "Whether the command connects to another machine, such as ssh"},
+						"screen_mode":     {Type: "string", Description: "\"alternate\" while a full-screen program has the pane, otherwise \"normal\""},
+						"pane_dead":       {Type: "boolean", Description: "Whether the pane's process has exited"},
+						"last_line":       {Type: "string", Description: "The last non-blank line of the pane"},
+						"regex_match":     {Type: "boolean", Description: "Whether the last line matches the prompt regex"},
+						"password_prompt": {Type: "boolean", Description: "Whether the last line asks for a password or passphrase"},
+						"osc133":          {Type: "string", Description: "What the shell's OSC 133 markers show: \"prompt\" or \"running\"; absent without markers"},
+						"typed":           {Type: "string", Description: "Text already typed at a prompt the markers show"},
+					},
+					Required: []string{"at_prompt", "confidence", "reason", "command", "shell", "remote", "screen_mode", "pane_dead", "last_line", "regex_match", "password_prompt"},
+				},
+			},
+			{
+				Name:        "detect_shell",
+				Description: "Identify the shell running in a pane and suggest a --prompt-regex from the prompt it is showing. Run it at an idle prompt before relying on run_command",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: withTargetProperties(map[string]mcp.Property{}),
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"shell":                  {Type: "string", Description: "Detected shell, e.g. \"bash\"; empty if the pane is running something else"},
+						"command":                {Type: "string", Description: "The pane's foreground command"},
+						"prompt":                 {Type: "string", Description: "Text before the cursor on its line, normally the prompt"},
+						"suggested_prompt_regex": {Type: "string", Description: "Pattern matching the prompt, suitable for --prompt-regex"},
+						"prompt_regex_matches":   {Type: "boolean", Description: "Whether the prompt regex in use matches the prompt"},
+					},
+					Required: []string{"shell", "command", "prompt", "suggested_prompt_regex", "prompt_regex_matches"},
+				},
+			},
+			{
+				Name:        "git_status",
+				Description: "Report the git state of a pane's working directory: branch, ahead/behind counts and changed files. git runs directly from the server, so nothing is typed into the pane. The directory is the one the pane's process is in on this machine, not on a host reached through ssh",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: withTargetProperties(map[string]mcp.Property{}),
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"path":       {Type: "string", Description: "The pane's working directory"},
+						"repository": {Type: "boolean", Description: "Whether path is in a git working tree; the other fields are empty when it isn't"},
+						"branch":     {Type: "string", Description: "The checked-out branch; absent when HEAD is detached"},
+						"detached":   {Type: "boolean", Description: "Whether HEAD is detached"},
+						"upstream":   {Type: "string", Description: "The branch being tracked, e.g. origin/main"},
+						"ahead":      {Type: "integer", Description: "Commits on branch that upstream lacks"},
+						"behind":     {Type: "integer", Description: "Commits on upstream that branch lacks"},
+						"files":      {Type: "array", Description: "Changed paths, each with git's two-letter status (e.g. \" M\", \"A \", \"??\"), path and, for renames, orig_path"},
+					},
+					Required: []string{"path", "repository", "ahead", "behind", "files"},
+				},
+			},
+			{
+				Name:        "scroll_state",
+				Description: "Report whether a pane is in copy-mode (or another tmux mode) and how many lines it is scrolled back. Reads always return the live output regardless, but a pane in copy-mode ignores typed input until it leaves the mode",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: withTargetProperties(map[string]mcp.Property{}),
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"in_mode":         {Type: "boolean", Description: "Whether the pane is in a mode such as copy-mode"},
+						"mode":            {Type: "string", Description: "The mode's name, e.g. \"copy-mode\"; empty outside a mode"},
+						"scroll_position": {Type: "integer", Description: "Lines scrolled back from the bottom; 0 outside copy-mode"},
+					},
+					Required: []string{"in_mode", "mode", "scroll_position"},
+				},
+			},
+			{
+				Name:        "pane_env",
+				Description: "List environment variables without typing env into the pane: the session environment tmux gives new panes (show-environment) and, with process set, the live environment of the pane's process from /proc. Values of variables named like secrets (tokens, passwords, keys) are redacted",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"process": {
+							Type:        "boolean",
+							Description: "Also read the environment of the process tmux started in the pane, usually its shell, from /proc/<pid>/environ (default: false)",
+						},
+					}),
+					Required: []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"session":      {Type: "array", Description: "Session environment as {name, value} entries; removed marks a variable new panes start without, and redacted a value hidden in whole or in part"},
+						"process":      {Type: "array", Description: "Environment of the pane's process, in the same form; only when process was requested and could be read"},
+						"process_note": {Type: "string", Description: "Why the process environment could not be read"},
+						"pid":          {Type: "integer", Description: "Process ID whose environment was read"},
+					},
+					Required: []string{"session"},
+				},
+			},
+			{
+				Name:        "is_active",
+				Description: "Check whether a pane is still producing output: capture it twice, delay_ms apart, and report whether it changed and by how many lines. A quick liveness probe before deciding a command has finished",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"delay_ms": {
+							Type:        "number",
+							Description: "Milliseconds between the two captures, from 1 to 30000 (default: 500)",
+						},
+					}),
+					Required: []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"active":   {Type: "boolean", Description: "Whether the pane changed between the captures"},
+						"delay_ms": {Type: "integer", Description: "Milliseconds waited between the captures"},
+						"added":    {Type: "integer", Description: "Lines in the second capture that were not in the first"},
+						"removed":  {Type: "integer", Description: "Lines in the first capture that are not in the second"},
+					},
+					Required: []string{"active", "delay_ms", "added", "removed"},
+				},
+			},
+			{
+				Name:        "assert_output",
+				Description: "Check the pane's scrollback for a pattern and return a clean pass or fail: with present true (the default) the pattern must appear, with present false it must not. Lines are matched one at a time, and the newest matching line is returned to show why an absence check failed",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"pattern": {
+							Type:        "string",
+							Description: "RE2 regular expression matched against each line",
+						},
+						"present": {
+							Type:        "boolean",
+							Description: "Whether the pattern is expected to appear (default: true)",
+						},
+						"lines": {
+							Type:        "number",
+							Description: "Lines of scrollback history to search, besides the visible screen (default: 100). Use -1 or \"all\" for the entire history",
+						},
+					}),
+					Required: []string{"pattern"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"passed":         {Type: "boolean", Description: "Whether the assertion held"},
+						"present":        {Type: "boolean", Description: "The expectation checked: true if the pattern had to appear, false if it had to be absent"},
+						"matches":        {Type: "integer", Description: "Number of lines the pattern matched"},
+						"match":          {Type: "object", Description: "The newest matching line as {line_number, text}, numbered from 1 at the top of the searched lines; absent when nothing matched"},
+						"lines_searched": {Type: "integer", Description: "Number of lines searched"},
+					},
+					Required: []string{"passed", "present", "matches", "lines_searched"},
+				},
+			},
+			{
+				Name:        "capture_between",
+				Description: "Return the output between two marker lines the agent printed itself, such as echo \"==START==\" before a command and echo \"==END==\" after it. The scrollback is searched for the last line containing start_marker, and the lines after it are returned up to the next line containing end_marker, leaving out the marker lines. A marker-based alternative to prompt detection that the agent fully controls. If the end marker hasn't appeared yet, the lines up to the bottom of the pane are returned with a note",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"raw": rawProperty,
+						"start_marker": {
+							Type:        "string",
+							Description: "Text on the line before the wanted output, matched as a substring",
+						},
+						"end_marker": {
+							Type:        "string",
+							Description: "Text on the line after the wanted output, matched as a substring",
+						},
+						"lines": {
+							Type:        "number",
+							Description: "Lines of scrollback history to search, besides the visible screen (default: the entire history)",
+						},
+					}),
+					Required: []string{"start_marker", "end_marker"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"start_found": {Type: "boolean", Description: "Whether a line with the start marker was found"},
+						"end_found":   {Type: "boolean", Description: "Whether a line with the end marker followed it"},
+						"content":     {Type: "string", Description: "The lines between the markers; empty if the start marker wasn't found"},
+						"lines":       {Type: "integer", Description: "Number of lines in content"},
+						"note":        {Type: "string", Description: "Which marker was not found"},
+					},
+					Required: []string{"start_found", "end_found", "content", "lines"},
+				},
+			},
+			{
+				Name:        "tail_follow",
+				Description: "Read the last lines of a pane, then watch it for duration_ms and collect the lines that appear. With a _meta.progressToken the tail and each batch of new lines are sent as notifications/progress as they arrive. Cancelling the request with notifications/cancelled ends the watch early. Returns the tail, the new output and a count of new lines",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"lines": {
+							Type:        "number",
+							Description: "Lines of existing output to return first (default: 20)",
+						},
+						"duration_ms": {
+							Type:        "number",
+							Description: "How long to watch for new lines, in milliseconds, up to 600000 (default: 10000)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "Delay between captures while watching, in milliseconds (default: the server's --poll-interval)",
+						},
+					}),
+					Required: []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"tail":        {Type: "string", Description: "The last lines of output when the call started"},
+						"new_output":  {Type: "string", Description: "Lines that appeared or changed while watching, in order"},
+						"new_lines":   {Type: "integer", Description: "Number of lines in new_output"},
+						"duration_ms": {Type: "integer", Description: "How long the pane was watched"},
+						"cancelled":   {Type: "boolean", Description: "Whether the client cancelled the request before duration_ms passed"},
+					},
+					Required: []string{"tail", "new_output", "new_lines", "duration_ms", "cancelled"},
+				},
+			},
+			{
+				Name:        "get_mouse",
+				Description: "Report whether tmux mouse mode is on, which changes how scrolling and selection behave when driving a TUI",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"mouse": {Type: "boolean", Description: "Whether mouse mode is on"},
+					},
+					Required: []string{"mouse"},
+				},
+			},
+			{
+				Name:        "get_automatic_rename",
+				Description: "Report whether tmux names a window after the command running in it (the automatic-rename window option). rename_window turns it off for the window it renames",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: withTargetProperties(map[string]mcp.Property{}),
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"automatic_rename": {Type: "boolean", Description: "Whether automatic-rename is on for the window"},
+					},
+					Required: []string{"automatic_rename"},
+				},
+			},
+			{
+				Name:        "get_prefix",
+				Description: "Report the session's tmux prefix key (and prefix2, if set), e.g. C-b. send_keys always delivers keys to the program, but a tmux running inside the pane with the same prefix would swallow that key",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"prefix":      {Type: "string", Description: "Prefix key in tmux notation, e.g. \"C-b\"; empty when none is set"},
+						"description": {Type: "string", Description: "The prefix spelled out, e.g. \"Ctrl+b\""},
+						"prefix2":     {Type: "string", Description: "Secondary prefix key, present only when set"},
+					},
+					Required: []string{"prefix", "description"},
+				},
+			},
+			{
+				Name:        "list_keys",
+				Description: "List tmux's key bindings (tmux list-keys), e.g. to learn what a prefix sequence or a copy-mode key does in the user's configuration before sending it. Bindings are global to the tmux server",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"table": {
+							Type:        "string",
+							Description: "Only list this key table: \"prefix\" (keys pressed after the prefix), \"root\" (keys bound without it), \"copy-mode\" or \"copy-mode-vi\" (default: every table)",
+						},
+					},
+					Required: []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"table":    {Type: "string", Description: "The table asked for, absent when every table was listed"},
+						"bindings": {Type: "array", Description: "Bindings as objects with table, key (tmux notation, unescaped), command and, for keys bound with -r, repeat"},
+					},
+					Required: []string{"bindings"},
+				},
+			},
+			{
+				Name:        "clear_line",
+				Description: "Clear any text already typed at the shell prompt (C-e then C-u), or interrupt the foreground program with C-c for a fresh prompt, and report the resulting prompt line. Run it before typing a command into a pane a person may have used (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"interrupt": {
+							Type:        "boolean",
+							Description: "Send C-c instead, to abandon the line or stop a running program (default: false)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "How long to let the shell redraw before reading the line, in milliseconds (default: the server's --poll-interval)",
+						},
+					}),
+					Required: []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"prompt":    {Type: "string", Description: "Text before the cursor on its line"},
+						"leftover":  {Type: "string", Description: "Text still after the cursor, if any"},
+						"at_prompt": {Type: "boolean", Description: "Whether the line is a bare prompt matching the prompt regex"},
+					},
+					Required: []string{"prompt", "leftover", "at_prompt"},
+				},
+			},
+			{
+				Name:        "focus_pane",
+				Description: "Make a pane tmux's active pane, switching to its window, and use it for every later tool call that names no window or pane. Call with no window or pane to go back to following tmux's active pane (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: withTargetProperties(map[string]mcp.Property{}),
+					Required:   []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"focused": {Type: "boolean", Description: "Whether a pane is now focused; false after clearing the focus"},
+						"pane":    {Type: "object", Description: "The focused pane, with the fields of a list_panes entry"},
+					},
+					Required: []string{"focused"},
+				},
+			},
+			{
+				Name:        "capture_at_size",
+				Description: "Capture the pane with its window temporarily resized, e.g. wider to read a table that wraps at the current width, then restore the original size. tmux rewraps the history on resize and wrapped lines are joined. With clients attached the pane is captured at its current size instead, with a note, so nobody's view is disturbed (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"width": {
+							Type:        "number",
+							Description: "Width to capture at, in columns (1 to 10000)",
+						},
+						"height": {
+							Type:        "number",
+							Description: "Height to capture at, in rows (default: the current height)",
+						},
+					}),
+					Required: []string{"width"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"content": {Type: "string", Description: "Captured pane content, with wrapped lines joined"},
+						"resized": {Type: "boolean", Description: "Whether the window was resized for the capture; false when clients were attached"},
+						"width":   {Type: "integer", Description: "Width the pane was captured at"},
+						"height":  {Type: "integer", Description: "Height the pane was captured at"},
+						"note":    {Type: "string", Description: "Why the pane was captured at its current size, when it was"},
+					},
+					Required: []string{"content", "resized", "width", "height"},
+				},
+			},
+			{
+				Name:        "refresh",
+				Description: "Make the program in a pane repaint, then return the screen. Some full-screen programs leave a stale frame until they get a signal or input, so a capture can look out of date. \"resize\" shrinks the window by a column and restores it, which sends SIGWINCH to every pane in the window; \"redraw\" types C-l, which clears the screen at a shell prompt (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"raw": rawProperty,
+						"method": {
+							Type:        "string",
+							Description: "How to cause the repaint: \"resize\" (default) or \"redraw\"",
+						},
+						"delay_ms": {
+							Type:        "number",
+							Description: "Time to let the program repaint before capturing, in milliseconds, up to 10000 (default: 200)",
+						},
+					}),
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"method":  {Type: "string", Description: "The method used"},
+						"content": {Type: "string", Description: "The pane content after the repaint"},
+					},
+					Required: []string{"method", "content"},
+				},
+			},
+			{
+				Name:        "save_capture",
+				Description: "Capture the pane and write it to a file, e.g. to archive output for a report. The file must be inside the directory the server was started with --save-dir; paths outside it are rejected (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"path": {
+							Type:        "string",
+							Description: "File to write, relative to the save directory (or an absolute path inside it). Its directory must exist; an existing file is overwritten",
+						},
+						"scrollback_lines": {
+							Type:        "number",
+							Description: "Save only the last N lines of history and screen; -1 or \"all\" saves the entire history (default: the same content as read_terminal)",
+						},
+					}),
+					Required: []string{"path"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"path":  {Type: "string", Description: "Absolute path of the file written"},
+						"bytes": {Type: "integer", Description: "Number of bytes written"},
+					},
+					Required: []string{"path", "bytes"},
+				},
+			},
+			{
+				Name:        "rename_window",
+				Description: "Rename a window, by default the session's active window (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"name": {
+							Type:        "string",
+							Description: "New window name (must not be empty or contain control characters)",
+						},
+					}),
+					Required: []string{"name"},
+				},
+			},
+			{
+				Name:        "set_mouse",
+				Description: "Turn tmux mouse mode on or off globally and return the resulting state, e.g. to get predictable input before driving a TUI and restore it afterwards (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"enabled": {
+							Type:        "boolean",
+							Description: "true to turn mouse mode on, false to turn it off",
+						},
+					},
+					Required: []string{"enabled"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"mouse": {Type: "boolean", Description: "Whether mouse mode is on after the change"},
+					},
+					Required: []string{"mouse"},
+				},
+			},
+			{
+				Name:        "set_automatic_rename",
+				Description: "Turn a window's automatic-rename option on or off and return the resulting state, e.g. to hand a window renamed with rename_window back to tmux's automatic naming (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"enabled": {
+							Type:        "boolean",
+							Description: "true to let tmux name the window after its running command, false to keep the current name",
+						},
+					}),
+					Required: []string{"enabled"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"automatic_rename": {Type: "boolean", Description: "Whether automatic-rename is on for the window after the change"},
+					},
+					Required: []string{"automatic_rename"},
+				},
+			},
+			{
+				Name:        "run_command",
+				Description: "Type a single-line command into the terminal, press Enter, and wait for the shell prompt to return. Returns the command's output (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"command": {
+							Type:        "string",
+							Description: "The command line to run",
+						},
+						"timeout_ms": {
+							Type:        "number",
+							Description: "Maximum time to wait for the prompt to return, in milliseconds, up to 3600000 (default: 30000)",
+						},
+						"exit_code": {
+							Type:        "boolean",
+							Description: "Append an echo of $? to the command and report the exit status in the result (default: false)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "Delay between captures while waiting, in milliseconds (default: the server's --poll-interval)",
+						},
+						"scratch": {
+							Type:        "boolean",
+							Description: "Run in the session's scratch pane, creating it if need be, instead of window/pane (default: false; see get_or_create_scratch)",
+						},
+					}),
+					Required: []string{"command"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"command":   {Type: "string", Description: "The command that was run"},
+						"output":    {Type: "string", Description: "Text printed between the command line and the next prompt"},
+						"completed": {Type: "boolean", Description: "Whether the prompt returned before the timeout"},
+						"exit_code": {Type: "integer", Description: "The command's exit status; null unless exit_code was requested and the status line was seen"},
+						"note":      {Type: "string", Description: "Explanation when the exit code could not be determined"},
+					},
+					Required: []string{"command", "output", "completed"},
+				},
+			},
+			{
+				Name:        "run_command_to_resource",
+				Description: "Like run_command, but store the output on the server and return a terminal://command-output/<id> resource link instead of the text, with only its last lines inline. Read the full output with resources/read until it expires (--output-ttl, default 15m). Use it for commands with long output (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"command": {
+							Type:        "string",
+							Description: "The command line to run",
+						},
+						"tail_lines": {
+							Type:        "number",
+							Description: "Last lines of output to return inline, 0 for none (default: 5)",
+						},
+						"timeout_ms": {
+							Type:        "number",
+							Description: "Maximum time to wait for the prompt to return, in milliseconds, up to 3600000 (default: 30000)",
+						},
+						"exit_code": {
+							Type:        "boolean",
+							Description: "Append an echo of $? to the command and report the exit status in the result (default: false)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "Delay between captures while waiting, in milliseconds (default: the server's --poll-interval)",
+						},
+						"scratch": {
+							Type:        "boolean",
+							Description: "Run in the session's scratch pane, creating it if need be, instead of window/pane (default: false; see get_or_create_scratch)",
+						},
+					}),
+					Required: []string{"command"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"uri":        {Type: "string", Description: "terminal://command-output/<id> resource holding the full output"},
+						"command":    {Type: "string", Description: "The command that was run"},
+						"completed":  {Type: "boolean", Description: "Whether the prompt returned before the timeout"},
+						"exit_code":  {Type: "integer", Description: "The command's exit status; null unless exit_code was requested and the status line was seen"},
+						"bytes":      {Type: "integer", Description: "Size of the stored output"},
+						"lines":      {Type: "integer", Description: "Lines in the stored output"},
+						"tail":       {Type: "string", Description: "The output's last tail_lines lines"},
+						"expires_at": {Type: "string", Description: "When the resource stops being readable (RFC 3339)"},
+						"note":       {Type: "string", Description: "Explanation when the exit code could not be determined"},
+					},
+					Required: []string{"uri", "command", "completed", "bytes", "lines", "tail", "expires_at"},
+				},
+			},
+			{
+				Name:        "run_command_stream",
+				Description: "Like run_command, but streams output as it appears via notifications/progress when the request carries a _meta.progressToken, and can treat the command as finished once its output goes quiet. Returns the full output at the end (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"command": {
+							Type:        "string",
+							Description: "The command line to run",
+						},
+						"timeout_ms": {
+							Type:        "number",
+							Description: "Maximum time to wait for the command to finish, in milliseconds, up to 3600000 (default: 30000)",
+						},
+						"silence_ms": {
+							Type:        "number",
+							Description: "Also finish once the output has not changed for this many milliseconds, for commands that never return to a prompt (default: 0, wait for the prompt)",
+						},
+						"exit_code": {
+							Type:        "boolean",
+							Description: "Append an echo of $? to the command and report the exit status in the result (default: false)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "Delay between captures while waiting, in milliseconds (default: the server's --poll-interval)",
+						},
+					}),
+					Required: []string{"command"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"command":   {Type: "string", Description: "The command that was run"},
+						"output":    {Type: "string", Description: "Text printed between the command line and the next prompt"},
+						"completed": {Type: "boolean", Description: "Whether the prompt returned before the timeout"},
+						"exit_code": {Type: "integer", Description: "The command's exit status; null unless exit_code was requested and the status line was seen"},
+						"note":      {Type: "string", Description: "Explanation when the exit code could not be determined"},
+						"silent":    {Type: "boolean", Description: "Whether the wait ended because the output went quiet for silence_ms"},
+					},
+					Required: []string{"command", "output", "completed"},
+				},
+			},
+			{
+				Name:        "wait_for_exit",
+				Description: "Run a single-line command like run_command, wait for it to finish and report whether it succeeded from its exit status. A command still running at the timeout gives outcome \"indeterminate\", never success. Suited to loops such as re-running tests until they pass (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"command": {
+							Type:        "string",
+							Description: "The command line to run",
+						},
+						"timeout_ms": {
+							Type:        "number",
+							Description: "Maximum time to wait for the command to finish, in milliseconds, up to 3600000 (default: 30000)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "Delay between captures while waiting, in milliseconds (default: the server's --poll-interval)",
+						},
+					}),
+					Required: []string{"command"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"command":   {Type: "string", Description: "The command that was run"},
+						"output":    {Type: "string", Description: "Text printed between the command line and the next prompt"},
+						"completed": {Type: "boolean", Description: "Whether the prompt returned before the timeout"},
+						"exit_code": {Type: "integer", Description: "The command's exit status; null if it was not seen"},
+						"note":      {Type: "string", Description: "Explanation when the exit code could not be determined"},
+						"outcome":   {Type: "string", Description: "\"success\" (exit status 0), \"failure\" (non-zero) or \"indeterminate\" (no exit status, e.g. on timeout)"},
+						"success":   {Type: "boolean", Description: "Whether the command succeeded; null when the outcome is indeterminate"},
+					},
+					Required: []string{"command", "output", "completed", "outcome"},
+				},
+			},
+			{
+				Name:        "last_exit_code",
+				Description: "Report the exit status of the last command run at the pane's shell, without re-running it. Read from the shell's OSC 133 command-finished marker when it prints one, which sends nothing to the pane. Otherwise the shell is asked by typing a command that echoes $? and restores it: that needs --allow-writes, a POSIX shell (bash, zsh, sh, dash, ksh) at an idle prompt, and shows in the pane",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"osc_only": {
+							Type:        "boolean",
+							Description: "Only read OSC 133 markers and never type into the pane (default: false)",
+						},
+						"timeout_ms": {
+							Type:        "number",
+							Description: "Maximum time to wait for the shell's answer, in milliseconds (default: 5000)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "Delay between captures while waiting, in milliseconds (default: the server's --poll-interval)",
+						},
+					}),
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"exit_code": {Type: "integer", Description: "The last command's exit status; null if it could not be found"},
+						"source":    {Type: "string", Description: "\"osc133\" (read from the shell's marker), \"shell\" (echoed by the shell) or empty when unknown"},
+						"note":      {Type: "string", Description: "Why the exit status is unknown"},
+					},
+					Required: []string{"source"},
+				},
+			},
+			{
+				Name:        "run_script",
+				Description: "Run a multi-line script in a pane without typing it: the server writes it to a temporary file in the pane's working directory, types a single command that runs the file, returns the output and exit code like run_command, and then deletes the file. The pane must be at a local shell prompt (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"script": {
+							Type:        "string",
+							Description: "The script to run",
+						},
+						"interpreter": {
+							Type:        "string",
+							Description: "Program that runs the script file, e.g. \"sh\" or \"python3\" (default: bash)",
+						},
+						"source": {
+							Type:        "boolean",
+							Description: "Run the script in the pane's own shell with '.', so directory and variable changes persist; can't be combined with interpreter (default: false)",
+						},
+						"timeout_ms": {
+							Type:        "number",
+							Description: "Maximum time to wait for the script to finish, in milliseconds, from 1000 to 3600000 (default: 30000)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "Delay between captures while waiting, in milliseconds (default: the server's --poll-interval)",
+						},
+					}),
+					Required: []string{"script"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"command":   {Type: "string", Description: "The command typed into the pane to run the script file"},
+						"output":    {Type: "string", Description: "Text printed between the command line and the next prompt"},
+						"completed": {Type: "boolean", Description: "Whether the prompt returned before the timeout"},
+						"exit_code": {Type: "integer", Description: "The script's exit status; null if it was not seen"},
+						"note":      {Type: "string", Description: "Explanation when the exit code could not be determined"},
+					},
+					Required: []string{"command", "output", "completed"},
+				},
+			},
+			{
+				Name:        "unlock_writes",
+				Description: "Enable the tools that modify the tmux session in a server started read-only, until lock_writes or the server exits. Only works if the server was started with --allow-runtime-unlock, and needs the secret if one was set with --unlock-secret. Every change is logged",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"secret": {Type: "string", Description: "The secret given to the server with --unlock-secret, if any"},
+					},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"writes_enabled": {Type: "boolean", Description: "Whether write tools are now enabled"},
+						"changed":        {Type: "boolean", Description: "False if writes were already enabled"},
+					},
+					Required: []string{"writes_enabled", "changed"},
+				},
+			},
+			{
+				Name:        "lock_writes",
+				Description: "Return the server to read-only mode, disabling the tools that modify the tmux session, whether they were enabled at startup or with unlock_writes. Only works if the server was started with --allow-runtime-unlock. Every change is logged",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"writes_enabled": {Type: "boolean", Description: "Whether write tools are now enabled (always false)"},
+						"changed":        {Type: "boolean", Description: "False if the server was already read-only"},
+					},
+					Required: []string{"writes_enabled", "changed"},
+				},
+			},
+			{
+				Name:        "get_or_create_scratch",
+				Description: "Return the session's scratch pane, a window named \"mcp-scratch\" kept for the agent's own commands so they don't disturb the panes the user is working in. The window is created in the background on the first call and the same pane is returned on every later one. Pass {\"scratch\": true} to run_command to run there (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type:       "object",
+					Properties: map[string]mcp.Property{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"session": {Type: "string", Description: "The session holding the scratch pane"},
+						"window":  {Type: "string", Description: "Name the scratch window was created with"},
+						"pane":    {Type: "string", Description: "The scratch pane's ID, usable as the pane of any tool"},
+						"created": {Type: "boolean", Description: "Whether this call created the window"},
+					},
+					Required: []string{"session", "window", "pane", "created"},
+				},
+			},
+			{
+				Name:        "jobs",
+				Description: "List the background jobs of the shell in a pane, by typing its jobs command (e.g. \"jobs -l\") at the prompt. A command started with & returns to the prompt at once, so run_command reports it finished; this shows whether it is still running. The shell is detected from the pane's process (" + jobsShells() + "), and --jobs-command sets the command for others (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"shell": {
+							Type:        "string",
+							Description: "The shell at the pane's prompt, for when the pane's own process isn't it, e.g. \"bash\" behind ssh (default: detected)",
+						},
+					}),
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type:       "object",
+					Properties: jobsOutputProperties(nil),
+					Required:   []string{"shell", "jobs_command", "jobs", "running"},
+				},
+			},
+			{
+				Name:        "wait_job",
+				Description: "Wait until the shell in a pane has no background job running, or until a given job isn't, by listing its jobs as the jobs tool does every poll_ms. Stopped jobs don't count as running. Use it after starting work with & (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"job": {
+							Type:        "number",
+							Description: "Job number to wait for, as in %1 (default: every job)",
+						},
+						"shell": {
+							Type:        "string",
+							Description: "The shell at the pane's prompt, for when the pane's own process isn't it, e.g. \"bash\" behind ssh (default: detected)",
+						},
+						"timeout_ms": {
+							Type:        "number",
+							Description: "Maximum time to wait, in milliseconds, up to 600000 (default: 60000)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "Delay between job listings, in milliseconds; each one types a command into the pane (default: 1000)",
+						},
+					}),
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: jobsOutputProperties(map[string]mcp.Property{
+						"finished":  {Type: "boolean", Description: "Whether no job, or the requested job, was running when the wait ended"},
+						"waited_ms": {Type: "integer", Description: "How long the wait took"},
+						"cancelled": {Type: "boolean", Description: "Whether the client cancelled the request before the jobs finished"},
+					}),
+					Required: []string{"shell", "jobs_command", "jobs", "running", "finished", "waited_ms", "cancelled"},
+				},
+			},
+			{
+				Name:        "interrupt",
+				Description: "Send C-c to a pane and wait for its shell to come back to the foreground, reporting whether the running program actually stopped. Nothing is sent if the pane is already at a shell (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"timeout_ms": {
+							Type:        "number",
+							Description: "How long to wait for the shell to come back (default: 5000)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "Delay between checks of the foreground command, in milliseconds (default: the server's --poll-interval)",
+						},
+					}),
+					Required: []string{},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"interrupted": {Type: "string", Description: "Foreground command when the tool was called"},
+						"command":     {Type: "string", Description: "Foreground command afterwards"},
+						"stopped":     {Type: "boolean", Description: "Whether a shell is back in the foreground; null when the pane runs ssh or another remote client, whose remote programs tmux can't see"},
+						"sent":        {Type: "boolean", Description: "Whether C-c was sent; false when the pane was already at a shell"},
+					},
+					Required: []string{"interrupted", "command", "sent"},
+				},
+			},
+			{
+				Name:        "send_keys",
+				Description: "Type literal text and/or send tmux key names (e.g. \"Enter\", \"C-c\") to a pane. Text is sent first, then keys (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"text": {
+							Type:        "string",
+							Description: "Text to type literally; key names inside it are not interpreted",
+						},
+						"keys": {
+							Type:        "array",
+							Description: "tmux key names to send after the text, e.g. [\"Enter\"] or [\"C-c\"]",
+						},
+					}),
+					Required: []string{},
+				},
+			},
+			{
+				Name:        "send_secret",
+				Description: "Type a password or other secret into a pane that is asking for one, then press Enter. The secret is never returned and is replaced by [SECRET REDACTED] in the --trace-file trace and the --record recording. Nothing is sent unless the pane's last line is a password prompt or gpg's pinentry is running, since elsewhere the secret could be echoed or saved in shell history; force overrides this (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"secret": {
+							Type:        "string",
+							Description: "The secret to type, on a single line",
+						},
+						"enter": {
+							Type:        "boolean",
+							Description: "Press Enter after the secret (default true)",
+						},
+						"force": {
+							Type:        "boolean",
+							Description: "Send even though the pane doesn't look like it is asking for a password (default false)",
+						},
+					}),
+					Required: []string{"secret"},
+				},
+				OutputSchema: &mcp.InputSchema{
+					Type: "object",
+					Properties: map[string]mcp.Property{
+						"sent":              {Type: "boolean", Description: "Whether the secret was typed"},
+						"awaiting_password": {Type: "boolean", Description: "Whether the pane looked like it was asking for a password before anything was sent"},
+						"reason":            {Type: "string", Description: "Why the pane did or didn't look like it was asking for a password"},
+						"command":           {Type: "string", Description: "The pane's foreground command"},
+						"enter":             {Type: "boolean", Description: "Whether Enter was to be pressed after the secret"},
+					},
+					Required: []string{"sent", "awaiting_password", "reason", "command", "enter"},
+				},
+			},
+			{
+				Name:        "send_and_read",
+				Description: "Type text and/or send keys to a pane like send_keys, then wait a short delay (wait_ms) or until a pattern appears (until_pattern) and return the pane's content. Use it for quick interactive steps such as answering a [y/N] prompt (requires the server to be started with --allow-writes)",
+				InputSchema: mcp.InputSchema{
+					Type: "object",
+					Properties: withTargetProperties(map[string]mcp.Property{
+						"raw": rawProperty,
+						"text": {
+							Type:        "string",
+							Description: "Text to type literally; key names inside it are not interpreted",
+						},
+						"keys": {
+							Type:        "array",
+							Description: "tmux key names to send after the text, e.g. [\"Enter\"]",
+						},
+						"wait_ms": {
+							Type:        "number",
+							Description: "How long to wait before reading, in milliseconds (default: 500). Cannot be combined with until_pattern",
+						},
+						"until_pattern": {
+							Type:        "string",
+							Description: "Read as soon as this regular expression matches a line of the pane, e.g. \"\\[y/N\\]\"",
+						},
+						"timeout_ms": {
+							Type:        "number",
+							Description: "Maximum time to wait for until_pattern, in milliseconds (default: 10000)",
+						},
+						"poll_ms": {
+							Type:        "number",
+							Description: "Delay between captures while waiting for until_pattern, in milliseconds (default: the server's --poll-interval)",
+						},
+					}),
+					Required: []string{},
 				},
-			},
-			{
-				Name:        "read_scrollback",
-				Description: "Read scrollback history from the tmux session",
-				InputSchema: mcp.InputSchema{
+				OutputSchema: &mcp.InputSchema{
 					Type: "object",
 					Properties: map[string]mcp.Property{
-						"lines": {
-							Type:        "number",
-							Description: "Number of lines of scrollback history to retrieve (default: 100)",
-						},
+						"content": {Type: "string", Description: "The pane's visible content after waiting, with trailing blank lines removed"},
+						"matched": {Type: "boolean", Description: "Whether until_pattern appeared before the timeout; absent without until_pattern"},
 					},
-					Required: []string{},
+					Required: []string{"content"},
 				},
 			},
 			{
-				Name:        "get_terminal_info",
-				Description: "Get information about the terminal (dimensions, current path, etc.)",
+				Name:        "describe",
+				Description: "Describe the server in one call: server info, the active tmux session, and every available tool (with input schemas), resource and prompt",
 				InputSchema: mcp.InputSchema{
 					Type:       "object",
 					Properties: map[string]mcp.Property{},
@@ -187,6 +2307,207 @@ func (s *Server) listTools() *mcp.ListToolsResult {
 	}
 }
 
+// terminalInfo is the structured content of get_terminal_info
+type terminalInfo struct {
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	CurrentPath string `json:"current_path"`
+	PaneIndex   int    `json:"pane_index"`
+	// PaneDead is set when the pane's process has exited and its content
+	// is stale; PaneDeadStatus is then the exit status
+	PaneDead       bool `json:"pane_dead"`
+	PaneDeadStatus *int `json:"pane_dead_status,omitempty"`
+	// ScreenMode is screenAlternate while a full-screen program such as
+	// vim or less has the pane, and screenNormal otherwise
+	ScreenMode string `json:"screen_mode"`
+
+	AttachedClients int `json:"attached_clients"`
+}
+
+// Values of terminalInfo.ScreenMode
+const (
+	screenNormal    = "normal"
+	screenAlternate = "alternate"
+)
+
+// newTerminalInfo converts the string fields reported by GetPaneInfo.
+// tmux always reports these as integers, so parse failures leave zero.
+func newTerminalInfo(info map[string]string) terminalInfo {
+	width, _ := strconv.Atoi(info["width"])
+	height, _ := strconv.Atoi(info["height"])
+	paneIndex, _ := strconv.Atoi(info["pane_index"])
+	result := terminalInfo{
+		Width:       width,
+		Height:      height,
+		CurrentPath: info["current_path"],
+		PaneIndex:   paneIndex,
+		PaneDead:    info["pane_dead"] == "1",
+		ScreenMode:  screenNormal,
+	}
+	if info["alternate_on"] == "1" {
+		result.ScreenMode = screenAlternate
+	}
+	if status, err := strconv.Atoi(info["pane_dead_status"]); err == nil && result.PaneDead {
+		result.PaneDeadStatus = &status
+	}
+	return result
+}
+
+// deadNote describes a dead pane for appending to a tool's text result,
+// or returns "" if the pane is alive
+func (t terminalInfo) deadNote() string {
+	if !t.PaneDead {
+		return ""
+	}
+	if t.PaneDeadStatus != nil {
+		return fmt.Sprintf("warning: the pane's process exited with status %d; this content is stale and no new output will appear", *t.PaneDeadStatus)
+	}
+	return "warning: the pane's process has exited; this content is stale and no new output will appear"
+}
+
+// screenNote describes the alternate screen for appending to a tool's text
+// result, or returns "" on the normal screen
+func (t terminalInfo) screenNote() string {
+	if t.ScreenMode != screenAlternate {
+		return ""
+	}
+	return "alternate screen: a full-screen program is running; its display replaces the shell output until it exits"
+}
+
+// staleCapture turns a capture that failed because the pane's process
+// exited into the pane's last captured content, flagged as stale, so the
+// output of a command that just finished isn't lost to an opaque error. ok
+// is false for any other error.
+func staleCapture(err error) (content string, ok bool) {
+	var dead *tmux.PaneDeadError
+	if !errors.As(err, &dead) {
+		return "", false
+	}
+	if !dead.HasStale {
+		return appendNote("", "warning: the pane's process has exited and tmux can no longer capture it; no earlier capture of it is available"), true
+	}
+	return appendNote(strings.TrimRight(dead.Stale, "\n"),
+		"warning: the pane's process has exited and tmux can no longer capture it; this is the last content read before it exited and is stale"), true
+}
+
+// attachedStatus is the structured content of is_attached
+type attachedStatus struct {
+	Attached        bool `json:"attached"`
+	AttachedClients int  `json:"attached_clients"`
+}
+
+// paneList is the structured content of list_panes
+type paneList struct {
+	Panes []paneEntry `json:"panes"`
+	// SessionCreated is when the session was created (RFC 3339) and
+	// SessionAge how long ago that was, e.g. "3h12m"
+	SessionCreated string `json:"session_created,omitempty"`
+	SessionAge     string `json:"session_age,omitempty"`
+}
+
+// paneEntry describes one pane in list_panes
+type paneEntry struct {
+	PaneID       string `json:"pane_id"`
+	WindowIndex  int    `json:"window_index"`
+	WindowName   string `json:"window_name"`
+	PaneIndex    int    `json:"pane_index"`
+	Active       bool   `json:"active"`
+	WindowActive bool   `json:"window_active"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Command      string `json:"command"`
+	Dead         bool   `json:"pane_dead"`
+	DeadStatus   *int   `json:"pane_dead_status,omitempty"`
+	// WindowActivity is when the window last saw activity (RFC 3339) and
+	// WindowIdle how long ago that was
+	WindowActivity string `json:"window_activity,omitempty"`
+	WindowIdle     string `json:"window_idle,omitempty"`
+	// Focused marks the pane chosen with focus_pane, which tools use when
+	// no window or pane is given
+	Focused bool `json:"focused,omitempty"`
+}
+
+// newPaneEntry converts a tmux pane description for list_panes
+func newPaneEntry(pane tmux.PaneInfo) paneEntry {
+	return paneEntry{
+		PaneID:       pane.ID,
+		WindowIndex:  pane.WindowIndex,
+		WindowName:   pane.WindowName,
+		PaneIndex:    pane.PaneIndex,
+		Active:       pane.Active,
+		WindowActive: pane.WindowActive,
+		Width:        pane.Width,
+		Height:       pane.Height,
+		Command:      pane.Command,
+		Dead:         pane.Dead,
+		DeadStatus:   pane.DeadStatus,
+
+		WindowActivity: timestamp(pane.WindowActivity),
+		WindowIdle:     age(pane.WindowActivity),
+	}
+}
+
+// age returns how long ago t was for people, or "" for the zero time
+func age(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return humanAge(time.Since(t))
+}
+
+// clientList is the structured content of list_clients
+type clientList struct {
+	Clients []clientEntry `json:"clients"`
+}
+
+// clientEntry describes one attached client in list_clients
+type clientEntry struct {
+	TTY          string `json:"tty"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	LastActivity string `json:"last_activity"`
+}
+
+// optionValue is the structured content of get_option
+type optionValue struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Global bool   `json:"global"`
+}
+
+// scrollbackSize is the structured content of scrollback_size
+type scrollbackSize struct {
+	HistorySize  int `json:"history_size"`
+	HistoryLimit int `json:"history_limit"`
+}
+
+// describeResult is the payload returned by the describe tool
+type describeResult struct {
+	ServerInfo mcp.ServerInfo `json:"serverInfo"`
+	Session    string         `json:"session"`
+	Tools      []mcp.Tool     `json:"tools"`
+	Resources  []mcp.Resource `json:"resources"`
+	Prompts    []mcp.Prompt   `json:"prompts"`
+}
+
+// describe aggregates the tool, resource and prompt listings into a single
+// document for clients that don't issue the separate list requests
+func (s *Server) describe() (string, error) {
+	result := describeResult{
+		ServerInfo: s.serverInfo(),
+		Session:    s.tmuxManager.SessionName(),
+		Tools:      s.listTools().Tools,
+		Resources:  s.listResources().Resources,
+		Prompts:    []mcp.Prompt{},
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 func (s *Server) callTool(request *mcp.JSONRPCRequest) (*mcp.CallToolResult, error) {
 	paramsBytes, err := json.Marshal(request.Params)
 	if err != nil {
@@ -197,44 +2518,327 @@ func (s *Server) callTool(request *mcp.JSONRPCRequest) (*mcp.CallToolResult, err
 	if err := json.Unmarshal(paramsBytes, &toolRequest); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tool request: %w", err)
 	}
+	if toolRequest.Meta != nil {
+		s.client.progressToken = toolRequest.Meta.ProgressToken
+		defer func() { s.client.progressToken = nil }()
+	}
+
+	// Recreate the session if the tmux server was restarted since the
+	// last request
+	if err := s.tmuxManager.EnsureConnected(); err != nil {
+		return nil, err
+	}
 
 	switch toolRequest.Name {
 	case "read_terminal":
-		content, err := s.tmuxManager.CapturePane()
+		format := "text"
+		if formatVal, ok := toolRequest.Arguments["format"].(string); ok && formatVal != "" {
+			format = formatVal
+		}
+		if format != "text" && format != "html" && format != "lines" {
+			return nil, invalidParams(fmt.Sprintf("unsupported format: %s (expected \"text\", \"html\" or \"lines\")", format),
+				paramError{Field: "format", Expected: `string: "text", "html" or "lines"`})
+		}
+
+		target, err := targetArgument(toolRequest.Arguments)
 		if err != nil {
+			return nil, err
+		}
+
+		withCursor, _ := toolRequest.Arguments["cursor"].(bool)
+		if withCursor && format != "text" {
+			return nil, invalidParams("cursor is only supported with format \"text\"",
+				paramError{Field: "cursor", Expected: "false unless format is \"text\""})
+		}
+		warnDead, _ := toolRequest.Arguments["warn_dead"].(bool)
+		if refresh, _ := toolRequest.Arguments["refresh"].(bool); refresh {
+			if result := s.requireWrites("read_terminal with refresh"); result != nil {
+				return result, nil
+			}
+			if err := s.refreshPane(target, tmux.RefreshResize, defaultRefreshDelay); err != nil {
+				return toolError(err)
+			}
+		}
+		if format == "lines" {
+			if warnDead {
+				return nil, invalidParams("warn_dead is not supported with format \"lines\"",
+					paramError{Field: "warn_dead", Expected: "false when format is \"lines\""})
+			}
+			lines, err := s.tmuxManager.CaptureWrapped(target)
+			if err != nil {
+				return toolError(err)
+			}
+			text, err := wrappedLinesJSON(lines)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode lines: %w", err)
+			}
 			return &mcp.CallToolResult{
-				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s", err)}},
-				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: text}},
+			}, nil
+		}
+
+		content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{
+			EscapeSequences: format == "html",
+			Target:          target,
+		})
+		if err != nil {
+			stale, ok := staleCapture(err)
+			if !ok {
+				return toolError(err)
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{{Type: "text", Text: stale}},
 			}, nil
 		}
+		if withCursor {
+			cursor, err := s.tmuxManager.GetCursor(target)
+			if err != nil {
+				return toolError(err)
+			}
+			content = markCursor(content, cursor)
+		}
+		// Truncate before HTML conversion so markup isn't counted or cut
+		content = s.formatCapture(content, toolRequest.Arguments)
+		if format == "html" {
+			content = ansi.ToHTML(content)
+		}
+		if warnDead {
+			info, err := s.tmuxManager.GetPaneInfoFor(target)
+			if err != nil {
+				return toolError(err)
+			}
+			if note := newTerminalInfo(info).deadNote(); note != "" {
+				content = appendNote(strings.TrimRight(content, "\n"), note)
+			}
+		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{{Type: "text", Text: content}},
 		}, nil
 
 	case "read_scrollback":
-		lines := 100 // default
+		lines := defaultScrollbackLines
 		if linesVal, ok := toolRequest.Arguments["lines"]; ok {
-			switch v := linesVal.(type) {
-			case float64:
-				lines = int(v)
-			case int:
-				lines = v
+			if linesVal == "all" {
+				lines = tmux.AllLines
+			} else if n, err := coerceInt(linesVal); err == nil {
+				lines = n
+			} else {
+				return nil, invalidParams(fmt.Sprintf("invalid lines: %v", err),
+					paramError{Field: "lines", Expected: `integer, numeric string or "all"`})
+			}
+		}
+		// 0 means "use the default", matching an omitted argument
+		if lines == 0 {
+			lines = defaultScrollbackLines
+		}
+
+		format := "text"
+		if formatVal, ok := toolRequest.Arguments["format"].(string); ok && formatVal != "" {
+			format = formatVal
+		}
+		if format != "text" && format != "json" {
+			return nil, invalidParams(fmt.Sprintf("unsupported format: %s (expected \"text\" or \"json\")", format),
+				paramError{Field: "format", Expected: `string: "text" or "json"`})
+		}
+		withANSI, _ := toolRequest.Arguments["ansi"].(bool)
+		target, err := targetArgument(toolRequest.Arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := s.tmuxManager.GetScrollbackHistoryWithOptions(lines, tmux.CaptureOptions{
+			EscapeSequences: withANSI,
+			Target:          target,
+		})
+		if err != nil {
+			return toolError(err)
+		}
+		if format == "text" {
+			content = s.formatCapture(content, toolRequest.Arguments)
+		} else {
+			content = truncateLines(content, s.maxLineWidth)
+		}
+		if format == "json" {
+			content, err = scrollbackJSON(content, withANSI)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode scrollback: %w", err)
+			}
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: content}},
+		}, nil
+
+	case "read_scrollback_page":
+		return s.readScrollbackPage(toolRequest.Arguments)
+
+	case "read_range":
+		return s.readRange(toolRequest.Arguments)
+
+	case "read_with_context":
+		return s.readWithContext(toolRequest.Arguments)
+
+	case "read_window":
+		return s.readWindow(toolRequest.Arguments)
+
+	case "orient":
+		return s.orient(toolRequest.Arguments)
+
+	case "snapshot":
+		return s.snapshot(toolRequest.Arguments)
+
+	case "capture_grid_diff":
+		return s.captureGridDiff(toolRequest.Arguments)
+
+	case "capture_grid":
+		return s.captureGrid(toolRequest.Arguments)
+
+	case "diff_captures":
+		return s.diffCaptures(toolRequest.Arguments)
+
+	case "get_terminal_info":
+		info, err := s.tmuxManager.GetPaneInfo()
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s", err)}},
+				IsError: true,
+			}, nil
+		}
+
+		attached, err := s.tmuxManager.AttachedClients()
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s", err)}},
+				IsError: true,
+			}, nil
+		}
+
+		infoText := fmt.Sprintf("Terminal Info:\n- Width: %s\n- Height: %s\n- Current Path: %s\n- Pane Index: %s\n- Attached Clients: %d",
+			info["width"], info["height"], info["current_path"], info["pane_index"], attached)
+
+		structured := newTerminalInfo(info)
+		structured.AttachedClients = attached
+		if note := structured.deadNote(); note != "" {
+			infoText = appendNote(infoText, note)
+		}
+		if note := structured.screenNote(); note != "" {
+			infoText = appendNote(infoText, note)
+		}
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{{Type: "text", Text: infoText}},
+			StructuredContent: structured,
+		}, nil
+
+	case "terminal_caps":
+		return s.terminalCaps()
+
+	case "server_status":
+		return s.serverStatus()
+
+	case "list_panes":
+		panes, err := s.tmuxManager.ListPanes()
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s", err)}},
+				IsError: true,
+			}, nil
+		}
+
+		list := paneList{Panes: make([]paneEntry, 0, len(panes))}
+		var lines []string
+		for _, pane := range panes {
+			entry := newPaneEntry(pane)
+			entry.Focused = pane.ID == s.tmuxManager.Focused()
+			list.Panes = append(list.Panes, entry)
+			marker := ""
+			if pane.Active && pane.WindowActive {
+				marker = " (active)"
+			}
+			if entry.Focused {
+				marker += " (focused)"
 			}
+			if pane.Dead {
+				marker += " (dead)"
+			}
+			lines = append(lines, fmt.Sprintf("%s window %d %q pane %d: %s %dx%d%s",
+				pane.ID, pane.WindowIndex, pane.WindowName, pane.PaneIndex, pane.Command, pane.Width, pane.Height, marker))
+		}
+		text := strings.Join(lines, "\n")
+		if len(panes) > 0 && !panes[0].SessionCreated.IsZero() {
+			created := panes[0].SessionCreated
+			list.SessionCreated, list.SessionAge = timestamp(created), age(created)
+			text = appendNote(text, fmt.Sprintf("session created %s, %s ago", list.SessionCreated, list.SessionAge))
+		}
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{{Type: "text", Text: text}},
+			StructuredContent: list,
+		}, nil
+
+	case "overview":
+		return s.overviewTool()
+
+	case "bind_session":
+		return s.bindSession(toolRequest.Arguments)
+
+	case "is_attached":
+		attached, err := s.tmuxManager.AttachedClients()
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s", err)}},
+				IsError: true,
+			}, nil
+		}
+
+		text := "No clients are attached to the session"
+		if attached > 0 {
+			text = fmt.Sprintf("%d client(s) attached to the session; a human may be watching or typing", attached)
 		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: text}},
+			StructuredContent: attachedStatus{
+				Attached:        attached > 0,
+				AttachedClients: attached,
+			},
+		}, nil
 
-		content, err := s.tmuxManager.GetScrollbackHistory(lines)
+	case "popup_state":
+		return s.popupState()
+
+	case "list_clients":
+		clients, err := s.tmuxManager.ListClients()
 		if err != nil {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s", err)}},
 				IsError: true,
 			}, nil
 		}
+
+		list := clientList{Clients: make([]clientEntry, 0, len(clients))}
+		var lines []string
+		for _, client := range clients {
+			entry := clientEntry{
+				TTY:          client.TTY,
+				Width:        client.Width,
+				Height:       client.Height,
+				LastActivity: client.LastActivity.UTC().Format(time.RFC3339),
+			}
+			list.Clients = append(list.Clients, entry)
+			lines = append(lines, fmt.Sprintf("%s %dx%d last active %s", entry.TTY, entry.Width, entry.Height, entry.LastActivity))
+		}
+
+		text := "No clients are attached to the session"
+		if len(lines) > 0 {
+			text = strings.Join(lines, "\n")
+		}
 		return &mcp.CallToolResult{
-			Content: []mcp.Content{{Type: "text", Text: content}},
+			Content:           []mcp.Content{{Type: "text", Text: text}},
+			StructuredContent: list,
 		}, nil
 
-	case "get_terminal_info":
-		info, err := s.tmuxManager.GetPaneInfo()
+	case "visible_size":
+		return s.getVisibleSize(toolRequest.Arguments)
+
+	case "scrollback_size":
+		used, limit, err := s.tmuxManager.GetScrollbackSize()
 		if err != nil {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s", err)}},
@@ -242,11 +2846,164 @@ func (s *Server) callTool(request *mcp.JSONRPCRequest) (*mcp.CallToolResult, err
 			}, nil
 		}
 
-		infoText := fmt.Sprintf("Terminal Info:\n- Width: %s\n- Height: %s\n- Current Path: %s\n- Pane Index: %s",
-			info["width"], info["height"], info["current_path"], info["pane_index"])
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Scrollback: %d of %d lines", used, limit)}},
+			StructuredContent: scrollbackSize{
+				HistorySize:  used,
+				HistoryLimit: limit,
+			},
+		}, nil
+
+	case "get_option":
+		name, _ := toolRequest.Arguments["name"].(string)
+		if name == "" {
+			return nil, invalidParams("name is required",
+				paramError{Field: "name", Expected: "a tmux option name, e.g. \"history-limit\""})
+		}
+		global, _ := toolRequest.Arguments["global"].(bool)
+
+		value, err := s.tmuxManager.ShowOption(name, global)
+		if err != nil {
+			return toolError(err)
+		}
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{{Type: "text", Text: fmt.Sprintf("%s %s", name, value)}},
+			StructuredContent: optionValue{Name: name, Value: value, Global: global},
+		}, nil
+
+	case "at_prompt":
+		return s.atPrompt(toolRequest.Arguments)
+
+	case "detect_shell":
+		return s.detectShell(toolRequest.Arguments)
+
+	case "read_file":
+		return s.readFile(toolRequest.Arguments)
+
+	case "git_status":
+		return s.gitStatusTool(toolRequest.Arguments)
+
+	case "scroll_state":
+		return s.getScrollState(toolRequest.Arguments)
+
+	case "pane_env":
+		return s.paneEnv(toolRequest.Arguments)
+
+	case "is_active":
+		return s.isActive(toolRequest.Arguments)
+
+	case "assert_output":
+		return s.assertOutput(toolRequest.Arguments)
+
+	case "capture_between":
+		return s.captureBetween(toolRequest.Arguments)
+
+	case "tail_follow":
+		return s.tailFollow(toolRequest.Arguments, toolRequest.Meta, request.ID)
+
+	case "get_mouse":
+		return s.getMouse()
+
+	case "get_automatic_rename":
+		return s.getAutomaticRename(toolRequest.Arguments)
+
+	case "get_prefix":
+		return s.getPrefix()
+
+	case "list_keys":
+		return s.listKeys(toolRequest.Arguments)
+
+	case "focus_pane":
+		return s.focusPane(toolRequest.Arguments)
+
+	case "refresh":
+		return s.refresh(toolRequest.Arguments)
+
+	case "capture_at_size":
+		return s.captureAtSize(toolRequest.Arguments)
+
+	case "save_capture":
+		return s.saveCapture(toolRequest.Arguments)
+
+	case "rename_window":
+		if result := s.requireWrites(toolRequest.Name); result != nil {
+			return result, nil
+		}
+
+		target, err := targetArgument(toolRequest.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		name, _ := toolRequest.Arguments["name"].(string)
+		if err := s.tmuxManager.RenameWindow(target, name); err != nil {
+			return toolError(err)
+		}
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{{Type: "text", Text: fmt.Sprintf("Window renamed to %q", name)}},
+			StructuredContent: map[string]string{"name": name},
+		}, nil
+
+	case "set_mouse":
+		return s.setMouse(toolRequest.Arguments)
+
+	case "set_automatic_rename":
+		return s.setAutomaticRename(toolRequest.Arguments)
+
+	case "clear_line":
+		return s.clearLine(toolRequest.Arguments)
+
+	case "run_command":
+		return s.runCommand(toolRequest.Arguments)
 
+	case "run_command_to_resource":
+		return s.runCommandToResource(toolRequest.Arguments)
+
+	case "run_command_stream":
+		return s.runCommandStream(toolRequest.Arguments, toolRequest.Meta)
+
+	case "wait_for_exit":
+		return s.waitForExit(toolRequest.Arguments)
+
+	case "last_exit_code":
+		return s.lastExitCode(toolRequest.Arguments)
+
+	case "run_script":
+		return s.runScript(toolRequest.Arguments)
+
+	case "unlock_writes":
+		return s.unlockWrites(toolRequest.Arguments)
+
+	case "lock_writes":
+		return s.lockWrites()
+
+	case "get_or_create_scratch":
+		return s.getOrCreateScratch()
+
+	case "jobs":
+		return s.jobs(toolRequest.Arguments)
+
+	case "wait_job":
+		return s.waitJob(toolRequest.Arguments, request.ID)
+
+	case "interrupt":
+		return s.interrupt(toolRequest.Arguments)
+
+	case "send_keys":
+		return s.sendKeys(toolRequest.Arguments)
+
+	case "send_and_read":
+		return s.sendAndRead(toolRequest.Arguments)
+
+	case "send_secret":
+		return s.sendSecret(toolRequest.Arguments)
+
+	case "describe":
+		description, err := s.describe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe server: %w", err)
+		}
 		return &mcp.CallToolResult{
-			Content: []mcp.Content{{Type: "text", Text: infoText}},
+			Content: []mcp.Content{{Type: "text", Text: description}},
 		}, nil
 
 	default:
@@ -254,8 +3011,20 @@ func (s *Server) callTool(request *mcp.JSONRPCRequest) (*mcp.CallToolResult, err
 	}
 }
 
+// requireWrites returns an error result when tool, which modifies the tmux
+// session, is called on a read-only server, and nil when writes are allowed
+func (s *Server) requireWrites(tool string) *mcp.CallToolResult {
+	if s.writesEnabled {
+		return nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s modifies the tmux session and is disabled in read-only mode (start the server with --allow-writes)", tool)}},
+		IsError: true,
+	}
+}
+
 func (s *Server) listResources() *mcp.ListResourcesResult {
-	return &mcp.ListResourcesResult{
+	result := &mcp.ListResourcesResult{
 		Resources: []mcp.Resource{
 			{
 				URI:         "terminal://current",
@@ -269,8 +3038,23 @@ func (s *Server) listResources() *mcp.ListResourcesResult {
 				Description: "Terminal dimensions and metadata",
 				MimeType:    "text/plain",
 			},
+			{
+				URI:         "terminal://layout",
+				Name:        "Window Layout",
+				Description: "How the active window is split into panes: a tree of left-right and top-bottom splits with each pane's ID, position and size",
+				MimeType:    "application/json",
+			},
+			{
+				URI:         "terminal://health",
+				Name:        "Health",
+				Description: "Readiness for supervisors: status \"ok\", \"degraded\" (the session is missing) or \"unhealthy\" (tmux can't be run), with the tmux version, whether the session exists and the server's uptime. Reading it captures nothing and never creates the session",
+				MimeType:    "application/json",
+			},
 		},
 	}
+	// Outputs stored by run_command_to_resource come and go
+	result.Resources = append(result.Resources, s.commandOutputResources()...)
+	return result
 }
 
 func (s *Server) readResource(request *mcp.JSONRPCRequest) (*mcp.ReadResourceResult, error) {
@@ -284,12 +3068,33 @@ func (s *Server) readResource(request *mcp.JSONRPCRequest) (*mcp.ReadResourceRes
 		return nil, fmt.Errorf("failed to unmarshal resource request: %w", err)
 	}
 
+	// Health is read without side effects, before the session would be
+	// recreated
+	if resourceRequest.URI == "terminal://health" {
+		return s.readHealth(resourceRequest.URI)
+	}
+	// Stored outputs are served from memory
+	if strings.HasPrefix(resourceRequest.URI, commandOutputPrefix) {
+		return s.readCommandOutput(resourceRequest.URI)
+	}
+
+	// Recreate the session if the tmux server was restarted since the
+	// last request
+	if err := s.tmuxManager.EnsureConnected(); err != nil {
+		return nil, err
+	}
+
 	switch resourceRequest.URI {
 	case "terminal://current":
 		content, err := s.tmuxManager.CapturePane()
 		if err != nil {
-			return nil, err
+			stale, ok := staleCapture(err)
+			if !ok {
+				return nil, err
+			}
+			content = stale
 		}
+		content = suppressBinary(content)
 		return &mcp.ReadResourceResult{
 			Contents: []mcp.ResourceContent{
 				{
@@ -318,6 +3123,9 @@ func (s *Server) readResource(request *mcp.JSONRPCRequest) (*mcp.ReadResourceRes
 			},
 		}, nil
 
+	case "terminal://layout":
+		return s.readLayout(resourceRequest.URI)
+
 	default:
 		return nil, fmt.Errorf("unknown resource: %s", resourceRequest.URI)
 	}
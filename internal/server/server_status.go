@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// statusResult is the structured content of server_status
+type statusResult struct {
+	Server        string `json:"server"`
+	Version       string `json:"version"`
+	Session       string `json:"session"`
+	WritesEnabled bool   `json:"writes_enabled"`
+	Uptime        string `json:"uptime"`
+	// Metrics is absent unless the server runs with --metrics
+	Metrics []toolStats `json:"metrics,omitempty"`
+}
+
+// serverStatus handles the server_status tool
+func (s *Server) serverStatus() (*mcp.CallToolResult, error) {
+	result := statusResult{
+		Server:        s.name,
+		Version:       s.version,
+		Session:       s.tmuxManager.SessionName(),
+		WritesEnabled: s.writesEnabled,
+		Uptime:        time.Since(s.started).Round(time.Second).String(),
+		Metrics:       s.metrics.snapshot(),
+	}
+
+	mode := "read-only"
+	if result.WritesEnabled {
+		mode = "writes enabled"
+	}
+	lines := []string{fmt.Sprintf("%s %s on session %s, %s, up %s", result.Server, result.Version, result.Session, mode, result.Uptime)}
+	switch {
+	case s.metrics == nil:
+		lines = append(lines, "[metrics are off; start the server with --metrics to collect them]")
+	case len(result.Metrics) == 0:
+		lines = append(lines, "no tool calls yet")
+	default:
+		lines = append(lines, fmt.Sprintf("%-20s %6s %6s %10s %9s %9s", "tool", "calls", "errors", "bytes", "p50 ms", "p95 ms"))
+		for _, t := range result.Metrics {
+			lines = append(lines, fmt.Sprintf("%-20s %6d %6d %10d %9.1f %9.1f", t.Tool, t.Calls, t.Errors, t.CapturedBytes, t.P50Ms, t.P95Ms))
+		}
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: strings.Join(lines, "\n")}},
+		StructuredContent: result,
+	}, nil
+}
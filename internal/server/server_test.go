@@ -3,11 +3,15 @@ package server
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
 )
 
 func TestNewServer(t *testing.T) {
@@ -540,6 +544,25 @@ func TestServer_Start_ValidRequest(t *testing.T) {
 	}
 }
 
+func TestServer_Start_PrettyJSON(t *testing.T) {
+	requests := `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n" + `{"jsonrpc":"2.0","id":2,"method":"ping"}` + "\n"
+	output := &bytes.Buffer{}
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	srv.reader = strings.NewReader(requests)
+	srv.writer = output
+	WithPrettyJSON(true)(srv)
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	want := "{\n  \"jsonrpc\": \"2.0\",\n  \"id\": 1,\n  \"result\": {}\n}\n" +
+		"{\n  \"jsonrpc\": \"2.0\",\n  \"id\": 2,\n  \"result\": {}\n}\n"
+	if got := output.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
 func TestServer_handleInitialize(t *testing.T) {
 	srv := NewServer("test-session", &bytes.Buffer{}, &bytes.Buffer{})
 
@@ -694,3 +717,1450 @@ func TestServer_Start_ReadError(t *testing.T) {
 		t.Error("Start() should return error when reader fails")
 	}
 }
+
+// newFakeServer returns a server whose tmux manager answers every tmux
+// invocation with runner instead of a live tmux server
+func newFakeServer(runner tmux.RunnerFunc) *Server {
+	srv := NewServer("fake-session", &bytes.Buffer{}, &bytes.Buffer{}, WithPollInterval(tmux.MinPollInterval))
+	srv.tmuxManager = tmux.NewManagerWithRunner("fake-session", runner)
+	return srv
+}
+
+func TestServer_callTool_ReadScrollback_AllLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     interface{}
+		wantStart string
+	}{
+		{name: "negative one", lines: float64(-1), wantStart: "-"},
+		{name: "all string", lines: "all", wantStart: "-"},
+		{name: "zero uses default", lines: float64(0), wantStart: "-100"},
+		{name: "explicit count", lines: float64(20), wantStart: "-20"},
+		{name: "numeric string", lines: "50", wantStart: "-50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captureArgs []string
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				if args[0] == "capture-pane" {
+					captureArgs = args
+				}
+				return "", "", nil
+			})
+
+			response := srv.handleRequest(&mcp.JSONRPCRequest{
+				JSONRPC: "2.0",
+				ID:      1,
+				Method:  "tools/call",
+				Params: map[string]interface{}{
+					"name":      "read_scrollback",
+					"arguments": map[string]interface{}{"lines": tt.lines},
+				},
+			})
+			if response.Error != nil {
+				t.Fatalf("response.Error = %v, want nil", response.Error)
+			}
+
+			if len(captureArgs) == 0 {
+				t.Fatal("capture-pane was not invoked")
+			}
+			if got := captureArgs[5]; got != tt.wantStart {
+				t.Errorf("capture-pane start = %q, want %q", got, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_ReadScrollback_InvalidLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines interface{}
+	}{
+		{name: "word", lines: "fifty"},
+		{name: "fraction", lines: 2.5},
+		{name: "boolean", lines: true},
+		{name: "object", lines: map[string]interface{}{"n": float64(5)}},
+		{name: "null", lines: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				if args[0] == "capture-pane" {
+					t.Errorf("capture-pane ran with invalid lines: %v", args)
+				}
+				return "", "", nil
+			})
+
+			response := callFakeTool(srv, "read_scrollback", map[string]interface{}{"lines": tt.lines})
+			if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+				t.Fatalf("response.Error = %v, want invalid params", response.Error)
+			}
+		})
+	}
+}
+
+// callFakeTool invokes tool with arguments against srv and returns the response
+func callFakeTool(srv *Server, tool string, arguments map[string]interface{}) *mcp.JSONRPCResponse {
+	return srv.handleRequest(&mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params: map[string]interface{}{
+			"name":      tool,
+			"arguments": arguments,
+		},
+	})
+}
+
+// toolText returns the text of the first content item of a tool response
+func toolText(t *testing.T, response *mcp.JSONRPCResponse) string {
+	t.Helper()
+	if response.Error != nil {
+		t.Fatalf("response.Error = %v, want nil", response.Error)
+	}
+	result, ok := response.Result.(*mcp.CallToolResult)
+	if !ok {
+		t.Fatalf("response.Result = %T, want *mcp.CallToolResult", response.Result)
+	}
+	if len(result.Content) == 0 {
+		t.Fatal("result.Content is empty")
+	}
+	return result.Content[0].Text
+}
+
+func TestServer_callTool_ReadTerminal_HTML(t *testing.T) {
+	var captureArgs []string
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			captureArgs = args
+			return "\x1b[31m<err>\x1b[0m\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	text := toolText(t, callFakeTool(srv, "read_terminal", map[string]interface{}{"format": "html"}))
+
+	if captureArgs[len(captureArgs)-1] != "-e" {
+		t.Errorf("capture-pane args = %v, want -e to preserve escapes", captureArgs)
+	}
+	want := `<pre style="font-family:monospace"><span style="color:#cd0000">&lt;err&gt;</span>` + "\n</pre>"
+	if text != want {
+		t.Errorf("read_terminal html = %q, want %q", text, want)
+	}
+}
+
+func TestServer_callTool_ReadTerminal_UnsupportedFormat(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "read_terminal", map[string]interface{}{"format": "pdf"})
+	if response.Error == nil {
+		t.Fatal("response.Error is nil, expected error for unsupported format")
+	}
+}
+
+func TestServer_callTool_ReadScrollback_JSON(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			return "one\ntwo\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	text := toolText(t, callFakeTool(srv, "read_scrollback", map[string]interface{}{"format": "json"}))
+
+	want := `[{"line_number":1,"text":"one"},{"line_number":2,"text":"two"}]`
+	if text != want {
+		t.Errorf("read_scrollback json = %s, want %s", text, want)
+	}
+}
+
+func TestServer_callTool_Describe(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		return "", "", nil
+	})
+
+	text := toolText(t, callFakeTool(srv, "describe", map[string]interface{}{}))
+
+	var result describeResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("describe returned invalid JSON: %v", err)
+	}
+	if result.ServerInfo.Name != ServerName {
+		t.Errorf("serverInfo.name = %q, want %q", result.ServerInfo.Name, ServerName)
+	}
+	if result.Session != "fake-session" {
+		t.Errorf("session = %q, want fake-session", result.Session)
+	}
+	if len(result.Tools) != len(srv.listTools().Tools) {
+		t.Errorf("len(tools) = %d, want %d", len(result.Tools), len(srv.listTools().Tools))
+	}
+	if len(result.Resources) != len(srv.listResources().Resources) {
+		t.Errorf("len(resources) = %d, want %d", len(result.Resources), len(srv.listResources().Resources))
+	}
+	if result.Prompts == nil {
+		t.Error("prompts is nil, want empty list")
+	}
+}
+
+// validateStructuredContent checks that structured marshals to a JSON object
+// matching schema: every required property is present, no undeclared
+// properties appear, and each value has the declared JSON type (optional
+// properties may also be null)
+func validateStructuredContent(t *testing.T, schema *mcp.InputSchema, structured interface{}) {
+	t.Helper()
+	if schema == nil {
+		t.Fatal("tool has no outputSchema")
+	}
+
+	data, err := json.Marshal(structured)
+	if err != nil {
+		t.Fatalf("failed to marshal structuredContent: %v", err)
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		t.Fatalf("structuredContent %s is not a JSON object: %v", data, err)
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			t.Errorf("structuredContent missing required property %q", name)
+		}
+	}
+	for name, value := range object {
+		property, ok := schema.Properties[name]
+		if !ok {
+			t.Errorf("structuredContent has undeclared property %q", name)
+			continue
+		}
+		if value == nil && !isRequired(schema, name) {
+			continue
+		}
+		if !matchesJSONType(value, property.Type) {
+			t.Errorf("structuredContent property %q = %v (%T), want type %s", name, value, value, property.Type)
+		}
+	}
+}
+
+// isRequired reports whether schema lists name as a required property
+func isRequired(schema *mcp.InputSchema, name string) bool {
+	for _, required := range schema.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesJSONType reports whether a decoded JSON value has the given JSON
+// Schema type
+func matchesJSONType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// findTool returns the tools/list entry for name
+func findTool(t *testing.T, srv *Server, name string) mcp.Tool {
+	t.Helper()
+	for _, tool := range srv.listTools().Tools {
+		if tool.Name == name {
+			return tool
+		}
+	}
+	t.Fatalf("tool %q not found in tools list", name)
+	return mcp.Tool{}
+}
+
+func TestServer_callTool_StructuredContent(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] != "display-message" {
+			return "", "", nil
+		}
+		switch args[len(args)-1] {
+		case "#{history_size},#{history_limit}":
+			return "1234,2000\n", "", nil
+		default:
+			return "80,24,/home/user,0,0,,0\n", "", nil
+		}
+	})
+
+	for _, name := range []string{"get_terminal_info", "scrollback_size", "is_attached", "list_clients"} {
+		t.Run(name, func(t *testing.T) {
+			response := callFakeTool(srv, name, map[string]interface{}{})
+			if response.Error != nil {
+				t.Fatalf("response.Error = %v, want nil", response.Error)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			if result.IsError {
+				t.Fatalf("tool returned error: %v", result.Content)
+			}
+			validateStructuredContent(t, findTool(t, srv, name).OutputSchema, result.StructuredContent)
+		})
+	}
+}
+
+func TestServer_listTools_OutputSchemaOmitted(t *testing.T) {
+	srv := NewServer("test-session", &bytes.Buffer{}, &bytes.Buffer{})
+
+	data, err := json.Marshal(findTool(t, srv, "read_terminal"))
+	if err != nil {
+		t.Fatalf("Failed to marshal tool: %v", err)
+	}
+	if strings.Contains(string(data), "outputSchema") {
+		t.Errorf("read_terminal should not declare an outputSchema, got %s", data)
+	}
+}
+
+func TestServer_handleInitialize_ServerInfoOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantName    string
+		wantVersion string
+	}{
+		{
+			name:        "defaults",
+			wantName:    ServerName,
+			wantVersion: ServerVersion,
+		},
+		{
+			name:        "custom name keeps build version",
+			opts:        []Option{WithServerName("wingman-prod-db1")},
+			wantName:    "wingman-prod-db1",
+			wantVersion: ServerVersion,
+		},
+		{
+			name:        "custom name and version",
+			opts:        []Option{WithServerName("wingman-laptop"), WithServerVersion("1.2.3")},
+			wantName:    "wingman-laptop",
+			wantVersion: "1.2.3",
+		},
+		{
+			name:        "empty overrides keep defaults",
+			opts:        []Option{WithServerName(""), WithServerVersion("")},
+			wantName:    ServerName,
+			wantVersion: ServerVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer("test-session", &bytes.Buffer{}, &bytes.Buffer{}, tt.opts...)
+
+			result, err := srv.handleInitialize(&mcp.JSONRPCRequest{})
+			if err != nil {
+				t.Fatalf("handleInitialize() error = %v", err)
+			}
+			if result.ServerInfo.Name != tt.wantName {
+				t.Errorf("ServerInfo.Name = %q, want %q", result.ServerInfo.Name, tt.wantName)
+			}
+			if result.ServerInfo.Version != tt.wantVersion {
+				t.Errorf("ServerInfo.Version = %q, want %q", result.ServerInfo.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_RenameWindow(t *testing.T) {
+	tests := []struct {
+		name          string
+		writesEnabled bool
+		wantRenamed   bool
+	}{
+		{name: "read-only rejects", writesEnabled: false, wantRenamed: false},
+		{name: "writes enabled renames", writesEnabled: true, wantRenamed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renamed := false
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				if args[0] == "rename-window" {
+					renamed = true
+				}
+				return "", "", nil
+			})
+			srv.writesEnabled = tt.writesEnabled
+
+			response := callFakeTool(srv, "rename_window", map[string]interface{}{"name": "scratch"})
+			if response.Error != nil {
+				t.Fatalf("response.Error = %v, want nil", response.Error)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			if result.IsError == tt.wantRenamed {
+				t.Errorf("result.IsError = %v, want %v (%v)", result.IsError, !tt.wantRenamed, result.Content)
+			}
+			if renamed != tt.wantRenamed {
+				t.Errorf("rename-window invoked = %v, want %v", renamed, tt.wantRenamed)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_RenameWindow_Target(t *testing.T) {
+	tests := []struct {
+		name      string
+		focus     string
+		arguments map[string]interface{}
+		want      string
+	}{
+		{name: "window", arguments: map[string]interface{}{"window": "main"}, want: "%0"},
+		{name: "pane", arguments: map[string]interface{}{"pane": "%4"}, want: "%4"},
+		{name: "focused pane", focus: "main", arguments: map[string]interface{}{}, want: "%0"},
+		{name: "default", arguments: map[string]interface{}{}, want: "fake-session"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls [][]string
+			srv := newFakePaneServer(&calls)
+			if tt.focus != "" {
+				callFakeTool(srv, "focus_pane", map[string]interface{}{"window": tt.focus})
+			}
+
+			tt.arguments["name"] = "build"
+			if result := callFakeTool(srv, "rename_window", tt.arguments).Result.(*mcp.CallToolResult); result.IsError {
+				t.Fatalf("rename_window = %v", result.Content)
+			}
+			want := []string{"rename-window", "-t", tt.want, "--", "build"}
+			if got := calls[len(calls)-1]; !reflect.DeepEqual(got, want) {
+				t.Errorf("last call = %v, want %v", got, want)
+			}
+		})
+	}
+
+	var calls [][]string
+	srv := newFakePaneServer(&calls)
+	response := callFakeTool(srv, "rename_window", map[string]interface{}{"name": "build", "window": "nope"})
+	if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("rename_window for an unknown window: error = %+v, want -32602", response.Error)
+	}
+}
+
+func TestServer_callTool_SetMouse(t *testing.T) {
+	tests := []struct {
+		name          string
+		writesEnabled bool
+		arguments     map[string]interface{}
+		wantSet       []string
+		wantIsError   bool
+		wantCode      int
+	}{
+		{name: "read-only rejects", arguments: map[string]interface{}{"enabled": true}, wantIsError: true},
+		{name: "turns on", writesEnabled: true, arguments: map[string]interface{}{"enabled": true},
+			wantSet: []string{"set-option", "-g", "mouse", "on"}},
+		{name: "turns off", writesEnabled: true, arguments: map[string]interface{}{"enabled": false},
+			wantSet: []string{"set-option", "-g", "mouse", "off"}},
+		{name: "missing enabled", writesEnabled: true, arguments: map[string]interface{}{}, wantCode: mcp.CodeInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mouse := "off"
+			var set []string
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "set-option":
+					set = args
+					mouse = args[3]
+				case "show-options":
+					return mouse + "\n", "", nil
+				}
+				return "", "", nil
+			})
+			srv.writesEnabled = tt.writesEnabled
+
+			response := callFakeTool(srv, "set_mouse", tt.arguments)
+			if tt.wantCode != 0 {
+				if response.Error == nil || response.Error.Code != tt.wantCode {
+					t.Fatalf("response.Error = %v, want code %d", response.Error, tt.wantCode)
+				}
+				return
+			}
+			if response.Error != nil {
+				t.Fatalf("response.Error = %v, want nil", response.Error)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			if result.IsError != tt.wantIsError {
+				t.Errorf("result.IsError = %v, want %v (%v)", result.IsError, tt.wantIsError, result.Content)
+			}
+			if !reflect.DeepEqual(set, tt.wantSet) {
+				t.Errorf("set-option args = %v, want %v", set, tt.wantSet)
+			}
+			if tt.wantSet != nil {
+				want := mouseState{Mouse: tt.wantSet[3] == "on"}
+				if result.StructuredContent != want {
+					t.Errorf("structuredContent = %+v, want %+v", result.StructuredContent, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_callTool_RunCommand(t *testing.T) {
+	captures := []string{"$ \n", "$ date\nMon Jan 1\n$ \n"}
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			out := captures[0]
+			if len(captures) > 1 {
+				captures = captures[1:]
+			}
+			return out, "", nil
+		}
+		return "", "", nil
+	})
+	srv.writesEnabled = true
+
+	response := callFakeTool(srv, "run_command", map[string]interface{}{"command": "date", "timeout_ms": float64(2000)})
+	if text := toolText(t, response); text != "Mon Jan 1" {
+		t.Errorf("run_command text = %q, want %q", text, "Mon Jan 1")
+	}
+
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "run_command").OutputSchema, result.StructuredContent)
+	if got := result.StructuredContent.(commandResult); !got.Completed {
+		t.Errorf("structuredContent.completed = false, want true")
+	}
+}
+
+func TestServer_callTool_RunCommand_ReadOnly(t *testing.T) {
+	sent := false
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "send-keys" {
+			sent = true
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "run_command", map[string]interface{}{"command": "rm -rf /tmp/x"})
+	result := response.Result.(*mcp.CallToolResult)
+	if !result.IsError {
+		t.Error("run_command should fail in read-only mode")
+	}
+	if sent {
+		t.Error("run_command sent keys in read-only mode")
+	}
+}
+
+func TestServer_callTool_RunCommand_InvalidTimeout(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "send-keys" {
+			t.Errorf("run_command sent keys for an invalid timeout: %v", args)
+		}
+		return "", "", nil
+	})
+	srv.writesEnabled = true
+
+	for _, timeout := range []interface{}{float64(-1), float64(3600001), float64(1e12)} {
+		response := callFakeTool(srv, "run_command", map[string]interface{}{"command": "date", "timeout_ms": timeout})
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("run_command(timeout_ms %v) error = %+v, want -32602", timeout, response.Error)
+		}
+	}
+}
+
+func TestServer_callTool_RunCommand_ExitCode(t *testing.T) {
+	captures := []string{"$ \n", "$ test -d /nope; echo \"__EXIT__$?\"\n__EXIT__1\n$ \n"}
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			out := captures[0]
+			if len(captures) > 1 {
+				captures = captures[1:]
+			}
+			return out, "", nil
+		}
+		return "", "", nil
+	})
+	srv.writesEnabled = true
+
+	response := callFakeTool(srv, "run_command", map[string]interface{}{"command": "test -d /nope", "exit_code": true})
+	if text := toolText(t, response); text != "[exit code: 1]" {
+		t.Errorf("run_command text = %q", text)
+	}
+
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "run_command").OutputSchema, result.StructuredContent)
+	structured := result.StructuredContent.(commandResult)
+	if structured.ExitCode == nil || *structured.ExitCode != 1 {
+		t.Errorf("structuredContent.exit_code = %v, want 1", structured.ExitCode)
+	}
+}
+
+func TestServer_pollIntervalArgument(t *testing.T) {
+	srv := NewServer("test-session", &bytes.Buffer{}, &bytes.Buffer{}, WithPollInterval(500*time.Millisecond))
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      time.Duration
+		wantErr   bool
+	}{
+		{name: "server default", arguments: map[string]interface{}{}, want: 500 * time.Millisecond},
+		{name: "per-call override", arguments: map[string]interface{}{"poll_ms": float64(50)}, want: 50 * time.Millisecond},
+		{name: "too small", arguments: map[string]interface{}{"poll_ms": float64(1)}, wantErr: true},
+		{name: "negative", arguments: map[string]interface{}{"poll_ms": float64(-100)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := srv.pollIntervalArgument(tt.arguments)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pollIntervalArgument() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("pollIntervalArgument() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_IsAttached(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "list-clients" {
+			return "/dev/pts/3 80x24 1700000000\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "is_attached", map[string]interface{}{})
+	result := response.Result.(*mcp.CallToolResult)
+	status, ok := result.StructuredContent.(attachedStatus)
+	if !ok {
+		t.Fatalf("structuredContent = %T, want attachedStatus", result.StructuredContent)
+	}
+	if !status.Attached || status.AttachedClients != 1 {
+		t.Errorf("is_attached = %+v, want attached with 1 client", status)
+	}
+}
+
+func TestServer_callTool_ListClients(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		want   []clientEntry
+	}{
+		{
+			name:   "none attached",
+			stdout: "",
+			want:   []clientEntry{},
+		},
+		{
+			name:   "one attached",
+			stdout: "/dev/pts/3 120x40 1700000000\n",
+			want: []clientEntry{{
+				TTY:          "/dev/pts/3",
+				Width:        120,
+				Height:       40,
+				LastActivity: time.Unix(1700000000, 0).UTC().Format(time.RFC3339),
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				if args[0] == "list-clients" {
+					return tt.stdout, "", nil
+				}
+				return "", "", nil
+			})
+
+			response := callFakeTool(srv, "list_clients", map[string]interface{}{})
+			result := response.Result.(*mcp.CallToolResult)
+			if result.IsError {
+				t.Fatalf("list_clients returned error: %v", result.Content)
+			}
+			list, ok := result.StructuredContent.(clientList)
+			if !ok {
+				t.Fatalf("structuredContent = %T, want clientList", result.StructuredContent)
+			}
+			if !reflect.DeepEqual(list.Clients, tt.want) {
+				t.Errorf("clients = %+v, want %+v", list.Clients, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_Start_IdleTimeout(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	srv.reader = reader
+	WithIdleTimeout(50 * time.Millisecond)(srv)
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Start() }()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrIdleTimeout) {
+			t.Errorf("Start() error = %v, want ErrIdleTimeout", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start() did not shut down after the idle timeout")
+	}
+}
+
+func TestServer_Start_IdleTimeoutResetByRequests(t *testing.T) {
+	reader, writer := io.Pipe()
+	output := &bytes.Buffer{}
+
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	srv.reader = reader
+	srv.writer = output
+	WithIdleTimeout(150 * time.Millisecond)(srv)
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Start() }()
+
+	// Keep sending requests for longer than the idle timeout
+	for i := 0; i < 5; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if _, err := io.WriteString(writer, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`+"\n"); err != nil {
+			t.Fatalf("failed to write request: %v", err)
+		}
+	}
+	writer.Close()
+
+	if err := <-errc; err != nil {
+		t.Errorf("Start() error = %v, want nil after EOF", err)
+	}
+}
+
+func TestServer_Start_InitTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{name: "no initialize", input: `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n", wantErr: ErrInitTimeout},
+		{name: "initialized in time", input: `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, writer := io.Pipe()
+			srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+			srv.reader = reader
+			srv.writer = io.Discard
+			WithInitTimeout(100 * time.Millisecond)(srv)
+
+			errc := make(chan error, 1)
+			go func() { errc <- srv.Start() }()
+			if _, err := io.WriteString(writer, tt.input); err != nil {
+				t.Fatalf("failed to write request: %v", err)
+			}
+
+			// Stay connected past the timeout, then hang up
+			time.Sleep(200 * time.Millisecond)
+			writer.Close()
+			select {
+			case err := <-errc:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Start() error = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("Start() did not return")
+			}
+		})
+	}
+}
+
+func TestServer_callTool_ReadRange(t *testing.T) {
+	var captureArgs []string
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			return "200,24\n", "", nil
+		case "capture-pane":
+			captureArgs = args
+			return "line a\nline b\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "read_range", map[string]interface{}{"start": float64(-500), "end": float64(-100)})
+	if got := toolText(t, response); got != "line a\nline b\n" {
+		t.Errorf("read_range text = %q", got)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "read_range").OutputSchema, result.StructuredContent)
+	structured := result.StructuredContent.(rangeResult)
+	if structured.Start != -200 || structured.End != -100 {
+		t.Errorf("read_range range = %d..%d, want -200..-100 after clamping", structured.Start, structured.End)
+	}
+	if !strings.Contains(strings.Join(captureArgs, " "), "-S -200 -E -100") {
+		t.Errorf("capture-pane args = %v, want -S -200 -E -100", captureArgs)
+	}
+}
+
+func TestServer_callTool_ReadRange_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+	}{
+		{name: "start after end", arguments: map[string]interface{}{"start": float64(10), "end": float64(-10)}},
+		{name: "missing end", arguments: map[string]interface{}{"start": float64(0)}},
+		{name: "fractional start", arguments: map[string]interface{}{"start": 1.5, "end": float64(3)}},
+		{name: "string end", arguments: map[string]interface{}{"start": float64(0), "end": "5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+
+			response := callFakeTool(srv, "read_range", tt.arguments)
+			if response.Error == nil {
+				t.Fatal("response.Error is nil, want invalid params")
+			}
+			if response.Error.Code != mcp.CodeInvalidParams {
+				t.Errorf("response.Error.Code = %d, want %d", response.Error.Code, mcp.CodeInvalidParams)
+			}
+		})
+	}
+}
+
+// fakePaneListing is list-panes output for two windows: window 0 "main"
+// with pane %0, and the active window 1 "scratch" with pane %4
+const fakePaneListing = "%0\t0\tmain\t0\t1\t0\t80\t24\tbash\t0\t\t1700000000\t1699990000\n" +
+	"%4\t1\tscratch\t0\t1\t1\t80\t24\tbash\t0\t\t1700000000\t1699990000\n"
+
+// newFakePaneServer returns a write-enabled fake server whose session has
+// the panes in fakePaneListing, recording every tmux invocation in calls
+func newFakePaneServer(calls *[][]string) *Server {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		*calls = append(*calls, args)
+		if args[0] == "list-panes" {
+			return fakePaneListing, "", nil
+		}
+		return "", "", nil
+	})
+	WithWritesEnabled(true)(srv)
+	return srv
+}
+
+func TestTargetArgument(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      tmux.Target
+		wantErr   bool
+	}{
+		{name: "absent", arguments: map[string]interface{}{}, want: tmux.Target{}},
+		{name: "pane ID", arguments: map[string]interface{}{"pane": "%3"}, want: tmux.Target{Pane: "%3"}},
+		{name: "numeric window and pane", arguments: map[string]interface{}{"window": float64(2), "pane": float64(1)}, want: tmux.Target{Window: "2", Pane: "1"}},
+		{name: "window name", arguments: map[string]interface{}{"window": "logs"}, want: tmux.Target{Window: "logs"}},
+		{name: "fractional pane", arguments: map[string]interface{}{"pane": 1.5}, wantErr: true},
+		{name: "boolean window", arguments: map[string]interface{}{"window": true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := targetArgument(tt.arguments)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("targetArgument() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("targetArgument() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_SendKeys(t *testing.T) {
+	var calls [][]string
+	srv := newFakePaneServer(&calls)
+
+	response := callFakeTool(srv, "send_keys", map[string]interface{}{
+		"window": "main",
+		"text":   "echo hi",
+		"keys":   []interface{}{"Enter"},
+	})
+	toolText(t, response)
+
+	var sent [][]string
+	for _, call := range calls {
+		if call[0] == "send-keys" {
+			sent = append(sent, call)
+		}
+	}
+	want := [][]string{
+		{"send-keys", "-t", "%0", "-l", "--", "echo hi"},
+		{"send-keys", "-t", "%0", "Enter"},
+	}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("send-keys calls = %v, want %v", sent, want)
+	}
+}
+
+func TestServer_callTool_SendKeys_Rejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+	}{
+		{name: "unknown pane", arguments: map[string]interface{}{"pane": "%9", "text": "ls"}},
+		{name: "unknown window", arguments: map[string]interface{}{"window": "nope", "keys": []interface{}{"C-c"}}},
+		{name: "nothing to send", arguments: map[string]interface{}{"window": "main"}},
+		{name: "keys not strings", arguments: map[string]interface{}{"keys": []interface{}{float64(1)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls [][]string
+			srv := newFakePaneServer(&calls)
+
+			response := callFakeTool(srv, "send_keys", tt.arguments)
+			if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+				t.Fatalf("response.Error = %+v, want invalid params", response.Error)
+			}
+			for _, call := range calls {
+				if call[0] == "send-keys" {
+					t.Errorf("send_keys sent %v despite invalid arguments", call)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_callTool_SendKeys_ReadOnly(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "send-keys" {
+			t.Errorf("send_keys ran %v in read-only mode", args)
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "send_keys", map[string]interface{}{"text": "ls"})
+	result := response.Result.(*mcp.CallToolResult)
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "--allow-writes") {
+		t.Errorf("send_keys in read-only mode = %+v, want an --allow-writes error", result)
+	}
+}
+
+func TestServer_callTool_RunCommand_Target(t *testing.T) {
+	var calls [][]string
+	srv := newFakePaneServer(&calls)
+
+	response := callFakeTool(srv, "run_command", map[string]interface{}{
+		"command":    "ls",
+		"pane":       "%0",
+		"timeout_ms": float64(20),
+	})
+	toolText(t, response)
+
+	for _, call := range calls {
+		if (call[0] == "send-keys" || call[0] == "capture-pane") && call[2] != "%0" {
+			t.Errorf("%s targeted %q, want %%0", call[0], call[2])
+		}
+	}
+}
+
+func TestServer_callTool_ListPanes(t *testing.T) {
+	var calls [][]string
+	srv := newFakePaneServer(&calls)
+
+	response := callFakeTool(srv, "list_panes", map[string]interface{}{})
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "list_panes").OutputSchema, result.StructuredContent)
+
+	list := result.StructuredContent.(paneList)
+	if len(list.Panes) != 2 || list.Panes[1].PaneID != "%4" || !list.Panes[1].WindowActive {
+		t.Errorf("list_panes = %+v, want panes %%0 and active %%4", list.Panes)
+	}
+	if list.SessionCreated != "2023-11-14T19:26:40Z" || list.SessionAge == "" {
+		t.Errorf("session_created, session_age = %q, %q, want 2023-11-14T19:26:40Z and an age", list.SessionCreated, list.SessionAge)
+	}
+	if pane := list.Panes[0]; pane.WindowActivity != "2023-11-14T22:13:20Z" || pane.WindowIdle == "" {
+		t.Errorf("window_activity, window_idle = %q, %q, want 2023-11-14T22:13:20Z and an age", pane.WindowActivity, pane.WindowIdle)
+	}
+	if text := toolText(t, response); !strings.Contains(text, "[session created 2023-11-14T19:26:40Z, ") {
+		t.Errorf("list_panes text = %q, want a session created note", text)
+	}
+}
+
+func TestServer_callTool_Snapshot(t *testing.T) {
+	var calls [][]string
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		calls = append(calls, args)
+		switch args[0] {
+		case "list-panes":
+			return fakePaneListing, "", nil
+		case "display-message":
+			return "80,24,/srv/app,0,0,,0\n", "", nil
+		case "capture-pane":
+			return "$ make   \nok\n$ \n\n\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "snapshot", map[string]interface{}{"window": "scratch", "trim": true})
+	text := toolText(t, response)
+	if want := "$ make\nok\n$\n[pane 0, 80x24, cwd /srv/app, token s1]"; text != want {
+		t.Errorf("snapshot text = %q, want %q", text, want)
+	}
+
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "snapshot").OutputSchema, result.StructuredContent)
+	want := snapshotResult{Content: "$ make\nok\n$\n", Width: 80, Height: 24, CurrentPath: "/srv/app", PaneIndex: 0, ScreenMode: screenNormal, Token: "s1"}
+	if got := result.StructuredContent.(snapshotResult); got != want {
+		t.Errorf("snapshot structuredContent = %+v, want %+v", got, want)
+	}
+
+	for _, call := range calls {
+		if (call[0] == "display-message" || call[0] == "capture-pane") && call[2] != "%4" {
+			t.Errorf("%s targeted %q, want %%4", call[0], call[2])
+		}
+	}
+}
+
+func TestServer_callTool_ReadTerminal_Cursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+		want   string
+	}{
+		{name: "on screen", cursor: "2,1,2\n", want: "old\n$ ls\n$ ‸\n[cursor at column 2, row 1]"},
+		{name: "below capture", cursor: "0,9,12\n", want: "old\n$ ls\n$ \n[cursor at column 0, row 9 is outside the captured lines]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "capture-pane":
+					return "old\n$ ls\n$ \n", "", nil
+				case "display-message":
+					return tt.cursor, "", nil
+				}
+				return "", "", nil
+			})
+
+			got := toolText(t, callFakeTool(srv, "read_terminal", map[string]interface{}{"cursor": true}))
+			if got != tt.want {
+				t.Errorf("read_terminal text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_ReadTerminal_CursorRequiresText(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+
+	response := callFakeTool(srv, "read_terminal", map[string]interface{}{"cursor": true, "format": "html"})
+	if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("response.Error = %+v, want invalid params", response.Error)
+	}
+}
+
+func TestServer_callTool_Snapshot_Cursor(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			if strings.Contains(args[len(args)-1], "cursor_x") {
+				return "4,0,2\n", "", nil
+			}
+			return "80,2,/tmp,0,0,,0\n", "", nil
+		case "capture-pane":
+			return ">>> \n\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "snapshot", map[string]interface{}{"cursor": true, "trim": true})
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "snapshot").OutputSchema, result.StructuredContent)
+
+	got := result.StructuredContent.(snapshotResult)
+	if got.Content != ">>> ‸\n" {
+		t.Errorf("snapshot content = %q, want %q", got.Content, ">>> ‸\n")
+	}
+	if got.CursorX == nil || got.CursorY == nil || *got.CursorX != 4 || *got.CursorY != 0 {
+		t.Errorf("snapshot cursor = (%v, %v), want (4, 0)", got.CursorX, got.CursorY)
+	}
+}
+
+func TestServer_callTool_GetOption(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] != "show-options" {
+			return "", "", nil
+		}
+		switch args[len(args)-1] {
+		case "history-limit":
+			return "50000\n", "", nil
+		default:
+			return "", "invalid option: " + args[len(args)-1], exitStatus(1)
+		}
+	})
+
+	response := callFakeTool(srv, "get_option", map[string]interface{}{"name": "history-limit", "global": true})
+	if got := toolText(t, response); got != "history-limit 50000" {
+		t.Errorf("get_option text = %q", got)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "get_option").OutputSchema, result.StructuredContent)
+	if want := (optionValue{Name: "history-limit", Value: "50000", Global: true}); result.StructuredContent != want {
+		t.Errorf("get_option structuredContent = %+v, want %+v", result.StructuredContent, want)
+	}
+
+	for _, args := range []map[string]interface{}{{"name": "nosuch"}, {}} {
+		response := callFakeTool(srv, "get_option", args)
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("get_option(%v) error = %+v, want invalid params", args, response.Error)
+		}
+	}
+}
+
+func TestServer_DeadPaneWarnings(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			return "80,24,/tmp,0,1,3,0\n", "", nil
+		case "capture-pane":
+			return "stale output\n", "", nil
+		case "list-panes":
+			return "%0\t0\tmain\t0\t1\t1\t80\t24\tsh\t1\t3\t1700000000\t1699990000\n", "", nil
+		}
+		return "", "", nil
+	})
+	const warning = "[warning: the pane's process exited with status 3; this content is stale and no new output will appear]"
+
+	t.Run("read_terminal", func(t *testing.T) {
+		got := toolText(t, callFakeTool(srv, "read_terminal", map[string]interface{}{"warn_dead": true}))
+		if want := "stale output\n" + warning; got != want {
+			t.Errorf("read_terminal text = %q, want %q", got, want)
+		}
+		plain := toolText(t, callFakeTool(srv, "read_terminal", map[string]interface{}{}))
+		if strings.Contains(plain, "warning") {
+			t.Errorf("read_terminal warned without warn_dead: %q", plain)
+		}
+	})
+
+	t.Run("get_terminal_info", func(t *testing.T) {
+		response := callFakeTool(srv, "get_terminal_info", map[string]interface{}{})
+		if !strings.HasSuffix(toolText(t, response), warning) {
+			t.Errorf("get_terminal_info text = %q, want it to end with the dead-pane warning", toolText(t, response))
+		}
+		result := response.Result.(*mcp.CallToolResult)
+		validateStructuredContent(t, findTool(t, srv, "get_terminal_info").OutputSchema, result.StructuredContent)
+		info := result.StructuredContent.(terminalInfo)
+		if !info.PaneDead || info.PaneDeadStatus == nil || *info.PaneDeadStatus != 3 {
+			t.Errorf("get_terminal_info = %+v, want pane_dead with status 3", info)
+		}
+	})
+
+	t.Run("list_panes", func(t *testing.T) {
+		result := callFakeTool(srv, "list_panes", map[string]interface{}{}).Result.(*mcp.CallToolResult)
+		validateStructuredContent(t, findTool(t, srv, "list_panes").OutputSchema, result.StructuredContent)
+		panes := result.StructuredContent.(paneList).Panes
+		if len(panes) != 1 || !panes[0].Dead || *panes[0].DeadStatus != 3 {
+			t.Errorf("list_panes = %+v, want one dead pane with status 3", panes)
+		}
+	})
+}
+
+func TestServer_AlternateScreen(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "display-message":
+			return "80,24,/tmp,0,0,,1\n", "", nil
+		case "capture-pane":
+			return "~\n~\n\"notes.txt\" 2L\n", "", nil
+		}
+		return "", "", nil
+	})
+	const note = "[alternate screen: a full-screen program is running; its display replaces the shell output until it exits]"
+
+	for _, name := range []string{"get_terminal_info", "snapshot"} {
+		t.Run(name, func(t *testing.T) {
+			response := callFakeTool(srv, name, map[string]interface{}{})
+			if text := toolText(t, response); !strings.HasSuffix(text, note) {
+				t.Errorf("%s text = %q, want it to end with the alternate screen note", name, text)
+			}
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, name).OutputSchema, result.StructuredContent)
+			data, err := json.Marshal(result.StructuredContent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), `"screen_mode":"alternate"`) {
+				t.Errorf("%s structuredContent = %s, want screen_mode alternate", name, data)
+			}
+		})
+	}
+}
+
+func TestServer_dispatch_Lifecycle(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+
+	request := func(method string) *mcp.JSONRPCRequest {
+		return &mcp.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: method}
+	}
+
+	// Before the handshake only initialize and ping are answered
+	response := srv.dispatch(request("tools/list"))
+	if response == nil || response.Error == nil {
+		t.Fatalf("tools/list before initialize = %+v, want an error", response)
+	}
+	if response.Error.Code != mcp.CodeServerNotInitialized {
+		t.Errorf("response.Error.Code = %d, want %d", response.Error.Code, mcp.CodeServerNotInitialized)
+	}
+	if response.ID != 1 {
+		t.Errorf("response.ID = %v, want 1", response.ID)
+	}
+	for _, method := range []string{"ping", "initialize"} {
+		if response := srv.dispatch(request(method)); response == nil || response.Error != nil {
+			t.Errorf("%s before initialized = %+v, want a result", method, response)
+		}
+	}
+
+	// initialize alone is not enough; the client must confirm it
+	if response := srv.dispatch(request("tools/list")); response.Error == nil {
+		t.Error("tools/list before notifications/initialized succeeded, want an error")
+	}
+
+	notification := &mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"}
+	if response := srv.dispatch(notification); response != nil {
+		t.Errorf("notifications/initialized response = %+v, want none", response)
+	}
+
+	response = srv.dispatch(request("tools/list"))
+	if response == nil || response.Error != nil {
+		t.Fatalf("tools/list after initialization = %+v, want a result", response)
+	}
+	if _, ok := response.Result.(*mcp.ListToolsResult); !ok {
+		t.Errorf("response.Result = %T, want *mcp.ListToolsResult", response.Result)
+	}
+}
+
+func TestServer_dispatch_UnknownNotification(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+
+	notification := &mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/cancelled"}
+	if response := srv.dispatch(notification); response != nil {
+		t.Errorf("dispatch(notification) = %+v, want no response", response)
+	}
+	if srv.client.Initialized {
+		t.Error("an unrelated notification marked the server initialized")
+	}
+}
+
+func TestServer_callTool_ReadWindow(t *testing.T) {
+	// Window 0 is split into %1 and %2; window 1 holds %3
+	listing := "%1\t0\tedit\t0\t1\t1\t40\t24\tvim\t0\t\t1700000000\t1699990000\n" +
+		"%2\t0\tedit\t1\t0\t1\t40\t24\tbash\t0\t\t1700000000\t1699990000\n" +
+		"%3\t1\tlogs\t0\t1\t0\t80\t24\ttail\t0\t\t1700000000\t1699990000\n"
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "list-panes":
+			return listing, "", nil
+		case "capture-pane":
+			return "output of " + args[2] + "   \n\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "read_window", map[string]interface{}{"trim": true})
+	want := "--- pane %1 (index 0) ---\noutput of %1\n--- pane %2 (index 1) ---\noutput of %2"
+	if text := toolText(t, response); text != want {
+		t.Errorf("read_window text = %q, want %q", text, want)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "read_window").OutputSchema, result.StructuredContent)
+	window := result.StructuredContent.(windowResult)
+	if window.WindowIndex != 0 || window.WindowName != "edit" || len(window.Panes) != 2 {
+		t.Fatalf("structuredContent = %+v, want window 0 \"edit\" with 2 panes", window)
+	}
+	if pane := window.Panes[1]; pane.PaneID != "%2" || pane.Command != "bash" || pane.Content != "output of %2\n" {
+		t.Errorf("panes[1] = %+v, want %%2 running bash", pane)
+	}
+
+	response = callFakeTool(srv, "read_window", map[string]interface{}{"window": "logs"})
+	if text := toolText(t, response); !strings.HasPrefix(text, "--- pane %3 (index 0) ---\n") {
+		t.Errorf("read_window window=logs text = %q, want pane %%3", text)
+	}
+
+	for _, arguments := range []map[string]interface{}{{"window": "nosuch"}, {"pane": "1"}} {
+		response := callFakeTool(srv, "read_window", arguments)
+		if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+			t.Errorf("read_window %v error = %v, want code %d", arguments, response.Error, mcp.CodeInvalidParams)
+		}
+	}
+}
+
+func TestServer_callTool_ReadTerminal_Lines(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "display-message" {
+			return "0,3\nabcde\nfg\n$\nabcdefg\n$\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "read_terminal", map[string]interface{}{"format": "lines"})
+	want := `[{"text":"abcde","wrapped":false},{"text":"fg","wrapped":true},{"text":"$","wrapped":false}]`
+	if text := toolText(t, response); text != want {
+		t.Errorf("read_terminal lines = %s, want %s", text, want)
+	}
+
+	response = callFakeTool(srv, "read_terminal", map[string]interface{}{"format": "lines", "warn_dead": true})
+	if response.Error == nil || response.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("lines with warn_dead error = %v, want code %d", response.Error, mcp.CodeInvalidParams)
+	}
+}
+
+func TestServer_callTool_Overview(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "list-windows":
+			return "fake-session\t1\t0\tmain\t1\t%0\tbash\nother\t0\t0\tlogs\t1\t%1\ttail\n", "", nil
+		case "list-sessions":
+			return "fake-session\t1\t1\t1699990000\t200\t50\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "overview", map[string]interface{}{})
+	want := "session \"fake-session\" (managed, 1 attached, 200x50)\n" +
+		"  window 0 \"main\": %0 bash (active)\n" +
+		"session \"other\"\n" +
+		"  window 0 \"logs\": %1 tail (active)"
+	if text := toolText(t, response); text != want {
+		t.Errorf("overview text = %q, want %q", text, want)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "overview").OutputSchema, result.StructuredContent)
+	got := result.StructuredContent.(overview)
+	if len(got.Sessions) != 2 || !got.Sessions[0].Managed || got.Sessions[1].Managed {
+		t.Fatalf("structuredContent = %+v, want two sessions with only fake-session managed", got)
+	}
+	if got.Sessions[0].Created != "2023-11-14T19:26:40Z" || got.Sessions[0].Width != 200 || got.Sessions[1].Created != "" {
+		t.Errorf("structuredContent = %+v, want list-sessions details for fake-session only", got)
+	}
+}
+
+func TestServer_callTool_Overview_AllowSession(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "list-windows" {
+			return "fake-session\t1\t0\tmain\t1\t%0\tbash\n" +
+				"build-1\t0\t0\tmake\t1\t%4\tmake\n" +
+				"other\t0\t0\tlogs\t1\t%1\ttail\n", "", nil
+		}
+		return "", "", nil
+	})
+	WithBindableSessions([]string{"build-*"})(srv)
+
+	response := callFakeTool(srv, "overview", map[string]interface{}{})
+	got := response.Result.(*mcp.CallToolResult).StructuredContent.(overview)
+	var names []string
+	for _, session := range got.Sessions {
+		names = append(names, session.Name)
+	}
+	if want := []string{"fake-session", "build-1"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("overview sessions = %v, want %v", names, want)
+	}
+	if text := toolText(t, response); strings.Contains(text, "other") {
+		t.Errorf("overview text = %q, want the session outside --allow-session left out", text)
+	}
+}
+
+func TestServer_callTool_ScrollState(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "display-message" {
+			return "1\tcopy-mode\t10\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "scroll_state", map[string]interface{}{})
+	want := "pane is in copy-mode, scrolled back 10 lines; reads still return the live output"
+	if text := toolText(t, response); text != want {
+		t.Errorf("scroll_state text = %q, want %q", text, want)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "scroll_state").OutputSchema, result.StructuredContent)
+	if got := result.StructuredContent.(scrollState); got != (scrollState{InMode: true, Mode: "copy-mode", ScrollPosition: 10}) {
+		t.Errorf("structuredContent = %+v", got)
+	}
+}
+
+func TestServer_Start_SingleShot(t *testing.T) {
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","method":"notifications/cancelled"}`,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+	}, "\n") + "\n"
+	output := &bytes.Buffer{}
+
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	srv.reader = strings.NewReader(input)
+	srv.writer = output
+	WithSingleShot(true)(srv)
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error = %v, want nil", err)
+	}
+
+	var responses []mcp.JSONRPCResponse
+	decoder := json.NewDecoder(output)
+	for decoder.More() {
+		var response mcp.JSONRPCResponse
+		if err := decoder.Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		responses = append(responses, response)
+	}
+	if len(responses) != 1 {
+		t.Fatalf("got %d responses, want 1", len(responses))
+	}
+	if responses[0].Error != nil {
+		t.Errorf("response.Error = %v, want nil without the initialize handshake", responses[0].Error)
+	}
+	if id, _ := responses[0].ID.(float64); id != 1 {
+		t.Errorf("response ID = %v, want 1", responses[0].ID)
+	}
+}
+
+func TestServer_callTool_ReadTerminal_PaneExited(t *testing.T) {
+	captured := false
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			if !captured {
+				captured = true
+				return "$ ./build.sh\nbuild finished\n", "", nil
+			}
+			return "", "can't find pane: %0", exitStatus(1)
+		}
+		return "", "", nil
+	})
+
+	if got := toolText(t, callFakeTool(srv, "read_terminal", map[string]interface{}{})); got != "$ ./build.sh\nbuild finished\n" {
+		t.Fatalf("read_terminal text = %q", got)
+	}
+	got := toolText(t, callFakeTool(srv, "read_terminal", map[string]interface{}{}))
+	want := "$ ./build.sh\nbuild finished\n[warning: the pane's process has exited and tmux can no longer capture it; this is the last content read before it exited and is stale]"
+	if got != want {
+		t.Errorf("read_terminal text after exit = %q, want %q", got, want)
+	}
+}
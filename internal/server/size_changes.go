@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// resizedResources are the resources a pane's size change updates: its
+// dimensions, the window's layout, and the content, which tmux rewraps.
+// They are the resources resources/subscribe accepts, since nothing else
+// sends notifications/resources/updated.
+var resizedResources = []string{"terminal://info", "terminal://layout", "terminal://current"}
+
+// paneSize is a pane's size as last seen
+type paneSize struct {
+	Width  int
+	Height int
+}
+
+// sizeTracker remembers the size each pane had when orient or snapshot
+// last read it, per session and pane
+type sizeTracker map[string]paneSize
+
+// observe records a pane's size and reports whether it differs from the
+// size seen before, which it returns. The first sighting of a pane is not
+// a change.
+func (t sizeTracker) observe(session, pane string, size paneSize) (changed bool, previous paneSize) {
+	key := session + "\x00" + pane
+	previous, seen := t[key]
+	t[key] = size
+	return seen && previous != size, previous
+}
+
+// checkSize records the size of a pane orient or snapshot read. When it
+// has changed since the last read, the client is sent
+// notifications/resources/updated for each resource the change affects
+// that it subscribed to.
+func (s *Server) checkSize(pane string, size paneSize) (changed bool, previous paneSize) {
+	if s.sizes == nil {
+		s.sizes = sizeTracker{}
+	}
+	changed, previous = s.sizes.observe(s.tmuxManager.SessionName(), pane, size)
+	if !changed {
+		return false, previous
+	}
+	for _, uri := range resizedResources {
+		if s.client.subscribed(uri) {
+			_ = s.notify("notifications/resources/updated", mcp.ResourceUpdatedParams{URI: uri})
+		}
+	}
+	return true, previous
+}
+
+// resizeNote explains a size change in orient and snapshot text
+func resizeNote(previous, current paneSize) string {
+	return fmt.Sprintf("pane resized from %dx%d to %dx%d since the last check: wrapped lines have reflowed, so re-capture rather than reuse earlier captures or grids",
+		previous.Width, previous.Height, current.Width, current.Height)
+}
+
+// subscribeResource handles resources/subscribe and resources/unsubscribe
+func (s *Server) subscribeResource(request *mcp.JSONRPCRequest, on bool) (interface{}, error) {
+	paramsBytes, err := json.Marshal(request.Params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+	var params mcp.SubscribeRequest
+	if err := json.Unmarshal(paramsBytes, &params); err != nil || !slices.Contains(resizedResources, params.URI) {
+		return nil, invalidParams(fmt.Sprintf("cannot subscribe to %q", params.URI),
+			paramError{Field: "uri", Expected: strings.Join(resizedResources, ", ")})
+	}
+	s.client.subscribe(params.URI, on)
+	return struct{}{}, nil
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestSizeTracker_observe(t *testing.T) {
+	sizes := sizeTracker{}
+	if changed, _ := sizes.observe("work", "%1", paneSize{80, 24}); changed {
+		t.Error("first sighting reported as a change")
+	}
+	if changed, _ := sizes.observe("work", "%1", paneSize{80, 24}); changed {
+		t.Error("same size reported as a change")
+	}
+	if changed, _ := sizes.observe("other", "%1", paneSize{120, 40}); changed {
+		t.Error("a pane in another session was compared with work's")
+	}
+	changed, previous := sizes.observe("work", "%1", paneSize{120, 40})
+	if !changed || previous != (paneSize{80, 24}) {
+		t.Errorf("observe() after a resize = %v, %v, want true, 80x24", changed, previous)
+	}
+}
+
+func TestServer_SizeChanged(t *testing.T) {
+	size := "80,24"
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "list-panes":
+			width, height, _ := strings.Cut(size, ",")
+			return "%0\t0\tmain\t0\t1\t1\t" + width + "\t" + height + "\tbash\t0\t\t1700000000\t1699990000\n", "", nil
+		case "display-message":
+			if args[len(args)-1] == "#{pane_id}" {
+				return "%0\n", "", nil
+			}
+			return size + ",/src,0,0,,0\n", "", nil
+		case "capture-pane":
+			return "$ \n", "", nil
+		}
+		return "", "", nil
+	})
+	var out bytes.Buffer
+	srv.writer = &out
+	subscribe := srv.handleRequest(&mcp.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "resources/subscribe",
+		Params: map[string]interface{}{"uri": "terminal://layout"}})
+	if subscribe.Error != nil {
+		t.Fatalf("resources/subscribe error = %v", subscribe.Error)
+	}
+
+	orient := func() orientResult {
+		t.Helper()
+		result := callFakeTool(srv, "orient", map[string]interface{}{}).Result.(*mcp.CallToolResult)
+		return result.StructuredContent.(orientResult)
+	}
+	if orient().SizeChanged {
+		t.Error("first orient reported a size change")
+	}
+	size = "120,40"
+	if got := orient(); !got.SizeChanged || got.PreviousWidth != 80 || got.PreviousHeight != 24 {
+		t.Errorf("orient after a resize = %+v, want size_changed from 80x24", got)
+	}
+	if !strings.Contains(out.String(), `"method":"notifications/resources/updated","params":{"uri":"terminal://layout"}`) {
+		t.Errorf("notifications = %q, want terminal://layout updated", out.String())
+	}
+	if strings.Contains(out.String(), "terminal://info") {
+		t.Errorf("notifications = %q, want none for unsubscribed resources", out.String())
+	}
+
+	// snapshot shares the last size orient saw
+	result := callFakeTool(srv, "snapshot", map[string]interface{}{}).Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "snapshot").OutputSchema, result.StructuredContent)
+	if result.StructuredContent.(snapshotResult).SizeChanged {
+		t.Error("snapshot at an unchanged size reported a change")
+	}
+
+	unknown := srv.handleRequest(&mcp.JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "resources/subscribe",
+		Params: map[string]interface{}{"uri": "terminal://health"}})
+	if unknown.Error == nil || unknown.Error.Code != mcp.CodeInvalidParams {
+		t.Errorf("subscribing to terminal://health error = %v, want invalid params", unknown.Error)
+	}
+}
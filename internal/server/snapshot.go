@@ -0,0 +1,154 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/ansi"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// snapshotResult is the structured content of snapshot
+type snapshotResult struct {
+	Content     string `json:"content"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	CurrentPath string `json:"current_path"`
+	PaneIndex   int    `json:"pane_index"`
+	CursorX     *int   `json:"cursor_x,omitempty"`
+	CursorY     *int   `json:"cursor_y,omitempty"`
+
+	PaneDead       bool   `json:"pane_dead"`
+	PaneDeadStatus *int   `json:"pane_dead_status,omitempty"`
+	ScreenMode     string `json:"screen_mode"`
+
+	// SizeChanged is set when the pane's size differs from the last time
+	// orient or snapshot read it; Previous* is the size then
+	SizeChanged    bool `json:"size_changed"`
+	PreviousWidth  int  `json:"previous_width,omitempty"`
+	PreviousHeight int  `json:"previous_height,omitempty"`
+
+	// Token names the stored copy of Content for a later diff_captures
+	Token string `json:"token"`
+}
+
+// maxSnapshots bounds the snapshot store; saving beyond it forgets the
+// oldest snapshot
+const maxSnapshots = 32
+
+// snapshotStore keeps recent captures by token so diff_captures can compare
+// the pane against them. Tokens are never reused within a server's life.
+type snapshotStore struct {
+	next    int
+	tokens  []string
+	content map[string]string
+}
+
+// save stores content and returns its token
+func (st *snapshotStore) save(content string) string {
+	if st.content == nil {
+		st.content = make(map[string]string)
+	}
+	st.next++
+	token := fmt.Sprintf("s%d", st.next)
+	st.content[token] = content
+	st.tokens = append(st.tokens, token)
+	if len(st.tokens) > maxSnapshots {
+		delete(st.content, st.tokens[0])
+		st.tokens = st.tokens[1:]
+	}
+	return token
+}
+
+// get returns the content stored under token, if it is still held
+func (st *snapshotStore) get(token string) (string, bool) {
+	content, ok := st.content[token]
+	return content, ok
+}
+
+// snapshot handles the snapshot tool: the pane's content and its metadata
+// in a single call, for orienting at the start of a turn
+func (s *Server) snapshot(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	withANSI, _ := arguments["ansi"].(bool)
+	trim, _ := arguments["trim"].(bool)
+	withCursor, _ := arguments["cursor"].(bool)
+
+	info, err := s.tmuxManager.GetPaneInfoFor(target)
+	if err != nil {
+		return toolError(err)
+	}
+	content, err := s.tmuxManager.CapturePaneWithOptions(tmux.CaptureOptions{
+		EscapeSequences: withANSI,
+		Target:          target,
+	})
+	if err != nil {
+		return toolError(err)
+	}
+	// The stored copy is always plain text so it diffs cleanly against a
+	// later capture, whatever this read asked for
+	token := s.snapshots.save(ansi.Strip(content))
+	var cursor *tmux.Cursor
+	if withCursor {
+		c, err := s.tmuxManager.GetCursor(target)
+		if err != nil {
+			return toolError(err)
+		}
+		cursor = &c
+		// Escape sequences would throw off the column count
+		if !withANSI {
+			content, _ = insertCursor(content, c.X, c.Y, c.PaneHeight)
+		}
+	}
+	if trim {
+		content = trimContent(content)
+	}
+	content = s.formatCapture(content, arguments)
+
+	pane := newTerminalInfo(info)
+	structured := snapshotResult{
+		Content:     content,
+		Width:       pane.Width,
+		Height:      pane.Height,
+		CurrentPath: pane.CurrentPath,
+		PaneIndex:   pane.PaneIndex,
+
+		PaneDead:       pane.PaneDead,
+		PaneDeadStatus: pane.PaneDeadStatus,
+		ScreenMode:     pane.ScreenMode,
+
+		Token: token,
+	}
+	if cursor != nil {
+		structured.CursorX, structured.CursorY = &cursor.X, &cursor.Y
+	}
+	paneID, err := s.tmuxManager.PaneID(target)
+	if err != nil {
+		return toolError(err)
+	}
+	size := paneSize{Width: pane.Width, Height: pane.Height}
+	changed, previous := s.checkSize(paneID, size)
+	if changed {
+		structured.SizeChanged, structured.PreviousWidth, structured.PreviousHeight = true, previous.Width, previous.Height
+	}
+	text := appendNote(strings.TrimRight(content, "\n"), fmt.Sprintf("pane %d, %dx%d, cwd %s, token %s",
+		pane.PaneIndex, pane.Width, pane.Height, pane.CurrentPath, token))
+	if changed {
+		text = appendNote(text, resizeNote(previous, size))
+	}
+	if note := pane.deadNote(); note != "" {
+		text = appendNote(text, note)
+	}
+	if note := pane.screenNote(); note != "" {
+		text = appendNote(text, note)
+	}
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: structured,
+	}, nil
+}
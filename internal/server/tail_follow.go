@@ -0,0 +1,147 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const (
+	// defaultTailLines is how many lines tail_follow returns up front
+	defaultTailLines = 20
+
+	// defaultFollowDuration and maxFollowDuration bound how long
+	// tail_follow watches the pane
+	defaultFollowDuration = 10 * time.Second
+	maxFollowDuration     = 10 * time.Minute
+
+	// followSlack is how many lines beyond the tail each poll captures, so
+	// output arriving between two polls still overlaps the previous
+	// capture and can be told apart from it. Output longer than this
+	// between polls is reported only in part.
+	followSlack = 200
+)
+
+// tailFollowResult is the structured content of tail_follow
+type tailFollowResult struct {
+	Tail      string `json:"tail"`
+	NewOutput string `json:"new_output"`
+	NewLines  int    `json:"new_lines"`
+	// DurationMs is how long the pane was watched, less than requested
+	// when the request was cancelled
+	DurationMs int  `json:"duration_ms"`
+	Cancelled  bool `json:"cancelled"`
+}
+
+// tailFollow handles the tail_follow tool: it reads the last lines of the
+// pane and then watches it for a while, reporting each batch of new lines
+// as a notifications/progress message when the request carries a progress
+// token. The result repeats everything, with a count of the new lines.
+// The watch ends early if the client cancels the request.
+func (s *Server) tailFollow(arguments map[string]interface{}, meta *mcp.RequestMeta, id interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := intArgument(arguments, "lines", defaultTailLines)
+	if err != nil {
+		return nil, err
+	}
+	if lines < 1 {
+		return nil, invalidParams(fmt.Sprintf("lines must be positive, got %d", lines),
+			paramError{Field: "lines", Expected: "positive number of lines"})
+	}
+	durationMs, err := intArgument(arguments, "duration_ms", int(defaultFollowDuration/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	duration := time.Duration(durationMs) * time.Millisecond
+	if duration < 0 || duration > maxFollowDuration {
+		return nil, invalidParams(fmt.Sprintf("duration_ms must be between 0 and %d", maxFollowDuration.Milliseconds()),
+			paramError{Field: "duration_ms", Expected: fmt.Sprintf("number of milliseconds from 0 to %d", maxFollowDuration.Milliseconds())})
+	}
+	pollInterval, err := s.pollIntervalArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	capture := func() ([]string, error) {
+		content, err := s.tmuxManager.GetScrollbackHistoryWithOptions(lines+followSlack, tmux.CaptureOptions{Target: target})
+		if err != nil {
+			return nil, err
+		}
+		return splitLines(trimContent(content)), nil
+	}
+
+	var token interface{}
+	if meta != nil {
+		token = meta.ProgressToken
+	}
+	updates := 0
+	send := func(text string) {
+		if token == nil {
+			return
+		}
+		updates++
+		// A client that has gone away only loses the progress
+		_ = s.notify("notifications/progress", mcp.ProgressParams{
+			ProgressToken: token,
+			Progress:      float64(updates),
+			Message:       truncateLines(text, s.maxLineWidth),
+		})
+	}
+
+	start := time.Now()
+	previous, err := capture()
+	if err != nil {
+		return toolError(err)
+	}
+	tail := previous[max(0, len(previous)-lines):]
+	result := tailFollowResult{Tail: strings.Join(tail, "\n")}
+	send(result.Tail)
+
+	var added []string
+	deadline := start.Add(duration)
+	for time.Now().Before(deadline) {
+		if result.Cancelled = s.cancellations.cancelled(id); result.Cancelled {
+			break
+		}
+		time.Sleep(min(pollInterval, time.Until(deadline)))
+
+		current, err := capture()
+		if err != nil {
+			return toolError(err)
+		}
+		var batch []string
+		for _, op := range diffLines(previous, current) {
+			if op.kind == '+' {
+				batch = append(batch, op.line)
+			}
+		}
+		if len(batch) > 0 {
+			added = append(added, batch...)
+			send(strings.Join(batch, "\n"))
+		}
+		previous = current
+	}
+
+	result.NewOutput = strings.Join(added, "\n")
+	result.NewLines = len(added)
+	result.DurationMs = int(time.Since(start) / time.Millisecond)
+
+	text := result.Tail
+	if result.NewOutput != "" {
+		text += "\n" + result.NewOutput
+	}
+	note := fmt.Sprintf("%d new lines in %dms", result.NewLines, result.DurationMs)
+	if result.Cancelled {
+		note = "cancelled: " + note
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: appendNote(truncateLines(text, s.maxLineWidth), note)}},
+		StructuredContent: result,
+	}, nil
+}
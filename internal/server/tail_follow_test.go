@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_TailFollow(t *testing.T) {
+	captures := []string{
+		"$ tail -f app.log\nbooting\nlistening\n",
+		"$ tail -f app.log\nbooting\nlistening\nGET /\n",
+		"$ tail -f app.log\nbooting\nlistening\nGET /\n",
+		"$ tail -f app.log\nbooting\nlistening\nGET /\nGET /health\nPOST /login\n",
+	}
+	var starts []string
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			starts = append(starts, args[5])
+			out := captures[0]
+			if len(captures) > 1 {
+				captures = captures[1:]
+			}
+			return out, "", nil
+		}
+		return "", "", nil
+	})
+
+	response := srv.handleRequest(&mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      7,
+		Method:  "tools/call",
+		Params: map[string]interface{}{
+			"name":      "tail_follow",
+			"arguments": map[string]interface{}{"lines": float64(2), "duration_ms": float64(200), "poll_ms": float64(20)},
+			"_meta":     map[string]interface{}{"progressToken": "tail-1"},
+		},
+	})
+	text := toolText(t, response)
+	if want := "booting\nlistening\nGET /\nGET /health\nPOST /login\n[3 new lines in "; !strings.HasPrefix(text, want) {
+		t.Errorf("tail_follow text = %q, want prefix %q", text, want)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "tail_follow").OutputSchema, result.StructuredContent)
+	got := result.StructuredContent.(tailFollowResult)
+	if got.Tail != "booting\nlistening" || got.NewOutput != "GET /\nGET /health\nPOST /login" || got.NewLines != 3 || got.Cancelled {
+		t.Errorf("tail_follow = %+v", got)
+	}
+	if got.DurationMs < 200 {
+		t.Errorf("duration_ms = %d, want at least 200", got.DurationMs)
+	}
+	if starts[0] != "-202" {
+		t.Errorf("capture-pane -S %q, want -202", starts[0])
+	}
+
+	var messages []string
+	decoder := json.NewDecoder(srv.writer.(*bytes.Buffer))
+	for decoder.More() {
+		var notification struct {
+			Method string             `json:"method"`
+			Params mcp.ProgressParams `json:"params"`
+		}
+		if err := decoder.Decode(&notification); err != nil {
+			t.Fatalf("failed to decode notification: %v", err)
+		}
+		if notification.Method != "notifications/progress" || notification.Params.ProgressToken != "tail-1" {
+			t.Errorf("notification = %+v, want notifications/progress for tail-1", notification)
+		}
+		messages = append(messages, notification.Params.Message)
+	}
+	if want := []string{"booting\nlistening", "GET /", "GET /health\nPOST /login"}; !reflect.DeepEqual(messages, want) {
+		t.Errorf("streamed messages = %q, want %q", messages, want)
+	}
+}
+
+func TestServer_callTool_TailFollow_Cancelled(t *testing.T) {
+	polls := 0
+	var srv *Server
+	srv = newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "capture-pane" {
+			polls++
+			if polls == 3 {
+				// callFakeTool sends its request with ID 1
+				srv.cancellations.cancel(1)
+			}
+			return "$ make\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "tail_follow", map[string]interface{}{"duration_ms": float64(60000), "poll_ms": float64(10)})
+	got := response.Result.(*mcp.CallToolResult).StructuredContent.(tailFollowResult)
+	if !got.Cancelled || got.DurationMs >= 60000 {
+		t.Errorf("tail_follow = %+v, want it cancelled early", got)
+	}
+	if text := toolText(t, response); !strings.Contains(text, "[cancelled: 0 new lines in") {
+		t.Errorf("tail_follow text = %q, want a cancellation note", text)
+	}
+	if srv.cancellations.cancelled(1) {
+		t.Error("cancellation still recorded after the request was answered")
+	}
+}
+
+func TestServer_decodeRequests_NotesCancellation(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	srv.reader = strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"abc","reason":"user"}}` + "\n")
+
+	requests := make(chan decodedRequest)
+	done := make(chan struct{})
+	defer close(done)
+	go srv.decodeRequests(requests, done)
+
+	// The cancellation is recorded before the main loop takes it
+	next := <-requests
+	if next.err != nil || next.request.Method != "notifications/cancelled" {
+		t.Fatalf("decoded %+v", next)
+	}
+	if !srv.cancellations.cancelled("abc") {
+		t.Error("request abc not marked cancelled")
+	}
+	if srv.cancellations.cancelled(1) {
+		t.Error("unrelated request marked cancelled")
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// targetProperties are the input schema properties shared by every tool
+// that can address a specific pane
+var targetProperties = map[string]mcp.Property{
+	"window": {Type: "string", Description: "Window index or name (default: the active window)"},
+	"pane":   {Type: "string", Description: "Pane index within the window, or a pane ID such as \"%3\" from list_panes (default: the window's active pane)"},
+}
+
+// withTargetProperties returns props extended with the pane target properties
+func withTargetProperties(props map[string]mcp.Property) map[string]mcp.Property {
+	for name, prop := range targetProperties {
+		props[name] = prop
+	}
+	return props
+}
+
+// targetArgument reads the optional window and pane tool arguments. Both
+// accept a string or an integer index.
+func targetArgument(arguments map[string]interface{}) (tmux.Target, error) {
+	var target tmux.Target
+	var invalid []paramError
+	for _, field := range []struct {
+		name string
+		dest *string
+	}{
+		{name: "window", dest: &target.Window},
+		{name: "pane", dest: &target.Pane},
+	} {
+		switch v := arguments[field.name].(type) {
+		case nil:
+		case string:
+			*field.dest = v
+		case float64:
+			if v != math.Trunc(v) {
+				invalid = append(invalid, paramError{Field: field.name, Expected: "string or integer index"})
+				continue
+			}
+			*field.dest = strconv.Itoa(int(v))
+		default:
+			invalid = append(invalid, paramError{Field: field.name, Expected: "string or integer index"})
+		}
+	}
+	if len(invalid) > 0 {
+		return tmux.Target{}, invalidParams("invalid pane target", invalid...)
+	}
+	return target, nil
+}
+
+// toolError reports a manager failure from a tool. Unknown targets and
+// options are the caller's mistake and are rejected as invalid params;
+// anything else becomes an error result.
+func toolError(err error) (*mcp.CallToolResult, error) {
+	switch {
+	case errors.Is(err, tmux.ErrUnknownTarget):
+		return nil, invalidParams(err.Error(),
+			paramError{Field: "window", Expected: "a window listed by list_panes"},
+			paramError{Field: "pane", Expected: "a pane listed by list_panes"})
+	case errors.Is(err, tmux.ErrUnknownOption):
+		return nil, invalidParams(err.Error(),
+			paramError{Field: "name", Expected: "a tmux option name, e.g. \"history-limit\""})
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s", err)}},
+		IsError: true,
+	}, nil
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// capsResult is the structured content of terminal_caps
+type capsResult struct {
+	Term       string `json:"term"`
+	ClientTerm string `json:"client_term,omitempty"`
+	ColorTerm  string `json:"colorterm,omitempty"`
+	Locale     string `json:"locale,omitempty"`
+	Color256   bool   `json:"color_256"`
+	TrueColor  bool   `json:"truecolor"`
+	UTF8       bool   `json:"utf8"`
+}
+
+// terminalCaps handles the terminal_caps tool
+func (s *Server) terminalCaps() (*mcp.CallToolResult, error) {
+	caps, err := s.tmuxManager.TerminalCaps()
+	if err != nil {
+		return toolError(err)
+	}
+
+	result := capsResult{
+		Term:       caps.Term,
+		ClientTerm: caps.ClientTerm,
+		ColorTerm:  caps.ColorTerm,
+		Locale:     caps.Locale,
+		Color256:   caps.Color256,
+		TrueColor:  caps.TrueColor,
+		UTF8:       caps.UTF8,
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: result.text()}},
+		StructuredContent: result,
+	}, nil
+}
+
+// text summarises the capabilities on a line each
+func (c capsResult) text() string {
+	colors := "16 colors"
+	switch {
+	case c.TrueColor:
+		colors = "truecolor"
+	case c.Color256:
+		colors = "256 colors"
+	}
+	client := "no client attached"
+	if c.ClientTerm != "" {
+		client = "client terminal " + c.ClientTerm
+	}
+	locale := c.Locale
+	if locale == "" {
+		locale = "unset"
+	}
+	encoding := "not UTF-8"
+	if c.UTF8 {
+		encoding = "UTF-8"
+	}
+	return strings.Join([]string{
+		fmt.Sprintf("TERM %s (%s)", c.Term, client),
+		"colors: " + colors,
+		fmt.Sprintf("locale: %s (%s)", locale, encoding),
+	}, "\n")
+}
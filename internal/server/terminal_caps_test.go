@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_TerminalCaps(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		switch args[0] {
+		case "show-options":
+			return "tmux-256color\n", "", nil
+		case "show-environment":
+			if args[len(args)-1] == "LANG" {
+				return "LANG=en_GB.UTF-8\n", "", nil
+			}
+			return "", "unknown variable: " + args[len(args)-1], exitStatus(1)
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "terminal_caps", map[string]interface{}{})
+	wantText := "TERM tmux-256color (no client attached)\ncolors: 256 colors\nlocale: en_GB.UTF-8 (UTF-8)"
+	if text := toolText(t, response); text != wantText {
+		t.Errorf("terminal_caps text = %q, want %q", text, wantText)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "terminal_caps").OutputSchema, result.StructuredContent)
+	want := capsResult{Term: "tmux-256color", Locale: "en_GB.UTF-8", Color256: true, UTF8: true}
+	if got := result.StructuredContent.(capsResult); got != want {
+		t.Errorf("terminal_caps structuredContent = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+const (
+	// traceIn and traceOut mark the direction of a traced message
+	traceIn  = "in"
+	traceOut = "out"
+
+	// traceBuffer is how many messages may wait to be written to the trace
+	// before further ones are dropped
+	traceBuffer = 1024
+)
+
+// secretArguments names, by tool, the argument of a tools/call that holds
+// a secret and is replaced by tmux.RedactedSecret in the trace
+var secretArguments = map[string]string{
+	"send_secret":   "secret",
+	"unlock_writes": "secret",
+}
+
+// traceEntry is one JSON-RPC message in the trace, stored as a line of
+// JSON
+type traceEntry struct {
+	Time      time.Time       `json:"time"`
+	Direction string          `json:"direction"`
+	Message   json.RawMessage `json:"message"`
+	// Dropped counts the messages left out of the trace just before this
+	// one because it was falling behind
+	Dropped int `json:"dropped,omitempty"`
+}
+
+// tracer writes every message the server reads or writes, verbatim, to a
+// JSON Lines file. Messages are queued and written by a goroutine of their
+// own so a slow disk never holds up the client; if the queue fills up,
+// messages are dropped and the next entry says how many.
+type tracer struct {
+	now     func() time.Time
+	entries chan traceEntry
+	done    chan struct{}
+
+	// mu guards dropped and closed: requests are read and responses
+	// written on different goroutines, and reading can outlast Start
+	mu      sync.Mutex
+	dropped int
+	closed  bool
+}
+
+// newTracer starts a tracer writing to w
+func newTracer(w io.Writer) *tracer {
+	t := &tracer{
+		now:     time.Now,
+		entries: make(chan traceEntry, traceBuffer),
+		done:    make(chan struct{}),
+	}
+	go t.write(w)
+	return t
+}
+
+// record queues message, which travelled in direction, for the trace
+func (t *tracer) record(direction string, message []byte) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, message); err != nil {
+		// Only valid JSON is ever passed in, but keep whatever it was
+		quoted, _ := json.Marshal(string(message))
+		compact.Reset()
+		compact.Write(quoted)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	entry := traceEntry{
+		Time:      t.now().UTC(),
+		Direction: direction,
+		Message:   compact.Bytes(),
+		Dropped:   t.dropped,
+	}
+	select {
+	case t.entries <- entry:
+		t.dropped = 0
+	default:
+		t.dropped++
+	}
+}
+
+// write encodes queued entries to w until the tracer is closed
+func (t *tracer) write(w io.Writer) {
+	defer close(t.done)
+	encoder := json.NewEncoder(w)
+	for entry := range t.entries {
+		// A failed write only costs the trace, not the session
+		_ = encoder.Encode(entry)
+	}
+}
+
+// close writes out the queued entries and stops the tracer
+func (t *tracer) close() {
+	t.mu.Lock()
+	t.closed = true
+	close(t.entries)
+	t.mu.Unlock()
+	<-t.done
+}
+
+// traceWriter passes everything written to it through to the client and
+// records it as an outbound message. json.Encoder writes each message in a
+// single call, so every Write is one message.
+type traceWriter struct {
+	w      io.Writer
+	tracer *tracer
+}
+
+func (tw traceWriter) Write(p []byte) (int, error) {
+	n, err := tw.w.Write(p)
+	if n > 0 {
+		tw.tracer.record(traceOut, p[:n])
+	}
+	return n, err
+}
+
+// redactSecretArgument returns a tools/call message with the secret
+// argument of its tool, if it has one (see secretArguments), replaced by
+// tmux.RedactedSecret, for the trace. Any other message is returned
+// unchanged.
+func redactSecretArgument(message []byte) []byte {
+	var request struct {
+		Method string `json:"method"`
+		Params struct {
+			Name string `json:"name"`
+		} `json:"params"`
+	}
+	if json.Unmarshal(message, &request) != nil || request.Method != "tools/call" {
+		return message
+	}
+	name, ok := secretArguments[request.Params.Name]
+	if !ok {
+		return message
+	}
+
+	var generic map[string]interface{}
+	if json.Unmarshal(message, &generic) != nil {
+		return message
+	}
+	params, _ := generic["params"].(map[string]interface{})
+	arguments, _ := params["arguments"].(map[string]interface{})
+	if _, ok := arguments[name]; !ok {
+		return message
+	}
+	arguments[name] = tmux.RedactedSecret
+	redacted, err := json.Marshal(generic)
+	if err != nil {
+		// Leave the message out rather than record the secret
+		return []byte(`"` + tmux.RedactedSecret + `"`)
+	}
+	return redacted
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_Start_Trace(t *testing.T) {
+	requests := `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n" +
+		`{"jsonrpc": "2.0", "method": "notifications/initialized"}` + "\n"
+	trace := &bytes.Buffer{}
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	srv.reader = strings.NewReader(requests)
+	srv.writer = &bytes.Buffer{}
+	// Indented output is traced compactly, one message per line
+	WithPrettyJSON(true)(srv)
+	WithTrace(trace)(srv)
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// Requests are read ahead of the responses, so only the order within
+	// each direction is fixed
+	want := map[string][]string{
+		traceIn:  {`{"jsonrpc":"2.0","id":1,"method":"ping"}`, `{"jsonrpc":"2.0","method":"notifications/initialized"}`},
+		traceOut: {`{"jsonrpc":"2.0","id":1,"result":{}}`},
+	}
+	got := map[string][]string{}
+	scanner := bufio.NewScanner(trace)
+	for scanner.Scan() {
+		var entry traceEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("trace line %q: %v", scanner.Text(), err)
+		}
+		if entry.Time.IsZero() {
+			t.Errorf("trace line %q has no timestamp", scanner.Text())
+		}
+		got[entry.Direction] = append(got[entry.Direction], string(entry.Message))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("trace = %v, want %v", got, want)
+	}
+}
+
+// traceHandshake initializes a session ahead of the requests a trace test
+// sends
+const traceHandshake = `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}` + "\n" +
+	`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n"
+
+// blockedWriter never returns from Write until released
+type blockedWriter struct{ release chan struct{} }
+
+func (w blockedWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestTracer_DropsWhenBehind(t *testing.T) {
+	out := blockedWriter{release: make(chan struct{})}
+	tr := newTracer(out)
+
+	// The writer takes one entry and blocks on it; the queue holds the
+	// next traceBuffer, and the rest must be dropped rather than block
+	finished := make(chan struct{})
+	go func() {
+		for i := 0; i < traceBuffer+10; i++ {
+			tr.record(traceIn, []byte(`{}`))
+		}
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("record blocked on a stalled trace file")
+	}
+
+	tr.mu.Lock()
+	dropped := tr.dropped
+	tr.mu.Unlock()
+	if dropped == 0 {
+		t.Error("no messages dropped from a full queue")
+	}
+	close(out.release)
+	tr.close()
+	// Recording after close is ignored rather than panicking
+	tr.record(traceOut, []byte(`{}`))
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// writeLockResult is the structured content of unlock_writes and
+// lock_writes
+type writeLockResult struct {
+	WritesEnabled bool `json:"writes_enabled"`
+	// Changed is false when writes were already in the requested state
+	Changed bool `json:"changed"`
+}
+
+// unlockWrites handles the unlock_writes tool: it turns on write tools in
+// a server started read-only, so an operator can grant write access for a
+// task without a restart. It needs --allow-runtime-unlock, and the secret
+// when one was configured.
+func (s *Server) unlockWrites(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireRuntimeUnlock("unlock_writes"); result != nil {
+		return result, nil
+	}
+	if s.unlockSecret != "" {
+		secret, _ := arguments["secret"].(string)
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(s.unlockSecret)) != 1 {
+			log.Printf("Refused to unlock writes: wrong or missing secret")
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{{Type: "text", Text: "Error: unlock_writes needs the secret the server was started with (--unlock-secret)"}},
+				IsError: true,
+			}, nil
+		}
+	}
+	return s.setWritesEnabled(true), nil
+}
+
+// lockWrites handles the lock_writes tool: it returns the server to
+// read-only mode, whether it was started with --allow-writes or unlocked
+// later
+func (s *Server) lockWrites() (*mcp.CallToolResult, error) {
+	if result := s.requireRuntimeUnlock("lock_writes"); result != nil {
+		return result, nil
+	}
+	return s.setWritesEnabled(false), nil
+}
+
+// setWritesEnabled switches write tools on or off, logging the transition
+func (s *Server) setWritesEnabled(enabled bool) *mcp.CallToolResult {
+	result := writeLockResult{WritesEnabled: enabled, Changed: s.writesEnabled != enabled}
+	s.writesEnabled = enabled
+
+	state := "read-only"
+	if enabled {
+		state = "writes enabled"
+	}
+	text := "server is already " + state
+	if result.Changed {
+		log.Printf("Runtime write access changed: now %s", state)
+		text = "server is now " + state
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: text}},
+		StructuredContent: result,
+	}
+}
+
+// requireRuntimeUnlock returns an error result for tool unless the server
+// was started with --allow-runtime-unlock
+func (s *Server) requireRuntimeUnlock(tool string) *mcp.CallToolResult {
+	if s.runtimeUnlock {
+		return nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error: %s is disabled: start the server with --allow-runtime-unlock to let write access change at runtime", tool)}},
+		IsError: true,
+	}
+}
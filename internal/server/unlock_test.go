@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+func TestServer_callTool_UnlockWrites_Disabled(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+
+	for _, tool := range []string{"unlock_writes", "lock_writes"} {
+		result := callFakeTool(srv, tool, nil).Result.(*mcp.CallToolResult)
+		if !result.IsError {
+			t.Errorf("%s without --allow-runtime-unlock = %+v, want an error", tool, result)
+		}
+	}
+	if srv.writesEnabled {
+		t.Error("writes enabled without --allow-runtime-unlock")
+	}
+}
+
+func TestServer_callTool_UnlockWrites(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	WithRuntimeUnlock(true, "")(srv)
+
+	steps := []struct {
+		tool        string
+		wantEnabled bool
+		wantChanged bool
+	}{
+		{tool: "unlock_writes", wantEnabled: true, wantChanged: true},
+		{tool: "unlock_writes", wantEnabled: true, wantChanged: false},
+		{tool: "lock_writes", wantEnabled: false, wantChanged: true},
+		{tool: "lock_writes", wantEnabled: false, wantChanged: false},
+	}
+	for i, step := range steps {
+		result := callFakeTool(srv, step.tool, nil).Result.(*mcp.CallToolResult)
+		validateStructuredContent(t, findTool(t, srv, step.tool).OutputSchema, result.StructuredContent)
+		got := result.StructuredContent.(writeLockResult)
+		if got.WritesEnabled != step.wantEnabled || got.Changed != step.wantChanged || srv.writesEnabled != step.wantEnabled {
+			t.Errorf("step %d %s = %+v (server %v), want enabled=%v changed=%v",
+				i, step.tool, got, srv.writesEnabled, step.wantEnabled, step.wantChanged)
+		}
+	}
+}
+
+func TestServer_callTool_UnlockWrites_Secret(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	WithRuntimeUnlock(true, "hunter2")(srv)
+
+	for _, arguments := range []map[string]interface{}{nil, {"secret": "hunter3"}} {
+		result := callFakeTool(srv, "unlock_writes", arguments).Result.(*mcp.CallToolResult)
+		if !result.IsError || srv.writesEnabled {
+			t.Errorf("unlock_writes(%v) = %+v, want refused", arguments, result)
+		}
+	}
+
+	result := callFakeTool(srv, "unlock_writes", map[string]interface{}{"secret": "hunter2"}).Result.(*mcp.CallToolResult)
+	if result.IsError || !srv.writesEnabled {
+		t.Errorf("unlock_writes with the secret = %+v, want writes enabled", result)
+	}
+}
+
+func TestServer_Start_TraceRedactsUnlockSecret(t *testing.T) {
+	requests := traceHandshake + `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"unlock_writes","arguments":{"secret":"hunter2"}}}` + "\n"
+	trace := &bytes.Buffer{}
+	srv := newFakeServer(func(args ...string) (string, string, error) { return "", "", nil })
+	srv.reader = strings.NewReader(requests)
+	srv.writer = &bytes.Buffer{}
+	WithRuntimeUnlock(true, "hunter2")(srv)
+	WithTrace(trace)(srv)
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if !srv.writesEnabled {
+		t.Errorf("unlock_writes with the secret didn't enable writes")
+	}
+	if strings.Contains(trace.String(), "hunter2") || !strings.Contains(trace.String(), tmux.RedactedSecret) {
+		t.Errorf("trace = %s, want the unlock secret replaced by %s", trace, tmux.RedactedSecret)
+	}
+}
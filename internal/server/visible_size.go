@@ -0,0 +1,32 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+// visibleSize is the structured content of visible_size
+type visibleSize struct {
+	PaneWidth  int `json:"pane_width"`
+	PaneHeight int `json:"pane_height"`
+}
+
+// getVisibleSize handles the visible_size tool: just the pane's size, for
+// sizing read_range and capture_grid requests without the cost of
+// get_terminal_info
+func (s *Server) getVisibleSize(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, err := targetArgument(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height, err := s.tmuxManager.PaneSize(target)
+	if err != nil {
+		return toolError(err)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: "text", Text: fmt.Sprintf("%dx%d", width, height)}},
+		StructuredContent: visibleSize{PaneWidth: width, PaneHeight: height},
+	}, nil
+}
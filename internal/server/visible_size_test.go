@@ -0,0 +1,32 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_VisibleSize(t *testing.T) {
+	var format []string
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "display-message" {
+			format = args
+			return "132x43\n", "", nil
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "visible_size", map[string]interface{}{})
+	if text := toolText(t, response); text != "132x43" {
+		t.Errorf("visible_size text = %q, want 132x43", text)
+	}
+	result := response.Result.(*mcp.CallToolResult)
+	validateStructuredContent(t, findTool(t, srv, "visible_size").OutputSchema, result.StructuredContent)
+	if got, want := result.StructuredContent, (visibleSize{PaneWidth: 132, PaneHeight: 43}); got != want {
+		t.Errorf("visible_size = %+v, want %+v", got, want)
+	}
+	if want := []string{"display-message", "-t", "fake-session", "-p", "#{pane_width}x#{pane_height}"}; !reflect.DeepEqual(format, want) {
+		t.Errorf("display-message args = %q, want %q", format, want)
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/tmux"
+)
+
+// Outcomes of wait_for_exit
+const (
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+	// outcomeIndeterminate means the exit status was never seen, usually
+	// because the command outlived the timeout
+	outcomeIndeterminate = "indeterminate"
+)
+
+// exitResult is the structured content of wait_for_exit
+type exitResult struct {
+	commandResult
+	Outcome string `json:"outcome"`
+	// Success is null when the outcome is indeterminate, so a timeout can
+	// never be mistaken for a pass
+	Success *bool `json:"success"`
+}
+
+// waitForExit handles the wait_for_exit tool: it runs a command like
+// run_command with the exit status always requested, and reduces the
+// result to success, failure or indeterminate
+func (s *Server) waitForExit(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if result := s.requireWrites("wait_for_exit"); result != nil {
+		return result, nil
+	}
+
+	result, err := s.executeCommand(arguments, func(opts *tmux.RunOptions) {
+		opts.ExitSentinel = s.exitSentinel
+	})
+	if err != nil || result.IsError {
+		return result, err
+	}
+
+	command := result.StructuredContent.(commandResult)
+	structured := exitResult{commandResult: command, Outcome: outcomeIndeterminate}
+	if command.Completed && command.ExitCode != nil {
+		success := *command.ExitCode == 0
+		structured.Success = &success
+		structured.Outcome = outcomeFailure
+		if success {
+			structured.Outcome = outcomeSuccess
+		}
+	}
+
+	result.StructuredContent = structured
+	result.Content[0].Text = "outcome: " + structured.Outcome + "\n" + result.Content[0].Text
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/mcp"
+)
+
+func TestServer_callTool_WaitForExit(t *testing.T) {
+	tests := []struct {
+		name        string
+		captures    []string
+		timeoutMs   float64
+		wantOutcome string
+		wantSuccess *bool
+		wantText    string
+	}{
+		{
+			name:        "success",
+			captures:    []string{"$ \n", "$ make test; echo \"__EXIT__$?\"\nok\n__EXIT__0\n$ \n"},
+			timeoutMs:   2000,
+			wantOutcome: outcomeSuccess,
+			wantSuccess: boolPtr(true),
+			wantText:    "outcome: success\nok\n[exit code: 0]",
+		},
+		{
+			name:        "failure",
+			captures:    []string{"$ \n", "$ make test; echo \"__EXIT__$?\"\nFAIL\n__EXIT__2\n$ \n"},
+			timeoutMs:   2000,
+			wantOutcome: outcomeFailure,
+			wantSuccess: boolPtr(false),
+			wantText:    "outcome: failure\nFAIL\n[exit code: 2]",
+		},
+		{
+			name:        "timeout",
+			captures:    []string{"$ \n", "$ make test; echo \"__EXIT__$?\"\nrunning\n"},
+			timeoutMs:   20,
+			wantOutcome: outcomeIndeterminate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captures := tt.captures
+			srv := newFakeServer(func(args ...string) (string, string, error) {
+				if args[0] == "capture-pane" {
+					out := captures[0]
+					if len(captures) > 1 {
+						captures = captures[1:]
+					}
+					return out, "", nil
+				}
+				return "", "", nil
+			})
+			srv.writesEnabled = true
+
+			response := callFakeTool(srv, "wait_for_exit", map[string]interface{}{"command": "make test", "timeout_ms": tt.timeoutMs})
+			text := toolText(t, response)
+			if tt.wantText != "" && text != tt.wantText {
+				t.Errorf("wait_for_exit text = %q, want %q", text, tt.wantText)
+			}
+
+			result := response.Result.(*mcp.CallToolResult)
+			validateStructuredContent(t, findTool(t, srv, "wait_for_exit").OutputSchema, result.StructuredContent)
+			got := result.StructuredContent.(exitResult)
+			if got.Outcome != tt.wantOutcome {
+				t.Errorf("outcome = %q, want %q", got.Outcome, tt.wantOutcome)
+			}
+			if (got.Success == nil) != (tt.wantSuccess == nil) || (got.Success != nil && *got.Success != *tt.wantSuccess) {
+				t.Errorf("success = %v, want %v", got.Success, tt.wantSuccess)
+			}
+		})
+	}
+}
+
+func TestServer_callTool_WaitForExit_ReadOnly(t *testing.T) {
+	srv := newFakeServer(func(args ...string) (string, string, error) {
+		if args[0] == "send-keys" {
+			t.Errorf("wait_for_exit sent keys with writes disabled: %v", args)
+		}
+		return "", "", nil
+	})
+
+	response := callFakeTool(srv, "wait_for_exit", map[string]interface{}{"command": "make test"})
+	if result := response.Result.(*mcp.CallToolResult); !result.IsError {
+		t.Error("wait_for_exit with writes disabled: IsError = false, want true")
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
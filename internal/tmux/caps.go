@@ -0,0 +1,117 @@
+package tmux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TerminalCaps describes what programs in the session can expect of the
+// terminal. It is a best guess from tmux's configuration and environment:
+// a program may still set its own TERM or locale.
+type TerminalCaps struct {
+	// Term is the TERM tmux gives programs in its panes (default-terminal)
+	Term string
+	// ClientTerm is the TERM of the terminal the first attached client runs
+	// in, or "" when no client is attached
+	ClientTerm string
+	// ColorTerm is the COLORTERM variable in the session environment
+	ColorTerm string
+	// Locale is the effective character type locale: LC_ALL, else
+	// LC_CTYPE, else LANG from the session environment
+	Locale string
+
+	Color256  bool
+	TrueColor bool
+	UTF8      bool
+}
+
+// TerminalCaps reads the session's terminal type and environment and
+// infers its color and UTF-8 support
+func (m *Manager) TerminalCaps() (*TerminalCaps, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return nil, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	caps := &TerminalCaps{}
+	if caps.Term, err = m.ShowOption("default-terminal", false); err != nil {
+		return nil, err
+	}
+
+	stdout, _, err := m.run("list-clients", "-t", m.sessionName,
+		"-F", "#{client_termname}\t#{client_utf8}\t#{client_termfeatures}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clients: %w", err)
+	}
+	var clientUTF8 bool
+	var features []string
+	if first, _, _ := strings.Cut(stdout, "\n"); first != "" {
+		fields := strings.Split(first, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected client format: %s", first)
+		}
+		caps.ClientTerm = fields[0]
+		clientUTF8 = fields[1] == "1"
+		features = strings.Split(fields[2], ",")
+	}
+
+	env := map[string]string{}
+	for _, name := range []string{"COLORTERM", "LC_ALL", "LC_CTYPE", "LANG"} {
+		if env[name], err = m.environment(name); err != nil {
+			return nil, err
+		}
+	}
+	caps.ColorTerm = env["COLORTERM"]
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if env[name] != "" {
+			caps.Locale = env[name]
+			break
+		}
+	}
+
+	caps.Color256 = strings.Contains(caps.Term, "256color") || hasFeature(features, "256")
+	caps.TrueColor = caps.ColorTerm == "truecolor" || caps.ColorTerm == "24bit" || hasFeature(features, "RGB")
+	caps.Color256 = caps.Color256 || caps.TrueColor
+	locale := strings.ToLower(caps.Locale)
+	caps.UTF8 = strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8") || clientUTF8
+	return caps, nil
+}
+
+// environment returns the value of one variable in the environment tmux
+// gives new processes in the session: the session's own environment, then
+// the global one. A variable that is unset, or removed from the session's
+// environment, gives "". Variables are only ever read by name, as the full
+// environment may hold secrets.
+func (m *Manager) environment(name string) (string, error) {
+	for _, scope := range [][]string{{"-t", m.sessionName}, {"-g"}} {
+		args := append(append([]string{"show-environment"}, scope...), "--", name)
+		stdout, stderr, err := m.run(args...)
+		if err != nil {
+			if strings.Contains(stderr, "unknown variable") {
+				continue
+			}
+			return "", fmt.Errorf("failed to read %s from the environment: %w (stderr: %s)", name, err, stderr)
+		}
+		line := strings.TrimRight(stdout, "\n")
+		if strings.HasPrefix(line, "-") {
+			return "", nil
+		}
+		_, value, _ := strings.Cut(line, "=")
+		return value, nil
+	}
+	return "", nil
+}
+
+// hasFeature reports whether a tmux terminal feature list includes name
+func hasFeature(features []string, name string) bool {
+	for _, feature := range features {
+		if feature == name {
+			return true
+		}
+	}
+	return false
+}
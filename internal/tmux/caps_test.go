@@ -0,0 +1,84 @@
+package tmux
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManager_TerminalCaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		term    string
+		clients string
+		session map[string]string
+		global  map[string]string
+		want    TerminalCaps
+	}{
+		{
+			name:   "detached with a UTF-8 locale",
+			term:   "tmux-256color",
+			global: map[string]string{"LANG": "en_US.UTF-8"},
+			want:   TerminalCaps{Term: "tmux-256color", Locale: "en_US.UTF-8", Color256: true, UTF8: true},
+		},
+		{
+			name:    "truecolor client",
+			term:    "screen",
+			clients: "xterm-direct\t1\tRGB,title\n",
+			session: map[string]string{"COLORTERM": "truecolor"},
+			global:  map[string]string{"LANG": "C"},
+			want: TerminalCaps{Term: "screen", ClientTerm: "xterm-direct", ColorTerm: "truecolor", Locale: "C",
+				Color256: true, TrueColor: true, UTF8: true},
+		},
+		{
+			name:    "LC_ALL wins and the session can remove a variable",
+			term:    "screen",
+			session: map[string]string{"LANG": "-", "LC_ALL": "C"},
+			global:  map[string]string{"LANG": "en_US.UTF-8"},
+			want:    TerminalCaps{Term: "screen", Locale: "C"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var shown []string
+			m := NewManagerWithRunner("fake-session", RunnerFunc(func(args ...string) (string, string, error) {
+				switch args[0] {
+				case "show-options":
+					return tt.term + "\n", "", nil
+				case "list-clients":
+					return tt.clients, "", nil
+				case "show-environment":
+					name := args[len(args)-1]
+					shown = append(shown, name)
+					env := tt.global
+					if args[1] == "-t" {
+						env = tt.session
+					}
+					switch value, ok := env[name]; {
+					case !ok:
+						return "", "unknown variable: " + name, exitError(1)
+					case value == "-":
+						return "-" + name + "\n", "", nil
+					default:
+						return name + "=" + value + "\n", "", nil
+					}
+				}
+				return "", "", nil
+			}))
+
+			got, err := m.TerminalCaps()
+			if err != nil {
+				t.Fatalf("TerminalCaps() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("TerminalCaps() = %+v, want %+v", *got, tt.want)
+			}
+			for _, name := range shown {
+				if name == "" || strings.HasPrefix(name, "-") {
+					t.Errorf("show-environment ran without a variable name: %v", shown)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package tmux
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/ansi"
+)
+
+const (
+	// DefaultPromptPattern matches a line ending in a typical shell prompt
+	// terminator ($, #, %, > or ❯) followed only by whitespace
+	DefaultPromptPattern = `[$#%>❯]\s*$`
+
+	// DefaultCommandTimeout bounds how long RunCommand waits for the prompt
+	DefaultCommandTimeout = 30 * time.Second
+
+	// DefaultPollInterval is how often polling operations re-capture the pane
+	DefaultPollInterval = 200 * time.Millisecond
+
+	// MinPollInterval is the shortest accepted poll interval. Every poll
+	// spawns a tmux process, so intervals below this mostly burn CPU.
+	MinPollInterval = 10 * time.Millisecond
+
+	// DefaultExitSentinel prefixes the line RunCommand prints to report a
+	// command's exit status
+	DefaultExitSentinel = "__EXIT__"
+)
+
+// sentinelPattern restricts exit sentinels to characters that need no
+// quoting inside a double-quoted shell string
+var sentinelPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ValidateExitSentinel checks that sentinel can be embedded in the echo
+// command RunCommand appends
+func ValidateExitSentinel(sentinel string) error {
+	if !sentinelPattern.MatchString(sentinel) {
+		return fmt.Errorf("invalid exit sentinel %q: only letters, digits and underscores are allowed", sentinel)
+	}
+	return nil
+}
+
+// ValidatePollInterval checks that interval is usable for polling the pane
+func ValidatePollInterval(interval time.Duration) error {
+	if interval < MinPollInterval {
+		return fmt.Errorf("poll interval %s is too small: must be at least %s", interval, MinPollInterval)
+	}
+	return nil
+}
+
+// RunOptions controls how RunCommand detects that a command has finished
+type RunOptions struct {
+	// Prompt matches the shell prompt line that appears once the command
+	// completes. Defaults to DefaultPromptPattern.
+	Prompt *regexp.Regexp
+	// Detector, when set, recognises the prompt instead of Prompt (see
+	// NewPromptDetector)
+	Detector PromptDetector
+	// Timeout bounds the wait for the prompt. Defaults to DefaultCommandTimeout.
+	Timeout time.Duration
+	// PollInterval is the delay between captures. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+	// ExitSentinel, when set, makes RunCommand append
+	// `; echo "<sentinel>$?"` to the command and report the exit status
+	// parsed from the resulting line, which is removed from the output
+	ExitSentinel string
+	// Target selects the pane to run the command in; the zero value uses
+	// the session's active pane
+	Target Target
+	// Silence, when positive, also treats the command as finished once its
+	// output has not changed for this long, for commands that never return
+	// to a recognisable prompt
+	Silence time.Duration
+	// OnOutput, when set, is called with the output so far each time a
+	// capture finds it has changed
+	OnOutput func(output string)
+}
+
+// CommandResult is the outcome of RunCommand
+type CommandResult struct {
+	// Output is the text the command printed, excluding the echoed command
+	// line and the prompt that followed it
+	Output string
+	// Completed reports whether a new prompt appeared before the timeout.
+	// When false, Output holds whatever had been printed so far.
+	Completed bool
+	// ExitCode is the command's exit status. It is nil unless an exit
+	// sentinel was requested and its line appeared before the timeout.
+	ExitCode *int
+	// Silent reports that no prompt appeared but the output stopped
+	// changing for RunOptions.Silence, which ended the wait early
+	Silent bool
+}
+
+// SendText types text into the session's active pane literally, without
+// interpreting key names and without pressing Enter
+func (m *Manager) SendText(text string) error {
+	return m.SendTextTo(Target{}, text)
+}
+
+// SendTextTo types text literally into the pane selected by target
+func (m *Manager) SendTextTo(target Target, text string) error {
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return err
+	}
+	return m.sendText(resolved, text)
+}
+
+// SendKeys sends tmux key names (e.g. "Enter", "C-c") to the session's
+// active pane
+func (m *Manager) SendKeys(keys ...string) error {
+	return m.SendKeysTo(Target{}, keys...)
+}
+
+// SendKeysTo sends tmux key names to the pane selected by target
+func (m *Manager) SendKeysTo(target Target, keys ...string) error {
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return err
+	}
+	return m.sendKeys(resolved, keys...)
+}
+
+// sendText types text into an already resolved target
+func (m *Manager) sendText(target, text string) error {
+	// "--" stops tmux treating text that starts with "-" as a flag
+	_, stderr, err := m.run("send-keys", "-t", target, "-l", "--", text)
+	if err != nil {
+		return fmt.Errorf("failed to send text: %w (stderr: %s)", err, stderr)
+	}
+	return nil
+}
+
+// sendKeys sends key names to an already resolved target
+func (m *Manager) sendKeys(target string, keys ...string) error {
+	args := append([]string{"send-keys", "-t", target}, keys...)
+	_, stderr, err := m.run(args...)
+	if err != nil {
+		return fmt.Errorf("failed to send keys: %w (stderr: %s)", err, stderr)
+	}
+	return nil
+}
+
+// RunCommand types command into the session's active pane, presses Enter and
+// waits until a new line matching the prompt pattern appears after the
+// command's echo. If no prompt appears before the timeout, the output
+// captured so far is returned with Completed set to false.
+//
+// Output is located by comparing against a capture taken before the command
+// is sent, so it assumes the pane is sitting at an idle prompt and that the
+// history limit is not reached while the command runs.
+func (m *Manager) RunCommand(command string, opts RunOptions) (*CommandResult, error) {
+	if err := ValidateCommandLine(command); err != nil {
+		return nil, err
+	}
+	if opts.Prompt == nil {
+		opts.Prompt = regexp.MustCompile(DefaultPromptPattern)
+	}
+	if opts.Detector == nil {
+		opts.Detector = &RegexDetector{Prompt: opts.Prompt}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultCommandTimeout
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	sent := command
+	if opts.ExitSentinel != "" {
+		if err := ValidateExitSentinel(opts.ExitSentinel); err != nil {
+			return nil, err
+		}
+		sent = withExitSentinel(command, opts.ExitSentinel)
+	}
+
+	// Resolve the target once so every capture and keystroke reaches the
+	// same pane
+	exists, err := m.SessionExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return nil, &SessionNotFoundError{Session: m.sessionName}
+	}
+	target, err := m.resolveTarget(opts.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	captureOpts := CaptureOptions{JoinLines: true}
+	if reader, ok := opts.Detector.(escapeReader); ok && reader.readsEscapes() {
+		// The detector needs the markers; they are stripped from the output
+		captureOpts.EscapeSequences = true
+		captureOpts.KeepPromptMarks = true
+	}
+	before, err := m.capture(target, captureOpts)
+	if err != nil {
+		return nil, err
+	}
+	// The command is typed onto the last line of the baseline (the prompt)
+	start := len(trimBlankLines(strings.Split(before, "\n"))) - 1
+	if start < 0 {
+		start = 0
+	}
+
+	if err := m.sendText(target, sent); err != nil {
+		return nil, err
+	}
+	if err := m.sendKeys(target, "Enter"); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(opts.Timeout)
+	var last string
+	changed := time.Now()
+	for {
+		time.Sleep(opts.PollInterval)
+
+		content, err := m.capture(target, captureOpts)
+		if err != nil {
+			return nil, err
+		}
+		output, done := extractCommandOutput(strings.Split(content, "\n"), start, sent, opts.Detector)
+		now := time.Now()
+		if output != last {
+			last, changed = output, now
+			if opts.OnOutput != nil {
+				opts.OnOutput(output)
+			}
+		}
+		silent := !done && opts.Silence > 0 && now.Sub(changed) >= opts.Silence
+		if done || silent || now.After(deadline) {
+			result := &CommandResult{Output: output, Completed: done, Silent: silent}
+			if opts.ExitSentinel != "" {
+				result.Output, result.ExitCode = extractExitCode(output, opts.ExitSentinel)
+			}
+			return result, nil
+		}
+	}
+}
+
+// exitStatusVariable holds the status EchoExitStatus reports while it is
+// echoed, so it can be restored afterwards
+const exitStatusVariable = "__wingman_status"
+
+// EchoExitStatus asks the shell in the pane for the exit status of its last
+// command, by running a command that echoes $? after opts.ExitSentinel and
+// then restores $? with a subshell exiting with the same status. It works in
+// POSIX shells; the command it types shows in the pane, and in the shell's
+// history unless the shell ignores commands starting with a space (bash's
+// HISTCONTROL=ignorespace, zsh's HIST_IGNORE_SPACE). opts.ExitSentinel defaults to
+// DefaultExitSentinel. ExitCode is nil when the status line did not appear
+// before the timeout.
+func (m *Manager) EchoExitStatus(opts RunOptions) (*CommandResult, error) {
+	sentinel := opts.ExitSentinel
+	if sentinel == "" {
+		sentinel = DefaultExitSentinel
+	}
+	if err := ValidateExitSentinel(sentinel); err != nil {
+		return nil, err
+	}
+	opts.ExitSentinel = ""
+	command := fmt.Sprintf(` %[1]s=$?; echo "%[2]s$%[1]s"; (exit $%[1]s)`, exitStatusVariable, sentinel)
+
+	result, err := m.RunCommand(command, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Output, result.ExitCode = extractExitCode(result.Output, sentinel)
+	return result, nil
+}
+
+// ValidateCommandLine checks that command can be typed at a shell prompt
+// and run with a single Enter: it must be one non-empty line
+func ValidateCommandLine(command string) error {
+	if strings.TrimSpace(command) == "" {
+		return fmt.Errorf("command must not be empty")
+	}
+	if strings.ContainsAny(command, "\r\n") {
+		return fmt.Errorf("command must be a single line")
+	}
+	return nil
+}
+
+// extractCommandOutput finds the echo of command at or after line start and
+// returns the lines printed after it, without escape sequences. done reports
+// whether the last line is a prompt, in which case that line is excluded
+// from output.
+func extractCommandOutput(lines []string, start int, command string, detector PromptDetector) (output string, done bool) {
+	lines = trimBlankLines(lines)
+	if start >= len(lines) {
+		return "", false
+	}
+
+	region := lines[start:]
+	echo := -1
+	for i, line := range region {
+		if strings.Contains(ansi.Strip(line), command) {
+			echo = i
+			break
+		}
+	}
+	if echo < 0 {
+		return "", false
+	}
+
+	body := region[echo+1:]
+	if n := len(body); n > 0 && detector.IsPrompt(body[n-1]) {
+		return stripLines(body[:n-1]), true
+	}
+	return stripLines(body), false
+}
+
+// withExitSentinel appends an echo of the exit status to command
+func withExitSentinel(command, sentinel string) string {
+	command = strings.TrimRight(command, " \t")
+	echo := fmt.Sprintf(`echo "%s$?"`, sentinel)
+
+	switch {
+	case strings.HasSuffix(command, "&") && !strings.HasSuffix(command, "&&"):
+		// A backgrounded command is already terminated by "&"
+		return command + " " + echo
+	case strings.HasSuffix(command, ";"):
+		return command + " " + echo
+	default:
+		return command + "; " + echo
+	}
+}
+
+// extractExitCode finds the last "<sentinel><status>" line in output and
+// returns output with that line removed along with the parsed status. If no
+// such line exists the output is returned unchanged with a nil status.
+func extractExitCode(output, sentinel string) (string, *int) {
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, sentinel) {
+			continue
+		}
+		code, err := strconv.Atoi(strings.TrimPrefix(line, sentinel))
+		if err != nil {
+			continue
+		}
+		lines = append(lines[:i], lines[i+1:]...)
+		return strings.Join(lines, "\n"), &code
+	}
+	return output, nil
+}
+
+// trimBlankLines drops trailing whitespace-only lines, which tmux pads the
+// visible pane with below the cursor
+func trimBlankLines(lines []string) []string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lines[:end]
+}
@@ -0,0 +1,352 @@
+package tmux
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestManager_RunCommand_PromptDetection(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: "old output\nuser@host:~$ \n\n\n"}).
+		on("capture-pane", fakeResponse{stdout: "old output\nuser@host:~$ make\n\n\n"}).
+		on("capture-pane", fakeResponse{stdout: "old output\nuser@host:~$ make\nbuilding...\n\n"}).
+		on("capture-pane", fakeResponse{stdout: "old output\nuser@host:~$ make\nbuilding...\ndone\nuser@host:~$ \n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	result, err := m.RunCommand("make", RunOptions{PollInterval: time.Millisecond, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("RunCommand() error = %v", err)
+	}
+	if !result.Completed {
+		t.Error("RunCommand() Completed = false, want true")
+	}
+	if want := "building...\ndone"; result.Output != want {
+		t.Errorf("RunCommand() Output = %q, want %q", result.Output, want)
+	}
+
+	wantSend := []string{"send-keys", "-t", "fake-session", "-l", "--", "make"}
+	if got := runner.calls[2]; !reflect.DeepEqual(got, wantSend) {
+		t.Errorf("first send-keys = %v, want %v", got, wantSend)
+	}
+	wantEnter := []string{"send-keys", "-t", "fake-session", "Enter"}
+	if got := runner.lastCall("send-keys"); !reflect.DeepEqual(got, wantEnter) {
+		t.Errorf("last send-keys = %v, want %v", got, wantEnter)
+	}
+}
+
+func TestManager_RunCommand_Target(t *testing.T) {
+	runner := newFakeRunner().
+		on("list-panes", fakeResponse{stdout: paneListing}).
+		on("capture-pane", fakeResponse{stdout: "$ \n"}).
+		on("capture-pane", fakeResponse{stdout: "$ ls\nfile\n$ \n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	result, err := m.RunCommand("ls", RunOptions{
+		Target:       Target{Window: "logs tail"},
+		PollInterval: time.Millisecond,
+		Timeout:      time.Second,
+	})
+	if err != nil {
+		t.Fatalf("RunCommand() error = %v", err)
+	}
+	if !result.Completed || result.Output != "file" {
+		t.Errorf("RunCommand() = %+v, want completed with output \"file\"", result)
+	}
+	for _, call := range runner.calls {
+		if (call[0] == "capture-pane" || call[0] == "send-keys") && call[2] != "%2" {
+			t.Errorf("%s targeted %q, want %%2", call[0], call[2])
+		}
+	}
+}
+
+func TestManager_RunCommand_UnknownTarget(t *testing.T) {
+	runner := newFakeRunner().on("list-panes", fakeResponse{stdout: paneListing})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	_, err := m.RunCommand("ls", RunOptions{Target: Target{Pane: "%42"}})
+	if !errors.Is(err, ErrUnknownTarget) {
+		t.Fatalf("RunCommand() error = %v, want ErrUnknownTarget", err)
+	}
+	if runner.lastCall("send-keys") != nil {
+		t.Error("RunCommand() sent keys to an unknown target")
+	}
+}
+
+func TestManager_RunCommand_CustomPrompt(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: "[dev] >>> \n"}).
+		on("capture-pane", fakeResponse{stdout: "[dev] >>> print(1)\n1\n[dev] >>> \n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	result, err := m.RunCommand("print(1)", RunOptions{
+		Prompt:       regexp.MustCompile(`^\[dev\] >>> $`),
+		PollInterval: time.Millisecond,
+		Timeout:      time.Second,
+	})
+	if err != nil {
+		t.Fatalf("RunCommand() error = %v", err)
+	}
+	if !result.Completed || result.Output != "1" {
+		t.Errorf("RunCommand() = %+v, want completed with output \"1\"", result)
+	}
+}
+
+func TestManager_RunCommand_Timeout(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: "$ \n"}).
+		on("capture-pane", fakeResponse{stdout: "$ sleep 100\nstill going\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	result, err := m.RunCommand("sleep 100", RunOptions{PollInterval: time.Millisecond, Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("RunCommand() error = %v", err)
+	}
+	if result.Completed {
+		t.Error("RunCommand() Completed = true, want false on timeout")
+	}
+	if result.Output != "still going" {
+		t.Errorf("RunCommand() Output = %q, want partial output %q", result.Output, "still going")
+	}
+}
+
+func TestManager_RunCommand_Silence(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: "$ \n"}).
+		on("capture-pane", fakeResponse{stdout: "$ tail -f app.log\n"}).
+		on("capture-pane", fakeResponse{stdout: "$ tail -f app.log\nstarted\n"}).
+		on("capture-pane", fakeResponse{stdout: "$ tail -f app.log\nstarted\nlistening\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	var updates []string
+	result, err := m.RunCommand("tail -f app.log", RunOptions{
+		PollInterval: time.Millisecond,
+		Timeout:      time.Second,
+		Silence:      20 * time.Millisecond,
+		OnOutput:     func(output string) { updates = append(updates, output) },
+	})
+	if err != nil {
+		t.Fatalf("RunCommand() error = %v", err)
+	}
+	if result.Completed || !result.Silent {
+		t.Errorf("RunCommand() Completed = %v, Silent = %v, want false, true", result.Completed, result.Silent)
+	}
+	if want := "started\nlistening"; result.Output != want {
+		t.Errorf("RunCommand() Output = %q, want %q", result.Output, want)
+	}
+	// The first capture after sending shows only the echo, with no output
+	wantUpdates := []string{"started", "started\nlistening"}
+	if !reflect.DeepEqual(updates, wantUpdates) {
+		t.Errorf("OnOutput calls = %q, want %q", updates, wantUpdates)
+	}
+}
+
+func TestManager_RunCommand_InvalidCommand(t *testing.T) {
+	for _, command := range []string{"", "   ", "echo a\necho b"} {
+		runner := newFakeRunner()
+		m := NewManagerWithRunner("fake-session", runner)
+
+		if _, err := m.RunCommand(command, RunOptions{}); err == nil {
+			t.Errorf("RunCommand(%q) should return error", command)
+		}
+		if got := runner.lastCall("send-keys"); got != nil {
+			t.Errorf("RunCommand(%q) sent keys: %v", command, got)
+		}
+	}
+}
+
+func TestExtractCommandOutput(t *testing.T) {
+	prompt := regexp.MustCompile(DefaultPromptPattern)
+
+	tests := []struct {
+		name       string
+		lines      []string
+		start      int
+		wantOutput string
+		wantDone   bool
+	}{
+		{
+			name:     "echo not yet visible",
+			lines:    []string{"$ "},
+			start:    0,
+			wantDone: false,
+		},
+		{
+			name:       "command with no output",
+			lines:      []string{"$ true", "$ ", ""},
+			start:      0,
+			wantOutput: "",
+			wantDone:   true,
+		},
+		{
+			name:       "earlier output is ignored",
+			lines:      []string{"$ true", "$ ", "$ true", "result", "$ "},
+			start:      2,
+			wantOutput: "result",
+			wantDone:   true,
+		},
+		{
+			name:       "output still streaming",
+			lines:      []string{"$ true", "line 1", "line 2"},
+			start:      0,
+			wantOutput: "line 1\nline 2",
+			wantDone:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, done := extractCommandOutput(tt.lines, tt.start, "true", &RegexDetector{Prompt: prompt})
+			if output != tt.wantOutput || done != tt.wantDone {
+				t.Errorf("extractCommandOutput() = (%q, %v), want (%q, %v)", output, done, tt.wantOutput, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestManager_RunCommand_ExitSentinel(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: "$ \n"}).
+		on("capture-pane", fakeResponse{stdout: "$ false; echo \"__RC__$?\"\n__RC__1\n$ \n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	result, err := m.RunCommand("false", RunOptions{
+		ExitSentinel: "__RC__",
+		PollInterval: time.Millisecond,
+		Timeout:      time.Second,
+	})
+	if err != nil {
+		t.Fatalf("RunCommand() error = %v", err)
+	}
+	if result.ExitCode == nil || *result.ExitCode != 1 {
+		t.Fatalf("RunCommand() ExitCode = %v, want 1", result.ExitCode)
+	}
+	if result.Output != "" {
+		t.Errorf("RunCommand() Output = %q, want sentinel line stripped", result.Output)
+	}
+
+	wantSend := []string{"send-keys", "-t", "fake-session", "-l", "--", `false; echo "__RC__$?"`}
+	if got := runner.calls[2]; !reflect.DeepEqual(got, wantSend) {
+		t.Errorf("send-keys = %v, want %v", got, wantSend)
+	}
+}
+
+func TestManager_RunCommand_ExitSentinelTimeout(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: "$ \n"}).
+		on("capture-pane", fakeResponse{stdout: "$ sleep 60; echo \"__EXIT__$?\"\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	result, err := m.RunCommand("sleep 60", RunOptions{
+		ExitSentinel: DefaultExitSentinel,
+		PollInterval: time.Millisecond,
+		Timeout:      10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("RunCommand() error = %v", err)
+	}
+	if result.Completed || result.ExitCode != nil {
+		t.Errorf("RunCommand() = %+v, want incomplete with nil exit code", result)
+	}
+}
+
+func TestManager_RunCommand_InvalidSentinel(t *testing.T) {
+	m := NewManagerWithRunner("fake-session", newFakeRunner())
+	if _, err := m.RunCommand("true", RunOptions{ExitSentinel: `$(rm -rf ~)`}); err == nil {
+		t.Error("RunCommand() should reject a sentinel with shell metacharacters")
+	}
+}
+
+func TestManager_EchoExitStatus(t *testing.T) {
+	echo := ` __wingman_status=$?; echo "__RC__$__wingman_status"; (exit $__wingman_status)`
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: "$ \n"}).
+		on("capture-pane", fakeResponse{stdout: "$ " + echo + "\n__RC__130\n$ \n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	result, err := m.EchoExitStatus(RunOptions{
+		ExitSentinel: "__RC__",
+		PollInterval: time.Millisecond,
+		Timeout:      time.Second,
+	})
+	if err != nil {
+		t.Fatalf("EchoExitStatus() error = %v", err)
+	}
+	if result.ExitCode == nil || *result.ExitCode != 130 {
+		t.Fatalf("EchoExitStatus() ExitCode = %v, want 130", result.ExitCode)
+	}
+
+	wantSend := []string{"send-keys", "-t", "fake-session", "-l", "--", echo}
+	if got := runner.calls[2]; !reflect.DeepEqual(got, wantSend) {
+		t.Errorf("send-keys = %v, want %v", got, wantSend)
+	}
+}
+
+func TestWithExitSentinel(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "make", want: `make; echo "__EXIT__$?"`},
+		{command: "make ; ", want: `make ; echo "__EXIT__$?"`},
+		{command: "sleep 5 &", want: `sleep 5 & echo "__EXIT__$?"`},
+		{command: "a && b", want: `a && b; echo "__EXIT__$?"`},
+	}
+
+	for _, tt := range tests {
+		if got := withExitSentinel(tt.command, DefaultExitSentinel); got != tt.want {
+			t.Errorf("withExitSentinel(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestExtractExitCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		wantOutput string
+		wantCode   *int
+	}{
+		{name: "success", output: "ok\n__EXIT__0", wantOutput: "ok", wantCode: intPtr(0)},
+		{name: "failure", output: "__EXIT__127", wantOutput: "", wantCode: intPtr(127)},
+		{name: "missing", output: "still running", wantOutput: "still running", wantCode: nil},
+		{name: "not a number", output: "__EXIT__$?", wantOutput: "__EXIT__$?", wantCode: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, code := extractExitCode(tt.output, DefaultExitSentinel)
+			if output != tt.wantOutput {
+				t.Errorf("extractExitCode() output = %q, want %q", output, tt.wantOutput)
+			}
+			if !reflect.DeepEqual(code, tt.wantCode) {
+				t.Errorf("extractExitCode() code = %v, want %v", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestValidatePollInterval(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		wantErr  bool
+	}{
+		{interval: DefaultPollInterval},
+		{interval: MinPollInterval},
+		{interval: time.Millisecond, wantErr: true},
+		{interval: 0, wantErr: true},
+		{interval: -time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidatePollInterval(tt.interval); (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePollInterval(%s) error = %v, wantErr %v", tt.interval, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EnvVar is one variable in a tmux or process environment
+type EnvVar struct {
+	Name  string
+	Value string
+	// Removed marks a variable tmux removes from the global environment
+	// for this session ("-NAME" in show-environment), so new panes start
+	// without it
+	Removed bool
+}
+
+// SessionEnvironment returns the session's environment as shown by
+// show-environment: the variables tmux passes to new panes on top of the
+// global environment. The panes already running keep the environment they
+// started with; see PanePID for reading that from the process.
+func (m *Manager) SessionEnvironment() ([]EnvVar, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return nil, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	stdout, _, err := m.run("show-environment", "-t", m.sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to show environment: %w", err)
+	}
+	return parseShowEnvironment(stdout), nil
+}
+
+// parseShowEnvironment parses show-environment output: one NAME=value
+// line per variable, or -NAME for one removed from the session
+func parseShowEnvironment(out string) []EnvVar {
+	vars := []EnvVar{}
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "-"); ok {
+			vars = append(vars, EnvVar{Name: name, Removed: true})
+			continue
+		}
+		name, value, _ := strings.Cut(line, "=")
+		vars = append(vars, EnvVar{Name: name, Value: value})
+	}
+	return vars
+}
+
+// ParseEnviron parses the NUL-separated NAME=value list of a process
+// environment, as read from /proc/<pid>/environ
+func ParseEnviron(data []byte) []EnvVar {
+	vars := []EnvVar{}
+	for _, entry := range strings.Split(string(data), "\x00") {
+		if entry == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(entry, "=")
+		vars = append(vars, EnvVar{Name: name, Value: value})
+	}
+	return vars
+}
+
+// PanePID returns the process ID of the program tmux started in the pane
+// target selects, usually its shell (#{pane_pid})
+func (m *Manager) PanePID(target Target) (int, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return 0, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return 0, err
+	}
+
+	stdout, _, err := m.run("display-message", "-t", resolved, "-p", "#{pane_pid}")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pane pid: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(stdout))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected pane pid %q", strings.TrimSpace(stdout))
+	}
+	return pid, nil
+}
+
+// PaneID returns the ID of the pane target selects (#{pane_id}), e.g. "%3"
+func (m *Manager) PaneID(target Target) (string, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return "", fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return "", &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return "", err
+	}
+
+	stdout, _, err := m.run("display-message", "-t", resolved, "-p", "#{pane_id}")
+	if err != nil {
+		return "", fmt.Errorf("failed to get pane id: %w", err)
+	}
+	return strings.TrimSpace(stdout), nil
+}
@@ -0,0 +1,55 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManager_SessionEnvironment(t *testing.T) {
+	runner := newFakeRunner().on("show-environment", fakeResponse{
+		stdout: "DISPLAY=:0\n-SSH_AGENT_PID\nLS_OPTS=-l=a\n",
+	})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	got, err := m.SessionEnvironment()
+	if err != nil {
+		t.Fatalf("SessionEnvironment() error = %v", err)
+	}
+	want := []EnvVar{
+		{Name: "DISPLAY", Value: ":0"},
+		{Name: "SSH_AGENT_PID", Removed: true},
+		{Name: "LS_OPTS", Value: "-l=a"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SessionEnvironment() = %+v, want %+v", got, want)
+	}
+	if args := runner.lastCall("show-environment"); !reflect.DeepEqual(args, []string{"show-environment", "-t", "fake-session"}) {
+		t.Errorf("show-environment args = %v", args)
+	}
+}
+
+func TestParseEnviron(t *testing.T) {
+	got := ParseEnviron([]byte("HOME=/home/dev\x00PATH=/usr/bin:/bin\x00EMPTY=\x00"))
+	want := []EnvVar{
+		{Name: "HOME", Value: "/home/dev"},
+		{Name: "PATH", Value: "/usr/bin:/bin"},
+		{Name: "EMPTY"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseEnviron() = %+v, want %+v", got, want)
+	}
+}
+
+func TestManager_PanePID(t *testing.T) {
+	m := NewManagerWithRunner("fake-session", newFakeRunner().on("display-message", fakeResponse{stdout: "4242\n"}))
+	if pid, err := m.PanePID(Target{}); err != nil || pid != 4242 {
+		t.Errorf("PanePID() = %d, %v, want 4242", pid, err)
+	}
+}
+
+func TestManager_PaneID(t *testing.T) {
+	m := NewManagerWithRunner("fake-session", newFakeRunner().on("display-message", fakeResponse{stdout: "%3\n"}))
+	if id, err := m.PaneID(Target{}); err != nil || id != "%3" {
+		t.Errorf("PaneID() = %q, %v, want %%3", id, err)
+	}
+}
@@ -0,0 +1,200 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSessionNotFound is matched (via errors.Is) by the error returned when
+// the managed session does not exist
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionNotFoundError reports that the named session does not exist
+type SessionNotFoundError struct {
+	Session string
+}
+
+func (e *SessionNotFoundError) Error() string {
+	return fmt.Sprintf("session '%s' does not exist", e.Session)
+}
+
+// Is makes errors.Is(err, ErrSessionNotFound) succeed
+func (e *SessionNotFoundError) Is(target error) bool {
+	return target == ErrSessionNotFound
+}
+
+// ErrAmbiguousSession is matched (via errors.Is) by the error returned
+// when a session query matches more than one session
+var ErrAmbiguousSession = errors.New("ambiguous session")
+
+// AmbiguousSessionError reports that Query matches each of Candidates
+type AmbiguousSessionError struct {
+	Query      string
+	Candidates []string
+}
+
+func (e *AmbiguousSessionError) Error() string {
+	return fmt.Sprintf("session '%s' is ambiguous: it matches %s", e.Query, strings.Join(e.Candidates, ", "))
+}
+
+// Is makes errors.Is(err, ErrAmbiguousSession) succeed
+func (e *AmbiguousSessionError) Is(target error) bool {
+	return target == ErrAmbiguousSession
+}
+
+// ErrUnknownTarget is matched (via errors.Is) by the error returned when a
+// Target does not select any pane in the session
+var ErrUnknownTarget = errors.New("unknown target")
+
+// UnknownTargetError reports that no pane in the session matches Target
+type UnknownTargetError struct {
+	Session string
+	Target  Target
+}
+
+func (e *UnknownTargetError) Error() string {
+	return fmt.Sprintf("no %s in session '%s'", e.Target, e.Session)
+}
+
+// Is makes errors.Is(err, ErrUnknownTarget) succeed
+func (e *UnknownTargetError) Is(target error) bool {
+	return target == ErrUnknownTarget
+}
+
+// ErrUnknownOption is matched (via errors.Is) by the error returned when
+// tmux does not recognise an option name
+var ErrUnknownOption = errors.New("unknown option")
+
+// UnknownOptionError reports that tmux has no option called Name
+type UnknownOptionError struct {
+	Name string
+}
+
+func (e *UnknownOptionError) Error() string {
+	return fmt.Sprintf("unknown tmux option %q", e.Name)
+}
+
+// Is makes errors.Is(err, ErrUnknownOption) succeed
+func (e *UnknownOptionError) Is(target error) bool {
+	return target == ErrUnknownOption
+}
+
+// CommandError records a failed tmux invocation. Its message is that of the
+// underlying error so callers can keep wrapping it as before, while the
+// arguments and stderr stay available via errors.As.
+type CommandError struct {
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// transientMessages are stderr fragments tmux emits for failures that
+// usually clear up on their own, such as a server that is still starting
+// or a socket briefly held by another client. "Connection refused" is not
+// one: it means a stale socket left by a crashed server, which waiting
+// won't fix (see serverGoneMessages).
+var transientMessages = []string{
+	"server exited unexpectedly",
+	"lost server",
+	"Resource temporarily unavailable",
+	"Interrupted system call",
+}
+
+// isTransient reports whether a failed tmux invocation is worth retrying.
+// Only failures where tmux ran and complained about its server or socket
+// qualify; a missing session or bad arguments will fail the same way again.
+func isTransient(err error, stderr string) bool {
+	if err == nil || exitCode(err) < 0 {
+		return false
+	}
+	for _, msg := range transientMessages {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// serverGoneMessages are stderr fragments tmux emits when no server is
+// listening on the socket, e.g. after `tmux kill-server`. Older releases
+// say "no server running"; newer ones report why connecting failed: a
+// missing socket, or a stale one left by a server that crashed.
+var serverGoneMessages = []string{
+	"no server running",
+	"No such file or directory",
+	"Connection refused",
+}
+
+// isServerGone reports whether a failed tmux invocation found no server to
+// talk to. Unlike a transient failure this doesn't clear up by retrying; the
+// session has to be created again.
+func isServerGone(err error, stderr string) bool {
+	if err == nil || exitCode(err) < 0 {
+		return false
+	}
+	for _, msg := range serverGoneMessages {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrPaneDead is matched (via errors.Is) by the error returned when tmux
+// can't capture a pane because its process has exited
+var ErrPaneDead = errors.New("pane is dead")
+
+// PaneDeadError reports that capture-pane failed because the pane's process
+// exited and tmux no longer holds its content, as some tmux versions do for
+// panes that remain-on-exit didn't keep. Stale is the content of the last
+// successful capture of the same target, if HasStale is set.
+type PaneDeadError struct {
+	Target   string
+	Stale    string
+	HasStale bool
+	Err      error
+}
+
+func (e *PaneDeadError) Error() string {
+	return fmt.Sprintf("pane %s has exited and can no longer be captured", e.Target)
+}
+
+func (e *PaneDeadError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrPaneDead) succeed
+func (e *PaneDeadError) Is(target error) bool {
+	return target == ErrPaneDead
+}
+
+// paneDeadMessages are stderr fragments tmux emits when capture-pane is
+// asked for a pane whose process has exited. Targets are checked against
+// the live panes just before capturing, so a pane that can't be found by
+// then has closed in between.
+var paneDeadMessages = []string{
+	"pane is dead",
+	"can't find pane",
+}
+
+// isPaneDead reports whether a failed capture-pane found its pane gone
+func isPaneDead(err error, stderr string) bool {
+	if err == nil || exitCode(err) < 0 {
+		return false
+	}
+	for _, msg := range paneDeadMessages {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Grid is the visible area of a pane as a grid of cells. Rows holds Height
+// rows of Width cells each, addressed as Rows[row][column] from the top-left.
+// A cell holds one character together with any combining marks on it; a
+// blank cell holds " ". A wide character, such as a CJK ideograph, fills
+// its own cell and leaves the cell to its right as "".
+type Grid struct {
+	Width  int
+	Height int
+	Rows   [][]string
+	// PaneID is the pane captured, e.g. "%3"
+	PaneID string
+}
+
+// CaptureGrid captures the visible area of the pane selected by target
+// and lays it out cell by cell
+func (m *Manager) CaptureGrid(target Target) (*Grid, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return nil, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	// The size and the capture run in one tmux invocation so a resize in
+	// between can't make them disagree
+	stdout, _, err := m.run(
+		"display-message", "-t", resolved, "-p", "#{pane_width},#{pane_height},#{pane_id}", ";",
+		"capture-pane", "-t", resolved, "-p")
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture pane: %w", err)
+	}
+
+	size, content, _ := strings.Cut(stdout, "\n")
+	parts := strings.Split(size, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unexpected pane size format: %s", size)
+	}
+	width, errW := strconv.Atoi(parts[0])
+	height, errH := strconv.Atoi(parts[1])
+	if errW != nil || errH != nil || width < 0 || height < 0 {
+		return nil, fmt.Errorf("unexpected pane size format: %s", size)
+	}
+
+	grid := newGrid(content, width, height)
+	grid.PaneID = parts[2]
+	return grid, nil
+}
+
+// newGrid lays captured lines out as a width by height grid. tmux has
+// already expanded tabs to spaces and drops trailing blanks, so lines are
+// padded with blank cells; anything beyond the grid is cut off.
+func newGrid(content string, width, height int) *Grid {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	grid := &Grid{Width: width, Height: height, Rows: make([][]string, height)}
+	for y := range grid.Rows {
+		row := make([]string, width)
+		for x := range row {
+			row[x] = " "
+		}
+		if y < len(lines) {
+			fillRow(row, lines[y])
+		}
+		grid.Rows[y] = row
+	}
+	return grid
+}
+
+// fillRow writes the characters of line into row from the left
+func fillRow(row []string, line string) {
+	next, last := 0, -1
+	for _, r := range line {
+		w := runeWidth(r)
+		if w == 0 {
+			// A combining mark belongs to the character before it
+			if last >= 0 {
+				row[last] += string(r)
+			}
+			continue
+		}
+		if next+w > len(row) {
+			return
+		}
+		row[next] = string(r)
+		if w == 2 {
+			row[next+1] = ""
+		}
+		last, next = next, next+w
+	}
+}
+
+// wideRanges lists the code points terminals draw two cells wide: the
+// East Asian Wide and Fullwidth blocks and the emoji presentation ranges
+var wideRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2329, Hi: 0x232a, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23ec, Stride: 1},
+		{Lo: 0x25fd, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2615, Stride: 1},
+		{Lo: 0x26aa, Hi: 0x26ab, Stride: 1},
+		{Lo: 0x26bd, Hi: 0x26be, Stride: 1},
+		{Lo: 0x26c4, Hi: 0x26c5, Stride: 1},
+		{Lo: 0x2705, Hi: 0x2705, Stride: 1},
+		{Lo: 0x270a, Hi: 0x270b, Stride: 1},
+		{Lo: 0x274c, Hi: 0x274c, Stride: 1},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1},
+		{Lo: 0x3041, Hi: 0x33ff, Stride: 1},
+		{Lo: 0x3400, Hi: 0x4dbf, Stride: 1},
+		{Lo: 0x4e00, Hi: 0x9fff, Stride: 1},
+		{Lo: 0xa000, Hi: 0xa4cf, Stride: 1},
+		{Lo: 0xa960, Hi: 0xa97f, Stride: 1},
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1},
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1},
+		{Lo: 0xfe10, Hi: 0xfe19, Stride: 1},
+		{Lo: 0xfe30, Hi: 0xfe6f, Stride: 1},
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1},
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x16fe0, Hi: 0x18cff, Stride: 1},
+		{Lo: 0x1b000, Hi: 0x1b2ff, Stride: 1},
+		{Lo: 0x1f004, Hi: 0x1f004, Stride: 1},
+		{Lo: 0x1f0cf, Hi: 0x1f0cf, Stride: 1},
+		{Lo: 0x1f18e, Hi: 0x1f18e, Stride: 1},
+		{Lo: 0x1f191, Hi: 0x1f19a, Stride: 1},
+		{Lo: 0x1f200, Hi: 0x1f251, Stride: 1},
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f680, Hi: 0x1f6ff, Stride: 1},
+		{Lo: 0x1f7e0, Hi: 0x1f7eb, Stride: 1},
+		{Lo: 0x1f90c, Hi: 0x1f9ff, Stride: 1},
+		{Lo: 0x1fa70, Hi: 0x1faff, Stride: 1},
+		{Lo: 0x20000, Hi: 0x2fffd, Stride: 1},
+		{Lo: 0x30000, Hi: 0x3fffd, Stride: 1},
+	},
+}
+
+// runeWidth returns the number of cells r occupies: 0 for combining marks
+// and other zero-width characters, 2 for wide characters and 1 otherwise
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(wideRanges, r):
+		return 2
+	}
+	return 1
+}
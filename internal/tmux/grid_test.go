@@ -0,0 +1,114 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewGrid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		width   int
+		height  int
+		want    [][]string
+	}{
+		{
+			name:    "padded",
+			content: "ab\n\n",
+			width:   3,
+			height:  3,
+			want:    [][]string{{"a", "b", " "}, {" ", " ", " "}, {" ", " ", " "}},
+		},
+		{
+			name:    "wide characters",
+			content: "日x\n",
+			width:   4,
+			height:  1,
+			want:    [][]string{{"日", "", "x", " "}},
+		},
+		{
+			name:    "combining mark",
+			content: "éz\n",
+			width:   2,
+			height:  1,
+			want:    [][]string{{"é", "z"}},
+		},
+		{
+			name:    "wide character at the edge",
+			content: "ab本\n",
+			width:   3,
+			height:  1,
+			want:    [][]string{{"a", "b", " "}},
+		},
+		{
+			name:    "extra lines cut off",
+			content: "a\nb\nc\n",
+			width:   1,
+			height:  2,
+			want:    [][]string{{"a"}, {"b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newGrid(tt.content, tt.width, tt.height)
+			if got.Width != tt.width || got.Height != tt.height {
+				t.Errorf("newGrid() size = %dx%d, want %dx%d", got.Width, got.Height, tt.width, tt.height)
+			}
+			if !reflect.DeepEqual(got.Rows, tt.want) {
+				t.Errorf("newGrid() rows = %q, want %q", got.Rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_CaptureGrid(t *testing.T) {
+	runner := newFakeRunner().on("display-message", fakeResponse{stdout: "3,2,%0\n$ l\n\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	got, err := m.CaptureGrid(Target{})
+	if err != nil {
+		t.Fatalf("CaptureGrid() error = %v", err)
+	}
+	want := &Grid{Width: 3, Height: 2, Rows: [][]string{{"$", " ", "l"}, {" ", " ", " "}}, PaneID: "%0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CaptureGrid() = %+v, want %+v", got, want)
+	}
+
+	wantArgs := []string{
+		"display-message", "-t", "fake-session", "-p", "#{pane_width},#{pane_height},#{pane_id}", ";",
+		"capture-pane", "-t", "fake-session", "-p"}
+	if args := runner.lastCall("display-message"); !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("tmux args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestManager_CaptureGrid_Malformed(t *testing.T) {
+	runner := newFakeRunner().on("display-message", fakeResponse{stdout: "wide\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if _, err := m.CaptureGrid(Target{}); err == nil {
+		t.Error("CaptureGrid() with malformed size succeeded, want error")
+	}
+}
+
+func TestRuneWidth(t *testing.T) {
+	tests := map[rune]int{
+		'a':      1,
+		'é':      1,
+		'─':      1,
+		'日':      2,
+		'한':      2,
+		'Ａ':      2,
+		'🚀':      2,
+		'\u0301': 0, // combining acute accent
+		'\u200b': 0, // zero width space
+	}
+
+	for r, want := range tests {
+		if got := runeWidth(r); got != want {
+			t.Errorf("runeWidth(%q) = %d, want %d", r, got, want)
+		}
+	}
+}
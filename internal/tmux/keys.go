@@ -0,0 +1,76 @@
+package tmux
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// KeyBinding is one key binding listed by tmux list-keys
+type KeyBinding struct {
+	// Table is the key table holding the binding, e.g. "prefix" for keys
+	// pressed after the prefix, "root" for keys bound without it, or
+	// "copy-mode-vi"
+	Table string
+	// Key is the key in tmux notation, unescaped, e.g. "C-b" or "\""
+	Key string
+	// Command is the tmux command the key runs
+	Command string
+	// Repeat is set for bindings made with bind-key -r, which can be
+	// pressed again without the prefix for a short while
+	Repeat bool
+}
+
+// bindingLine matches a line of list-keys output, such as
+// "bind-key -r -T prefix Up select-pane -U"
+var bindingLine = regexp.MustCompile(`^bind-key\s+(-r\s+)?-T\s+(\S+)\s+(\S+)\s+(.*)$`)
+
+// ListKeys returns tmux's key bindings, only those in table unless it is
+// empty. Bindings are global to the tmux server, so they are the same for
+// every session.
+func (m *Manager) ListKeys(table string) ([]KeyBinding, error) {
+	args := []string{"list-keys"}
+	if table != "" {
+		args = append(args, "-T", table)
+	}
+	stdout, stderr, err := m.run(args...)
+	if err != nil {
+		if strings.Contains(stderr, "doesn't exist") {
+			return nil, fmt.Errorf("unknown key table %q", table)
+		}
+		return nil, fmt.Errorf("failed to list keys: %w (stderr: %s)", err, stderr)
+	}
+	return parseKeyBindings(stdout), nil
+}
+
+// parseKeyBindings reads list-keys output. Lines that aren't bindings are
+// skipped.
+func parseKeyBindings(output string) []KeyBinding {
+	bindings := []KeyBinding{}
+	for _, line := range strings.Split(output, "\n") {
+		match := bindingLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		bindings = append(bindings, KeyBinding{
+			Table:   match[2],
+			Key:     unescapeKey(match[3]),
+			Command: strings.TrimSpace(match[4]),
+			Repeat:  match[1] != "",
+		})
+	}
+	return bindings
+}
+
+// unescapeKey undoes the quoting list-keys applies to keys that are
+// special to tmux's command parser: a backslash before the character
+// (\; \" \#) in recent versions, quotes ('"' "'") in older ones
+func unescapeKey(key string) string {
+	if len(key) == 2 && key[0] == '\\' {
+		return key[1:]
+	}
+	if len(key) == 3 && (key[0] == '"' || key[0] == '\'') && key[2] == key[0] {
+		return key[1:2]
+	}
+	return key
+}
@@ -0,0 +1,51 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeyBindings(t *testing.T) {
+	output := `bind-key    -T copy-mode-vi C-b               send-keys -X page-up
+bind-key    -T prefix       \"                split-window
+bind-key    -T prefix       \;                last-pane
+bind-key    -T prefix       '#'               list-buffers
+bind-key -r -T prefix       Up                select-pane -U
+bind-key    -T root         MouseDown1Pane    select-pane -t = \; send-keys -M
+`
+	want := []KeyBinding{
+		{Table: "copy-mode-vi", Key: "C-b", Command: "send-keys -X page-up"},
+		{Table: "prefix", Key: `"`, Command: "split-window"},
+		{Table: "prefix", Key: ";", Command: "last-pane"},
+		{Table: "prefix", Key: "#", Command: "list-buffers"},
+		{Table: "prefix", Key: "Up", Command: "select-pane -U", Repeat: true},
+		{Table: "root", Key: "MouseDown1Pane", Command: `select-pane -t = \; send-keys -M`},
+	}
+	if got := parseKeyBindings(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeyBindings() = %+v, want %+v", got, want)
+	}
+}
+
+func TestManager_ListKeys(t *testing.T) {
+	runner := newFakeRunner().
+		on("list-keys", fakeResponse{stdout: "bind-key -T prefix c new-window\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	bindings, err := m.ListKeys("prefix")
+	if err != nil {
+		t.Fatalf("ListKeys() error = %v", err)
+	}
+	if len(bindings) != 1 || bindings[0].Key != "c" {
+		t.Errorf("ListKeys() = %+v", bindings)
+	}
+	if got, want := runner.lastCall("list-keys"), []string{"list-keys", "-T", "prefix"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("list-keys args = %q, want %q", got, want)
+	}
+
+	runner = newFakeRunner().
+		on("list-keys", fakeResponse{stderr: "table nope doesn't exist\n", err: exitError(1)})
+	m = NewManagerWithRunner("fake-session", runner)
+	if _, err := m.ListKeys("nope"); err == nil || err.Error() != `unknown key table "nope"` {
+		t.Errorf("ListKeys(nope) error = %v, want an unknown table error", err)
+	}
+}
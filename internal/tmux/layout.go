@@ -0,0 +1,207 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Directions a LayoutCell can be split in
+const (
+	// LayoutLeftRight places a cell's children side by side, left to right
+	LayoutLeftRight = "left-right"
+	// LayoutTopBottom stacks a cell's children top to bottom
+	LayoutTopBottom = "top-bottom"
+)
+
+// LayoutCell is one node of a window's layout tree: either a pane, or a
+// region split among Children. Positions are in cells from the top-left of
+// the window.
+type LayoutCell struct {
+	Width  int
+	Height int
+	X      int
+	Y      int
+	// Split is LayoutLeftRight or LayoutTopBottom for a split region, and
+	// empty for a pane
+	Split    string
+	Children []LayoutCell
+	// PaneID is the pane's ID, such as "%3". It is empty for split regions
+	// and in layouts from tmux releases that didn't record pane IDs.
+	PaneID string
+}
+
+// WindowLayout returns the layout of the window holding the pane target
+// selects, parsed from #{window_layout}, along with the raw layout string
+func (m *Manager) WindowLayout(target Target) (LayoutCell, string, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return LayoutCell{}, "", fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return LayoutCell{}, "", &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return LayoutCell{}, "", err
+	}
+
+	stdout, _, err := m.run("display-message", "-t", resolved, "-p", "#{window_layout}")
+	if err != nil {
+		return LayoutCell{}, "", fmt.Errorf("failed to get window layout: %w", err)
+	}
+	layout := strings.TrimSpace(stdout)
+	root, err := ParseLayout(layout)
+	if err != nil {
+		return LayoutCell{}, "", err
+	}
+	return root, layout, nil
+}
+
+// ParseLayout parses a tmux window layout string such as
+// "bb62,159x48,0,0{79x48,0,0,1,79x48,80,0,2}": a checksum of the rest of
+// the string, then the window's cell. A cell is "WxH,X,Y" followed by a
+// pane number for a pane, or by its children in {} (side by side) or []
+// (stacked).
+func ParseLayout(layout string) (LayoutCell, error) {
+	sum, body, ok := strings.Cut(layout, ",")
+	if !ok || len(sum) != 4 {
+		return LayoutCell{}, fmt.Errorf("invalid layout %q: missing checksum", layout)
+	}
+	want, err := strconv.ParseUint(sum, 16, 16)
+	if err != nil {
+		return LayoutCell{}, fmt.Errorf("invalid layout %q: bad checksum %q", layout, sum)
+	}
+	if got := layoutChecksum(body); uint64(got) != want {
+		return LayoutCell{}, fmt.Errorf("invalid layout %q: checksum %04x does not match %s", layout, got, sum)
+	}
+
+	p := layoutParser{s: body}
+	cell, err := p.cell()
+	if err != nil {
+		return LayoutCell{}, fmt.Errorf("invalid layout %q: %w", layout, err)
+	}
+	if p.pos != len(p.s) {
+		return LayoutCell{}, fmt.Errorf("invalid layout %q: unexpected %q at offset %d", layout, p.s[p.pos:], p.pos)
+	}
+	return cell, nil
+}
+
+// layoutChecksum computes the checksum tmux prefixes to a layout, as in
+// tmux's layout_checksum: a 16-bit rotate-right and add over each byte
+func layoutChecksum(s string) uint16 {
+	var sum uint16
+	for i := 0; i < len(s); i++ {
+		sum = (sum >> 1) + ((sum & 1) << 15)
+		sum += uint16(s[i])
+	}
+	return sum
+}
+
+// layoutParser reads a layout string after its checksum
+type layoutParser struct {
+	s   string
+	pos int
+}
+
+// cell parses one cell and, recursively, its children
+func (p *layoutParser) cell() (LayoutCell, error) {
+	var cell LayoutCell
+	var err error
+	if cell.Width, err = p.number(); err != nil {
+		return LayoutCell{}, err
+	}
+	if err := p.expect('x'); err != nil {
+		return LayoutCell{}, err
+	}
+	if cell.Height, err = p.number(); err != nil {
+		return LayoutCell{}, err
+	}
+	if err := p.expect(','); err != nil {
+		return LayoutCell{}, err
+	}
+	if cell.X, err = p.number(); err != nil {
+		return LayoutCell{}, err
+	}
+	if err := p.expect(','); err != nil {
+		return LayoutCell{}, err
+	}
+	if cell.Y, err = p.number(); err != nil {
+		return LayoutCell{}, err
+	}
+
+	switch {
+	case p.peek() == '{' || p.peek() == '[':
+		open := p.s[p.pos]
+		closing, split := byte('}'), LayoutLeftRight
+		if open == '[' {
+			closing, split = ']', LayoutTopBottom
+		}
+		p.pos++
+		cell.Split = split
+		for {
+			child, err := p.cell()
+			if err != nil {
+				return LayoutCell{}, err
+			}
+			cell.Children = append(cell.Children, child)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if err := p.expect(closing); err != nil {
+			return LayoutCell{}, err
+		}
+	case p.peek() == ',' && p.paneNumberFollows():
+		p.pos++
+		id, err := p.number()
+		if err != nil {
+			return LayoutCell{}, err
+		}
+		cell.PaneID = "%" + strconv.Itoa(id)
+	}
+	return cell, nil
+}
+
+// paneNumberFollows reports whether the "," at the current position
+// introduces a pane number rather than the next sibling cell, whose width
+// is followed by "x"
+func (p *layoutParser) paneNumberFollows() bool {
+	i := p.pos + 1
+	for i < len(p.s) && p.s[i] >= '0' && p.s[i] <= '9' {
+		i++
+	}
+	return i > p.pos+1 && (i == len(p.s) || p.s[i] != 'x')
+}
+
+// number parses an unsigned decimal number
+func (p *layoutParser) number() (int, error) {
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, fmt.Errorf("expected a number at offset %d", start)
+	}
+	return strconv.Atoi(p.s[start:p.pos])
+}
+
+// expect consumes c or fails
+func (p *layoutParser) expect(c byte) error {
+	if p.peek() != c {
+		return fmt.Errorf("expected %q at offset %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+// peek returns the next byte, or 0 at the end of the string
+func (p *layoutParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
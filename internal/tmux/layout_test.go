@@ -0,0 +1,73 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  string
+		want    LayoutCell
+		wantErr bool
+	}{
+		{
+			name:   "single pane",
+			layout: "b262,80x24,0,0,5",
+			want:   LayoutCell{Width: 80, Height: 24, PaneID: "%5"},
+		},
+		{
+			name:   "nested splits",
+			layout: "1780,80x24,0,0{40x24,0,0,1,39x24,41,0[39x12,41,0,2,39x11,41,13,3]}",
+			want: LayoutCell{Width: 80, Height: 24, Split: LayoutLeftRight, Children: []LayoutCell{
+				{Width: 40, Height: 24, PaneID: "%1"},
+				{Width: 39, Height: 24, X: 41, Split: LayoutTopBottom, Children: []LayoutCell{
+					{Width: 39, Height: 12, X: 41, PaneID: "%2"},
+					{Width: 39, Height: 11, X: 41, Y: 13, PaneID: "%3"},
+				}},
+			}},
+		},
+		{
+			// Layouts from before tmux recorded pane IDs
+			name:   "without pane ids",
+			layout: "bb62,159x48,0,0{79x48,0,0,79x48,80,0}",
+			want: LayoutCell{Width: 159, Height: 48, Split: LayoutLeftRight, Children: []LayoutCell{
+				{Width: 79, Height: 48},
+				{Width: 79, Height: 48, X: 80},
+			}},
+		},
+		{name: "checksum mismatch", layout: "0000,80x24,0,0,5", wantErr: true},
+		{name: "missing checksum", layout: "80x24,0,0,5", wantErr: true},
+		{name: "unterminated split", layout: "bb62,159x48,0,0{79x48,0,0,79x48,80,0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLayout(tt.layout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLayout() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_WindowLayout(t *testing.T) {
+	runner := newFakeRunner().on("display-message", fakeResponse{stdout: "b262,80x24,0,0,5\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	root, raw, err := m.WindowLayout(Target{})
+	if err != nil {
+		t.Fatalf("WindowLayout() error = %v", err)
+	}
+	if raw != "b262,80x24,0,0,5" || root.PaneID != "%5" {
+		t.Errorf("WindowLayout() = %+v, %q", root, raw)
+	}
+	want := []string{"display-message", "-t", "fake-session", "-p", "#{window_layout}"}
+	if got := runner.lastCall("display-message"); !reflect.DeepEqual(got, want) {
+		t.Errorf("display-message args = %v, want %v", got, want)
+	}
+}
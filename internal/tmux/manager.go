@@ -1,35 +1,184 @@
 package tmux
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 )
 
 const (
 	SessionPrefix = "mcp-wingman"
+
+	// AllLines requests the entire scrollback history from GetScrollbackHistory
+	AllLines = -1
+
+	// DefaultMaxAttempts is how many times a read-only tmux invocation is
+	// tried when it fails with a transient error
+	DefaultMaxAttempts = 3
+
+	// retryBaseDelay is the wait before the first retry; it doubles after
+	// each further failed attempt
+	retryBaseDelay = 50 * time.Millisecond
 )
 
 // Manager handles tmux session management
 type Manager struct {
 	sessionName string
+	runner      CommandRunner
+	maxAttempts int
+	retryDelay  time.Duration
+
+	// captureArgs are extra flags added to every capture-pane invocation
+	captureArgs []string
+
+	// normalizeNewlines applies NormalizeNewlines to captured content
+	normalizeNewlines bool
+
+	// startupCommands are typed into a session when EnsureSession creates
+	// it
+	startupCommands []string
+
+	// serverGone is set when a command finds no tmux server running, so
+	// the next EnsureConnected recreates the session
+	serverGone bool
+
+	// focused is the ID of the pane chosen with FocusPane. While that pane
+	// exists, the zero Target selects it instead of the session's active
+	// pane.
+	focused string
+
+	// lastCaptures holds the most recent content captured from each live
+	// target, so a pane that exits before it can be read again still has
+	// its output reported (see PaneDeadError). Entries go once the pane is
+	// reported dead or is missing from a pane listing.
+	lastCaptures map[string]lastCapture
+}
+
+// lastCapture is the content of a target's latest capture, with the flags
+// it was taken with, so it is only reported to a capture asking for the
+// same flags
+type lastCapture struct {
+	flags   string
+	content string
 }
 
 // NewManager creates a new tmux manager
 func NewManager(sessionName string) *Manager {
+	return NewManagerWithRunner(sessionName, execRunner{})
+}
+
+// NewManagerWithRunner creates a new tmux manager that executes tmux
+// commands through runner instead of the tmux binary
+func NewManagerWithRunner(sessionName string, runner CommandRunner) *Manager {
 	if sessionName == "" {
 		sessionName = SessionPrefix
 	}
 	return &Manager{
-		sessionName: sessionName,
+		sessionName:       sessionName,
+		runner:            runner,
+		maxAttempts:       DefaultMaxAttempts,
+		retryDelay:        retryBaseDelay,
+		normalizeNewlines: true,
 	}
 }
 
+// Runner returns the runner the manager executes tmux through
+func (m *Manager) Runner() CommandRunner {
+	return m.runner
+}
+
+// SetRunner replaces the runner the manager executes tmux through, e.g.
+// to wrap it in a RecordingRunner
+func (m *Manager) SetRunner(runner CommandRunner) {
+	m.runner = runner
+}
+
+// SetMaxAttempts sets how many times a read-only tmux invocation is tried
+// when it fails with a transient error. Values below 1 disable retrying.
+func (m *Manager) SetMaxAttempts(attempts int) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	m.maxAttempts = attempts
+}
+
+// readOnlyCommands are the tmux subcommands that only query the server, so
+// running one twice is harmless. Anything else, above all send-keys, may
+// already have taken effect when tmux reports a transient failure, and a
+// retry would type the keys or make the change a second time.
+var readOnlyCommands = map[string]bool{
+	"capture-pane":     true,
+	"display-message":  true,
+	"has-session":      true,
+	"list-buffers":     true,
+	"list-clients":     true,
+	"list-keys":        true,
+	"list-panes":       true,
+	"list-sessions":    true,
+	"list-windows":     true,
+	"ls":               true,
+	"show-buffer":      true,
+	"show-environment": true,
+	"show-options":     true,
+}
+
+// run executes tmux through the runner. A read-only command is retried
+// with exponential backoff while it fails with a transient error; any other
+// runs once.
+func (m *Manager) run(args ...string) (stdout, stderr string, err error) {
+	attempts := 1
+	if len(args) > 0 && readOnlyCommands[args[0]] {
+		attempts = m.maxAttempts
+	}
+	delay := m.retryDelay
+	for attempt := 1; ; attempt++ {
+		stdout, stderr, err = m.runner.Run(args...)
+		if attempt >= attempts || !isTransient(err, stderr) {
+			if isServerGone(err, stderr) {
+				m.serverGone = true
+			}
+			if err != nil {
+				err = &CommandError{Args: args, Stderr: stderr, Err: err}
+			}
+			return stdout, stderr, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// SessionName returns the name of the tmux session the manager targets
+func (m *Manager) SessionName() string {
+	return m.sessionName
+}
+
+// SetSession points the manager at another existing session, so later
+// calls target it. The focused pane belongs to the old session and is
+// forgotten. Unlike NewManager, nothing is created: the session must
+// already exist.
+func (m *Manager) SetSession(name string) error {
+	sessions, err := m.Sessions()
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session == name {
+			m.sessionName = name
+			m.focused = ""
+			return nil
+		}
+	}
+	return &SessionNotFoundError{Session: name}
+}
+
 // EnsureSession ensures a tmux session exists, creating it if necessary
 func (m *Manager) EnsureSession() error {
 	// First check if tmux is installed
-	if err := checkTmuxInstalled(); err != nil {
+	if err := checkInstalled(m.runner); err != nil {
 		return err
 	}
 
@@ -41,24 +190,68 @@ func (m *Manager) EnsureSession() error {
 
 	if !exists {
 		// Create new session in detached mode
-		cmd := exec.Command("tmux", "new-session", "-d", "-s", m.sessionName)
-		var stderr bytes.Buffer
-		cmd.Stderr = &stderr
+		_, stderr, err := m.run("new-session", "-d", "-s", m.sessionName)
+		if err != nil {
+			return fmt.Errorf("failed to create tmux session '%s': %w (stderr: %s)", m.sessionName, err, stderr)
+		}
+		if err := m.runStartupCommands(); err != nil {
+			return err
+		}
+	}
 
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to create tmux session '%s': %w (stderr: %s)", m.sessionName, err, stderr.String())
+	m.serverGone = false
+	return nil
+}
+
+// SetStartupCommands sets commands to type into the session, each followed
+// by Enter, whenever EnsureSession has to create it. They never run in a
+// session that already exists, so attaching can't disturb someone's work.
+func (m *Manager) SetStartupCommands(commands []string) error {
+	for _, command := range commands {
+		if err := ValidateCommandLine(command); err != nil {
+			return fmt.Errorf("invalid startup command %q: %w", command, err)
 		}
 	}
+	m.startupCommands = append([]string(nil), commands...)
+	return nil
+}
 
+// runStartupCommands types the startup commands into a new session. The
+// shell may still be starting, but the pane buffers the keystrokes until
+// it reads them.
+func (m *Manager) runStartupCommands() error {
+	for _, command := range m.startupCommands {
+		if err := m.sendText(m.sessionName, command); err != nil {
+			return fmt.Errorf("failed to run startup command %q: %w", command, err)
+		}
+		if err := m.sendKeys(m.sessionName, "Enter"); err != nil {
+			return fmt.Errorf("failed to run startup command %q: %w", command, err)
+		}
+	}
 	return nil
 }
 
+// EnsureConnected re-runs EnsureSession if an earlier command found the
+// tmux server gone (for example after `tmux kill-server`), so the manager
+// recovers once a server is available again instead of failing from then
+// on. It does nothing while the server is reachable.
+func (m *Manager) EnsureConnected() error {
+	if !m.serverGone {
+		return nil
+	}
+	return m.EnsureSession()
+}
+
 // checkTmuxInstalled verifies that tmux is installed and accessible
 func checkTmuxInstalled() error {
-	cmd := exec.Command("tmux", "-V")
-	err := cmd.Run()
+	return checkInstalled(execRunner{})
+}
+
+// checkInstalled verifies that runner can execute tmux
+func checkInstalled(runner CommandRunner) error {
+	_, _, err := runner.Run("-V")
 	if err != nil {
-		if _, ok := err.(*exec.ExitError); ok {
+		if exitCode(err) >= 0 {
 			return fmt.Errorf("tmux is not installed or not in PATH")
 		}
 		return fmt.Errorf("failed to verify tmux installation: %w", err)
@@ -66,129 +259,830 @@ func checkTmuxInstalled() error {
 	return nil
 }
 
+// Version returns the tmux version string reported by `tmux -V`,
+// e.g. "tmux 3.4"
+func Version() (string, error) {
+	return tmuxVersion(execRunner{})
+}
+
+// Version reports the version of tmux behind the manager's runner, which
+// also shows whether tmux can be run at all
+func (m *Manager) Version() (string, error) {
+	return tmuxVersion(m.runner)
+}
+
+// tmuxVersion asks runner for the tmux version string
+func tmuxVersion(runner CommandRunner) (string, error) {
+	stdout, stderr, err := runner.Run("-V")
+	if err != nil {
+		return "", fmt.Errorf("failed to run tmux -V: %w (stderr: %s)", err, strings.TrimSpace(stderr))
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// exitCode returns the process exit status carried by err, or -1 if err
+// does not describe a process that ran and exited
+func exitCode(err error) int {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
 // SessionExists checks if the tmux session exists
 func (m *Manager) SessionExists() (bool, error) {
-	cmd := exec.Command("tmux", "has-session", "-t", m.sessionName)
-	err := cmd.Run()
+	_, _, err := m.run("has-session", "-t", m.sessionName)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			// Exit code 1 means session doesn't exist
-			if exitErr.ExitCode() == 1 {
-				return false, nil
-			}
+		// Exit code 1 means session doesn't exist
+		if exitCode(err) == 1 {
+			return false, nil
 		}
 		return false, err
 	}
 	return true, nil
 }
 
+// CaptureOptions controls how pane content is captured
+type CaptureOptions struct {
+	// EscapeSequences preserves text and background attributes as ANSI
+	// escape sequences (capture-pane -e)
+	EscapeSequences bool
+	// JoinLines joins wrapped lines back into the logical lines the
+	// program printed (capture-pane -J)
+	JoinLines bool
+	// Target selects the pane to capture; the zero value captures the
+	// session's active pane
+	Target Target
+	// KeepPromptMarks keeps the OSC 133 prompt markers that an escape
+	// sequence capture can contain, which are otherwise stripped (see
+	// StripPromptMarks)
+	KeepPromptMarks bool
+}
+
+// safeCaptureFlags are the capture-pane flags accepted by SetCaptureArgs.
+// None of them take a value, change the pane being captured or send the
+// output anywhere but stdout.
+const safeCaptureFlags = "aCeJNPqT"
+
+// ParseCaptureArgs splits a space-separated list of extra capture-pane
+// flags (e.g. "-N -a") and checks that each is in the safe set
+func ParseCaptureArgs(s string) ([]string, error) {
+	args := strings.Fields(s)
+	if err := validateCaptureArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// validateCaptureArgs rejects anything but flags from safeCaptureFlags,
+// alone or combined (e.g. "-NC")
+func validateCaptureArgs(args []string) error {
+	for _, arg := range args {
+		flags, ok := strings.CutPrefix(arg, "-")
+		if !ok || flags == "" {
+			return fmt.Errorf("invalid capture argument %q: only flags are allowed", arg)
+		}
+		for _, flag := range flags {
+			if !strings.ContainsRune(safeCaptureFlags, flag) {
+				return fmt.Errorf("capture flag -%c is not allowed (allowed: -%s)", flag,
+					strings.Join(strings.Split(safeCaptureFlags, ""), " -"))
+			}
+		}
+	}
+	return nil
+}
+
+// SetCaptureArgs adds extra flags to every capture-pane invocation, for
+// setups that need behaviour the structured CaptureOptions don't cover.
+// Only flags that take no value and leave the target and output alone are
+// accepted (see ParseCaptureArgs).
+func (m *Manager) SetCaptureArgs(args []string) error {
+	if err := validateCaptureArgs(args); err != nil {
+		return err
+	}
+	m.captureArgs = append([]string(nil), args...)
+	return nil
+}
+
+// SetNormalizeNewlines sets whether captured content is passed through
+// NormalizeNewlines, which it is by default
+func (m *Manager) SetNormalizeNewlines(enabled bool) {
+	m.normalizeNewlines = enabled
+}
+
+// normalize applies the manager's newline handling to captured content
+// and strips prompt markers unless opts keeps them
+func (m *Manager) normalize(content string, opts CaptureOptions) string {
+	if !opts.KeepPromptMarks {
+		content = StripPromptMarks(content)
+	}
+	if !m.normalizeNewlines {
+		return content
+	}
+	return NormalizeNewlines(content)
+}
+
+// captureFlags returns the flags for a capture: those selected by opts
+// followed by the configured extra arguments
+func (m *Manager) captureFlags(opts CaptureOptions) []string {
+	return append(opts.args(), m.captureArgs...)
+}
+
+// args returns the capture-pane flags selected by opts
+func (o CaptureOptions) args() []string {
+	var args []string
+	if o.EscapeSequences {
+		args = append(args, "-e")
+	}
+	if o.JoinLines {
+		args = append(args, "-J")
+	}
+	return args
+}
+
 // CapturePane captures the current pane content
 func (m *Manager) CapturePane() (string, error) {
+	return m.CapturePaneWithOptions(CaptureOptions{})
+}
+
+// CapturePaneWithOptions captures the current pane content as described by opts
+func (m *Manager) CapturePaneWithOptions(opts CaptureOptions) (string, error) {
 	// First verify the session exists
 	exists, err := m.SessionExists()
 	if err != nil {
 		return "", fmt.Errorf("failed to check session: %w", err)
 	}
 	if !exists {
-		return "", fmt.Errorf("session '%s' does not exist", m.sessionName)
+		return "", &SessionNotFoundError{Session: m.sessionName}
 	}
 
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
+	target, err := m.resolveTarget(opts.Target)
+	if err != nil {
+		return "", err
+	}
+	return m.capture(target, opts)
+}
 
-	cmd := exec.Command("tmux", "capture-pane", "-t", m.sessionName, "-p", "-S", "-")
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// capture runs capture-pane on an already resolved target. If tmux can't
+// capture the pane because its process has exited, the error is a
+// PaneDeadError carrying the target's last captured content.
+func (m *Manager) capture(target string, opts CaptureOptions) (string, error) {
+	flags := m.captureFlags(opts)
+	args := append([]string{"capture-pane", "-t", target, "-p", "-S", "-"}, flags...)
+	key := fmt.Sprintf("%s %t", strings.Join(flags, " "), opts.KeepPromptMarks)
 
-	err = cmd.Run()
+	stdout, stderr, err := m.run(args...)
 	if err != nil {
-		return "", fmt.Errorf("failed to capture pane: %w (stderr: %s)", err, stderr.String())
+		if isPaneDead(err, stderr) {
+			last, ok := m.lastCaptures[target]
+			delete(m.lastCaptures, target)
+			ok = ok && last.flags == key
+			return "", &PaneDeadError{Target: target, Stale: last.content, HasStale: ok, Err: err}
+		}
+		return "", fmt.Errorf("failed to capture pane: %w (stderr: %s)", err, stderr)
 	}
 
-	return stdout.String(), nil
+	stdout = m.normalize(stdout, opts)
+	if m.lastCaptures == nil {
+		m.lastCaptures = map[string]lastCapture{}
+	}
+	m.lastCaptures[target] = lastCapture{flags: key, content: stdout}
+	return stdout, nil
 }
 
-// GetPaneInfo returns information about the current pane
+// forgetCaptures drops the last captures of pane IDs that aren't among
+// panes, which have closed since they were read
+func (m *Manager) forgetCaptures(panes []PaneInfo) {
+	for target := range m.lastCaptures {
+		if !strings.HasPrefix(target, "%") {
+			continue
+		}
+		if !slices.ContainsFunc(panes, func(pane PaneInfo) bool { return pane.ID == target }) {
+			delete(m.lastCaptures, target)
+		}
+	}
+}
+
+// GetPaneInfo returns information about the current pane. pane_dead is
+// "1" when the pane's process has exited (and remain-on-exit kept the pane
+// open), in which case pane_dead_status holds its exit status.
 func (m *Manager) GetPaneInfo() (map[string]string, error) {
+	return m.GetPaneInfoFor(Target{})
+}
+
+// GetPaneInfoFor returns information about the pane selected by target
+func (m *Manager) GetPaneInfoFor(target Target) (map[string]string, error) {
 	// First verify the session exists
 	exists, err := m.SessionExists()
 	if err != nil {
 		return nil, fmt.Errorf("failed to check session: %w", err)
 	}
 	if !exists {
-		return nil, fmt.Errorf("session '%s' does not exist", m.sessionName)
+		return nil, &SessionNotFoundError{Session: m.sessionName}
 	}
 
-	var stdout bytes.Buffer
-
-	// Get pane format info: width, height, current path, pane index
-	cmd := exec.Command("tmux", "display-message",
-		"-t", m.sessionName,
-		"-p", "#{pane_width},#{pane_height},#{pane_current_path},#{pane_index}")
-	cmd.Stdout = &stdout
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return nil, err
+	}
 
-	err = cmd.Run()
+	// Get pane format info: width, height, current path, pane index,
+	// whether the pane's process has exited and whether a full-screen
+	// program has switched it to the alternate screen
+	stdout, _, err := m.run("display-message",
+		"-t", resolved,
+		"-p", "#{pane_width},#{pane_height},#{pane_current_path},#{pane_index},#{pane_dead},#{pane_dead_status},#{alternate_on}")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pane info: %w", err)
 	}
 
-	parts := strings.Split(strings.TrimSpace(stdout.String()), ",")
-	if len(parts) < 4 {
-		return nil, fmt.Errorf("unexpected pane info format: %s", stdout.String())
+	parts := strings.Split(strings.TrimSpace(stdout), ",")
+	if len(parts) < 7 {
+		return nil, fmt.Errorf("unexpected pane info format: %s", stdout)
 	}
+	// The path may itself contain commas, so take the fixed fields from
+	// either end
+	n := len(parts)
 
 	return map[string]string{
-		"width":        parts[0],
-		"height":       parts[1],
-		"current_path": parts[2],
-		"pane_index":   parts[3],
+		"width":            parts[0],
+		"height":           parts[1],
+		"current_path":     strings.Join(parts[2:n-4], ","),
+		"pane_index":       parts[n-4],
+		"pane_dead":        parts[n-3],
+		"pane_dead_status": parts[n-2],
+		"alternate_on":     parts[n-1],
 	}, nil
 }
 
-// GetScrollbackHistory gets the scrollback history from the pane
+// Cursor is the cursor position within a pane. X and Y are zero-based and
+// relative to the top-left of the visible area, which is the last
+// PaneHeight lines of a capture.
+type Cursor struct {
+	X          int
+	Y          int
+	PaneHeight int
+}
+
+// GetCursor returns the cursor position in the pane selected by target
+func (m *Manager) GetCursor(target Target) (Cursor, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return Cursor{}, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return Cursor{}, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	stdout, _, err := m.run("display-message", "-t", resolved, "-p", "#{cursor_x},#{cursor_y},#{pane_height}")
+	if err != nil {
+		return Cursor{}, fmt.Errorf("failed to get cursor position: %w", err)
+	}
+
+	parts := strings.Split(strings.TrimSpace(stdout), ",")
+	if len(parts) != 3 {
+		return Cursor{}, fmt.Errorf("unexpected cursor format: %s", stdout)
+	}
+	var nums [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Cursor{}, fmt.Errorf("unexpected cursor format: %s", stdout)
+		}
+		nums[i] = n
+	}
+
+	return Cursor{X: nums[0], Y: nums[1], PaneHeight: nums[2]}, nil
+}
+
+// ScrollState describes whether a pane is in a mode such as copy-mode, in
+// which a person may have scrolled it back through its history
+type ScrollState struct {
+	InMode bool
+	// Mode is the mode's name, e.g. "copy-mode", or empty outside a mode
+	Mode string
+	// Position is how many lines the view is scrolled back from the
+	// bottom; 0 outside copy-mode
+	Position int
+}
+
+// GetScrollState returns the mode and scroll position of the pane selected
+// by target. Captures are unaffected by it: capture-pane reads the pane's
+// live contents however far a person has scrolled back in copy-mode.
+func (m *Manager) GetScrollState(target Target) (ScrollState, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return ScrollState{}, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return ScrollState{}, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return ScrollState{}, err
+	}
+
+	stdout, _, err := m.run("display-message", "-t", resolved, "-p", "#{pane_in_mode}\t#{pane_mode}\t#{scroll_position}")
+	if err != nil {
+		return ScrollState{}, fmt.Errorf("failed to get scroll state: %w", err)
+	}
+
+	fields := strings.Split(strings.TrimRight(stdout, "\n"), "\t")
+	if len(fields) != 3 {
+		return ScrollState{}, fmt.Errorf("unexpected scroll state format: %s", stdout)
+	}
+	state := ScrollState{InMode: fields[0] == "1", Mode: fields[1]}
+	// scroll_position is empty outside copy-mode
+	if fields[2] != "" {
+		if state.Position, err = strconv.Atoi(fields[2]); err != nil {
+			return ScrollState{}, fmt.Errorf("unexpected scroll state format: %s", stdout)
+		}
+	}
+	return state, nil
+}
+
+// CurrentCommand returns the name of the process in the foreground of the
+// target pane (#{pane_current_command}), e.g. "bash" or "vim"
+func (m *Manager) CurrentCommand(target Target) (string, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return "", fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return "", &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return "", err
+	}
+
+	stdout, _, err := m.run("display-message", "-t", resolved, "-p", "#{pane_current_command}")
+	if err != nil {
+		return "", fmt.Errorf("failed to get current command: %w", err)
+	}
+	return strings.TrimRight(stdout, "\n"), nil
+}
+
+// GetScrollbackHistory gets the scrollback history from the pane. Passing
+// AllLines returns the whole history buffer rather than the last lines lines.
 func (m *Manager) GetScrollbackHistory(lines int) (string, error) {
+	return m.GetScrollbackHistoryWithOptions(lines, CaptureOptions{})
+}
+
+// GetScrollbackHistoryWithOptions gets the scrollback history from the pane
+// as described by opts
+func (m *Manager) GetScrollbackHistoryWithOptions(lines int, opts CaptureOptions) (string, error) {
+	if lines < 0 && lines != AllLines {
+		return "", fmt.Errorf("invalid line count %d: must be non-negative, or %d for the entire history", lines, AllLines)
+	}
+
 	// First verify the session exists
 	exists, err := m.SessionExists()
 	if err != nil {
 		return "", fmt.Errorf("failed to check session: %w", err)
 	}
 	if !exists {
-		return "", fmt.Errorf("session '%s' does not exist", m.sessionName)
+		return "", &SessionNotFoundError{Session: m.sessionName}
 	}
 
-	var stdout bytes.Buffer
+	target, err := m.resolveTarget(opts.Target)
+	if err != nil {
+		return "", err
+	}
 
-	linesArg := fmt.Sprintf("-%d", lines)
-	cmd := exec.Command("tmux", "capture-pane", "-t", m.sessionName, "-p", "-S", linesArg)
-	cmd.Stdout = &stdout
+	// "-S -" starts the capture at the beginning of the history
+	startArg := "-"
+	if lines != AllLines {
+		startArg = fmt.Sprintf("-%d", lines)
+	}
 
-	err = cmd.Run()
+	// "-E -" ends it at the bottom of the live screen, spelled out so the
+	// lines counted back from are always the newest, whether or not
+	// someone has the pane scrolled up in copy mode. ("-E -0" would be
+	// the top line of the screen.)
+	args := append([]string{"capture-pane", "-t", target, "-p", "-S", startArg, "-E", "-"}, m.captureFlags(opts)...)
+
+	stdout, _, err := m.run(args...)
 	if err != nil {
 		return "", fmt.Errorf("failed to capture scrollback: %w", err)
 	}
 
-	return stdout.String(), nil
+	return m.normalize(stdout, opts), nil
+}
+
+// LineRange is an inclusive range of pane line numbers as understood by
+// capture-pane: 0 is the first visible line and negative numbers count back
+// into the scrollback history
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// CaptureRange captures lines start through end (inclusive) of the pane.
+// The range is clamped to the lines that actually exist, from the oldest
+// history line to the bottom of the visible pane, and the range used is
+// returned alongside the content.
+func (m *Manager) CaptureRange(start, end int, opts CaptureOptions) (string, LineRange, error) {
+	if start > end {
+		return "", LineRange{}, fmt.Errorf("invalid range %d..%d: start must not be after end", start, end)
+	}
+
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return "", LineRange{}, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return "", LineRange{}, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	target, err := m.resolveTarget(opts.Target)
+	if err != nil {
+		return "", LineRange{}, err
+	}
+
+	history, height, err := m.paneExtent(target)
+	if err != nil {
+		return "", LineRange{}, err
+	}
+
+	used := LineRange{Start: clamp(start, -history, height-1), End: clamp(end, -history, height-1)}
+	content, err := m.captureLines(target, used, opts)
+	if err != nil {
+		return "", LineRange{}, err
+	}
+	return content, used, nil
+}
+
+// paneExtent returns the number of history lines and visible lines of the
+// resolved target
+func (m *Manager) paneExtent(target string) (history, height int, err error) {
+	stdout, _, err := m.run("display-message", "-t", target, "-p", "#{history_size},#{pane_height}")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get pane extent: %w", err)
+	}
+	parts := strings.Split(strings.TrimSpace(stdout), ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("unexpected pane extent format: %s", stdout)
+	}
+	history, errH := strconv.Atoi(parts[0])
+	height, errP := strconv.Atoi(parts[1])
+	if errH != nil || errP != nil {
+		return 0, 0, fmt.Errorf("unexpected pane extent format: %s", stdout)
+	}
+	return history, height, nil
+}
+
+// captureLines captures the lines of the resolved target in used, which
+// must already be clamped to the pane's extent
+func (m *Manager) captureLines(target string, used LineRange, opts CaptureOptions) (string, error) {
+	args := append([]string{"capture-pane", "-t", target, "-p",
+		"-S", strconv.Itoa(used.Start), "-E", strconv.Itoa(used.End)}, m.captureFlags(opts)...)
+	stdout, _, err := m.run(args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to capture range: %w", err)
+	}
+	return m.normalize(stdout, opts), nil
+}
+
+// clamp limits n to the range [lo, hi]
+func clamp(n, lo, hi int) int {
+	return max(lo, min(n, hi))
+}
+
+// ClientInfo describes a tmux client attached to the session
+type ClientInfo struct {
+	TTY          string
+	Width        int
+	Height       int
+	LastActivity time.Time
+}
+
+// ListClients returns the tmux clients (terminals) attached to the session.
+// An empty list means nobody is attached.
+func (m *Manager) ListClients() ([]ClientInfo, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return nil, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	stdout, _, err := m.run("list-clients", "-t", m.sessionName,
+		"-F", "#{client_tty} #{client_width}x#{client_height} #{client_activity}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clients: %w", err)
+	}
+
+	clients := []ClientInfo{}
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		client, err := parseClientLine(line)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// parseClientLine parses one "<tty> <width>x<height> <activity>" line of
+// list-clients output
+func parseClientLine(line string) (ClientInfo, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return ClientInfo{}, fmt.Errorf("unexpected client format: %s", line)
+	}
+
+	width, height, ok := parseSize(fields[1])
+	if !ok {
+		return ClientInfo{}, fmt.Errorf("unexpected client size: %s", fields[1])
+	}
+	activity, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return ClientInfo{}, fmt.Errorf("unexpected client activity %q: %w", fields[2], err)
+	}
+
+	return ClientInfo{
+		TTY:          fields[0],
+		Width:        width,
+		Height:       height,
+		LastActivity: time.Unix(activity, 0),
+	}, nil
+}
+
+// parseSize parses a "<width>x<height>" string
+func parseSize(size string) (width, height int, ok bool) {
+	w, h, found := strings.Cut(size, "x")
+	if !found {
+		return 0, 0, false
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// AttachedClients returns the number of tmux clients (terminals) currently
+// attached to the session, i.e. whether a human is likely watching it
+func (m *Manager) AttachedClients() (int, error) {
+	clients, err := m.ListClients()
+	if err != nil {
+		return 0, err
+	}
+	return len(clients), nil
+}
+
+// GetScrollbackSize returns the number of lines currently held in the
+// pane's scrollback history and the configured maximum (history-limit)
+func (m *Manager) GetScrollbackSize() (used int, limit int, err error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return 0, 0, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	target, err := m.resolveTarget(Target{})
+	if err != nil {
+		return 0, 0, err
+	}
+	stdout, _, err := m.run("display-message", "-t", target, "-p", "#{history_size},#{history_limit}")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get scrollback size: %w", err)
+	}
+
+	parts := strings.Split(strings.TrimSpace(stdout), ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("unexpected scrollback size format: %s", stdout)
+	}
+	used, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected history size %q: %w", parts[0], err)
+	}
+	limit, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected history limit %q: %w", parts[1], err)
+	}
+
+	return used, limit, nil
+}
+
+// RenameWindow renames the window holding the pane target selects, by
+// default the session's active window
+func (m *Manager) RenameWindow(target Target, name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return err
+	}
+
+	// "--" stops tmux treating a name that starts with "-" as a flag
+	_, stderr, err := m.run("rename-window", "-t", resolved, "--", name)
+	if err != nil {
+		return fmt.Errorf("failed to rename window: %w (stderr: %s)", err, stderr)
+	}
+	return nil
+}
+
+// ShowOption returns the value of a tmux option. With global set it reads
+// the global value (show-options -g); otherwise it reads the value in
+// effect for the session, including anything inherited from the global
+// options (show-options -A). tmux works out from the name whether it is a
+// server, session or window option.
+func (m *Manager) ShowOption(name string, global bool) (string, error) {
+	if name == "" || strings.IndexFunc(name, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return "", &UnknownOptionError{Name: name}
+	}
+
+	args := []string{"show-options", "-v", "-g", "--", name}
+	if !global {
+		// First verify the session exists
+		exists, err := m.SessionExists()
+		if err != nil {
+			return "", fmt.Errorf("failed to check session: %w", err)
+		}
+		if !exists {
+			return "", &SessionNotFoundError{Session: m.sessionName}
+		}
+		args = []string{"show-options", "-v", "-A", "-t", m.sessionName, "--", name}
+	}
+
+	stdout, stderr, err := m.run(args...)
+	if err != nil {
+		if strings.Contains(stderr, "invalid option") || strings.Contains(stderr, "unknown option") ||
+			strings.Contains(stderr, "ambiguous option") {
+			return "", &UnknownOptionError{Name: name}
+		}
+		return "", fmt.Errorf("failed to show option %s: %w (stderr: %s)", name, err, stderr)
+	}
+
+	return strings.TrimRight(stdout, "\n"), nil
+}
+
+// Mouse reports whether mouse mode is on globally (show-options -g mouse)
+func (m *Manager) Mouse() (bool, error) {
+	value, err := m.ShowOption("mouse", true)
+	if err != nil {
+		return false, err
+	}
+	return value == "on", nil
+}
+
+// Prefix returns the session's prefix keys (the prefix and prefix2
+// options) in tmux's key notation, such as "C-b". prefix2 is "" when it is
+// unset.
+func (m *Manager) Prefix() (prefix, prefix2 string, err error) {
+	prefix, err = m.ShowOption("prefix", false)
+	if err != nil {
+		return "", "", err
+	}
+	prefix2, err = m.ShowOption("prefix2", false)
+	if err != nil {
+		return "", "", err
+	}
+	// tmux shows an unset key option as "None"
+	if prefix == "None" {
+		prefix = ""
+	}
+	if prefix2 == "None" {
+		prefix2 = ""
+	}
+	return prefix, prefix2, nil
+}
+
+// SameKey reports whether two tmux key names name the same key, treating
+// "^b" as "C-b" and the letter of a C- key in either case, as tmux does
+func SameKey(a, b string) bool {
+	return a != "" && normalizeKey(a) == normalizeKey(b)
+}
+
+// keyModifiers names the modifier prefixes of tmux key notation
+var keyModifiers = map[byte]string{'C': "Ctrl", 'M': "Alt", 'S': "Shift"}
+
+// splitKey separates a key name into its modifier letters and the key
+// itself, so "M-S-Up" gives "MS" and "Up"
+func splitKey(key string) (mods, base string) {
+	if len(key) == 2 && key[0] == '^' {
+		return "C", key[1:]
+	}
+	for len(key) > 2 && key[1] == '-' {
+		mod := key[0] &^ 0x20 // upper case
+		if _, ok := keyModifiers[mod]; !ok {
+			break
+		}
+		mods += string(mod)
+		key = key[2:]
+	}
+	return mods, key
+}
+
+// normalizeKey rewrites a key name into one spelling per key for SameKey
+func normalizeKey(key string) string {
+	mods, base := splitKey(key)
+	if strings.Contains(mods, "C") && len(base) == 1 {
+		base = strings.ToLower(base)
+	}
+	var b strings.Builder
+	for _, mod := range mods {
+		b.WriteRune(mod)
+		b.WriteByte('-')
+	}
+	return b.String() + base
+}
+
+// DescribeKey renders a tmux key name for people, spelling out its
+// modifiers: "C-b" becomes "Ctrl+b" and "M-S-Up" becomes "Alt+Shift+Up"
+func DescribeKey(key string) string {
+	mods, base := splitKey(key)
+	var parts []string
+	for i := 0; i < len(mods); i++ {
+		parts = append(parts, keyModifiers[mods[i]])
+	}
+	return strings.Join(append(parts, base), "+")
+}
+
+// SetMouse turns global mouse mode on or off (set-option -g mouse), which
+// changes how scrolling and selection behave in every session
+func (m *Manager) SetMouse(enabled bool) error {
+	value := "off"
+	if enabled {
+		value = "on"
+	}
+	_, stderr, err := m.run("set-option", "-g", "mouse", value)
+	if err != nil {
+		return fmt.Errorf("failed to set mouse mode: %w (stderr: %s)", err, stderr)
+	}
+	return nil
+}
+
+// validateName checks that a window or session name is non-empty and free of
+// control characters, which tmux would otherwise store verbatim
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("name must not contain control characters")
+		}
+	}
+	return nil
 }
 
 // ListSessions lists all tmux sessions
 func ListSessions() ([]string, error) {
-	var stdout bytes.Buffer
+	return listSessions(execRunner{})
+}
 
-	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}")
-	cmd.Stdout = &stdout
+// Sessions lists all tmux sessions on the server the manager talks to
+func (m *Manager) Sessions() ([]string, error) {
+	return listSessions(m.runner)
+}
 
-	err := cmd.Run()
+// listSessions lists all tmux sessions visible to runner
+func listSessions(runner CommandRunner) ([]string, error) {
+	stdout, _, err := runner.Run("list-sessions", "-F", "#{session_name}")
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			// Exit code 1 with "no server running" is expected when no sessions exist
-			if exitErr.ExitCode() == 1 {
-				return []string{}, nil
-			}
+		// Exit code 1 with "no server running" is expected when no sessions exist
+		if exitCode(err) == 1 {
+			return []string{}, nil
 		}
 		return nil, fmt.Errorf("failed to list sessions: %w", err)
 	}
 
-	sessions := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	sessions := strings.Split(strings.TrimSpace(stdout), "\n")
 	if len(sessions) == 1 && sessions[0] == "" {
 		return []string{}, nil
 	}
@@ -198,6 +1092,6 @@ func ListSessions() ([]string, error) {
 
 // KillSession kills the tmux session
 func (m *Manager) KillSession() error {
-	cmd := exec.Command("tmux", "kill-session", "-t", m.sessionName)
-	return cmd.Run()
+	_, _, err := m.run("kill-session", "-t", m.sessionName)
+	return err
 }
@@ -1,10 +1,14 @@
 package tmux
 
 import (
+	"errors"
 	"os"
 	"os/exec"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewManager(t *testing.T) {
@@ -449,3 +453,973 @@ func TestManager_GetScrollbackHistory_NonexistentSession(t *testing.T) {
 		t.Error("GetScrollbackHistory() should return error for nonexistent session")
 	}
 }
+
+func TestManager_GetScrollbackHistory_StartArg(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     int
+		wantStart string
+	}{
+		{
+			name:      "last 50 lines",
+			lines:     50,
+			wantStart: "-50",
+		},
+		{
+			name:      "entire history",
+			lines:     AllLines,
+			wantStart: "-",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("capture-pane", fakeResponse{stdout: "history\n"})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			content, err := m.GetScrollbackHistory(tt.lines)
+			if err != nil {
+				t.Fatalf("GetScrollbackHistory() error = %v", err)
+			}
+			if content != "history\n" {
+				t.Errorf("GetScrollbackHistory() = %q, want %q", content, "history\n")
+			}
+
+			// The end is the live bottom of the screen, never the scroll
+			// position of copy mode
+			want := []string{"capture-pane", "-t", "fake-session", "-p", "-S", tt.wantStart, "-E", "-"}
+			if got := runner.lastCall("capture-pane"); !reflect.DeepEqual(got, want) {
+				t.Errorf("capture-pane args = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestManager_GetScrollbackHistory_InvalidLines(t *testing.T) {
+	runner := newFakeRunner()
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if _, err := m.GetScrollbackHistory(-5); err == nil {
+		t.Error("GetScrollbackHistory(-5) should return error")
+	}
+	if len(runner.calls) != 0 {
+		t.Errorf("GetScrollbackHistory(-5) ran tmux %d times, want 0", len(runner.calls))
+	}
+}
+
+func TestManager_SessionExists_Fake(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    bool
+		wantErr bool
+	}{
+		{name: "exists", err: nil, want: true},
+		{name: "missing", err: exitError(1), want: false},
+		{name: "other failure", err: exitError(2), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("has-session", fakeResponse{err: tt.err})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			got, err := m.SessionExists()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SessionExists() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SessionExists() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_CapturePaneWithOptions_EscapeSequences(t *testing.T) {
+	runner := newFakeRunner()
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if _, err := m.CapturePaneWithOptions(CaptureOptions{EscapeSequences: true}); err != nil {
+		t.Fatalf("CapturePaneWithOptions() error = %v", err)
+	}
+
+	want := []string{"capture-pane", "-t", "fake-session", "-p", "-S", "-", "-e"}
+	if got := runner.lastCall("capture-pane"); !reflect.DeepEqual(got, want) {
+		t.Errorf("capture-pane args = %v, want %v", got, want)
+	}
+}
+
+func TestParseCaptureArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: []string{}},
+		{name: "single flag", input: "-N", want: []string{"-N"}},
+		{name: "several flags", input: " -N  -a ", want: []string{"-N", "-a"}},
+		{name: "combined flags", input: "-NC", want: []string{"-NC"}},
+		{name: "target flag", input: "-t other", wantErr: true},
+		{name: "range flag", input: "-S -10", wantErr: true},
+		{name: "buffer flag", input: "-b buf", wantErr: true},
+		{name: "combined with unsafe flag", input: "-Nt", wantErr: true},
+		{name: "bare value", input: "foo", wantErr: true},
+		{name: "lone dash", input: "-", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCaptureArgs(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCaptureArgs(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCaptureArgs(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_SetCaptureArgs(t *testing.T) {
+	runner := newFakeRunner()
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if err := m.SetCaptureArgs([]string{"-N"}); err != nil {
+		t.Fatalf("SetCaptureArgs() error = %v", err)
+	}
+	if err := m.SetCaptureArgs([]string{"-t", "other"}); err == nil {
+		t.Fatal("SetCaptureArgs() with -t succeeded, want error")
+	}
+
+	if _, err := m.CapturePaneWithOptions(CaptureOptions{EscapeSequences: true}); err != nil {
+		t.Fatalf("CapturePaneWithOptions() error = %v", err)
+	}
+	want := []string{"capture-pane", "-t", "fake-session", "-p", "-S", "-", "-e", "-N"}
+	if got := runner.lastCall("capture-pane"); !reflect.DeepEqual(got, want) {
+		t.Errorf("capture-pane args = %v, want %v", got, want)
+	}
+}
+
+func TestManager_GetScrollbackSize(t *testing.T) {
+	runner := newFakeRunner().on("display-message", fakeResponse{stdout: "1500,2000\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	used, limit, err := m.GetScrollbackSize()
+	if err != nil {
+		t.Fatalf("GetScrollbackSize() error = %v", err)
+	}
+	if used != 1500 || limit != 2000 {
+		t.Errorf("GetScrollbackSize() = (%d, %d), want (1500, 2000)", used, limit)
+	}
+}
+
+func TestManager_RenameWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  string
+		wantErr bool
+	}{
+		{name: "simple name", window: "build"},
+		{name: "leading dash", window: "-scratch"},
+		{name: "unicode", window: "déploiement"},
+		{name: "empty", window: "", wantErr: true},
+		{name: "newline", window: "a\nb", wantErr: true},
+		{name: "escape", window: "a\x1b[31m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner()
+			m := NewManagerWithRunner("fake-session", runner)
+
+			err := m.RenameWindow(Target{}, tt.window)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenameWindow(%q) error = %v, wantErr %v", tt.window, err, tt.wantErr)
+			}
+
+			got := runner.lastCall("rename-window")
+			if tt.wantErr {
+				if got != nil {
+					t.Errorf("rename-window ran for invalid name: %v", got)
+				}
+				return
+			}
+			want := []string{"rename-window", "-t", "fake-session", "--", tt.window}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("rename-window args = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestManager_AttachedClients(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		want   int
+	}{
+		{name: "none", stdout: "", want: 0},
+		{name: "one", stdout: "/dev/pts/1 80x24 1700000000\n", want: 1},
+		{name: "two", stdout: "/dev/pts/1 80x24 1700000000\n/dev/pts/4 200x50 1700000100\n", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("list-clients", fakeResponse{stdout: tt.stdout})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			got, err := m.AttachedClients()
+			if err != nil {
+				t.Fatalf("AttachedClients() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AttachedClients() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_ListClients(t *testing.T) {
+	runner := newFakeRunner().on("list-clients", fakeResponse{stdout: "/dev/pts/1 80x24 1700000000\n/dev/ttys004 212x58 1700000123\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	clients, err := m.ListClients()
+	if err != nil {
+		t.Fatalf("ListClients() error = %v", err)
+	}
+
+	want := []ClientInfo{
+		{TTY: "/dev/pts/1", Width: 80, Height: 24, LastActivity: time.Unix(1700000000, 0)},
+		{TTY: "/dev/ttys004", Width: 212, Height: 58, LastActivity: time.Unix(1700000123, 0)},
+	}
+	if !reflect.DeepEqual(clients, want) {
+		t.Errorf("ListClients() = %+v, want %+v", clients, want)
+	}
+
+	wantArgs := []string{"list-clients", "-t", "fake-session", "-F", "#{client_tty} #{client_width}x#{client_height} #{client_activity}"}
+	if args := runner.lastCall("list-clients"); !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("list-clients args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestManager_ListClients_NoneAttached(t *testing.T) {
+	m := NewManagerWithRunner("fake-session", newFakeRunner())
+
+	clients, err := m.ListClients()
+	if err != nil {
+		t.Fatalf("ListClients() error = %v", err)
+	}
+	if clients == nil || len(clients) != 0 {
+		t.Errorf("ListClients() = %#v, want empty non-nil slice", clients)
+	}
+}
+
+func TestManager_ListClients_Malformed(t *testing.T) {
+	runner := newFakeRunner().on("list-clients", fakeResponse{stdout: "/dev/pts/1 wide 17\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if _, err := m.ListClients(); err == nil {
+		t.Error("ListClients() should reject malformed output")
+	}
+}
+
+// countCalls returns how many times subcommand was invoked
+func countCalls(f *fakeRunner, subcommand string) int {
+	n := 0
+	for _, call := range f.calls {
+		if len(call) > 0 && call[0] == subcommand {
+			n++
+		}
+	}
+	return n
+}
+
+func TestManager_RetriesTransientFailure(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stderr: "server exited unexpectedly", err: exitError(1)}).
+		on("capture-pane", fakeResponse{stdout: "hello\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+	m.retryDelay = time.Millisecond
+
+	content, err := m.CapturePane()
+	if err != nil {
+		t.Fatalf("CapturePane() error = %v", err)
+	}
+	if content != "hello\n" {
+		t.Errorf("CapturePane() = %q, want %q", content, "hello\n")
+	}
+	if got := countCalls(runner, "capture-pane"); got != 2 {
+		t.Errorf("capture-pane called %d times, want 2", got)
+	}
+}
+
+func TestManager_RetryGivesUp(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stderr: "lost server", err: exitError(1)})
+	m := NewManagerWithRunner("fake-session", runner)
+	m.retryDelay = time.Millisecond
+
+	if _, err := m.CapturePane(); err == nil {
+		t.Fatal("CapturePane() should fail once retries are exhausted")
+	}
+	if got := countCalls(runner, "capture-pane"); got != DefaultMaxAttempts {
+		t.Errorf("capture-pane called %d times, want %d", got, DefaultMaxAttempts)
+	}
+}
+
+func TestManager_DoesNotRetrySendKeys(t *testing.T) {
+	// tmux may have typed the keys before the connection dropped
+	runner := newFakeRunner().
+		on("send-keys", fakeResponse{stderr: "server exited unexpectedly", err: exitError(1)}).
+		on("send-keys", fakeResponse{})
+	m := NewManagerWithRunner("fake-session", runner)
+	m.retryDelay = time.Millisecond
+
+	if err := m.SendKeys("make test"); err == nil {
+		t.Fatal("SendKeys() should report the failure rather than retry")
+	}
+	if got := countCalls(runner, "send-keys"); got != 1 {
+		t.Errorf("send-keys called %d times, want 1", got)
+	}
+}
+
+func TestManager_DoesNotRetryPermanentFailure(t *testing.T) {
+	runner := newFakeRunner().
+		on("has-session", fakeResponse{stderr: "can't find session: fake-session", err: exitError(1)})
+	m := NewManagerWithRunner("fake-session", runner)
+	m.retryDelay = time.Millisecond
+
+	_, err := m.CapturePane()
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("CapturePane() error = %v, want ErrSessionNotFound", err)
+	}
+	if got := countCalls(runner, "has-session"); got != 1 {
+		t.Errorf("has-session called %d times, want 1", got)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		stderr string
+		want   bool
+	}{
+		{name: "success", err: nil, want: false},
+		{name: "server exited", err: exitError(1), stderr: "server exited unexpectedly", want: true},
+		{name: "socket busy", err: exitError(1), stderr: "error connecting to /tmp/tmux-0/default (Resource temporarily unavailable)", want: true},
+		{name: "session not found", err: exitError(1), stderr: "can't find session: x", want: false},
+		{name: "no server", err: exitError(1), stderr: "no server running on /tmp/tmux-0/default", want: false},
+		{name: "stale socket", err: exitError(1), stderr: "error connecting to /tmp/tmux-0/default (Connection refused)", want: false},
+		{name: "not executed", err: errors.New("exec: \"tmux\": executable file not found in $PATH"), stderr: "lost server", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err, tt.stderr); got != tt.want {
+				t.Errorf("isTransient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsServerGone(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		stderr string
+		want   bool
+	}{
+		{name: "success", err: nil, want: false},
+		{name: "no server", err: exitError(1), stderr: "no server running on /tmp/tmux-0/default", want: true},
+		{name: "missing socket", err: exitError(1), stderr: "error connecting to /tmp/tmux-0/default (No such file or directory)", want: true},
+		{name: "stale socket", err: exitError(1), stderr: "error connecting to /tmp/tmux-0/default (Connection refused)", want: true},
+		{name: "socket busy", err: exitError(1), stderr: "error connecting to /tmp/tmux-0/default (Resource temporarily unavailable)", want: false},
+		{name: "session not found", err: exitError(1), stderr: "can't find session: x", want: false},
+		{name: "not executed", err: errors.New("exec: \"tmux\": executable file not found in $PATH"), stderr: "no server running", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isServerGone(tt.err, tt.stderr); got != tt.want {
+				t.Errorf("isServerGone() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_CapturePane_DeadPaneOtherFlags(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: "\x1b[31merror\x1b[0m\n"}).
+		on("capture-pane", fakeResponse{stderr: "pane is dead", err: exitError(1)})
+	m := NewManagerWithRunner("fake-session", runner)
+	m.SetMaxAttempts(1)
+
+	if _, err := m.CapturePaneWithOptions(CaptureOptions{EscapeSequences: true}); err != nil {
+		t.Fatalf("CapturePaneWithOptions() error = %v", err)
+	}
+	// A plain read must not get back content captured with escapes
+	_, err := m.CapturePane()
+	var dead *PaneDeadError
+	if !errors.As(err, &dead) {
+		t.Fatalf("CapturePane() error = %v, want a *PaneDeadError", err)
+	}
+	if dead.HasStale {
+		t.Errorf("PaneDeadError = %+v, want no stale content from a capture with other flags", dead)
+	}
+}
+
+func TestManager_ListPanes_ForgetsClosedPanes(t *testing.T) {
+	runner := newFakeRunner().
+		on("list-panes", fakeResponse{stdout: paneListing}).
+		on("capture-pane", fakeResponse{stdout: "one\n"}).
+		on("list-panes", fakeResponse{stdout: paneListing}).
+		on("capture-pane", fakeResponse{stdout: "two\n"}).
+		// Pane %2 closes
+		on("list-panes", fakeResponse{stdout: strings.SplitAfter(paneListing, "\n")[0]})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	for _, pane := range []string{"%0", "%2"} {
+		if _, err := m.CapturePaneWithOptions(CaptureOptions{Target: Target{Pane: pane}}); err != nil {
+			t.Fatalf("CapturePaneWithOptions(%s) error = %v", pane, err)
+		}
+	}
+	if len(m.lastCaptures) != 2 {
+		t.Fatalf("last captures = %v, want one per pane read", m.lastCaptures)
+	}
+	if _, err := m.ListPanes(); err != nil {
+		t.Fatalf("ListPanes() error = %v", err)
+	}
+	if _, ok := m.lastCaptures["%2"]; ok || len(m.lastCaptures) != 1 {
+		t.Errorf("last captures = %v, want only %%0's once %%2 has closed", m.lastCaptures)
+	}
+}
+
+func TestManager_EnsureConnected_ServerRestarted(t *testing.T) {
+	noServer := fakeResponse{stderr: "no server running on /tmp/tmux-0/default", err: exitError(1)}
+	runner := newFakeRunner().
+		// The server is killed: the first read fails, and so does the
+		// check made while reconnecting, until new-session starts a server
+		on("has-session", noServer).
+		on("has-session", noServer).
+		on("has-session", fakeResponse{}).
+		on("capture-pane", fakeResponse{stdout: "back\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if _, err := m.CapturePane(); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("CapturePane() with no server error = %v, want ErrSessionNotFound", err)
+	}
+
+	if err := m.EnsureConnected(); err != nil {
+		t.Fatalf("EnsureConnected() error = %v", err)
+	}
+	if got := countCalls(runner, "new-session"); got != 1 {
+		t.Errorf("new-session called %d times, want 1", got)
+	}
+
+	content, err := m.CapturePane()
+	if err != nil {
+		t.Fatalf("CapturePane() after reconnect error = %v", err)
+	}
+	if content != "back\n" {
+		t.Errorf("CapturePane() = %q, want %q", content, "back\n")
+	}
+
+	// Once reconnected there is nothing more to do
+	calls := len(runner.calls)
+	if err := m.EnsureConnected(); err != nil {
+		t.Fatalf("EnsureConnected() error = %v", err)
+	}
+	if len(runner.calls) != calls {
+		t.Errorf("EnsureConnected() ran %v, want no tmux commands", runner.calls[calls:])
+	}
+}
+
+func TestManager_EnsureConnected_StaleSocket(t *testing.T) {
+	// A crashed server leaves its socket behind, so connecting is refused
+	// until a new server replaces it
+	refused := fakeResponse{stderr: "error connecting to /tmp/tmux-0/default (Connection refused)", err: exitError(1)}
+	runner := newFakeRunner().
+		on("has-session", refused).
+		on("has-session", refused).
+		on("has-session", fakeResponse{})
+	m := NewManagerWithRunner("fake-session", runner)
+	// Any retry would sleep for a second or more
+	m.retryDelay = time.Second
+
+	start := time.Now()
+	if _, err := m.CapturePane(); err == nil {
+		t.Fatal("CapturePane() on a stale socket should fail")
+	}
+	if got := countCalls(runner, "has-session"); got != 1 {
+		t.Errorf("has-session called %d times, want 1", got)
+	}
+	if err := m.EnsureConnected(); err != nil {
+		t.Fatalf("EnsureConnected() error = %v", err)
+	}
+	if got := countCalls(runner, "new-session"); got != 1 {
+		t.Errorf("new-session called %d times, want 1", got)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("reconnecting took %v, want no retry backoff", elapsed)
+	}
+}
+
+func TestManager_EnsureSession_StartupCommands(t *testing.T) {
+	commands := []string{"cd ~/project", "source .venv/bin/activate"}
+	tests := []struct {
+		name       string
+		hasSession fakeResponse
+		wantSent   [][]string
+	}{
+		{
+			name:       "created",
+			hasSession: fakeResponse{stderr: "can't find session: fake-session", err: exitError(1)},
+			wantSent: [][]string{
+				{"send-keys", "-t", "fake-session", "-l", "--", "cd ~/project"},
+				{"send-keys", "-t", "fake-session", "Enter"},
+				{"send-keys", "-t", "fake-session", "-l", "--", "source .venv/bin/activate"},
+				{"send-keys", "-t", "fake-session", "Enter"},
+			},
+		},
+		{
+			name:       "attached to existing",
+			hasSession: fakeResponse{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("has-session", tt.hasSession)
+			m := NewManagerWithRunner("fake-session", runner)
+			if err := m.SetStartupCommands(commands); err != nil {
+				t.Fatalf("SetStartupCommands() error = %v", err)
+			}
+
+			if err := m.EnsureSession(); err != nil {
+				t.Fatalf("EnsureSession() error = %v", err)
+			}
+			var sent [][]string
+			for _, call := range runner.calls {
+				if call[0] == "send-keys" {
+					sent = append(sent, call)
+				}
+			}
+			if !reflect.DeepEqual(sent, tt.wantSent) {
+				t.Errorf("send-keys calls = %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestManager_SetStartupCommands_Invalid(t *testing.T) {
+	m := NewManagerWithRunner("fake-session", newFakeRunner())
+	for _, command := range []string{"", "  ", "cd /tmp\nls"} {
+		if err := m.SetStartupCommands([]string{"ls", command}); err == nil {
+			t.Errorf("SetStartupCommands(%q) error = nil, want error", command)
+		}
+	}
+	if m.startupCommands != nil {
+		t.Errorf("startupCommands = %q after invalid input, want unchanged", m.startupCommands)
+	}
+}
+
+func TestTmuxVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    fakeResponse
+		want    string
+		wantErr bool
+	}{
+		{name: "installed", resp: fakeResponse{stdout: "tmux 3.4\n"}, want: "tmux 3.4"},
+		{name: "not installed", resp: fakeResponse{err: exec.ErrNotFound}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("-V", tt.resp)
+			got, err := tmuxVersion(runner)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tmuxVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, exec.ErrNotFound) {
+				t.Errorf("tmuxVersion() error = %v, want it to wrap exec.ErrNotFound", err)
+			}
+			if got != tt.want {
+				t.Errorf("tmuxVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_CommandError(t *testing.T) {
+	runner := newFakeRunner().
+		on("rename-window", fakeResponse{stderr: "can't find window: 7", err: exitError(1)})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	err := m.RenameWindow(Target{}, "build")
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("RenameWindow() error = %v, want a *CommandError", err)
+	}
+	wantArgs := []string{"rename-window", "-t", "fake-session", "--", "build"}
+	if !reflect.DeepEqual(cmdErr.Args, wantArgs) {
+		t.Errorf("CommandError.Args = %v, want %v", cmdErr.Args, wantArgs)
+	}
+	if cmdErr.Stderr != "can't find window: 7" {
+		t.Errorf("CommandError.Stderr = %q", cmdErr.Stderr)
+	}
+	if exitCode(err) != 1 {
+		t.Errorf("exitCode() = %d, want 1 through the wrapper", exitCode(err))
+	}
+}
+
+func TestManager_CaptureRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     int
+		end       int
+		wantRange LineRange
+	}{
+		{name: "within bounds", start: -50, end: -10, wantRange: LineRange{Start: -50, End: -10}},
+		{name: "visible lines", start: 0, end: 5, wantRange: LineRange{Start: 0, End: 5}},
+		{name: "clamped to history", start: -5000, end: -900, wantRange: LineRange{Start: -1000, End: -900}},
+		{name: "clamped to pane bottom", start: 10, end: 100, wantRange: LineRange{Start: 10, End: 23}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().
+				on("display-message", fakeResponse{stdout: "1000,24\n"}).
+				on("capture-pane", fakeResponse{stdout: "lines\n"})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			content, used, err := m.CaptureRange(tt.start, tt.end, CaptureOptions{})
+			if err != nil {
+				t.Fatalf("CaptureRange() error = %v", err)
+			}
+			if content != "lines\n" {
+				t.Errorf("CaptureRange() content = %q", content)
+			}
+			if used != tt.wantRange {
+				t.Errorf("CaptureRange() range = %+v, want %+v", used, tt.wantRange)
+			}
+
+			wantArgs := []string{"capture-pane", "-t", "fake-session", "-p",
+				"-S", strconv.Itoa(tt.wantRange.Start), "-E", strconv.Itoa(tt.wantRange.End)}
+			if args := runner.lastCall("capture-pane"); !reflect.DeepEqual(args, wantArgs) {
+				t.Errorf("capture-pane args = %v, want %v", args, wantArgs)
+			}
+		})
+	}
+}
+
+func TestManager_CaptureRange_StartAfterEnd(t *testing.T) {
+	runner := newFakeRunner()
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if _, _, err := m.CaptureRange(5, -5, CaptureOptions{}); err == nil {
+		t.Fatal("CaptureRange() should reject start > end")
+	}
+	if len(runner.calls) != 0 {
+		t.Errorf("CaptureRange() ran tmux %v for an invalid range", runner.calls)
+	}
+}
+
+func TestManager_GetCursor(t *testing.T) {
+	runner := newFakeRunner().on("display-message", fakeResponse{stdout: "12,3,24\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	cursor, err := m.GetCursor(Target{})
+	if err != nil {
+		t.Fatalf("GetCursor() error = %v", err)
+	}
+	if want := (Cursor{X: 12, Y: 3, PaneHeight: 24}); cursor != want {
+		t.Errorf("GetCursor() = %+v, want %+v", cursor, want)
+	}
+
+	wantArgs := []string{"display-message", "-t", "fake-session", "-p", "#{cursor_x},#{cursor_y},#{pane_height}"}
+	if args := runner.lastCall("display-message"); !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("display-message args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestManager_GetScrollState(t *testing.T) {
+	tests := []struct {
+		name    string
+		stdout  string
+		want    ScrollState
+		wantErr bool
+	}{
+		{name: "not in a mode", stdout: "0\t\t\n", want: ScrollState{}},
+		{name: "scrolled back", stdout: "1\tcopy-mode\t10\n", want: ScrollState{InMode: true, Mode: "copy-mode", Position: 10}},
+		{name: "other mode", stdout: "1\ttree-mode\t\n", want: ScrollState{InMode: true, Mode: "tree-mode"}},
+		{name: "malformed", stdout: "1\tcopy-mode\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("display-message", fakeResponse{stdout: tt.stdout})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			got, err := m.GetScrollState(Target{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetScrollState() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetScrollState() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_ShowOption(t *testing.T) {
+	tests := []struct {
+		name     string
+		option   string
+		global   bool
+		resp     fakeResponse
+		want     string
+		wantArgs []string
+		wantErr  error
+	}{
+		{
+			name:     "global",
+			option:   "history-limit",
+			global:   true,
+			resp:     fakeResponse{stdout: "2000\n"},
+			want:     "2000",
+			wantArgs: []string{"show-options", "-v", "-g", "--", "history-limit"},
+		},
+		{
+			name:     "session with inherited values",
+			option:   "mouse",
+			resp:     fakeResponse{stdout: "on\n"},
+			want:     "on",
+			wantArgs: []string{"show-options", "-v", "-A", "-t", "fake-session", "--", "mouse"},
+		},
+		{
+			name:    "unknown option",
+			option:  "nosuch",
+			global:  true,
+			resp:    fakeResponse{stderr: "invalid option: nosuch", err: exitError(1)},
+			wantErr: ErrUnknownOption,
+		},
+		{
+			name:    "malformed name",
+			option:  "mouse on",
+			wantErr: ErrUnknownOption,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("show-options", tt.resp)
+			m := NewManagerWithRunner("fake-session", runner)
+
+			got, err := m.ShowOption(tt.option, tt.global)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ShowOption() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ShowOption() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ShowOption() = %q, want %q", got, tt.want)
+			}
+			if args := runner.lastCall("show-options"); !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("show-options args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestManager_Prefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     map[string]string
+		wantPrefix  string
+		wantPrefix2 string
+	}{
+		{name: "default", options: map[string]string{"prefix": "C-b", "prefix2": "None"}, wantPrefix: "C-b"},
+		{name: "both set", options: map[string]string{"prefix": "C-a", "prefix2": "C-b"}, wantPrefix: "C-a", wantPrefix2: "C-b"},
+		{name: "no prefix", options: map[string]string{"prefix": "None", "prefix2": "None"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManagerWithRunner("fake-session", RunnerFunc(func(args ...string) (string, string, error) {
+				if args[0] == "show-options" {
+					return tt.options[args[len(args)-1]] + "\n", "", nil
+				}
+				return "", "", nil
+			}))
+
+			prefix, prefix2, err := m.Prefix()
+			if err != nil {
+				t.Fatalf("Prefix() error = %v", err)
+			}
+			if prefix != tt.wantPrefix || prefix2 != tt.wantPrefix2 {
+				t.Errorf("Prefix() = %q, %q, want %q, %q", prefix, prefix2, tt.wantPrefix, tt.wantPrefix2)
+			}
+		})
+	}
+}
+
+func TestSameKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "C-b", b: "C-b", want: true},
+		{a: "C-b", b: "^B", want: true},
+		{a: "c-B", b: "C-b", want: true},
+		{a: "C-b", b: "b", want: false},
+		{a: "M-a", b: "M-A", want: false},
+		{a: "C-Up", b: "C-up", want: false},
+		{a: "", b: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := SameKey(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameKey(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeKey(t *testing.T) {
+	tests := map[string]string{
+		"C-b":    "Ctrl+b",
+		"^A":     "Ctrl+A",
+		"M-S-Up": "Alt+Shift+Up",
+		"F12":    "F12",
+		"C-M-x":  "Ctrl+Alt+x",
+	}
+
+	for key, want := range tests {
+		if got := DescribeKey(key); got != want {
+			t.Errorf("DescribeKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestManager_SetMouse(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{name: "on", enabled: true, want: "on"},
+		{name: "off", enabled: false, want: "off"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("show-options", fakeResponse{stdout: tt.want + "\n"})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			if err := m.SetMouse(tt.enabled); err != nil {
+				t.Fatalf("SetMouse() error = %v", err)
+			}
+			wantArgs := []string{"set-option", "-g", "mouse", tt.want}
+			if args := runner.lastCall("set-option"); !reflect.DeepEqual(args, wantArgs) {
+				t.Errorf("set-option args = %v, want %v", args, wantArgs)
+			}
+
+			enabled, err := m.Mouse()
+			if err != nil {
+				t.Fatalf("Mouse() error = %v", err)
+			}
+			if enabled != tt.enabled {
+				t.Errorf("Mouse() = %v, want %v", enabled, tt.enabled)
+			}
+		})
+	}
+}
+
+func TestManager_GetPaneInfo_Parsing(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		want   map[string]string
+	}{
+		{
+			name:   "live pane",
+			stdout: "80,24,/home/user,0,0,,0\n",
+			want: map[string]string{"width": "80", "height": "24", "current_path": "/home/user",
+				"pane_index": "0", "pane_dead": "0", "pane_dead_status": "", "alternate_on": "0"},
+		},
+		{
+			name:   "dead pane with comma in path",
+			stdout: "120,40,/srv/a,b,2,1,137,1\n",
+			want: map[string]string{"width": "120", "height": "40", "current_path": "/srv/a,b",
+				"pane_index": "2", "pane_dead": "1", "pane_dead_status": "137", "alternate_on": "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("display-message", fakeResponse{stdout: tt.stdout})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			info, err := m.GetPaneInfo()
+			if err != nil {
+				t.Fatalf("GetPaneInfo() error = %v", err)
+			}
+			if !reflect.DeepEqual(info, tt.want) {
+				t.Errorf("GetPaneInfo() = %v, want %v", info, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_CapturePane_DeadPane(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: "$ make\nok\n"}).
+		on("capture-pane", fakeResponse{stderr: "pane is dead", err: exitError(1)})
+	m := NewManagerWithRunner("fake-session", runner)
+	m.SetMaxAttempts(1)
+
+	// Nothing has been captured from the pane yet
+	fresh := NewManagerWithRunner("fake-session", newFakeRunner().
+		on("capture-pane", fakeResponse{stderr: "can't find pane: %3", err: exitError(1)}))
+	_, err := fresh.CapturePane()
+	var dead *PaneDeadError
+	if !errors.As(err, &dead) {
+		t.Fatalf("CapturePane() error = %v, want a *PaneDeadError", err)
+	}
+	if dead.HasStale {
+		t.Errorf("PaneDeadError.HasStale = true before any capture, stale %q", dead.Stale)
+	}
+
+	if _, err := m.CapturePane(); err != nil {
+		t.Fatalf("CapturePane() error = %v", err)
+	}
+	_, err = m.CapturePane()
+	if !errors.Is(err, ErrPaneDead) {
+		t.Fatalf("CapturePane() error = %v, want ErrPaneDead", err)
+	}
+	if !errors.As(err, &dead) || !dead.HasStale || dead.Stale != "$ make\nok\n" {
+		t.Errorf("PaneDeadError = %+v, want the earlier capture as stale content", dead)
+	}
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Stderr != "pane is dead" {
+		t.Errorf("PaneDeadError does not wrap the tmux failure: %v", err)
+	}
+
+	// The dead pane's content is reported once, then forgotten
+	if _, ok := m.lastCaptures["fake-session"]; ok {
+		t.Errorf("last capture kept after the pane was reported dead")
+	}
+
+	// Other capture failures are reported as before
+	other := NewManagerWithRunner("fake-session", newFakeRunner().
+		on("capture-pane", fakeResponse{stderr: "bad flag", err: exitError(1)}))
+	if _, err := other.CapturePane(); errors.Is(err, ErrPaneDead) {
+		t.Errorf("CapturePane() error = %v, want an ordinary failure", err)
+	}
+}
@@ -0,0 +1,42 @@
+package tmux
+
+import "strings"
+
+// NormalizeNewlines converts "\r\n" line endings to "\n" and replays bare
+// carriage returns the way a terminal does: each "\r" moves back to the
+// start of the line and later text overwrites what was there, so a progress
+// bar redrawn with "\r" collapses to its final state. Text that a shorter
+// redraw doesn't reach stays visible, as it would on screen.
+func NormalizeNewlines(s string) string {
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.Contains(line, "\r") {
+			lines[i] = overwriteLine(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// overwriteLine replays the carriage returns in one line
+func overwriteLine(line string) string {
+	var buf []rune
+	col := 0
+	for _, r := range line {
+		if r == '\r' {
+			col = 0
+			continue
+		}
+		if col < len(buf) {
+			buf[col] = r
+		} else {
+			buf = append(buf, r)
+		}
+		col++
+	}
+	return string(buf)
+}
@@ -0,0 +1,51 @@
+package tmux
+
+import "testing"
+
+func TestNormalizeNewlines(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "unchanged", in: "$ ls\na b\n", want: "$ ls\na b\n"},
+		{name: "crlf", in: "one\r\ntwo\r\n", want: "one\ntwo\n"},
+		{
+			name: "download progress",
+			in: "$ curl -O https://example.com/big.iso\n" +
+				"big.iso    3%[>          ]  12.0M  4.1MB/s\rbig.iso   57%[======>    ] 233.5M  9.8MB/s\rbig.iso  100%[==========>] 409.6M 10.2MB/s in 40s\r\n" +
+				"$ \n",
+			want: "$ curl -O https://example.com/big.iso\n" +
+				"big.iso  100%[==========>] 409.6M 10.2MB/s in 40s\n" +
+				"$ \n",
+		},
+		{name: "shorter redraw leaves the tail", in: "Processing item 10\rDone\n", want: "Doneessing item 10\n"},
+		{name: "trailing carriage return", in: "50%\r", want: "50%"},
+		{name: "wide characters", in: "進捗 10%\r進捗 99%\n", want: "進捗 99%\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeNewlines(tt.in); got != tt.want {
+				t.Errorf("NormalizeNewlines(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_NormalizeNewlines(t *testing.T) {
+	runner := newFakeRunner().on("capture-pane", fakeResponse{stdout: "step 1/3\rstep 3/3\r\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if got, _ := m.CapturePane(); got != "step 3/3\n" {
+		t.Errorf("CapturePane() = %q, want the final redraw", got)
+	}
+	if got, _ := m.GetScrollbackHistory(10); got != "step 3/3\n" {
+		t.Errorf("GetScrollbackHistory() = %q, want the final redraw", got)
+	}
+
+	m.SetNormalizeNewlines(false)
+	if got, _ := m.CapturePane(); got != "step 1/3\rstep 3/3\r\n" {
+		t.Errorf("CapturePane() = %q, want the raw capture", got)
+	}
+}
@@ -0,0 +1,75 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SessionSummary describes one session on the tmux server for an overview
+type SessionSummary struct {
+	Name string
+	// Attached is the number of clients attached to the session
+	Attached int
+	Windows  []WindowSummary
+}
+
+// WindowSummary describes one window and its active pane
+type WindowSummary struct {
+	Index  int
+	Name   string
+	Active bool
+	// PaneID and Command identify the window's active pane and what it is
+	// running
+	PaneID  string
+	Command string
+}
+
+// overviewFormat is the list-windows format parsed by Overview. Pane
+// fields refer to each window's active pane.
+const overviewFormat = "#{session_name}\t#{session_attached}\t#{window_index}\t#{window_name}\t#{window_active}\t#{pane_id}\t#{pane_current_command}"
+
+// Overview summarises every session on the tmux server the manager talks
+// to, not just its own: each session's windows and what their active panes
+// are running. Sessions are listed in tmux's order. All windows are read
+// in one invocation, so the summary is consistent.
+func (m *Manager) Overview() ([]SessionSummary, error) {
+	stdout, _, err := m.runner.Run("list-windows", "-a", "-F", overviewFormat)
+	if err != nil {
+		// As with listSessions, exit code 1 means there is no server and so
+		// nothing to summarise
+		if exitCode(err) == 1 {
+			return []SessionSummary{}, nil
+		}
+		return nil, fmt.Errorf("failed to list windows: %w", err)
+	}
+
+	sessions := []SessionSummary{}
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("unexpected window format: %s", line)
+		}
+		attached, errA := strconv.Atoi(fields[1])
+		index, errI := strconv.Atoi(fields[2])
+		if errA != nil || errI != nil {
+			return nil, fmt.Errorf("unexpected window format: %s", line)
+		}
+
+		if n := len(sessions); n == 0 || sessions[n-1].Name != fields[0] {
+			sessions = append(sessions, SessionSummary{Name: fields[0], Attached: attached})
+		}
+		current := &sessions[len(sessions)-1]
+		current.Windows = append(current.Windows, WindowSummary{
+			Index:   index,
+			Name:    fields[3],
+			Active:  fields[4] == "1",
+			PaneID:  fields[5],
+			Command: fields[6],
+		})
+	}
+	return sessions, nil
+}
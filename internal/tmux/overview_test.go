@@ -0,0 +1,56 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManager_Overview(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    fakeResponse
+		want    []SessionSummary
+		wantErr bool
+	}{
+		{
+			name: "two sessions",
+			resp: fakeResponse{stdout: "work\t1\t0\teditor\t0\t%0\tvim\n" +
+				"work\t1\t1\tlogs tail\t1\t%1\ttail\n" +
+				"scratch\t0\t0\tbash\t1\t%2\tbash\n"},
+			want: []SessionSummary{
+				{Name: "work", Attached: 1, Windows: []WindowSummary{
+					{Index: 0, Name: "editor", PaneID: "%0", Command: "vim"},
+					{Index: 1, Name: "logs tail", Active: true, PaneID: "%1", Command: "tail"},
+				}},
+				{Name: "scratch", Windows: []WindowSummary{
+					{Index: 0, Name: "bash", Active: true, PaneID: "%2", Command: "bash"},
+				}},
+			},
+		},
+		{
+			name: "no server",
+			resp: fakeResponse{stderr: "no server running on /tmp/tmux-0/default", err: exitError(1)},
+			want: []SessionSummary{},
+		},
+		{
+			name:    "malformed",
+			resp:    fakeResponse{stdout: "work\tone\t0\teditor\t0\t%0\tvim\n"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("list-windows", tt.resp)
+			m := NewManagerWithRunner("work", runner)
+
+			got, err := m.Overview()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Overview() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Overview() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package tmux
+
+import "fmt"
+
+// Page locates one page of a pane's lines as captured by CapturePage
+type Page struct {
+	// Number is the page, counting from 1 at the oldest history line
+	Number int
+	// Size is the number of lines per page; the last page may be shorter
+	Size int
+	// Total is the number of pages the pane's lines currently make
+	Total int
+	// Lines is the range captured, in capture-pane line numbers. It is the
+	// zero range when Number is past Total and nothing was captured.
+	Lines LineRange
+}
+
+// CapturePage splits the pane's lines, from the oldest history line to the
+// bottom of the visible pane, into pages of size lines and captures page
+// number page, counting from 1. Pages are counted from the top, so a page
+// keeps its content as output is added below it, until the history limit
+// makes lines drop off the top. A page past the last one captures nothing,
+// with Total saying how many there are.
+func (m *Manager) CapturePage(page, size int, opts CaptureOptions) (string, Page, error) {
+	if page < 1 || size < 1 {
+		return "", Page{}, fmt.Errorf("invalid page %d of size %d: both must be at least 1", page, size)
+	}
+
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return "", Page{}, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return "", Page{}, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	target, err := m.resolveTarget(opts.Target)
+	if err != nil {
+		return "", Page{}, err
+	}
+	history, height, err := m.paneExtent(target)
+	if err != nil {
+		return "", Page{}, err
+	}
+
+	lines := history + height
+	result := Page{Number: page, Size: size, Total: max((lines+size-1)/size, 1)}
+	if page > result.Total {
+		return "", result, nil
+	}
+	start := -history + (page-1)*size
+	result.Lines = LineRange{Start: start, End: min(start+size-1, height-1)}
+
+	content, err := m.captureLines(target, result.Lines, opts)
+	if err != nil {
+		return "", Page{}, err
+	}
+	return content, result, nil
+}
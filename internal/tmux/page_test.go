@@ -0,0 +1,53 @@
+package tmux
+
+import (
+	"testing"
+)
+
+func TestManager_CapturePage(t *testing.T) {
+	// 1000 history lines and 24 visible make 1024 lines: ten full pages of
+	// 100 and a last page of 24
+	tests := []struct {
+		name        string
+		page        int
+		size        int
+		wantPage    Page
+		wantCapture bool
+	}{
+		{name: "first page", page: 1, size: 100, wantPage: Page{Number: 1, Size: 100, Total: 11, Lines: LineRange{Start: -1000, End: -901}}, wantCapture: true},
+		{name: "middle page", page: 5, size: 100, wantPage: Page{Number: 5, Size: 100, Total: 11, Lines: LineRange{Start: -600, End: -501}}, wantCapture: true},
+		{name: "short last page", page: 11, size: 100, wantPage: Page{Number: 11, Size: 100, Total: 11, Lines: LineRange{Start: 0, End: 23}}, wantCapture: true},
+		{name: "single page", page: 1, size: 5000, wantPage: Page{Number: 1, Size: 5000, Total: 1, Lines: LineRange{Start: -1000, End: 23}}, wantCapture: true},
+		{name: "past the end", page: 12, size: 100, wantPage: Page{Number: 12, Size: 100, Total: 11}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().
+				on("display-message", fakeResponse{stdout: "1000,24\n"}).
+				on("capture-pane", fakeResponse{stdout: "lines\n"})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			content, page, err := m.CapturePage(tt.page, tt.size, CaptureOptions{})
+			if err != nil {
+				t.Fatalf("CapturePage() error = %v", err)
+			}
+			if page != tt.wantPage {
+				t.Errorf("CapturePage() page = %+v, want %+v", page, tt.wantPage)
+			}
+			if captured := runner.lastCall("capture-pane") != nil; captured != tt.wantCapture || (content == "lines\n") != tt.wantCapture {
+				t.Errorf("CapturePage() content = %q after capturing: %v, want capturing: %v", content, captured, tt.wantCapture)
+			}
+		})
+	}
+}
+
+func TestManager_CapturePage_Invalid(t *testing.T) {
+	m := NewManagerWithRunner("fake-session", newFakeRunner())
+
+	for _, args := range [][2]int{{0, 100}, {1, 0}} {
+		if _, _, err := m.CapturePage(args[0], args[1], CaptureOptions{}); err == nil {
+			t.Errorf("CapturePage(%d, %d) error = nil, want an error", args[0], args[1])
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package tmux
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PopupMinVersion is the first tmux release with display-popup
+const PopupMinVersion = "3.2"
+
+// versionNumber matches the release number in `tmux -V` output such as
+// "tmux 3.3a", "tmux next-3.4" or "tmux 3.4-rc"
+var versionNumber = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// ParseVersion returns the major and minor release numbers in a tmux
+// version string. ok is false when there are none, as for a build from
+// tmux's master branch, which reports "tmux master".
+func ParseVersion(version string) (major, minor int, ok bool) {
+	match := versionNumber.FindStringSubmatch(version)
+	if match == nil {
+		return 0, 0, false
+	}
+	major, _ = strconv.Atoi(match[1])
+	minor, _ = strconv.Atoi(match[2])
+	return major, minor, true
+}
+
+// VersionAtLeast reports whether the tmux version string is the given
+// release or later. A version without a number is taken to be a recent
+// development build.
+func VersionAtLeast(version string, wantMajor, wantMinor int) bool {
+	major, minor, ok := ParseVersion(version)
+	if !ok {
+		return true
+	}
+	return major > wantMajor || (major == wantMajor && minor >= wantMinor)
+}
+
+// SupportsPopups reports whether the tmux version string has display-popup
+func SupportsPopups(version string) bool {
+	return VersionAtLeast(version, 3, 2)
+}
+
+// ServerPID returns the process ID of the tmux server (#{pid}). Popups
+// and panes run as its children.
+func (m *Manager) ServerPID() (int, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return 0, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	stdout, stderr, err := m.run("display-message", "-p", "-t", m.sessionName, "#{pid}")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get server pid: %w (stderr: %s)", err, stderr)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(stdout))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected server pid %q", strings.TrimSpace(stdout))
+	}
+	return pid, nil
+}
+
+// ServerPanePIDs returns the process IDs of the programs tmux started in
+// every pane of every session on the server (list-panes -a)
+func (m *Manager) ServerPanePIDs() ([]int, error) {
+	stdout, stderr, err := m.run("list-panes", "-a", "-F", "#{pane_pid}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list panes: %w (stderr: %s)", err, stderr)
+	}
+	pids := []int{}
+	for _, line := range strings.Split(stdout, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		pid, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected pane pid %q", line)
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
@@ -0,0 +1,44 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSupportsPopups(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"tmux 3.3a", true},
+		{"tmux 3.2", true},
+		{"tmux 3.1c", false},
+		{"tmux 2.9a", false},
+		{"tmux next-3.5", true},
+		{"tmux 3.4-rc", true},
+		{"tmux master", true},
+	}
+	for _, tt := range tests {
+		if got := SupportsPopups(tt.version); got != tt.want {
+			t.Errorf("SupportsPopups(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestManager_ServerPIDs(t *testing.T) {
+	runner := newFakeRunner().
+		on("display-message", fakeResponse{stdout: "100\n"}).
+		on("list-panes", fakeResponse{stdout: "101\n205\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if pid, err := m.ServerPID(); err != nil || pid != 100 {
+		t.Errorf("ServerPID() = %d, %v, want 100", pid, err)
+	}
+	pids, err := m.ServerPanePIDs()
+	if err != nil || !reflect.DeepEqual(pids, []int{101, 205}) {
+		t.Errorf("ServerPanePIDs() = %v, %v, want [101 205]", pids, err)
+	}
+	if args := runner.lastCall("list-panes"); !reflect.DeepEqual(args, []string{"list-panes", "-a", "-F", "#{pane_pid}"}) {
+		t.Errorf("list-panes args = %v", args)
+	}
+}
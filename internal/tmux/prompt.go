@@ -0,0 +1,262 @@
+package tmux
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/conall-obrien/mcp-ssh-wingman/internal/ansi"
+)
+
+// Prompt strategies select a PromptDetector by name, as given to
+// --prompt-strategy
+const (
+	PromptStrategyRegex   = "regex"
+	PromptStrategyOSCMark = "oscmark"
+)
+
+// PromptDetector recognises shell prompts in captured pane lines, which
+// is how RunCommand tells that a command has finished
+type PromptDetector interface {
+	// IsPrompt reports whether line is a shell prompt waiting for input
+	IsPrompt(line string) bool
+	// FindLastCommand returns the last command typed at a prompt in lines
+	// and the output it printed since. output runs up to the next prompt,
+	// or to the end of lines while the command is still running. Both are
+	// empty when lines hold no command.
+	FindLastCommand(lines []string) (cmd, output string)
+}
+
+// escapeReader is implemented by detectors that need escape sequences
+// kept in the lines they are given
+type escapeReader interface {
+	readsEscapes() bool
+}
+
+// NewPromptDetector returns the detector for a prompt strategy, oscmark
+// when strategy is empty. prompt is the regex the regex strategy uses, and
+// the oscmark strategy falls back to for lines without markers; nil
+// selects DefaultPromptPattern.
+func NewPromptDetector(strategy string, prompt *regexp.Regexp) (PromptDetector, error) {
+	if prompt == nil {
+		prompt = regexp.MustCompile(DefaultPromptPattern)
+	}
+	switch strategy {
+	case PromptStrategyRegex:
+		return &RegexDetector{Prompt: prompt}, nil
+	case PromptStrategyOSCMark, "":
+		return &OSC133Detector{Fallback: &RegexDetector{Prompt: prompt}}, nil
+	}
+	return nil, fmt.Errorf("unknown prompt strategy %q: expected %q or %q", strategy, PromptStrategyRegex, PromptStrategyOSCMark)
+}
+
+// RegexDetector recognises a prompt as a line matching a regular
+// expression, such as DefaultPromptPattern. It works with any shell, but a
+// line of output that happens to match looks like a prompt too.
+type RegexDetector struct {
+	Prompt *regexp.Regexp
+}
+
+// IsPrompt reports whether line matches the prompt pattern
+func (d *RegexDetector) IsPrompt(line string) bool {
+	return d.Prompt.MatchString(ansi.Strip(line))
+}
+
+// FindLastCommand finds the last line that starts with a prompt followed by
+// a command. The prompt is the shortest start of the line that the pattern
+// matches and that is followed by a space.
+func (d *RegexDetector) FindLastCommand(lines []string) (cmd, output string) {
+	lines = trimBlankLines(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		command, ok := d.commandOn(ansi.Strip(lines[i]))
+		if !ok {
+			continue
+		}
+		end := len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if d.IsPrompt(lines[j]) {
+				end = j
+				break
+			}
+		}
+		return command, stripLines(lines[i+1 : end])
+	}
+	return "", ""
+}
+
+// commandOn returns the command typed after a prompt on line, if any
+func (d *RegexDetector) commandOn(line string) (string, bool) {
+	for i, r := range line {
+		if r != ' ' || i == 0 {
+			continue
+		}
+		if d.Prompt.MatchString(line[:i+1]) {
+			command := strings.TrimSpace(line[i+1:])
+			return command, command != ""
+		}
+	}
+	return "", false
+}
+
+// osc133Mark matches a FinalTerm/OSC 133 semantic prompt marker: A starts
+// the prompt, B ends it where the command starts, C starts the command's
+// output and D ends it, optionally with the exit status
+var osc133Mark = regexp.MustCompile(`\x1b\]133;([ABCD])(?:;[^\x07\x1b]*)?(?:\x07|\x1b\\)`)
+
+// LastPromptMark returns the kind of the last OSC 133 marker in content
+// (A, B, C or D) and the text after it, without escape sequences or
+// surrounding blanks. kind is empty when content has no markers. After a B
+// marker, text is what has been typed at the prompt so far.
+func LastPromptMark(content string) (kind, text string) {
+	locs := osc133Mark.FindAllStringSubmatchIndex(content, -1)
+	if len(locs) == 0 {
+		return "", ""
+	}
+	last := locs[len(locs)-1]
+	return content[last[2]:last[3]], strings.TrimSpace(ansi.Strip(content[last[1]:]))
+}
+
+// osc133Status matches a command-finished marker (D) carrying the
+// command's exit status
+var osc133Status = regexp.MustCompile(`^\x1b\]133;D;(-?\d+)(?:;[^\x07\x1b]*)?(?:\x07|\x1b\\)$`)
+
+// LastExitStatus reads the exit status of the most recent command from the
+// OSC 133 markers in content, which must be captured with escape sequences
+// and prompt marks kept. ok is false when content has no finished marker
+// with a status, or when a command's output started (C) after the last one,
+// since that command's status isn't known yet.
+func LastExitStatus(content string) (status int, ok bool) {
+	locs := osc133Mark.FindAllStringSubmatchIndex(content, -1)
+	for n := len(locs) - 1; n >= 0; n-- {
+		switch content[locs[n][2]:locs[n][3]] {
+		case "C":
+			return 0, false
+		case "D":
+			match := osc133Status.FindStringSubmatch(content[locs[n][0]:locs[n][1]])
+			if match == nil {
+				return 0, false
+			}
+			status, err := strconv.Atoi(match[1])
+			return status, err == nil
+		}
+	}
+	return 0, false
+}
+
+// OSC133Detector reads the semantic prompt markers (OSC 133) that shells
+// with terminal integration print around their prompts, which tells
+// prompts from output without guessing. Lines are expected to be captured
+// with escape sequences. Lines without markers, for instance from a shell
+// without the integration or a tmux that doesn't pass the markers through
+// to captures, are handed to Fallback.
+type OSC133Detector struct {
+	Fallback PromptDetector
+}
+
+func (d *OSC133Detector) readsEscapes() bool { return true }
+
+// StripPromptMarks removes OSC 133 prompt markers from captured content,
+// leaving the prompts, commands and output they delimit
+func StripPromptMarks(content string) string {
+	if !strings.Contains(content, "\x1b]133;") {
+		return content
+	}
+	return osc133Mark.ReplaceAllString(content, "")
+}
+
+// marks returns the markers in line in order, with the text following
+// each one up to the next
+func marks(line string) (kinds []string, texts []string) {
+	locs := osc133Mark.FindAllStringSubmatchIndex(line, -1)
+	for n, loc := range locs {
+		end := len(line)
+		if n+1 < len(locs) {
+			end = locs[n+1][0]
+		}
+		kinds = append(kinds, line[loc[2]:loc[3]])
+		texts = append(texts, ansi.Strip(line[loc[1]:end]))
+	}
+	return kinds, texts
+}
+
+// IsPrompt reports whether line ends in a prompt with nothing typed after
+// it: its last marker ends a prompt (B) and only blanks follow
+func (d *OSC133Detector) IsPrompt(line string) bool {
+	kinds, texts := marks(line)
+	if len(kinds) == 0 {
+		return d.Fallback.IsPrompt(line)
+	}
+	last := len(kinds) - 1
+	return kinds[last] == "B" && strings.TrimSpace(texts[last]) == ""
+}
+
+// FindLastCommand finds the last prompt end (B) with a command after it.
+// The output is what follows the command's C marker, up to its D marker
+// or the next prompt.
+func (d *OSC133Detector) FindLastCommand(lines []string) (cmd, output string) {
+	lines = trimBlankLines(lines)
+	found := false
+	for i := len(lines) - 1; i >= 0; i-- {
+		kinds, texts := marks(lines[i])
+		if len(kinds) > 0 {
+			found = true
+		}
+		for k := len(kinds) - 1; k >= 0; k-- {
+			command := strings.TrimSpace(texts[k])
+			if kinds[k] != "B" || command == "" {
+				continue
+			}
+			return command, d.outputAfter(lines, i, kinds[k+1:], texts[k+1:])
+		}
+	}
+	if !found {
+		return d.Fallback.FindLastCommand(lines)
+	}
+	return "", ""
+}
+
+// outputAfter collects the output of a command typed on lines[start],
+// whose later markers on that line are kinds and texts
+func (d *OSC133Detector) outputAfter(lines []string, start int, kinds, texts []string) string {
+	var out []string
+	for k, kind := range kinds {
+		switch kind {
+		case "C":
+			if text := texts[k]; text != "" {
+				out = append(out, text)
+			}
+		case "D", "A":
+			return strings.Join(out, "\n")
+		}
+	}
+	for _, line := range lines[start+1:] {
+		kinds, texts := marks(line)
+		if len(kinds) == 0 {
+			out = append(out, ansi.Strip(line))
+			continue
+		}
+		// Text before the first marker belongs to the output
+		if before := ansi.Strip(line[:osc133Mark.FindStringIndex(line)[0]]); before != "" {
+			out = append(out, before)
+		}
+		for k, kind := range kinds {
+			if kind == "D" || kind == "A" {
+				return strings.Join(out, "\n")
+			}
+			if texts[k] != "" {
+				out = append(out, texts[k])
+			}
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// stripLines joins lines with their escape sequences removed
+func stripLines(lines []string) string {
+	stripped := make([]string, len(lines))
+	for i, line := range lines {
+		stripped[i] = ansi.Strip(line)
+	}
+	return strings.Join(stripped, "\n")
+}
@@ -0,0 +1,205 @@
+package tmux
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// osc wraps an OSC 133 marker the way shells with terminal integration
+// print it
+func osc(mark string) string {
+	return "\x1b]133;" + mark + "\x07"
+}
+
+func TestRegexDetector(t *testing.T) {
+	d := &RegexDetector{Prompt: regexp.MustCompile(DefaultPromptPattern)}
+
+	for line, want := range map[string]bool{
+		"user@host:~/src$ ":            true,
+		"\x1b[32muser@host\x1b[0m:~$ ": true,
+		"user@host:~/src$ make":        false,
+		"building target":              false,
+	} {
+		if got := d.IsPrompt(line); got != want {
+			t.Errorf("IsPrompt(%q) = %v, want %v", line, got, want)
+		}
+	}
+
+	lines := []string{
+		"user@host:~/src$ ls",
+		"main.go",
+		"user@host:~/src$ make test",
+		"ok   pkg/a",
+		"FAIL pkg/b",
+		"user@host:~/src$ ",
+		"",
+	}
+	cmd, output := d.FindLastCommand(lines)
+	if cmd != "make test" || output != "ok   pkg/a\nFAIL pkg/b" {
+		t.Errorf("FindLastCommand() = (%q, %q), want (\"make test\", \"ok   pkg/a\\nFAIL pkg/b\")", cmd, output)
+	}
+
+	// A command still running has its output so far
+	cmd, output = d.FindLastCommand(lines[:4])
+	if cmd != "make test" || output != "ok   pkg/a" {
+		t.Errorf("FindLastCommand(running) = (%q, %q)", cmd, output)
+	}
+
+	if cmd, output := d.FindLastCommand([]string{"user@host:~$ "}); cmd != "" || output != "" {
+		t.Errorf("FindLastCommand(no command) = (%q, %q), want empty", cmd, output)
+	}
+}
+
+func TestOSC133Detector(t *testing.T) {
+	fallback := &RegexDetector{Prompt: regexp.MustCompile(DefaultPromptPattern)}
+	d := &OSC133Detector{Fallback: fallback}
+
+	prompt := osc("A") + "~/src > " + osc("B")
+	for line, want := range map[string]bool{
+		prompt:                     true,
+		prompt + "  ":              true,
+		prompt + "make" + osc("C"): false,
+		// With markers, the prompt's text doesn't matter
+		osc("D;0") + osc("A") + "λ " + osc("B"): true,
+		// Without them, the fallback regex decides
+		"plain $ ":     true,
+		"plain output": false,
+	} {
+		if got := d.IsPrompt(line); got != want {
+			t.Errorf("IsPrompt(%q) = %v, want %v", line, got, want)
+		}
+	}
+
+	lines := []string{
+		prompt + "ls" + osc("C"),
+		"main.go",
+		osc("D;0") + prompt + "echo '$ not a prompt'" + osc("C"),
+		"$ not a prompt",
+		osc("D;0") + prompt,
+	}
+	cmd, output := d.FindLastCommand(lines)
+	if cmd != "echo '$ not a prompt'" || output != "$ not a prompt" {
+		t.Errorf("FindLastCommand() = (%q, %q)", cmd, output)
+	}
+
+	// Without markers the fallback decides
+	cmd, output = d.FindLastCommand([]string{"host$ uptime", "up 3 days", "host$ "})
+	if cmd != "uptime" || output != "up 3 days" {
+		t.Errorf("FindLastCommand(fallback) = (%q, %q)", cmd, output)
+	}
+}
+
+func TestNewPromptDetector(t *testing.T) {
+	if d, err := NewPromptDetector("", nil); err != nil {
+		t.Errorf("NewPromptDetector(\"\") error = %v", err)
+	} else if _, ok := d.(*OSC133Detector); !ok {
+		t.Errorf("NewPromptDetector(\"\") = %T, want *OSC133Detector", d)
+	}
+	if d, err := NewPromptDetector(PromptStrategyOSCMark, nil); err != nil {
+		t.Errorf("NewPromptDetector(oscmark) error = %v", err)
+	} else if _, ok := d.(*OSC133Detector); !ok {
+		t.Errorf("NewPromptDetector(oscmark) = %T, want *OSC133Detector", d)
+	}
+	if _, err := NewPromptDetector("magic", nil); err == nil {
+		t.Error("NewPromptDetector(magic) succeeded, want an error")
+	}
+}
+
+func TestManager_RunCommand_OSC133(t *testing.T) {
+	prompt := osc("A") + "$ " + osc("B")
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: prompt + "\n"}).
+		on("capture-pane", fakeResponse{stdout: prompt + "make" + osc("C") + "\nbuilt\n" + osc("D;0") + prompt + "\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	detector, _ := NewPromptDetector(PromptStrategyOSCMark, nil)
+	result, err := m.RunCommand("make", RunOptions{Detector: detector, PollInterval: MinPollInterval})
+	if err != nil {
+		t.Fatalf("RunCommand() error = %v", err)
+	}
+	if !result.Completed || result.Output != "built" {
+		t.Errorf("RunCommand() = %+v, want completed with output \"built\"", result)
+	}
+	if args := runner.lastCall("capture-pane"); !slices.Contains(args, "-e") {
+		t.Errorf("capture-pane args = %q, want -e to keep the markers", args)
+	}
+}
+
+func TestLastExitStatus(t *testing.T) {
+	prompt := osc("A") + "$ " + osc("B")
+	tests := []struct {
+		name       string
+		content    string
+		wantStatus int
+		wantOK     bool
+	}{
+		{name: "last command failed", content: prompt + "true" + osc("C") + "\n" + osc("D;0") + prompt + "false" + osc("C") + "\n" + osc("D;1") + prompt, wantStatus: 1, wantOK: true},
+		{name: "string terminator and extra parameters", content: prompt + "make" + osc("C") + "\n\x1b]133;D;2;aid=12\x1b\\" + prompt, wantStatus: 2, wantOK: true},
+		{name: "command still running", content: prompt + "true" + osc("C") + "\n" + osc("D;0") + prompt + "sleep 60" + osc("C") + "\n", wantOK: false},
+		{name: "marker without a status", content: prompt + "true" + osc("C") + "\n" + osc("D") + prompt, wantOK: false},
+		{name: "no markers", content: "$ false\n$ ", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, ok := LastExitStatus(tt.content)
+			if ok != tt.wantOK || status != tt.wantStatus {
+				t.Errorf("LastExitStatus() = %d, %v; want %d, %v", status, ok, tt.wantStatus, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLastPromptMark(t *testing.T) {
+	prompt := osc("A") + "\x1b[32m$\x1b[0m " + osc("B")
+	tests := []struct {
+		content  string
+		wantKind string
+		wantText string
+	}{
+		{content: prompt + "\n\n", wantKind: "B"},
+		{content: prompt + "git sta\n", wantKind: "B", wantText: "git sta"},
+		{content: prompt + "make" + osc("C") + "\nbuilding\n", wantKind: "C", wantText: "building"},
+		{content: "$ ls\n", wantKind: ""},
+	}
+
+	for _, tt := range tests {
+		kind, text := LastPromptMark(tt.content)
+		if kind != tt.wantKind || text != tt.wantText {
+			t.Errorf("LastPromptMark(%q) = %q, %q; want %q, %q", tt.content, kind, text, tt.wantKind, tt.wantText)
+		}
+	}
+}
+
+func TestStripPromptMarks(t *testing.T) {
+	content := osc("A") + "$ " + osc("B") + "ls" + osc("C") + "\nfile\n" + osc("D;0") + osc("A") + "$ " + osc("B")
+	if got, want := StripPromptMarks(content), "$ ls\nfile\n$ "; got != want {
+		t.Errorf("StripPromptMarks() = %q, want %q", got, want)
+	}
+	colored := "\x1b[1mbold\x1b[0m"
+	if got := StripPromptMarks(colored); got != colored {
+		t.Errorf("StripPromptMarks(%q) = %q, want it unchanged", colored, got)
+	}
+}
+
+func TestManager_CapturePane_StripsPromptMarks(t *testing.T) {
+	runner := newFakeRunner().
+		on("capture-pane", fakeResponse{stdout: osc("A") + "$ " + osc("B") + "\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	content, err := m.CapturePaneWithOptions(CaptureOptions{EscapeSequences: true})
+	if err != nil {
+		t.Fatalf("CapturePaneWithOptions() error = %v", err)
+	}
+	if content != "$ \n" {
+		t.Errorf("CapturePaneWithOptions() = %q, want the markers stripped", content)
+	}
+	content, err = m.CapturePaneWithOptions(CaptureOptions{EscapeSequences: true, KeepPromptMarks: true})
+	if err != nil {
+		t.Fatalf("CapturePaneWithOptions() error = %v", err)
+	}
+	if content != osc("A")+"$ "+osc("B")+"\n" {
+		t.Errorf("CapturePaneWithOptions(KeepPromptMarks) = %q, want the markers kept", content)
+	}
+}
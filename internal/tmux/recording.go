@@ -0,0 +1,155 @@
+package tmux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recordedCall is one tmux invocation in a recording, stored as a line of
+// JSON
+type recordedCall struct {
+	Time   time.Time `json:"time"`
+	Args   []string  `json:"args"`
+	Stdout string    `json:"stdout"`
+	Stderr string    `json:"stderr,omitempty"`
+	// ExitCode is tmux's exit status, or -1 when it could not be run at
+	// all, in which case Error holds the reason
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RecordingRunner passes every invocation through to another runner and
+// logs it, with its output and a timestamp, as a line of JSON. The log can
+// be played back with a ReplayRunner.
+type RecordingRunner struct {
+	runner CommandRunner
+	now    func() time.Time
+
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewRecordingRunner returns a runner that runs tmux through runner and
+// records each invocation to w
+func NewRecordingRunner(runner CommandRunner, w io.Writer) *RecordingRunner {
+	return &RecordingRunner{runner: runner, now: time.Now, encoder: json.NewEncoder(w)}
+}
+
+// Run runs tmux through the wrapped runner and records the result
+func (r *RecordingRunner) Run(args ...string) (string, string, error) {
+	stdout, stderr, err := r.runner.Run(args...)
+	r.record(args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+// RunSecret runs tmux through the wrapped runner and records the result
+// with args[secret] replaced by RedactedSecret
+func (r *RecordingRunner) RunSecret(secret int, args ...string) (string, string, error) {
+	var stdout, stderr string
+	var err error
+	if runner, ok := r.runner.(SecretRunner); ok {
+		stdout, stderr, err = runner.RunSecret(secret, args...)
+	} else {
+		stdout, stderr, err = r.runner.Run(args...)
+	}
+	r.record(redactArgs(secret, args), stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+// record logs one invocation and its result
+func (r *RecordingRunner) record(args []string, stdout, stderr string, err error) {
+	call := recordedCall{
+		Time:   r.now().UTC(),
+		Args:   args,
+		Stdout: stdout,
+		Stderr: stderr,
+	}
+	if err != nil {
+		call.ExitCode = exitCode(err)
+		if call.ExitCode < 0 {
+			call.Error = err.Error()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// A failed write only costs the recording, not the command
+	_ = r.encoder.Encode(call)
+}
+
+// ReplayRunner answers tmux invocations from a recording made by a
+// RecordingRunner instead of running tmux. Each invocation is matched by
+// its exact arguments. Repeated invocations get the recorded results in
+// order, and the last one repeats once they run out, so a tool that polls
+// more often than it did while recording still sees the final state.
+type ReplayRunner struct {
+	mu        sync.Mutex
+	responses map[string][]recordedCall
+}
+
+// NewReplayRunner loads a recording from r
+func NewReplayRunner(r io.Reader) (*ReplayRunner, error) {
+	replay := &ReplayRunner{responses: map[string][]recordedCall{}}
+	decoder := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		var call recordedCall
+		if err := decoder.Decode(&call); err != nil {
+			if errors.Is(err, io.EOF) {
+				return replay, nil
+			}
+			return nil, fmt.Errorf("invalid recording entry %d: %w", n, err)
+		}
+		key := replayKey(call.Args)
+		replay.responses[key] = append(replay.responses[key], call)
+	}
+}
+
+// Run returns the next recorded result for args. An invocation that was
+// never recorded fails as if tmux could not be run.
+func (r *ReplayRunner) Run(args ...string) (string, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := replayKey(args)
+	queue := r.responses[key]
+	if len(queue) == 0 {
+		return "", "", fmt.Errorf("no recorded response for tmux %s", strings.Join(args, " "))
+	}
+	call := queue[0]
+	if len(queue) > 1 {
+		r.responses[key] = queue[1:]
+	}
+
+	switch {
+	case call.ExitCode > 0:
+		return call.Stdout, call.Stderr, replayedExit(call.ExitCode)
+	case call.ExitCode < 0:
+		return call.Stdout, call.Stderr, errors.New(call.Error)
+	}
+	return call.Stdout, call.Stderr, nil
+}
+
+// RunSecret returns the next recorded result for args, which were recorded
+// with args[secret] redacted
+func (r *ReplayRunner) RunSecret(secret int, args ...string) (string, string, error) {
+	return r.Run(redactArgs(secret, args)...)
+}
+
+// replayKey identifies an invocation by its arguments
+func replayKey(args []string) string {
+	return strings.Join(args, "\x00")
+}
+
+// replayedExit is a recorded non-zero exit status
+type replayedExit int
+
+func (e replayedExit) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+
+// ExitCode returns the recorded status, so replayed failures are classified
+// like live ones
+func (e replayedExit) ExitCode() int { return int(e) }
@@ -0,0 +1,100 @@
+package tmux
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordingRunner_RoundTrip(t *testing.T) {
+	live := newFakeRunner().
+		on("has-session", fakeResponse{stderr: "can't find session: x\n", err: exitError(1)}).
+		on("has-session", fakeResponse{}).
+		on("capture-pane", fakeResponse{stdout: "$ \n"}).
+		on("-V", fakeResponse{err: errors.New("exec: \"tmux\": executable file not found in $PATH")})
+
+	var log bytes.Buffer
+	recorder := NewRecordingRunner(live, &log)
+	recorder.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+	calls := [][]string{
+		{"has-session", "-t", "x"},
+		{"has-session", "-t", "x"},
+		{"capture-pane", "-t", "x", "-p"},
+		{"-V"},
+	}
+	for _, args := range calls {
+		recorder.Run(args...)
+	}
+	if got := strings.Count(log.String(), "\n"); got != len(calls) {
+		t.Fatalf("recorded %d lines, want %d:\n%s", got, len(calls), log.String())
+	}
+	if !strings.Contains(log.String(), `"time":"2025-01-02T03:04:05Z"`) {
+		t.Errorf("recording lacks timestamps:\n%s", log.String())
+	}
+
+	replay, err := NewReplayRunner(&log)
+	if err != nil {
+		t.Fatalf("NewReplayRunner() error = %v", err)
+	}
+
+	// The failed has-session keeps its exit status, then the next result
+	// plays, then the last one repeats
+	if _, stderr, err := replay.Run("has-session", "-t", "x"); exitCode(err) != 1 || stderr != "can't find session: x\n" {
+		t.Errorf("first has-session = (%q, %v), want exit status 1", stderr, err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := replay.Run("has-session", "-t", "x"); err != nil {
+			t.Errorf("later has-session error = %v, want nil", err)
+		}
+	}
+	if stdout, _, err := replay.Run("capture-pane", "-t", "x", "-p"); err != nil || stdout != "$ \n" {
+		t.Errorf("capture-pane = (%q, %v), want (\"$ \\n\", nil)", stdout, err)
+	}
+	if _, _, err := replay.Run("-V"); err == nil || exitCode(err) != -1 {
+		t.Errorf("-V error = %v, want a failure to run tmux", err)
+	}
+	if _, _, err := replay.Run("kill-server"); err == nil {
+		t.Error("unrecorded invocation succeeded, want error")
+	}
+}
+
+func TestNewReplayRunner_Invalid(t *testing.T) {
+	if _, err := NewReplayRunner(strings.NewReader("{\"args\":[\"-V\"]}\nnot json\n")); err == nil {
+		t.Error("NewReplayRunner() with a corrupt entry succeeded, want error")
+	}
+}
+
+func TestReplayRunner_RunCommand(t *testing.T) {
+	// Recorded from tmux 3.3a running `echo hello` at a "# " prompt
+	f, err := os.Open("testdata/run_command.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	replay, err := NewReplayRunner(f)
+	if err != nil {
+		t.Fatalf("NewReplayRunner() error = %v", err)
+	}
+
+	m := NewManagerWithRunner("demo", replay)
+	if err := m.EnsureSession(); err != nil {
+		t.Fatalf("EnsureSession() error = %v", err)
+	}
+	result, err := m.RunCommand("echo hello", RunOptions{
+		Timeout:      time.Second,
+		PollInterval: MinPollInterval,
+		ExitSentinel: DefaultExitSentinel,
+	})
+	if err != nil {
+		t.Fatalf("RunCommand() error = %v", err)
+	}
+	if !result.Completed || result.Output != "hello" {
+		t.Errorf("RunCommand() = %+v, want completed with output \"hello\"", result)
+	}
+	if result.ExitCode == nil || *result.ExitCode != 0 {
+		t.Errorf("RunCommand() exit code = %v, want 0", result.ExitCode)
+	}
+}
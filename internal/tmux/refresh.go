@@ -0,0 +1,69 @@
+package tmux
+
+import "fmt"
+
+// Ways of making the program in a pane repaint, as given to Refresh
+const (
+	// RefreshResize shrinks the pane's window by a column and restores
+	// it, so the program gets SIGWINCH and redraws for the "new" size.
+	// Nothing is typed.
+	RefreshResize = "resize"
+	// RefreshRedraw sends C-l, which most full-screen programs take as a
+	// request to repaint. At a shell prompt it clears the screen.
+	RefreshRedraw = "redraw"
+)
+
+// Refresh coaxes the program in the pane target selects into repainting,
+// for programs that leave a stale frame on screen until something
+// happens. method is RefreshResize or RefreshRedraw. Both are seen by the
+// program: a resize reaches every pane in the window, and C-l is input.
+func (m *Manager) Refresh(target Target, method string) error {
+	if method != RefreshResize && method != RefreshRedraw {
+		return fmt.Errorf("unknown refresh method %q: expected %q or %q", method, RefreshResize, RefreshRedraw)
+	}
+
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return err
+	}
+	if method == RefreshRedraw {
+		return m.sendKeys(resolved, "C-l")
+	}
+
+	width, height, err := m.windowSize(resolved)
+	if err != nil {
+		return err
+	}
+	// A one-column window can only grow
+	nudged := width - 1
+	if nudged < 1 {
+		nudged = width + 1
+	}
+	if err := m.resizeWindow(resolved, nudged, height); err != nil {
+		return err
+	}
+	if err := m.resizeWindow(resolved, width, height); err != nil {
+		return fmt.Errorf("nudged the window to %dx%d but could not restore it: %w", nudged, height, err)
+	}
+
+	// resize-window leaves the window sized manually. With clients
+	// attached, hand the size back to them; a detached window keeps its
+	// size, since it would otherwise fall back to default-size.
+	clients, err := m.ListClients()
+	if err != nil {
+		return err
+	}
+	if len(clients) == 0 {
+		return nil
+	}
+	return m.resetWindowSize(resolved)
+}
@@ -0,0 +1,173 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxWindowSize is the largest width or height tmux accepts for a window
+const MaxWindowSize = 10000
+
+// WindowSize returns the size of the window holding the pane target selects
+func (m *Manager) WindowSize(target Target) (width, height int, err error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return 0, 0, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return 0, 0, err
+	}
+	return m.windowSize(resolved)
+}
+
+// PaneSize returns the visible width and height of the pane target
+// selects, in one tmux invocation after the usual checks. It is much
+// cheaper than GetPaneInfoFor when only the size is needed.
+func (m *Manager) PaneSize(target Target) (width, height int, err error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return 0, 0, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return 0, 0, err
+	}
+	stdout, _, err := m.run("display-message", "-t", resolved, "-p", "#{pane_width}x#{pane_height}")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get pane size: %w", err)
+	}
+	width, height, ok := parseSize(strings.TrimSpace(stdout))
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected pane size format: %s", stdout)
+	}
+	return width, height, nil
+}
+
+// windowSize reads the size of the window holding an already resolved target
+func (m *Manager) windowSize(target string) (width, height int, err error) {
+	stdout, _, err := m.run("display-message", "-t", target, "-p", "#{window_width}x#{window_height}")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get window size: %w", err)
+	}
+	width, height, ok := parseSize(strings.TrimSpace(stdout))
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected window size format: %s", stdout)
+	}
+	return width, height, nil
+}
+
+// ResizeWindow sets the size of the window holding the pane target selects.
+// tmux then sizes the window manually, no longer following the clients
+// attached to it, until its window-size option is reset.
+func (m *Manager) ResizeWindow(target Target, width, height int) error {
+	if err := validateWindowSize(width, height); err != nil {
+		return err
+	}
+
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return err
+	}
+	return m.resizeWindow(resolved, width, height)
+}
+
+// resizeWindow resizes the window holding an already resolved target
+func (m *Manager) resizeWindow(target string, width, height int) error {
+	_, stderr, err := m.run("resize-window", "-t", target, "-x", strconv.Itoa(width), "-y", strconv.Itoa(height))
+	if err != nil {
+		return fmt.Errorf("failed to resize window to %dx%d: %w (stderr: %s)", width, height, err, stderr)
+	}
+	return nil
+}
+
+// validateWindowSize checks that tmux will accept a window size
+func validateWindowSize(width, height int) error {
+	if width < 1 || width > MaxWindowSize || height < 1 || height > MaxWindowSize {
+		return fmt.Errorf("invalid window size %dx%d: width and height must be between 1 and %d", width, height, MaxWindowSize)
+	}
+	return nil
+}
+
+// CaptureAtSize resizes the window holding the pane target selects to
+// width x height, captures the pane with wrapped lines joined, and puts
+// the window back to its original size, even if the capture fails. tmux
+// rewraps the pane's history when it resizes, so long lines come back as
+// they would have been printed at the new width. A height of 0 keeps the
+// window's height. Afterwards the window's size follows its clients again,
+// as it did before, rather than staying manual.
+func (m *Manager) CaptureAtSize(target Target, width, height int) (string, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return "", fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return "", &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return "", err
+	}
+
+	origWidth, origHeight, err := m.windowSize(resolved)
+	if err != nil {
+		return "", err
+	}
+	if height == 0 {
+		height = origHeight
+	}
+	if err := validateWindowSize(width, height); err != nil {
+		return "", err
+	}
+
+	if err := m.resizeWindow(resolved, width, height); err != nil {
+		return "", err
+	}
+	content, captureErr := m.capture(resolved, CaptureOptions{JoinLines: true})
+	restoreErr := m.resizeWindow(resolved, origWidth, origHeight)
+	if restoreErr == nil {
+		// resize-window left the window sized manually; hand its size
+		// back to the clients that attach to it
+		restoreErr = m.resetWindowSize(resolved)
+	}
+	if captureErr != nil {
+		return "", captureErr
+	}
+	if restoreErr != nil {
+		return "", fmt.Errorf("captured at %dx%d but could not restore the original size: %w", width, height, restoreErr)
+	}
+	return content, nil
+}
+
+// resetWindowSize unsets the window-size option resize-window sets to
+// manual on the window holding an already resolved target, so the window
+// follows its clients' sizes again
+func (m *Manager) resetWindowSize(target string) error {
+	_, stderr, err := m.run("set-option", "-w", "-u", "-t", target, "window-size")
+	if err != nil {
+		return fmt.Errorf("failed to reset window-size: %w (stderr: %s)", err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManager_CaptureAtSize(t *testing.T) {
+	runner := newFakeRunner().
+		on("display-message", fakeResponse{stdout: "80x24\n"}).
+		on("capture-pane", fakeResponse{stdout: "a very wide table row\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	content, err := m.CaptureAtSize(Target{}, 200, 0)
+	if err != nil {
+		t.Fatalf("CaptureAtSize() error = %v", err)
+	}
+	if content != "a very wide table row\n" {
+		t.Errorf("CaptureAtSize() = %q", content)
+	}
+
+	var got [][]string
+	for _, call := range runner.calls {
+		switch call[0] {
+		case "resize-window", "capture-pane", "set-option":
+			got = append(got, call)
+		}
+	}
+	want := [][]string{
+		{"resize-window", "-t", "fake-session", "-x", "200", "-y", "24"},
+		{"capture-pane", "-t", "fake-session", "-p", "-S", "-", "-J"},
+		{"resize-window", "-t", "fake-session", "-x", "80", "-y", "24"},
+		{"set-option", "-w", "-u", "-t", "fake-session", "window-size"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestManager_CaptureAtSize_RestoresAfterFailedCapture(t *testing.T) {
+	runner := newFakeRunner().
+		on("display-message", fakeResponse{stdout: "80x24\n"}).
+		on("capture-pane", fakeResponse{stderr: "bad things", err: exitError(1)})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if _, err := m.CaptureAtSize(Target{}, 120, 40); err == nil {
+		t.Fatal("CaptureAtSize() error = nil, want the capture failure")
+	}
+	want := []string{"resize-window", "-t", "fake-session", "-x", "80", "-y", "24"}
+	if got := runner.lastCall("resize-window"); !reflect.DeepEqual(got, want) {
+		t.Errorf("last resize = %v, want %v", got, want)
+	}
+	want = []string{"set-option", "-w", "-u", "-t", "fake-session", "window-size"}
+	if got := runner.lastCall("set-option"); !reflect.DeepEqual(got, want) {
+		t.Errorf("last set-option = %v, want %v", got, want)
+	}
+}
+
+func TestManager_ResizeWindow_Invalid(t *testing.T) {
+	runner := newFakeRunner()
+	m := NewManagerWithRunner("fake-session", runner)
+
+	for _, size := range [][2]int{{0, 24}, {80, 0}, {MaxWindowSize + 1, 24}} {
+		if err := m.ResizeWindow(Target{}, size[0], size[1]); err == nil {
+			t.Errorf("ResizeWindow(%d, %d) error = nil, want invalid size", size[0], size[1])
+		}
+	}
+	if countCalls(runner, "resize-window") != 0 {
+		t.Error("resize-window ran with an invalid size")
+	}
+}
+
+func TestManager_Refresh(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		clients string
+		want    [][]string
+	}{
+		{
+			name:   "resize detached",
+			method: RefreshResize,
+			want: [][]string{
+				{"resize-window", "-t", "fake-session", "-x", "79", "-y", "24"},
+				{"resize-window", "-t", "fake-session", "-x", "80", "-y", "24"},
+			},
+		},
+		{
+			name:    "resize attached",
+			method:  RefreshResize,
+			clients: "/dev/pts/1 80x24 1700000000\n",
+			want: [][]string{
+				{"resize-window", "-t", "fake-session", "-x", "79", "-y", "24"},
+				{"resize-window", "-t", "fake-session", "-x", "80", "-y", "24"},
+				{"set-option", "-w", "-u", "-t", "fake-session", "window-size"},
+			},
+		},
+		{
+			name:   "redraw",
+			method: RefreshRedraw,
+			want:   [][]string{{"send-keys", "-t", "fake-session", "C-l"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().
+				on("display-message", fakeResponse{stdout: "80x24\n"}).
+				on("list-clients", fakeResponse{stdout: tt.clients})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			if err := m.Refresh(Target{}, tt.method); err != nil {
+				t.Fatalf("Refresh() error = %v", err)
+			}
+			var got [][]string
+			for _, call := range runner.calls {
+				switch call[0] {
+				case "resize-window", "set-option", "send-keys":
+					got = append(got, call)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("calls = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	m := NewManagerWithRunner("fake-session", newFakeRunner())
+	if err := m.Refresh(Target{}, "shake"); err == nil {
+		t.Error("Refresh(shake) succeeded, want an error")
+	}
+}
@@ -0,0 +1,37 @@
+package tmux
+
+import (
+	"bytes"
+	"os/exec"
+)
+
+// CommandRunner executes tmux with the given arguments and returns its
+// stdout and stderr. The Manager routes every tmux invocation through a
+// runner so tests can substitute canned output for a live tmux server.
+type CommandRunner interface {
+	Run(args ...string) (stdout string, stderr string, err error)
+}
+
+// RunnerFunc adapts an ordinary function to the CommandRunner interface
+type RunnerFunc func(args ...string) (string, string, error)
+
+// Run calls f(args...)
+func (f RunnerFunc) Run(args ...string) (string, string, error) {
+	return f(args...)
+}
+
+// execRunner runs the real tmux binary
+type execRunner struct{}
+
+// Run executes tmux with args and captures its output
+func (execRunner) Run(args ...string) (string, string, error) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("tmux", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
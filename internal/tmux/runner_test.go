@@ -0,0 +1,104 @@
+package tmux
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fakeResponse is a canned result for one tmux invocation
+type fakeResponse struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+// fakeRunner records tmux invocations and answers them from canned
+// responses keyed by tmux subcommand. When several responses are queued for
+// a subcommand they are returned in order, and the last one repeats.
+type fakeRunner struct {
+	calls     [][]string
+	responses map[string][]fakeResponse
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{responses: map[string][]fakeResponse{}}
+}
+
+// on queues a response for subcommand
+func (f *fakeRunner) on(subcommand string, resp fakeResponse) *fakeRunner {
+	f.responses[subcommand] = append(f.responses[subcommand], resp)
+	return f
+}
+
+func (f *fakeRunner) Run(args ...string) (string, string, error) {
+	f.calls = append(f.calls, args)
+	if len(args) == 0 {
+		return "", "", nil
+	}
+	queue := f.responses[args[0]]
+	if len(queue) == 0 {
+		return "", "", nil
+	}
+	resp := queue[0]
+	if len(queue) > 1 {
+		f.responses[args[0]] = queue[1:]
+	}
+	return resp.stdout, resp.stderr, resp.err
+}
+
+// lastCall returns the arguments of the most recent invocation of subcommand
+func (f *fakeRunner) lastCall(subcommand string) []string {
+	for i := len(f.calls) - 1; i >= 0; i-- {
+		if len(f.calls[i]) > 0 && f.calls[i][0] == subcommand {
+			return f.calls[i]
+		}
+	}
+	return nil
+}
+
+// exitError simulates a tmux process that exited with a non-zero status
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+func TestRunnerFunc(t *testing.T) {
+	var got []string
+	runner := RunnerFunc(func(args ...string) (string, string, error) {
+		got = args
+		return "out", "err", nil
+	})
+
+	stdout, stderr, err := runner.Run("list-sessions", "-F", "#{session_name}")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if stdout != "out" || stderr != "err" {
+		t.Errorf("Run() = (%q, %q), want (\"out\", \"err\")", stdout, stderr)
+	}
+	want := []string{"list-sessions", "-F", "#{session_name}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Run() args = %v, want %v", got, want)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "exit status", err: exitError(1), want: 1},
+		{name: "wrapped exit status", err: fmt.Errorf("wrapped: %w", exitError(2)), want: 2},
+		{name: "other error", err: fmt.Errorf("boom"), want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
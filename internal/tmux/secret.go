@@ -0,0 +1,64 @@
+package tmux
+
+import (
+	"fmt"
+	"slices"
+)
+
+// RedactedSecret stands in for a secret wherever an invocation is logged or
+// reported: recordings, and the arguments of a CommandError
+const RedactedSecret = "[SECRET REDACTED]"
+
+// SecretRunner is implemented by runners that log what they run, such as
+// RecordingRunner, so that an argument holding a secret stays out of the
+// log
+type SecretRunner interface {
+	// RunSecret runs tmux with args like Run, but must not log
+	// args[secret]
+	RunSecret(secret int, args ...string) (string, string, error)
+}
+
+// redactArgs returns a copy of args with args[secret] replaced by
+// RedactedSecret
+func redactArgs(secret int, args []string) []string {
+	redacted := slices.Clone(args)
+	redacted[secret] = RedactedSecret
+	return redacted
+}
+
+// runSecret runs tmux like run, with args[secret] kept out of logs and
+// errors. Like every send-keys, it is never retried, since a retry could
+// type a password twice.
+func (m *Manager) runSecret(secret int, args ...string) (stdout, stderr string, err error) {
+	if runner, ok := m.runner.(SecretRunner); ok {
+		stdout, stderr, err = runner.RunSecret(secret, args...)
+	} else {
+		stdout, stderr, err = m.runner.Run(args...)
+	}
+	if isServerGone(err, stderr) {
+		m.serverGone = true
+	}
+	if err != nil {
+		err = &CommandError{Args: redactArgs(secret, args), Stderr: stderr, Err: err}
+	}
+	return stdout, stderr, err
+}
+
+// SendSecret types secret literally into the pane selected by target, then
+// presses Enter if enter is set. The secret never appears in a recording or
+// in the error returned.
+func (m *Manager) SendSecret(target Target, secret string, enter bool) error {
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return err
+	}
+	// "--" stops tmux treating a secret that starts with "-" as a flag
+	args := []string{"send-keys", "-t", resolved, "-l", "--", secret}
+	if _, stderr, err := m.runSecret(len(args)-1, args...); err != nil {
+		return fmt.Errorf("failed to send secret: %w (stderr: %s)", err, stderr)
+	}
+	if enter {
+		return m.sendKeys(resolved, "Enter")
+	}
+	return nil
+}
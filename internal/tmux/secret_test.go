@@ -0,0 +1,48 @@
+package tmux
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestManager_SendSecret(t *testing.T) {
+	live := newFakeRunner()
+	var log bytes.Buffer
+	m := NewManagerWithRunner("fake-session", NewRecordingRunner(live, &log))
+
+	if err := m.SendSecret(Target{}, "-hunter2", true); err != nil {
+		t.Fatalf("SendSecret() error = %v", err)
+	}
+	want := [][]string{
+		{"send-keys", "-t", "fake-session", "-l", "--", "-hunter2"},
+		{"send-keys", "-t", "fake-session", "Enter"},
+	}
+	if !reflect.DeepEqual(live.calls, want) {
+		t.Errorf("tmux calls = %q, want %q", live.calls, want)
+	}
+	if strings.Contains(log.String(), "hunter2") || !strings.Contains(log.String(), RedactedSecret) {
+		t.Errorf("recording = %s, want the secret redacted", log.String())
+	}
+
+	replay, err := NewReplayRunner(&log)
+	if err != nil {
+		t.Fatalf("NewReplayRunner() error = %v", err)
+	}
+	if err := NewManagerWithRunner("fake-session", replay).SendSecret(Target{}, "-hunter2", true); err != nil {
+		t.Errorf("replayed SendSecret() error = %v", err)
+	}
+
+	failing := NewManagerWithRunner("fake-session", newFakeRunner().on("send-keys", fakeResponse{stderr: "no such pane", err: exitError(1)}))
+	err = failing.SendSecret(Target{}, "-hunter2", false)
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("SendSecret() error = %v, want a CommandError", err)
+	}
+	if strings.Contains(err.Error(), "hunter2") || slices.Contains(cmdErr.Args, "-hunter2") {
+		t.Errorf("SendSecret() error = %v (args %q), want the secret left out", err, cmdErr.Args)
+	}
+}
@@ -0,0 +1,147 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionInfo describes one session listed by list-sessions
+type SessionInfo struct {
+	Name string
+	// Windows is the number of windows in the session
+	Windows int
+	// Attached is the number of clients attached to the session
+	Attached int
+	// Created is when the session was created, or the zero time if tmux
+	// didn't report it
+	Created time.Time
+	// Width and Height are the size of the session's active window, which
+	// is what an attaching client sees
+	Width  int
+	Height int
+}
+
+// sessionFormat is the list-sessions format parsed by parseSessionLine.
+// Window fields refer to each session's active window.
+const sessionFormat = "#{session_name}\t#{session_windows}\t#{session_attached}\t#{session_created}\t#{window_width}\t#{window_height}"
+
+// ListSessionsDetailed lists all tmux sessions with their details
+func ListSessionsDetailed() ([]SessionInfo, error) {
+	return listSessionsDetailed(execRunner{})
+}
+
+// SessionsDetailed lists all tmux sessions on the server the manager talks
+// to, with their details
+func (m *Manager) SessionsDetailed() ([]SessionInfo, error) {
+	return listSessionsDetailed(m.runner)
+}
+
+// listSessionsDetailed lists all tmux sessions visible to runner
+func listSessionsDetailed(runner CommandRunner) ([]SessionInfo, error) {
+	stdout, _, err := runner.Run("list-sessions", "-F", sessionFormat)
+	if err != nil {
+		// As with listSessions, exit code 1 means there is no server
+		if exitCode(err) == 1 {
+			return []SessionInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return parseSessions(stdout)
+}
+
+// ResolveSession finds the session on the server that query names. A
+// session named exactly query always wins, whether or not allowed accepts
+// it, so the caller can refuse it by name. Otherwise query is matched
+// against the sessions allowed accepts (every session if allowed is nil):
+// first as a prefix of their names, then as a substring ignoring case.
+// The first kind of match to find anything must find exactly one session,
+// or an AmbiguousSessionError lists the candidates. Nothing matching gives
+// a SessionNotFoundError.
+func (m *Manager) ResolveSession(query string, allowed func(name string) bool) (string, error) {
+	sessions, err := listSessions(m.runner)
+	if err != nil {
+		return "", err
+	}
+	var candidates []string
+	for _, name := range sessions {
+		if name == query {
+			return name, nil
+		}
+		if allowed == nil || allowed(name) {
+			candidates = append(candidates, name)
+		}
+	}
+	if query == "" {
+		return "", &SessionNotFoundError{Session: query}
+	}
+
+	lower := strings.ToLower(query)
+	for _, match := range []func(name string) bool{
+		func(name string) bool { return strings.HasPrefix(name, query) },
+		func(name string) bool { return strings.Contains(strings.ToLower(name), lower) },
+	} {
+		var matches []string
+		for _, name := range candidates {
+			if match(name) {
+				matches = append(matches, name)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0], nil
+		default:
+			return "", &AmbiguousSessionError{Query: query, Candidates: matches}
+		}
+	}
+	return "", &SessionNotFoundError{Session: query}
+}
+
+// parseSessions reads list-sessions output in sessionFormat
+func parseSessions(output string) ([]SessionInfo, error) {
+	sessions := []SessionInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		session, err := parseSessionLine(line)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// parseSessionLine parses one line of list-sessions output in sessionFormat
+func parseSessionLine(line string) (SessionInfo, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 6 {
+		return SessionInfo{}, fmt.Errorf("unexpected session format: %s", line)
+	}
+
+	var nums [4]int
+	for i, field := range []string{fields[1], fields[2], fields[4], fields[5]} {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return SessionInfo{}, fmt.Errorf("unexpected session format: %s", line)
+		}
+		nums[i] = n
+	}
+	created, err := parseEpoch(fields[3])
+	if err != nil {
+		return SessionInfo{}, fmt.Errorf("unexpected session format: %s", line)
+	}
+
+	return SessionInfo{
+		Name:     fields[0],
+		Windows:  nums[0],
+		Attached: nums[1],
+		Created:  created,
+		Width:    nums[2],
+		Height:   nums[3],
+	}, nil
+}
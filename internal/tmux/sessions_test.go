@@ -0,0 +1,82 @@
+package tmux
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSessions(t *testing.T) {
+	output := "wingman\t3\t1\t1699990000\t200\t50\n" +
+		"build box\t1\t0\t1700000000\t80\t24\n" +
+		"\n"
+
+	sessions, err := parseSessions(output)
+	if err != nil {
+		t.Fatalf("parseSessions() error = %v", err)
+	}
+	want := []SessionInfo{
+		{Name: "wingman", Windows: 3, Attached: 1, Created: time.Unix(1699990000, 0).UTC(), Width: 200, Height: 50},
+		{Name: "build box", Windows: 1, Attached: 0, Created: time.Unix(1700000000, 0).UTC(), Width: 80, Height: 24},
+	}
+	if !reflect.DeepEqual(sessions, want) {
+		t.Errorf("parseSessions() = %+v, want %+v", sessions, want)
+	}
+
+	for _, line := range []string{"wingman\t3\t1", "wingman\tthree\t1\t1699990000\t200\t50"} {
+		if _, err := parseSessions(line); err == nil {
+			t.Errorf("parseSessions(%q) error = nil, want an error", line)
+		}
+	}
+}
+
+func TestManager_SessionsDetailed_NoServer(t *testing.T) {
+	runner := newFakeRunner().on("list-sessions", fakeResponse{stderr: "no server running", err: exitError(1)})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	sessions, err := m.SessionsDetailed()
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("SessionsDetailed() = %v, %v; want no sessions", sessions, err)
+	}
+}
+
+func TestManager_ResolveSession(t *testing.T) {
+	allowed := func(name string) bool { return name != "secret-deploy" }
+
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr error
+	}{
+		{name: "exact", query: "deploy", want: "deploy"},
+		{name: "exact beats prefix", query: "dev", want: "dev"},
+		{name: "exact but not allowed", query: "secret-deploy", want: "secret-deploy"},
+		{name: "prefix", query: "deploy-", want: "deploy-staging"},
+		{name: "prefix beats substring", query: "we", want: "web"},
+		{name: "substring ignoring case", query: "STAG", want: "deploy-staging"},
+		{name: "ambiguous prefix", query: "de", wantErr: ErrAmbiguousSession},
+		{name: "disallowed sessions are not candidates", query: "secret", wantErr: ErrSessionNotFound},
+		{name: "no match", query: "prod", wantErr: ErrSessionNotFound},
+		{name: "empty", query: "", wantErr: ErrSessionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("list-sessions", fakeResponse{stdout: "deploy\ndeploy-staging\ndev\ndev-web\nweb\nsecret-deploy\n"})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			got, err := m.ResolveSession(tt.query, allowed)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ResolveSession(%q) = %q, %v; want %v", tt.query, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ResolveSession(%q) = %q, %v; want %q", tt.query, got, err, tt.want)
+			}
+		})
+	}
+}
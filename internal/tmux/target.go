@@ -0,0 +1,308 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Target selects a pane within the managed session. The zero Target is the
+// session's active pane.
+type Target struct {
+	// Window is a window index or name; empty selects the active window
+	Window string
+	// Pane is a pane index within the window or a pane ID such as "%3".
+	// Empty selects the window's active pane.
+	Pane string
+}
+
+// IsZero reports whether t selects the session's active pane
+func (t Target) IsZero() bool {
+	return t.Window == "" && t.Pane == ""
+}
+
+func (t Target) String() string {
+	switch {
+	case t.IsZero():
+		return "active pane"
+	case t.Window == "":
+		return "pane " + t.Pane
+	case t.Pane == "":
+		return "window " + t.Window
+	default:
+		return fmt.Sprintf("window %s pane %s", t.Window, t.Pane)
+	}
+}
+
+// PaneInfo describes one pane in the session
+type PaneInfo struct {
+	ID          string
+	WindowIndex int
+	WindowName  string
+	PaneIndex   int
+	// Active reports whether this is the active pane of its window;
+	// WindowActive whether its window is the session's active window
+	Active       bool
+	WindowActive bool
+	Width        int
+	Height       int
+	Command      string
+	// Dead reports whether the pane's process has exited; the pane only
+	// remains if remain-on-exit is set, showing stale content. DeadStatus
+	// is the process's exit status, or nil if it is still running.
+	Dead       bool
+	DeadStatus *int
+	// WindowActivity is when the pane's window last saw activity and
+	// SessionCreated when the session was created. Either is the zero time
+	// if tmux didn't report it.
+	WindowActivity time.Time
+	SessionCreated time.Time
+}
+
+// paneFormat is the list-panes format parsed by parsePaneLine. Fields are
+// tab-separated because window names may contain spaces.
+const paneFormat = "#{pane_id}\t#{window_index}\t#{window_name}\t#{pane_index}\t#{pane_active}\t#{window_active}\t#{pane_width}\t#{pane_height}\t#{pane_current_command}\t#{pane_dead}\t#{pane_dead_status}\t#{window_activity}\t#{session_created}"
+
+// ListPanes returns every pane in every window of the session
+func (m *Manager) ListPanes() ([]PaneInfo, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return nil, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	return m.listPanes()
+}
+
+// WindowPanes returns the panes of one window in the session: the window
+// with the given index or name, or the active window when window is empty
+func (m *Manager) WindowPanes(window string) ([]PaneInfo, error) {
+	panes, err := m.ListPanes()
+	if err != nil {
+		return nil, err
+	}
+
+	t := Target{Window: window}
+	var matched []PaneInfo
+	for _, pane := range panes {
+		if t.matchesWindow(pane) {
+			matched = append(matched, pane)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, &UnknownTargetError{Session: m.sessionName, Target: t}
+	}
+	return matched, nil
+}
+
+// listPanes lists the session's panes without checking the session exists
+func (m *Manager) listPanes() ([]PaneInfo, error) {
+	stdout, _, err := m.run("list-panes", "-s", "-t", m.sessionName, "-F", paneFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list panes: %w", err)
+	}
+
+	panes := []PaneInfo{}
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		pane, err := parsePaneLine(line)
+		if err != nil {
+			return nil, err
+		}
+		panes = append(panes, pane)
+	}
+	m.forgetCaptures(panes)
+	return panes, nil
+}
+
+// parsePaneLine parses one line of list-panes output in paneFormat
+func parsePaneLine(line string) (PaneInfo, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 13 {
+		return PaneInfo{}, fmt.Errorf("unexpected pane format: %s", line)
+	}
+
+	var nums [4]int
+	for i, field := range []string{fields[1], fields[3], fields[6], fields[7]} {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return PaneInfo{}, fmt.Errorf("unexpected pane format: %s", line)
+		}
+		nums[i] = n
+	}
+
+	pane := PaneInfo{
+		ID:           fields[0],
+		WindowIndex:  nums[0],
+		WindowName:   fields[2],
+		PaneIndex:    nums[1],
+		Active:       fields[4] == "1",
+		WindowActive: fields[5] == "1",
+		Width:        nums[2],
+		Height:       nums[3],
+		Command:      fields[8],
+		Dead:         fields[9] == "1",
+	}
+	if pane.Dead && fields[10] != "" {
+		status, err := strconv.Atoi(fields[10])
+		if err != nil {
+			return PaneInfo{}, fmt.Errorf("unexpected pane format: %s", line)
+		}
+		pane.DeadStatus = &status
+	}
+	var err error
+	if pane.WindowActivity, err = parseEpoch(fields[11]); err != nil {
+		return PaneInfo{}, fmt.Errorf("unexpected pane format: %s", line)
+	}
+	if pane.SessionCreated, err = parseEpoch(fields[12]); err != nil {
+		return PaneInfo{}, fmt.Errorf("unexpected pane format: %s", line)
+	}
+	return pane, nil
+}
+
+// parseEpoch parses a tmux timestamp in seconds since the epoch. An empty
+// field gives the zero time.
+func parseEpoch(field string) (time.Time, error) {
+	if field == "" {
+		return time.Time{}, nil
+	}
+	secs, err := strconv.ParseInt(field, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+// matches reports whether pane is the one t selects
+func (t Target) matches(pane PaneInfo) bool {
+	if strings.HasPrefix(t.Pane, "%") {
+		// Pane IDs are unique, so the window is only a cross-check
+		return pane.ID == t.Pane && (t.Window == "" || t.matchesWindow(pane))
+	}
+	if !t.matchesWindow(pane) {
+		return false
+	}
+	if t.Pane == "" {
+		return pane.Active
+	}
+	return strconv.Itoa(pane.PaneIndex) == t.Pane
+}
+
+// matchesWindow reports whether pane is in the window t selects
+func (t Target) matchesWindow(pane PaneInfo) bool {
+	if t.Window == "" {
+		return pane.WindowActive
+	}
+	return strconv.Itoa(pane.WindowIndex) == t.Window || pane.WindowName == t.Window
+}
+
+// resolveTarget validates t against the session's live panes and returns
+// the tmux target string for it. The zero Target resolves to the focused
+// pane, or to the session itself without consulting tmux when no pane is
+// focused.
+func (m *Manager) resolveTarget(t Target) (string, error) {
+	if t.IsZero() {
+		return m.resolveFocused()
+	}
+
+	panes, err := m.listPanes()
+	if err != nil {
+		return "", err
+	}
+	for _, pane := range panes {
+		if t.matches(pane) {
+			// Pane IDs are only understood on their own, not after "session:"
+			return pane.ID, nil
+		}
+	}
+	return "", &UnknownTargetError{Session: m.sessionName, Target: t}
+}
+
+// resolveFocused returns the focused pane's ID, or the session name when
+// no pane is focused. A focused pane that has since closed is forgotten,
+// leaving tmux's active pane as the default again.
+func (m *Manager) resolveFocused() (string, error) {
+	if m.focused == "" {
+		return m.sessionName, nil
+	}
+	panes, err := m.listPanes()
+	if err != nil {
+		return "", err
+	}
+	for _, pane := range panes {
+		if pane.ID == m.focused {
+			return pane.ID, nil
+		}
+	}
+	m.focused = ""
+	return m.sessionName, nil
+}
+
+// FocusPane makes the pane t selects tmux's active pane, switching to its
+// window, and remembers it so the zero Target keeps selecting it even if
+// the active pane changes later. The zero Target clears the focus instead,
+// so the zero Target follows tmux's active pane again.
+func (m *Manager) FocusPane(t Target) (*PaneInfo, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return nil, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	if t.IsZero() {
+		m.focused = ""
+		return nil, nil
+	}
+
+	panes, err := m.listPanes()
+	if err != nil {
+		return nil, err
+	}
+	for _, pane := range panes {
+		if !t.matches(pane) {
+			continue
+		}
+		_, stderr, err := m.run("select-window", "-t", pane.ID, ";", "select-pane", "-t", pane.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select pane %s: %w (stderr: %s)", pane.ID, err, stderr)
+		}
+		m.focused = pane.ID
+		pane.Active, pane.WindowActive = true, true
+		return &pane, nil
+	}
+	return nil, &UnknownTargetError{Session: m.sessionName, Target: t}
+}
+
+// PaneFor returns the pane t selects among panes, as listed by ListPanes,
+// so a caller that already has the list needn't have tmux resolve t again.
+// The zero Target selects the focused pane, or the active pane of the
+// active window when none is focused.
+func (m *Manager) PaneFor(panes []PaneInfo, t Target) (PaneInfo, error) {
+	if t.IsZero() && m.focused != "" {
+		for _, pane := range panes {
+			if pane.ID == m.focused {
+				return pane, nil
+			}
+		}
+	}
+	for _, pane := range panes {
+		if t.matches(pane) {
+			return pane, nil
+		}
+	}
+	return PaneInfo{}, &UnknownTargetError{Session: m.sessionName, Target: t}
+}
+
+// Focused returns the ID of the focused pane, or "" when none is focused
+func (m *Manager) Focused() string {
+	return m.focused
+}
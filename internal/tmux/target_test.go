@@ -0,0 +1,223 @@
+package tmux
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// paneListing is list-panes output for a session with two windows: window
+// 0 "editor" with panes %0 and %1 (active), and the active window 1 "logs
+// tail" with pane %2
+const paneListing = "%0\t0\teditor\t0\t0\t0\t80\t24\tvim\t0\t\t1700000000\t1699990000\n" +
+	"%1\t0\teditor\t1\t1\t0\t80\t24\tbash\t0\t\t1700000000\t1699990000\n" +
+	"%2\t1\tlogs tail\t0\t1\t1\t160\t48\ttail\t0\t\t1700000000\t1699990000\n"
+
+func TestManager_ListPanes(t *testing.T) {
+	runner := newFakeRunner().on("list-panes", fakeResponse{stdout: paneListing})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	panes, err := m.ListPanes()
+	if err != nil {
+		t.Fatalf("ListPanes() error = %v", err)
+	}
+	if len(panes) != 3 {
+		t.Fatalf("ListPanes() returned %d panes, want 3", len(panes))
+	}
+	want := PaneInfo{ID: "%2", WindowIndex: 1, WindowName: "logs tail", PaneIndex: 0,
+		Active: true, WindowActive: true, Width: 160, Height: 48, Command: "tail",
+		WindowActivity: time.Unix(1700000000, 0).UTC(), SessionCreated: time.Unix(1699990000, 0).UTC()}
+	if !reflect.DeepEqual(panes[2], want) {
+		t.Errorf("ListPanes()[2] = %+v, want %+v", panes[2], want)
+	}
+}
+
+func TestManager_WindowPanes(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  string
+		wantIDs []string
+		wantErr error
+	}{
+		{name: "active window", window: "", wantIDs: []string{"%2"}},
+		{name: "by index", window: "0", wantIDs: []string{"%0", "%1"}},
+		{name: "by name", window: "logs tail", wantIDs: []string{"%2"}},
+		{name: "unknown window", window: "9", wantErr: ErrUnknownTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("list-panes", fakeResponse{stdout: paneListing})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			panes, err := m.WindowPanes(tt.window)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("WindowPanes(%q) error = %v, want %v", tt.window, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WindowPanes(%q) error = %v", tt.window, err)
+			}
+			var ids []string
+			for _, pane := range panes {
+				ids = append(ids, pane.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("WindowPanes(%q) = %v, want %v", tt.window, ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestManager_resolveTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  Target
+		want    string
+		wantErr bool
+	}{
+		{name: "zero target is the session", target: Target{}, want: "fake-session"},
+		{name: "pane ID", target: Target{Pane: "%0"}, want: "%0"},
+		{name: "window index uses its active pane", target: Target{Window: "0"}, want: "%1"},
+		{name: "window name", target: Target{Window: "logs tail"}, want: "%2"},
+		{name: "window and pane index", target: Target{Window: "0", Pane: "0"}, want: "%0"},
+		{name: "pane index in active window", target: Target{Pane: "0"}, want: "%2"},
+		{name: "unknown pane ID", target: Target{Pane: "%9"}, wantErr: true},
+		{name: "unknown window", target: Target{Window: "7"}, wantErr: true},
+		{name: "pane ID in the wrong window", target: Target{Window: "1", Pane: "%0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().on("list-panes", fakeResponse{stdout: paneListing})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			got, err := m.resolveTarget(tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrUnknownTarget) {
+				t.Errorf("resolveTarget() error = %v, want ErrUnknownTarget", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveTarget() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_FocusPane(t *testing.T) {
+	runner := newFakeRunner().on("list-panes", fakeResponse{stdout: paneListing})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	pane, err := m.FocusPane(Target{Window: "editor", Pane: "0"})
+	if err != nil {
+		t.Fatalf("FocusPane() error = %v", err)
+	}
+	if pane.ID != "%0" || !pane.Active || !pane.WindowActive {
+		t.Errorf("FocusPane() = %+v, want the now active pane %%0", pane)
+	}
+	want := []string{"select-window", "-t", "%0", ";", "select-pane", "-t", "%0"}
+	if args := runner.lastCall("select-window"); !reflect.DeepEqual(args, want) {
+		t.Errorf("select args = %v, want %v", args, want)
+	}
+
+	// Un-targeted operations now go to the focused pane, even though the
+	// listing still shows %2 as active
+	if err := m.SendTextTo(Target{}, "ls"); err != nil {
+		t.Fatalf("SendTextTo() error = %v", err)
+	}
+	if args := runner.lastCall("send-keys"); args[2] != "%0" {
+		t.Errorf("send-keys target = %q, want %%0", args[2])
+	}
+
+	if _, err := m.FocusPane(Target{Pane: "%9"}); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("FocusPane() unknown pane error = %v, want ErrUnknownTarget", err)
+	}
+	if m.Focused() != "%0" {
+		t.Errorf("Focused() after a failed FocusPane = %q, want %%0", m.Focused())
+	}
+
+	if _, err := m.FocusPane(Target{}); err != nil || m.Focused() != "" {
+		t.Errorf("FocusPane(zero) = %v, focused %q, want the focus cleared", err, m.Focused())
+	}
+}
+
+func TestManager_SetSession(t *testing.T) {
+	runner := newFakeRunner().
+		on("list-sessions", fakeResponse{stdout: "fake-session\nbuild\n"}).
+		on("list-panes", fakeResponse{stdout: paneListing})
+	m := NewManagerWithRunner("fake-session", runner)
+	if _, err := m.FocusPane(Target{Pane: "%0"}); err != nil {
+		t.Fatalf("FocusPane() error = %v", err)
+	}
+
+	if err := m.SetSession("buil"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("SetSession(prefix) error = %v, want ErrSessionNotFound", err)
+	}
+	if m.SessionName() != "fake-session" || m.Focused() != "%0" {
+		t.Errorf("failed SetSession changed the manager: session %q, focused %q", m.SessionName(), m.Focused())
+	}
+
+	if err := m.SetSession("build"); err != nil {
+		t.Fatalf("SetSession() error = %v", err)
+	}
+	if m.SessionName() != "build" {
+		t.Errorf("SessionName() = %q, want build", m.SessionName())
+	}
+	if m.Focused() != "" {
+		t.Errorf("Focused() = %q, want the focus cleared", m.Focused())
+	}
+}
+
+func TestManager_resolveTarget_FocusedPaneClosed(t *testing.T) {
+	runner := newFakeRunner().on("list-panes", fakeResponse{stdout: paneListing})
+	m := NewManagerWithRunner("fake-session", runner)
+	m.focused = "%7"
+
+	got, err := m.resolveTarget(Target{})
+	if err != nil {
+		t.Fatalf("resolveTarget() error = %v", err)
+	}
+	if got != "fake-session" || m.Focused() != "" {
+		t.Errorf("resolveTarget() = %q with focus %q, want the session and no focus", got, m.Focused())
+	}
+}
+
+func TestManager_SendTextTo(t *testing.T) {
+	runner := newFakeRunner().on("list-panes", fakeResponse{stdout: paneListing})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if err := m.SendTextTo(Target{Window: "0", Pane: "0"}, "make"); err != nil {
+		t.Fatalf("SendTextTo() error = %v", err)
+	}
+	want := []string{"send-keys", "-t", "%0", "-l", "--", "make"}
+	if args := runner.lastCall("send-keys"); !reflect.DeepEqual(args, want) {
+		t.Errorf("send-keys args = %v, want %v", args, want)
+	}
+
+	if err := m.SendTextTo(Target{Pane: "%9"}, "make"); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("SendTextTo() unknown pane error = %v, want ErrUnknownTarget", err)
+	}
+}
+
+func TestParsePaneLine_Dead(t *testing.T) {
+	pane, err := parsePaneLine("%5\t2\tbuild\t0\t1\t0\t80\t24\tmake\t1\t2\t1700000000\t1699990000")
+	if err != nil {
+		t.Fatalf("parsePaneLine() error = %v", err)
+	}
+	if !pane.Dead || pane.DeadStatus == nil || *pane.DeadStatus != 2 {
+		t.Errorf("parsePaneLine() dead = %v status = %v, want dead with status 2", pane.Dead, pane.DeadStatus)
+	}
+
+	live, err := parsePaneLine("%6\t2\tbuild\t1\t0\t0\t80\t24\tbash\t0\t\t1700000000\t1699990000")
+	if err != nil {
+		t.Fatalf("parsePaneLine() error = %v", err)
+	}
+	if live.Dead || live.DeadStatus != nil {
+		t.Errorf("parsePaneLine() live pane = %+v, want not dead", live)
+	}
+}
@@ -0,0 +1,108 @@
+package tmux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnsureWindow returns the ID of a pane in the session's window named
+// name, creating the window in the background if there is none. previous,
+// when not empty, is a pane ID returned by an earlier call; it is reused
+// for as long as the pane exists, even if its window has been renamed.
+// created reports whether the window was made by this call.
+func (m *Manager) EnsureWindow(name, previous string) (id string, created bool, err error) {
+	if name == "" {
+		return "", false, fmt.Errorf("window name must not be empty")
+	}
+
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return "", false, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	panes, err := m.listPanes()
+	if err != nil {
+		return "", false, err
+	}
+	if previous != "" {
+		for _, pane := range panes {
+			if pane.ID == previous {
+				return pane.ID, false, nil
+			}
+		}
+	}
+	// Prefer the window's active pane when the user has split it
+	for _, pane := range panes {
+		if pane.WindowName == name && pane.Active {
+			return pane.ID, false, nil
+		}
+	}
+
+	// -d leaves the user's current window selected
+	stdout, stderr, err := m.run("new-window", "-d", "-t", m.sessionName+":", "-n", name, "-P", "-F", "#{pane_id}")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create window %q: %w (stderr: %s)", name, err, strings.TrimSpace(stderr))
+	}
+	id = strings.TrimSpace(stdout)
+	if !strings.HasPrefix(id, "%") {
+		return "", false, fmt.Errorf("unexpected new-window output: %q", stdout)
+	}
+	return id, true, nil
+}
+
+// AutomaticRename reports whether tmux names the window selected by target
+// after the command running in it: the automatic-rename window option, as
+// in effect for the window (show-options -w -A)
+func (m *Manager) AutomaticRename(target Target) (bool, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return false, &SessionNotFoundError{Session: m.sessionName}
+	}
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return false, err
+	}
+
+	stdout, stderr, err := m.run("show-options", "-w", "-v", "-A", "-t", resolved, "automatic-rename")
+	if err != nil {
+		return false, fmt.Errorf("failed to show automatic-rename: %w (stderr: %s)", err, stderr)
+	}
+	return strings.TrimSpace(stdout) == "on", nil
+}
+
+// SetAutomaticRename sets the automatic-rename option of the window
+// selected by target (set-option -w). tmux's rename-window turns it off
+// for the window it renames, so turning it on again hands the name back
+// to tmux.
+func (m *Manager) SetAutomaticRename(target Target, enabled bool) error {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return &SessionNotFoundError{Session: m.sessionName}
+	}
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return err
+	}
+
+	value := "off"
+	if enabled {
+		value = "on"
+	}
+	_, stderr, err := m.run("set-option", "-w", "-t", resolved, "automatic-rename", value)
+	if err != nil {
+		return fmt.Errorf("failed to set automatic-rename: %w (stderr: %s)", err, stderr)
+	}
+	return nil
+}
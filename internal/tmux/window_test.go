@@ -0,0 +1,85 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManager_EnsureWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		window      string
+		previous    string
+		wantID      string
+		wantCreated bool
+	}{
+		{name: "existing window", window: "logs tail", wantID: "%2"},
+		{name: "active pane of split window", window: "editor", wantID: "%1"},
+		{name: "previous pane", window: "mcp-scratch", previous: "%0", wantID: "%0"},
+		{name: "new window", window: "mcp-scratch", wantID: "%7", wantCreated: true},
+		{name: "previous pane closed", window: "mcp-scratch", previous: "%5", wantID: "%7", wantCreated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newFakeRunner().
+				on("list-panes", fakeResponse{stdout: paneListing}).
+				on("new-window", fakeResponse{stdout: "%7\n"})
+			m := NewManagerWithRunner("fake-session", runner)
+
+			id, created, err := m.EnsureWindow(tt.window, tt.previous)
+			if err != nil {
+				t.Fatalf("EnsureWindow() error = %v", err)
+			}
+			if id != tt.wantID || created != tt.wantCreated {
+				t.Errorf("EnsureWindow() = %q, %v; want %q, %v", id, created, tt.wantID, tt.wantCreated)
+			}
+			call := runner.lastCall("new-window")
+			if !tt.wantCreated {
+				if call != nil {
+					t.Errorf("EnsureWindow() ran %v, want no new window", call)
+				}
+				return
+			}
+			want := []string{"new-window", "-d", "-t", "fake-session:", "-n", tt.window, "-P", "-F", "#{pane_id}"}
+			if !reflect.DeepEqual(call, want) {
+				t.Errorf("new-window call = %v, want %v", call, want)
+			}
+		})
+	}
+}
+
+func TestManager_EnsureWindow_Failure(t *testing.T) {
+	runner := newFakeRunner().
+		on("list-panes", fakeResponse{stdout: paneListing}).
+		on("new-window", fakeResponse{stderr: "create window failed: index in use", err: exitError(1)})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if _, _, err := m.EnsureWindow("mcp-scratch", ""); err == nil {
+		t.Fatal("EnsureWindow() error = nil, want an error")
+	}
+}
+
+func TestManager_AutomaticRename(t *testing.T) {
+	runner := newFakeRunner().
+		on("list-panes", fakeResponse{stdout: paneListing}).
+		on("show-options", fakeResponse{stdout: "on\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	enabled, err := m.AutomaticRename(Target{Window: "editor"})
+	if err != nil || !enabled {
+		t.Fatalf("AutomaticRename() = %v, %v; want true", enabled, err)
+	}
+	want := []string{"show-options", "-w", "-v", "-A", "-t", "%1", "automatic-rename"}
+	if got := runner.lastCall("show-options"); !reflect.DeepEqual(got, want) {
+		t.Errorf("show-options = %v, want %v", got, want)
+	}
+
+	if err := m.SetAutomaticRename(Target{}, false); err != nil {
+		t.Fatalf("SetAutomaticRename() error = %v", err)
+	}
+	want = []string{"set-option", "-w", "-t", "fake-session", "automatic-rename", "off"}
+	if got := runner.lastCall("set-option"); !reflect.DeepEqual(got, want) {
+		t.Errorf("set-option = %v, want %v", got, want)
+	}
+}
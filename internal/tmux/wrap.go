@@ -0,0 +1,99 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WrappedLine is one visual line of a pane. Wrapped reports whether the
+// line continues the previous one because the terminal wrapped it, rather
+// than starting after a real newline.
+type WrappedLine struct {
+	Text    string `json:"text"`
+	Wrapped bool   `json:"wrapped"`
+}
+
+// CaptureWrapped captures the pane's history and visible lines along with
+// where the terminal wrapped them, so a consumer can rebuild the logical
+// lines exactly: join each line with Wrapped set onto the one before it.
+//
+// tmux doesn't expose its per-line wrap flag, and comparing line lengths
+// with the pane width can't tell a wrapped line from one that exactly fills
+// the width. Instead the pane is captured twice, as visual lines (-N, which
+// keeps the trailing spaces a wrap point may fall on) and as joined logical
+// lines (-J), and the two are aligned. Both captures run in one tmux
+// invocation so output arriving in between can't make them disagree.
+func (m *Manager) CaptureWrapped(target Target) ([]WrappedLine, error) {
+	// First verify the session exists
+	exists, err := m.SessionExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !exists {
+		return nil, &SessionNotFoundError{Session: m.sessionName}
+	}
+
+	resolved, err := m.resolveTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	// The pane's extent comes first and says how many of the lines that
+	// follow belong to the visual capture
+	stdout, _, err := m.run(
+		"display-message", "-t", resolved, "-p", "#{history_size},#{pane_height}", ";",
+		"capture-pane", "-t", resolved, "-p", "-N", "-S", "-", ";",
+		"capture-pane", "-t", resolved, "-p", "-J", "-S", "-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture pane: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	parts := strings.Split(lines[0], ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("unexpected pane extent format: %s", lines[0])
+	}
+	history, errH := strconv.Atoi(parts[0])
+	height, errP := strconv.Atoi(parts[1])
+	if errH != nil || errP != nil || history < 0 || height < 0 || 1+history+height > len(lines) {
+		return nil, fmt.Errorf("unexpected pane extent format: %s", lines[0])
+	}
+	visual := lines[1 : 1+history+height]
+	joined := lines[1+history+height:]
+
+	return alignWrapped(visual, joined), nil
+}
+
+// alignWrapped marks which visual lines continue the one before, by
+// consuming visual lines until they add up to each joined logical line.
+// Trailing spaces are ignored in the comparison since the two capture modes
+// keep different amounts of them. Should the captures ever disagree, the
+// remaining lines are reported unwrapped.
+func alignWrapped(visual, joined []string) []WrappedLine {
+	result := make([]WrappedLine, 0, len(visual))
+	i := 0
+	for _, logical := range joined {
+		if i >= len(visual) {
+			break
+		}
+		want := strings.TrimRight(logical, " ")
+		end := i + 1
+		built := visual[i]
+		for strings.TrimRight(built, " ") != want && end < len(visual) && len(built) < len(want) {
+			built += visual[end]
+			end++
+		}
+		if strings.TrimRight(built, " ") != want {
+			break
+		}
+		for j := i; j < end; j++ {
+			result = append(result, WrappedLine{Text: visual[j], Wrapped: j > i})
+		}
+		i = end
+	}
+	for ; i < len(visual); i++ {
+		result = append(result, WrappedLine{Text: visual[i]})
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAlignWrapped(t *testing.T) {
+	tests := []struct {
+		name   string
+		visual []string
+		joined []string
+		want   []WrappedLine
+	}{
+		{
+			name:   "no wrapping",
+			visual: []string{"a", "b", ""},
+			joined: []string{"a", "b", ""},
+			want:   []WrappedLine{{Text: "a"}, {Text: "b"}, {Text: ""}},
+		},
+		{
+			name:   "wrapped line",
+			visual: []string{"abcde", "fg", "next"},
+			joined: []string{"abcdefg", "next"},
+			want:   []WrappedLine{{Text: "abcde"}, {Text: "fg", Wrapped: true}, {Text: "next"}},
+		},
+		{
+			// A line that exactly fills the width looks wrapped by length alone
+			name:   "full-width line followed by a real newline",
+			visual: []string{"abcde", "short"},
+			joined: []string{"abcde", "short"},
+			want:   []WrappedLine{{Text: "abcde"}, {Text: "short"}},
+		},
+		{
+			name:   "wrap point on a space",
+			visual: []string{"abcd ", "efg"},
+			joined: []string{"abcd efg  "},
+			want:   []WrappedLine{{Text: "abcd "}, {Text: "efg", Wrapped: true}},
+		},
+		{
+			name:   "wrapped over three lines",
+			visual: []string{"abc", "def", "gh"},
+			joined: []string{"abcdefgh"},
+			want:   []WrappedLine{{Text: "abc"}, {Text: "def", Wrapped: true}, {Text: "gh", Wrapped: true}},
+		},
+		{
+			name:   "captures disagree",
+			visual: []string{"a", "b", "c"},
+			joined: []string{"a", "x"},
+			want:   []WrappedLine{{Text: "a"}, {Text: "b"}, {Text: "c"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alignWrapped(tt.visual, tt.joined); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("alignWrapped() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_CaptureWrapped(t *testing.T) {
+	// One line of history and a two-line pane, then the joined capture
+	stdout := "1,2\n" +
+		"history\nabcde\nfg\n" +
+		"history\nabcdefg\n"
+	runner := newFakeRunner().on("display-message", fakeResponse{stdout: stdout})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	got, err := m.CaptureWrapped(Target{})
+	if err != nil {
+		t.Fatalf("CaptureWrapped() error = %v", err)
+	}
+	want := []WrappedLine{{Text: "history"}, {Text: "abcde"}, {Text: "fg", Wrapped: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CaptureWrapped() = %+v, want %+v", got, want)
+	}
+
+	wantArgs := []string{
+		"display-message", "-t", "fake-session", "-p", "#{history_size},#{pane_height}", ";",
+		"capture-pane", "-t", "fake-session", "-p", "-N", "-S", "-", ";",
+		"capture-pane", "-t", "fake-session", "-p", "-J", "-S", "-"}
+	if args := runner.lastCall("display-message"); !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("tmux args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestManager_CaptureWrapped_Malformed(t *testing.T) {
+	runner := newFakeRunner().on("display-message", fakeResponse{stdout: "5,24\nonly\n"})
+	m := NewManagerWithRunner("fake-session", runner)
+
+	if _, err := m.CaptureWrapped(Target{}); err == nil {
+		t.Error("CaptureWrapped() with truncated output succeeded, want error")
+	}
+}